@@ -0,0 +1,151 @@
+package gitignore
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// WithWindowsPaths makes Match and the other methods taking a path
+// relative to the root accept backslashes as separators, as in
+// `src\main.go` or `vendor\`, for paths that come from Windows APIs.
+// Without it a backslash is part of a name, which is how git reads one
+// outside Windows.
+func WithWindowsPaths() Option {
+	return func(c *config) {
+		c.windows = true
+	}
+}
+
+// toSlash returns relPath with backslashes for slashes if m takes Windows
+// paths.
+func (m *Matcher) toSlash(relPath string) string {
+	if !m.windows {
+		return relPath
+	}
+	return strings.ReplaceAll(relPath, `\`, "/")
+}
+
+// MatchAbs is Match for an absolute path, which is made relative to the
+// root given to New, NewFromDirectory, LoadDirectory or LoadCache, or the
+// directory of the file given to LoadIgnoreFile. A path outside the
+// root, or any path for a Matcher with no root, as one restored by
+// UnmarshalBinary, is not ignored. A trailing separator marks a
+// directory, as for Match.
+//
+// Besides the paths of the system it runs on, MatchAbs takes Windows
+// paths wherever it runs, with either separator: a drive letter, as in
+// `C:\repo\src\main.go`, a UNC path, as in `\\server\share\repo\x`, and
+// either of them after the `\\?\` long-path prefix. These are compared
+// with the root ignoring case, as Windows compares them, and "." and ".."
+// segments in them are resolved by name.
+func (m *Matcher) MatchAbs(absPath string) bool {
+	rel, ok := m.relPath(absPath)
+	if !ok {
+		return false
+	}
+	return m.Match(rel)
+}
+
+// relPath returns absPath relative to m's root, in the form Match takes.
+func (m *Matcher) relPath(absPath string) (string, bool) {
+	if m.root == "" {
+		return "", false
+	}
+	isDir := strings.HasSuffix(absPath, "/") || strings.HasSuffix(absPath, string(filepath.Separator))
+	var rel string
+	if root, ok := windowsAbs(m.root); ok {
+		p, ok := windowsAbs(absPath)
+		if !ok {
+			return "", false
+		}
+		isDir = isDir || strings.HasSuffix(absPath, `\`)
+		if len(p) < len(root) || !strings.EqualFold(p[:len(root)], root) {
+			return "", false
+		}
+		switch rest := p[len(root):]; {
+		case rest == "":
+			rel = "."
+		case strings.HasSuffix(root, "/"):
+			rel = rest
+		case rest[0] == '/':
+			rel = rest[1:]
+		default:
+			return "", false
+		}
+	} else {
+		if !filepath.IsAbs(absPath) {
+			return "", false
+		}
+		r, err := filepath.Rel(m.root, absPath)
+		if err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+			return "", false
+		}
+		rel = filepath.ToSlash(r)
+	}
+	if rel == "." {
+		return "", true
+	}
+	if isDir {
+		rel += "/"
+	}
+	return rel, true
+}
+
+// absRoot returns root as MatchAbs compares paths with it: absolute, and
+// cleaned, or "" if it can't be made absolute.
+func absRoot(root string) string {
+	if _, ok := windowsAbs(root); ok {
+		return root
+	}
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return ""
+	}
+	return abs
+}
+
+// windowsAbs returns p, if it is an absolute Windows path, with slashes
+// for backslashes, no long-path prefix and its "." and ".." segments
+// resolved: `C:\repo\x\..\y` is "C:/repo/y", and both `\\?\UNC\host\s\x`
+// and `\\host\s\x` are "//host/s/x". It reports false for any other path,
+// including an absolute path on systems other than Windows, which starts
+// with a single slash.
+func windowsAbs(p string) (string, bool) {
+	p = strings.ReplaceAll(p, `\`, "/")
+	if rest, ok := strings.CutPrefix(p, "//?/"); ok {
+		p = rest
+		if len(rest) >= 4 && strings.EqualFold(rest[:4], "UNC/") {
+			p = "//" + rest[4:]
+		}
+	}
+	var vol string
+	switch {
+	case len(p) >= 3 && isDriveLetter(p[0]) && p[1] == ':' && p[2] == '/':
+		vol = p[:2]
+	case len(p) > 2 && p[:2] == "//" && p[2] != '/':
+		// A host and a share name.
+		host := strings.IndexByte(p[2:], '/')
+		if host <= 0 {
+			return "", false
+		}
+		share := strings.IndexByte(p[3+host:], '/')
+		if share == 0 {
+			return "", false
+		}
+		if share < 0 {
+			return p, true
+		}
+		vol = p[:3+host+share]
+	default:
+		return "", false
+	}
+	if rest := path.Clean(p[len(vol):]); rest != "/" {
+		return vol + rest, true
+	}
+	return vol + "/", true
+}
+
+func isDriveLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
@@ -0,0 +1,104 @@
+package gitignore_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestMatchAbs(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{".gitignore": "*.log\nbuild/\n"})
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{filepath.Join(root, "src", "a.log"), true},
+		{filepath.Join(root, "build") + string(filepath.Separator), true},
+		{filepath.Join(root, "build", "x.go"), true},
+		{filepath.Join(root, "build"), false},
+		{root + "/src/../a.log", true},
+		{root + "/../a.log", false},
+		{root + "x.log", false},
+		{root, false},
+		{"src/a.log", false},
+	}
+	for _, opts := range [][]gitignore.Option{nil, {gitignore.WithWindowsPaths()}} {
+		m := gitignore.New(root, opts...)
+		for _, tt := range tests {
+			if got := m.MatchAbs(tt.path); got != tt.want {
+				t.Errorf("MatchAbs(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		}
+	}
+
+	// A relative root is made absolute when the Matcher is created.
+	t.Chdir(filepath.Dir(root))
+	m := gitignore.New(filepath.Base(root))
+	if !m.MatchAbs(filepath.Join(root, "a.log")) {
+		t.Error("expected a relative root to be resolved")
+	}
+}
+
+func TestMatchAbsWindows(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tests := []struct {
+		root, path string
+		want       bool
+	}{
+		{`C:\repo`, `C:\repo\src\a.log`, true},
+		{`C:\repo`, `c:/REPO/src/a.log`, true},
+		{`C:\repo\`, `C:\repo\a.log`, true},
+		{`C:\repo`, `C:\repo\build\`, true},
+		{`C:\repo`, `C:\repo\build`, false},
+		{`C:\repo`, `C:\repo\src\..\x.log`, true},
+		{`C:\repo`, `C:\repo\..\x.log`, false},
+		{`C:\repo`, `C:\repository\a.log`, false},
+		{`C:\repo`, `D:\repo\a.log`, false},
+		{`C:\repo`, `\\?\C:\repo\a.log`, true},
+		{`C:\repo`, `C:\repo`, false},
+		{`C:\repo`, `repo\a.log`, false},
+		{`C:\repo`, `/repo/a.log`, false},
+		{`C:\`, `C:\a.log`, true},
+		{`\\server\share\repo`, `\\server\share\repo\a.log`, true},
+		{`\\server\share\repo`, `\\?\UNC\SERVER\share\repo\a.log`, true},
+		{`\\server\share\repo`, `//server/share/repo/build/`, true},
+		{`\\server\share\repo`, `\\server\other\repo\a.log`, false},
+		{`\\server\share`, `\\server\share\a.log`, true},
+		{`\\server`, `\\server\a.log`, false},
+	}
+	for _, tt := range tests {
+		m := gitignore.New(tt.root)
+		m.AddPatterns([]byte("*.log\nbuild/\n"), "")
+		if got := m.MatchAbs(tt.path); got != tt.want {
+			t.Errorf("root %q: MatchAbs(%q) = %v, want %v", tt.root, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestWithWindowsPaths(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	win := gitignore.New(t.TempDir(), gitignore.WithWindowsPaths())
+	win.AddPatterns([]byte("src/gen/\n"), "")
+	if !win.Match(`src\gen\`) || win.Match(`src\gen`) || !win.Match(`src\gen\x.go`) {
+		t.Error("expected Match to take backslashes")
+	}
+	if !win.MatchPath(`src\gen`, true) || !win.MatchFull(`.\src\gen\sub\x.go`) ||
+		win.MatchDetail(`src\gen\x.go`).Pattern != "src/gen/" || !win.MatchDirContents(`src\gen`) {
+		t.Error("expected the other methods to take backslashes")
+	}
+
+	m := gitignore.New(t.TempDir())
+	m.AddPatterns([]byte("src/gen/\n"), "")
+	if m.Match(`src\gen\x.go`) || m.MatchFull(`src\gen\x.go`) {
+		t.Error("expected a backslash to be part of a name by default")
+	}
+}
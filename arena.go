@@ -0,0 +1,52 @@
+package gitignore
+
+import "strings"
+
+// WithArena makes the Matcher carve the compiled segments of its patterns
+// out of large shared chunks instead of allocating them pattern by
+// pattern. Each file's patterns are sized up front and usually land in a
+// chunk shared with the files loaded before it, so loading thousands of
+// .gitignore files takes a few allocations instead of one per pattern,
+// easing GC pressure. The cost is that a chunk is freed only with the
+// Matcher, and the room reserved for a file beyond its exact needs is
+// not reused.
+func WithArena() Option {
+	return func(c *config) {
+		c.arena = true
+	}
+}
+
+// arenaChunk is the smallest chunk, in segments, the arena allocates.
+const arenaChunk = 4096
+
+// segmentArena hands out segment slices from a chunk. A nil arena
+// allocates each slice separately.
+type segmentArena struct {
+	free []segment
+}
+
+// reserve makes sure the next n segments come from one chunk.
+func (a *segmentArena) reserve(n int) {
+	if a != nil && len(a.free) < n {
+		a.free = make([]segment, max(n, arenaChunk))
+	}
+}
+
+// alloc returns an empty slice with capacity n. Its capacity is exact, so
+// appending past it never overwrites the next slice.
+func (a *segmentArena) alloc(n int) []segment {
+	if a == nil {
+		return make([]segment, 0, n)
+	}
+	a.reserve(n)
+	s := a.free[:0:n]
+	a.free = a.free[n:]
+	return s
+}
+
+// arenaNeeds returns an upper bound on the segments compilePattern
+// allocates for the pattern lines in text.
+func arenaNeeds(text string) int {
+	lines := strings.Count(text, "\n") + 1
+	return strings.Count(text, "/") + lines*segmentsSlack
+}
@@ -0,0 +1,39 @@
+package gitignore_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestArena(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	patterns := []byte("*.log\n!keep.log\nbuild/\n/dist\n**/gen/**\ndocs/**/*.md\n**/a/\n\\!bang\n[\n")
+	plain := gitignore.New(t.TempDir())
+	arena := gitignore.New(t.TempDir(), gitignore.WithArena())
+	for i := range 50 {
+		dir := fmt.Sprintf("pkg%d", i)
+		plain.AddPatterns(patterns, dir)
+		arena.AddPatterns(patterns, dir)
+	}
+
+	for _, p := range []string{
+		"pkg3/a.log", "pkg3/keep.log", "pkg3/build/", "pkg3/build/x", "pkg3/dist",
+		"pkg3/x/dist", "pkg3/x/gen/y", "pkg3/docs/a/b.md", "pkg3/a/x/a", "pkg3/!bang", "a.log",
+	} {
+		if got, want := arena.Match(p), plain.Match(p); got != want {
+			t.Errorf("Match(%q) = %v with an arena, %v without", p, got, want)
+		}
+	}
+
+	add := func(m *gitignore.Matcher) func() {
+		return func() { m.AddPatterns(patterns, "pkg") }
+	}
+	withArena := testing.AllocsPerRun(20, add(gitignore.New(t.TempDir(), gitignore.WithArena())))
+	without := testing.AllocsPerRun(20, add(gitignore.New(t.TempDir())))
+	if withArena >= without {
+		t.Errorf("AddPatterns allocated %v times with an arena, %v without", withArena, without)
+	}
+}
@@ -0,0 +1,198 @@
+package gitignore
+
+import "strings"
+
+// File is an ignore file as written, line by line, for tools that edit
+// one without disturbing its formatting: ParseFile reads it, the lines
+// can be added, removed and moved, and Bytes writes it out again, byte
+// for byte as it was read but for the edits.
+type File struct {
+	BOM   bool // the file starts with a UTF-8 byte order mark, which Bytes keeps
+	Lines []Line
+}
+
+// LineKind says what a Line of a File is.
+type LineKind int
+
+const (
+	// LineBlank is an empty line, or one of spaces only.
+	LineBlank LineKind = iota
+	// LineComment is a line starting with "#".
+	LineComment
+	// LinePattern is a line holding a pattern.
+	LinePattern
+)
+
+func (k LineKind) String() string {
+	switch k {
+	case LineBlank:
+		return "blank"
+	case LineComment:
+		return "comment"
+	case LinePattern:
+		return "pattern"
+	}
+	return "LineKind(" + itoa(int(k)) + ")"
+}
+
+// Line is a line of a File. Text and Ending are what Bytes writes; the
+// other fields describe them, as git reads the line, and are not written.
+type Line struct {
+	Kind   LineKind
+	Text   string // as written, without the line ending
+	Ending string // "\n" or "\r\n"; "" or "\r" for a last line without a newline
+
+	// Start and End are the byte offsets of the line, ending included,
+	// in the data ParseFile read, or -1 for a line built by NewLine.
+	Start, End int
+
+	// For a LinePattern: the pattern once git trims its trailing spaces,
+	// whether it is a negation, directory-only or anchored, and why it
+	// doesn't compile, if it doesn't.
+	Pattern  string
+	Negate   bool
+	DirOnly  bool
+	Anchored bool
+	Invalid  string
+}
+
+// ParseFile reads data, an ignore file, into a File. It never fails:
+// every byte of data is in a Line, or is the byte order mark.
+func ParseFile(data []byte) *File {
+	f := &File{}
+	text := string(data)
+	off := 0
+	if strings.HasPrefix(text, utf8BOM) {
+		f.BOM = true
+		off = len(utf8BOM)
+	}
+	for off < len(text) {
+		end := strings.IndexByte(text[off:], '\n')
+		next := off + end + 1
+		if end < 0 {
+			end = len(text) - off
+			next = len(text)
+		}
+		raw := text[off : off+end]
+		l := NewLine(strings.TrimSuffix(raw, "\r"))
+		l.Ending = text[off+len(l.Text) : next]
+		l.Start, l.End = off, next
+		f.Lines = append(f.Lines, l)
+		off = next
+	}
+	return f
+}
+
+// NewLine returns the Line holding text, with a "\n" ending and no
+// place in the data read. text must not hold a newline.
+func NewLine(text string) Line {
+	l := Line{Text: text, Ending: "\n", Start: -1, End: -1}
+	trimmed := trimTrailingSpaces(text)
+	switch {
+	case trimmed == "":
+		l.Kind = LineBlank
+	case trimmed[0] == '#':
+		l.Kind = LineComment
+	default:
+		l.Kind = LinePattern
+		l.Pattern = trimmed
+		p, msg := compilePattern(trimmed, nil, nil)
+		l.Negate, l.DirOnly, l.Anchored, l.Invalid = p.negate, p.dirOnly, p.anchored, msg
+	}
+	return l
+}
+
+// Bytes returns f as an ignore file. For a File as ParseFile returned it,
+// that is the data it read.
+func (f *File) Bytes() []byte {
+	var b strings.Builder
+	if f.BOM {
+		b.WriteString(utf8BOM)
+	}
+	for _, l := range f.Lines {
+		b.WriteString(l.Text)
+		b.WriteString(l.Ending)
+	}
+	return []byte(b.String())
+}
+
+func (f *File) String() string {
+	return string(f.Bytes())
+}
+
+// Insert inserts lines before the line at index i, or at the end if i is
+// len(f.Lines). Their endings are made those of the file, "\r\n" if its
+// first ending is, and a last line without one is given one, so each
+// stays a line of its own.
+func (f *File) Insert(i int, lines ...Line) {
+	ending := f.ending()
+	if i == len(f.Lines) && i > 0 && f.Lines[i-1].Ending == "" {
+		f.Lines[i-1].Ending = ending
+	}
+	added := make([]Line, len(lines))
+	for j, l := range lines {
+		l.Ending = ending
+		added[j] = l
+	}
+	f.Lines = append(f.Lines[:i], append(added, f.Lines[i:]...)...)
+}
+
+// Append adds the pattern lines to the end of f, as Insert does.
+func (f *File) Append(patterns ...string) {
+	lines := make([]Line, len(patterns))
+	for i, p := range patterns {
+		lines[i] = NewLine(p)
+	}
+	f.Insert(len(f.Lines), lines...)
+}
+
+// Remove removes the line at index i. If it was the last line and had no
+// ending, the line before it loses its own, so the file still ends as it
+// did.
+func (f *File) Remove(i int) {
+	if i == len(f.Lines)-1 && f.Lines[i].Ending == "" && i > 0 {
+		f.Lines[i-1].Ending = ""
+	}
+	f.Lines = append(f.Lines[:i], f.Lines[i+1:]...)
+}
+
+// Move moves the line at index from to index to, shifting the lines
+// between. The line ending the file keeps its place as the one without
+// an ending, if it has none.
+func (f *File) Move(from, to int) {
+	if from == to {
+		return
+	}
+	n := len(f.Lines) - 1
+	noEnding := f.Lines[n].Ending == ""
+	if noEnding {
+		f.Lines[n].Ending = f.ending()
+	}
+	l := f.Lines[from]
+	f.Lines = append(f.Lines[:from], f.Lines[from+1:]...)
+	f.Lines = append(f.Lines[:to], append([]Line{l}, f.Lines[to:]...)...)
+	if noEnding {
+		f.Lines[n].Ending = ""
+	}
+}
+
+// Find returns the index of the first pattern line whose pattern is
+// pattern, or -1.
+func (f *File) Find(pattern string) int {
+	for i, l := range f.Lines {
+		if l.Kind == LinePattern && l.Pattern == pattern {
+			return i
+		}
+	}
+	return -1
+}
+
+// ending returns the line ending of f, that of its first line with one.
+func (f *File) ending() string {
+	for _, l := range f.Lines {
+		if l.Ending != "" {
+			return l.Ending
+		}
+	}
+	return "\n"
+}
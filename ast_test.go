@@ -0,0 +1,96 @@
+package gitignore_test
+
+import (
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestParseFileRoundTrip(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"\n",
+		"*.log\n",
+		"*.log",
+		"# comment\r\n\r\n/build/\r\n!keep  \r\n",
+		"\xef\xbb\xbf*.o\n  \n\tx \\ \n[a\nlast\r",
+		"a\n\n\n",
+	} {
+		f := gitignore.ParseFile([]byte(in))
+		if got := string(f.Bytes()); got != in {
+			t.Errorf("ParseFile(%q).Bytes() = %q", in, got)
+		}
+		// The offsets cover the data, in order.
+		off := 0
+		if f.BOM {
+			off = 3
+		}
+		for i, l := range f.Lines {
+			if l.Start != off || in[l.Start:l.End] != l.Text+l.Ending {
+				t.Errorf("%q: line %d = %+v, want it at %d", in, i, l, off)
+			}
+			off = l.End
+		}
+		if off != len(in) {
+			t.Errorf("%q: lines end at %d, want %d", in, off, len(in))
+		}
+	}
+}
+
+func TestParseFileLines(t *testing.T) {
+	f := gitignore.ParseFile([]byte("# build\r\n/dist/  \r\n   \r\n!src/keep\r\na\\\r\n"))
+	want := []gitignore.Line{
+		{Kind: gitignore.LineComment, Text: "# build"},
+		{Kind: gitignore.LinePattern, Text: "/dist/  ", Pattern: "/dist/", DirOnly: true, Anchored: true},
+		{Kind: gitignore.LineBlank, Text: "   "},
+		{Kind: gitignore.LinePattern, Text: "!src/keep", Pattern: "!src/keep", Negate: true, Anchored: true},
+		{Kind: gitignore.LinePattern, Text: `a\`, Pattern: `a\`, Invalid: "trailing backslash"},
+	}
+	if len(f.Lines) != len(want) {
+		t.Fatalf("Lines = %+v", f.Lines)
+	}
+	for i, l := range f.Lines {
+		w := want[i]
+		w.Ending, w.Start, w.End = "\r\n", l.Start, l.End
+		if l != w {
+			t.Errorf("line %d = %+v, want %+v", i, l, w)
+		}
+	}
+	if gitignore.LineComment.String() != "comment" {
+		t.Errorf("String() = %q", gitignore.LineComment.String())
+	}
+}
+
+func TestFileEdit(t *testing.T) {
+	f := gitignore.ParseFile([]byte("# deps\r\nnode_modules/\r\n\r\n# logs\r\n*.log"))
+	f.Append("*.tmp")
+	if got := f.String(); got != "# deps\r\nnode_modules/\r\n\r\n# logs\r\n*.log\r\n*.tmp\r\n" {
+		t.Errorf("Append: got %q", got)
+	}
+	f.Insert(2, gitignore.NewLine("vendor/"))
+	if got := f.String(); got != "# deps\r\nnode_modules/\r\nvendor/\r\n\r\n# logs\r\n*.log\r\n*.tmp\r\n" {
+		t.Errorf("Insert: got %q", got)
+	}
+	f.Remove(f.Find("*.log"))
+	if got := f.String(); got != "# deps\r\nnode_modules/\r\nvendor/\r\n\r\n# logs\r\n*.tmp\r\n" {
+		t.Errorf("Remove: got %q", got)
+	}
+	f.Move(f.Find("vendor/"), 0)
+	if got := f.String(); got != "vendor/\r\n# deps\r\nnode_modules/\r\n\r\n# logs\r\n*.tmp\r\n" {
+		t.Errorf("Move: got %q", got)
+	}
+	if f.Find("nope") != -1 {
+		t.Error("Find(nope) != -1")
+	}
+
+	// A file without a final newline keeps it that way.
+	f = gitignore.ParseFile([]byte("a\nb"))
+	f.Move(1, 0)
+	if got := f.String(); got != "b\na" {
+		t.Errorf("Move: got %q", got)
+	}
+	f.Remove(1)
+	if got := f.String(); got != "b" {
+		t.Errorf("Remove: got %q", got)
+	}
+}
@@ -0,0 +1,348 @@
+package gitignore
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Attributes holds the gitattributes of a repository, to answer which
+// attributes apply to a path, as git check-attr does: whether it is
+// marked binary, linguist-generated or export-ignore, say.
+//
+// Patterns have gitignore syntax and scoping, and are read from the
+// user's global attributes file (core.attributesFile, or git/attributes
+// under the XDG config directory), the .gitattributes files of the tree
+// and .git/info/attributes, in increasing order of precedence. As in
+// git, unlike in .gitignore, a pattern doesn't match the paths inside a
+// directory it matches, and negated patterns aren't allowed. Macros,
+// "[attr]name" lines, are read from all but the nested .gitattributes
+// files, and the built-in "binary" macro is always defined.
+type Attributes struct {
+	dirs   map[string]*attrSource // the .gitattributes files by directory
+	info   *attrSource            // .git/info/attributes
+	global *attrSource
+
+	macros map[string][]attrAssign
+	errors []PatternError
+}
+
+// attrSource is a file of attributes.
+type attrSource struct {
+	lines []attrLine
+}
+
+// attrLine is a pattern with the attributes it assigns.
+type attrLine struct {
+	p     pattern
+	attrs []attrAssign
+}
+
+// attrAssign is an attribute name with the value a line gives it, an
+// unspecified one for "!name".
+type attrAssign struct {
+	name  string
+	value AttrValue
+}
+
+// AttrState is the state of an attribute for a path.
+type AttrState int
+
+const (
+	// AttrUnspecified means no pattern gives the attribute a state, or
+	// the last one to name it does so as "!name".
+	AttrUnspecified AttrState = iota
+
+	// AttrSet means the attribute is set, as "name".
+	AttrSet
+
+	// AttrUnset means the attribute is unset, as "-name".
+	AttrUnset
+
+	// AttrString means the attribute is set to a string, as "name=value".
+	AttrString
+)
+
+// AttrValue is the state of an attribute for a path, and its value when
+// it is set to a string.
+type AttrValue struct {
+	State AttrState
+	Value string // for AttrString
+}
+
+// String returns v as git check-attr prints it: "set", "unset",
+// "unspecified", or the value.
+func (v AttrValue) String() string {
+	switch v.State {
+	case AttrSet:
+		return "set"
+	case AttrUnset:
+		return "unset"
+	case AttrString:
+		return v.Value
+	}
+	return "unspecified"
+}
+
+// binaryMacro is git's built-in definition of the binary attribute.
+const binaryMacro = "[attr]binary -diff -merge -text"
+
+// LoadAttributes reads the attributes of the repository in root: every
+// .gitattributes in the tree, including those in ignored directories,
+// which git reads all the same, along with .git/info/attributes and the
+// global attributes file. WithoutGit skips .git/info/attributes, and the
+// global file is read when the global excludes would be. WithCompat
+// decides whether a symlinked .gitattributes is read, as for .gitignore.
+// It returns an error only if root can't be read; lines git would warn
+// about and skip are listed by Errors.
+func LoadAttributes(root string, opts ...Option) (*Attributes, error) {
+	c := newConfig(opts)
+	m := newMatcher(c)
+	a := &Attributes{dirs: make(map[string]*attrSource), macros: make(map[string][]attrAssign)}
+	a.parse([]byte(binaryMacro), "", "", true)
+
+	if newGlobalExcludes(c) != nil {
+		var read []string
+		if path := globalGitFile("attributesfile", "attributes", &read); path != "" {
+			if data, err := os.ReadFile(path); err == nil {
+				a.global = a.parse(data, "", path, true)
+			}
+		}
+	}
+	if err := a.loadDir(m, root, ""); err != nil {
+		return nil, err
+	}
+	if !c.plain {
+		path := filepath.Join(root, ".git", "info", "attributes")
+		if data, err := os.ReadFile(path); err == nil {
+			a.info = a.parse(data, "", path, true)
+		}
+	}
+	return a, nil
+}
+
+// loadDir reads the .gitattributes in directory rel and those below it.
+func (a *Attributes) loadDir(m *Matcher, root, rel string) error {
+	dir := filepath.Join(root, filepath.FromSlash(rel))
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if rel == "" {
+			return err
+		}
+		return nil
+	}
+	if e := findFile(entries, ".gitattributes"); e != nil && !m.skipsLink(e.Type()) {
+		path := filepath.Join(dir, ".gitattributes")
+		if data, err := os.ReadFile(path); err == nil {
+			a.dirs[rel] = a.parse(data, rel, path, rel == "")
+		}
+	}
+	for _, e := range entries {
+		if e.IsDir() && e.Name() != ".git" {
+			if err := a.loadDir(m, root, joinRel(rel, e.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Errors returns the lines git would warn about and skip: invalid
+// patterns and attribute names, negated patterns, and macros defined in
+// nested .gitattributes files.
+func (a *Attributes) Errors() []PatternError {
+	return a.errors
+}
+
+// parse returns the attribute lines in data, read from source, the
+// .gitattributes of directory dir. macros is whether the file may define
+// macros, which are added to a's as they are read.
+func (a *Attributes) parse(data []byte, dir, source string, macros bool) *attrSource {
+	s := &attrSource{}
+	text := strings.TrimPrefix(string(data), utf8BOM)
+	dirSegs := splitDir(dir)
+	for lineNum, raw := range strings.Split(text, "\n") {
+		line := strings.TrimLeft(raw, attrBlank)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		perr := PatternError{Pattern: strings.TrimRight(line, attrBlank), Source: source, Line: lineNum + 1}
+		pat, rest, ok := cutAttrPattern(line)
+		if !ok {
+			perr.Message = "unterminated quoted pattern"
+			a.errors = append(a.errors, perr)
+			continue
+		}
+		attrs, msg := parseAttrs(rest)
+		if msg != "" {
+			perr.Message = msg
+			a.errors = append(a.errors, perr)
+			continue
+		}
+		if name, ok := strings.CutPrefix(pat, "[attr]"); ok {
+			switch {
+			case !macros:
+				perr.Message = "macros can only be defined in top-level attribute files"
+			case !validAttrName(name):
+				perr.Message = "invalid macro name"
+			default:
+				a.macros[name] = attrs
+				continue
+			}
+			a.errors = append(a.errors, perr)
+			continue
+		}
+		if strings.HasPrefix(pat, "!") {
+			perr.Message = "negative patterns are ignored in git attributes"
+			a.errors = append(a.errors, perr)
+			continue
+		}
+		p, msg := compileDirect(pat, dirSegs)
+		if msg != "" {
+			perr.Message = msg
+			a.errors = append(a.errors, perr)
+			continue
+		}
+		s.lines = append(s.lines, attrLine{p: p, attrs: attrs})
+	}
+	return s
+}
+
+// attrBlank holds the bytes git skips between the fields of a line.
+const attrBlank = " \t\r\n"
+
+// cutAttrPattern returns the pattern line starts with, unquoted if it is
+// quoted C-style, and the rest of the line. It reports false for an
+// unterminated quote.
+func cutAttrPattern(line string) (string, string, bool) {
+	if line[0] == '"' {
+		quoted, err := strconv.QuotedPrefix(line)
+		if err != nil {
+			return "", "", false
+		}
+		pat, err := strconv.Unquote(quoted)
+		return pat, line[len(quoted):], err == nil
+	}
+	if i := strings.IndexAny(line, attrBlank); i >= 0 {
+		return line[:i], line[i:], true
+	}
+	return line, "", true
+}
+
+// parseAttrs parses the attributes after a pattern, or returns why they
+// are invalid.
+func parseAttrs(text string) ([]attrAssign, string) {
+	var attrs []attrAssign
+	for _, f := range strings.FieldsFunc(text, func(r rune) bool { return strings.ContainsRune(attrBlank, r) }) {
+		var as attrAssign
+		switch {
+		case f[0] == '-':
+			as = attrAssign{f[1:], AttrValue{State: AttrUnset}}
+		case f[0] == '!':
+			as = attrAssign{f[1:], AttrValue{State: AttrUnspecified}}
+		default:
+			name, value, ok := strings.Cut(f, "=")
+			as = attrAssign{name, AttrValue{State: AttrSet}}
+			if ok {
+				as.value = AttrValue{State: AttrString, Value: value}
+			}
+		}
+		if !validAttrName(as.name) {
+			return nil, as.name + " is not a valid attribute name"
+		}
+		attrs = append(attrs, as)
+	}
+	return attrs, ""
+}
+
+// validAttrName reports whether name is a valid attribute name: ASCII
+// letters, digits, '-', '.' and '_', not starting with '-', and not
+// starting with "builtin_", which git reserves.
+func validAttrName(name string) bool {
+	if name == "" || name[0] == '-' || strings.HasPrefix(name, "builtin_") {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// Attrs returns the attributes specified for relPath, a path relative
+// to the root, slash-separated, with a trailing slash for a directory.
+// Attributes that are unspecified are left out. A path outside the
+// root, the root itself, or a path with a NUL byte has none.
+func (a *Attributes) Attrs(relPath string) map[string]AttrValue {
+	values := make(map[string]AttrValue)
+	a.fill(relPath, func(name string) bool {
+		_, done := values[name]
+		return done
+	}, func(name string, v AttrValue) {
+		values[name] = v
+	})
+	for name, v := range values {
+		if v.State == AttrUnspecified {
+			delete(values, name)
+		}
+	}
+	return values
+}
+
+// Attr returns the state of the attribute name for relPath, as Attrs
+// reports it.
+func (a *Attributes) Attr(relPath, name string) AttrValue {
+	return a.Attrs(relPath)[name]
+}
+
+// fill gives each attribute of relPath its value, as git does: going
+// through the lines that match relPath from the one with the most
+// precedence, and each line's attributes from right to left, the first
+// value found for an attribute is the one it gets. A macro set this way
+// gives its attributes the values in its definition, unless they already
+// have one. done reports whether an attribute has been given its value,
+// and set gives it.
+func (a *Attributes) fill(relPath string, done func(string) bool, set func(string, AttrValue)) {
+	if strings.IndexByte(relPath, 0) >= 0 {
+		return
+	}
+	isDir := strings.HasSuffix(relPath, "/")
+	relPath, ok := resolvePath(relPath)
+	if !ok || relPath == "" {
+		return
+	}
+	segs := strings.Split(relPath, "/")
+
+	var assign func(attrs []attrAssign)
+	assign = func(attrs []attrAssign) {
+		for i := len(attrs) - 1; i >= 0; i-- {
+			as := attrs[i]
+			if done(as.name) {
+				continue
+			}
+			set(as.name, as.value)
+			if def, ok := a.macros[as.name]; ok && as.value.State == AttrSet {
+				assign(def)
+			}
+		}
+	}
+	visit := func(s *attrSource) {
+		if s == nil {
+			return
+		}
+		for i := len(s.lines) - 1; i >= 0; i-- {
+			l := &s.lines[i]
+			if matchDirect(&l.p, segs, isDir) {
+				assign(l.attrs)
+			}
+		}
+	}
+	visit(a.info)
+	for i := len(segs) - 1; i >= 0; i-- {
+		visit(a.dirs[strings.Join(segs[:i], "/")])
+	}
+	visit(a.global)
+}
@@ -0,0 +1,155 @@
+package gitignore_test
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+// attrs builds the attributes Attrs returns from the lines git check-attr
+// -a prints for a path.
+func attrs(kv ...string) map[string]gitignore.AttrValue {
+	m := make(map[string]gitignore.AttrValue)
+	for i := 0; i < len(kv); i += 2 {
+		switch v := kv[i+1]; v {
+		case "set":
+			m[kv[i]] = gitignore.AttrValue{State: gitignore.AttrSet}
+		case "unset":
+			m[kv[i]] = gitignore.AttrValue{State: gitignore.AttrUnset}
+		default:
+			m[kv[i]] = gitignore.AttrValue{State: gitignore.AttrString, Value: v}
+		}
+	}
+	return m
+}
+
+func TestAttributes(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	writeFiles(t, xdg, map[string]string{"git/attributes": "*.txt glob=yes text\n*.md from=global\n"})
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitattributes": "[attr]mac foo -bar baz=1\n" +
+			"*.bin binary\n" +
+			"*.txt text eol=crlf -glob\n" +
+			"*.gen linguist-generated\n" +
+			"docs export-ignore\n" +
+			"docs/ dironly\n" +
+			"vendor/** vend\n" +
+			"/top.txt anchored\n" +
+			"*.mac mac\n" +
+			"*.umac -mac\n" +
+			"*.mac2 mac -foo\n" +
+			"*.mix a b c !b\n" +
+			"!neg x\n" +
+			"\"q x.c\" quoted\n" +
+			"*.bad ok -valid bad~name\n" +
+			"sub/*.c subc\n",
+		"sub/.gitattributes":      "*.c nested -text\n[attr]m2 x\n*.txt !eol\n",
+		"sub/deep/.gitattributes": "*.c deep=1 nested=2\n",
+		".git/info/attributes":    "*.c info\n",
+	})
+
+	// As git check-attr -a prints them.
+	want := map[string]map[string]gitignore.AttrValue{
+		"a.bin":          attrs("binary", "set", "diff", "unset", "merge", "unset", "text", "unset"),
+		"a.txt":          attrs("text", "set", "glob", "unset", "eol", "crlf"),
+		"top.txt":        attrs("text", "set", "glob", "unset", "eol", "crlf", "anchored", "set"),
+		"sub/top.txt":    attrs("text", "set", "glob", "unset"),
+		"a.gen":          attrs("linguist-generated", "set"),
+		"docs":           attrs("export-ignore", "set"),
+		"docs/":          attrs("export-ignore", "set", "dironly", "set"),
+		"docs/x":         attrs(),
+		"vendor/a/b":     attrs("vend", "set"),
+		"vendor":         attrs(),
+		"vendor/":        attrs(),
+		"x.mac":          attrs("mac", "set", "foo", "set", "bar", "unset", "baz", "1"),
+		"x.umac":         attrs("mac", "unset"),
+		"x.mac2":         attrs("mac", "set", "foo", "unset", "bar", "unset", "baz", "1"),
+		"x.mix":          attrs("a", "set", "c", "set"),
+		"neg":            attrs(),
+		"q x.c":          attrs("quoted", "set", "info", "set"),
+		"a.bad":          attrs(),
+		"sub/a.c":        attrs("text", "unset", "subc", "set", "info", "set", "nested", "set"),
+		"sub/deep/a.c":   attrs("text", "unset", "info", "set", "nested", "2", "deep", "1"),
+		"sub/deep/x/a.c": attrs("text", "unset", "info", "set", "nested", "2", "deep", "1"),
+		"sub/a.txt":      attrs("text", "set", "glob", "unset"),
+		"a.md":           attrs("from", "global"),
+		"":               attrs(),
+		"../a.bin":       attrs(),
+		"a\x00.bin":      attrs(),
+	}
+	a, err := gitignore.LoadAttributes(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range want {
+		if got := a.Attrs(path); !maps.Equal(got, want) {
+			t.Errorf("Attrs(%q) = %v, want %v", path, got, want)
+		}
+	}
+	if got := a.Attr("a.gen", "linguist-generated"); got.State != gitignore.AttrSet || got.String() != "set" {
+		t.Errorf("Attr(a.gen, linguist-generated) = %v, want set", got)
+	}
+	if got := a.Attr("a.gen", "export-ignore"); got.State != gitignore.AttrUnspecified {
+		t.Errorf("Attr(a.gen, export-ignore) = %v, want unspecified", got)
+	}
+
+	// The lines git warns about: the negated pattern, the invalid
+	// attribute name and the macro in a nested file.
+	var got []string
+	for _, e := range a.Errors() {
+		rel, _ := filepath.Rel(root, e.Source)
+		got = append(got, fmt.Sprintf("%s:%d", filepath.ToSlash(rel), e.Line))
+	}
+	if want := []string{".gitattributes:13", ".gitattributes:15", "sub/.gitattributes:2"}; !slices.Equal(got, want) {
+		t.Errorf("Errors() at %v, want %v", got, want)
+	}
+
+	// WithoutGit leaves out .git/info/attributes and the global file.
+	a, err = gitignore.LoadAttributes(root, gitignore.WithoutGit())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := a.Attrs("sub/a.c"), attrs("text", "unset", "subc", "set", "nested", "set"); !maps.Equal(got, want) {
+		t.Errorf("WithoutGit: Attrs(sub/a.c) = %v, want %v", got, want)
+	}
+	if got := a.Attrs("a.md"); len(got) != 0 {
+		t.Errorf("WithoutGit: Attrs(a.md) = %v, want none", got)
+	}
+}
+
+func TestAttributesSymlink(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"attrs": "*.c linked\n", "sub/.keep": ""})
+	if err := os.Symlink(filepath.Join(root, "attrs"), filepath.Join(root, "sub", ".gitattributes")); err != nil {
+		t.Skip(err)
+	}
+	for _, tt := range []struct {
+		opts []gitignore.Option
+		want int
+	}{
+		{nil, 0},
+		{[]gitignore.Option{gitignore.WithCompat(gitignore.CompatGit2_30)}, 1},
+	} {
+		a, err := gitignore.LoadAttributes(root, tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := a.Attrs("sub/a.c"); len(got) != tt.want {
+			t.Errorf("%d options: Attrs(sub/a.c) = %v", len(tt.opts), got)
+		}
+	}
+	if _, err := gitignore.LoadAttributes(filepath.Join(root, "missing")); !os.IsNotExist(err) {
+		t.Errorf("missing root: got %v", err)
+	}
+}
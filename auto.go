@@ -0,0 +1,50 @@
+package gitignore
+
+import (
+	"io/fs"
+	"strings"
+)
+
+// WithFS makes MatchAuto look paths up in fsys, whose root is the
+// repository root, rather than on disk under the root given to New. It
+// suits matchers for repositories that aren't on the local disk, and
+// tests.
+func WithFS(fsys fs.FS) Option {
+	return func(c *config) {
+		c.fsys = fsys
+	}
+}
+
+// MatchAuto is Match for a path that exists, finding out whether it is a
+// directory by looking it up instead of needing a trailing slash. Like
+// git, it doesn't follow symlinks, so a link to a directory matches as a
+// file. It looks paths up under the root given to New, LoadDirectory or
+// LoadCache, or in the filesystem set by WithFS. A path that can't be
+// looked up, or a Matcher with nowhere to look, as one restored by
+// UnmarshalBinary, matches as a file; a trailing slash still marks a
+// directory.
+func (m *Matcher) MatchAuto(relPath string) bool {
+	relPath, isDir, ok := m.path(relPath, true)
+	if !ok {
+		return false
+	}
+	if !isDir {
+		isDir = m.isDir(relPath)
+	}
+	return m.match(relPath, isDir)
+}
+
+// isDir reports whether relPath is a directory in m's filesystem.
+func (m *Matcher) isDir(relPath string) bool {
+	if m.fsys == nil {
+		return false
+	}
+	for strings.HasPrefix(relPath, "./") {
+		relPath = relPath[2:]
+	}
+	if relPath == "" || !fs.ValidPath(relPath) {
+		return false
+	}
+	info, err := fs.Lstat(m.fsys, relPath)
+	return err == nil && info.IsDir()
+}
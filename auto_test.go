@@ -0,0 +1,68 @@
+package gitignore_test
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"testing/fstest"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestMatchAuto(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":      "build/\nvendor/\nlink/\nmissing/\n",
+		"build/out.bin":   "",
+		"vendor":          "", // a file, so build/-style patterns don't apply
+		"src/build/a.go":  "",
+		"target/main.go":  "",
+		"target/.gitkeep": "",
+	})
+	if runtime.GOOS != "windows" {
+		if err := os.Symlink(filepath.Join(root, "target"), filepath.Join(root, "link")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := gitignore.New(root)
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"build", true},
+		{"./build", true},
+		{"src/build", true},
+		{"build/out.bin", true},
+		{"vendor", false},
+		{"link", false}, // git doesn't follow the link
+		{"missing", false},
+		{"missing/", true},
+		{"target", false},
+	}
+	for _, tt := range tests {
+		if got := m.MatchAuto(tt.path); got != tt.want {
+			t.Errorf("MatchAuto(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestMatchAutoFS(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	fsys := fstest.MapFS{
+		"dist":     {Mode: fs.ModeDir | 0755},
+		"dist.txt": {},
+	}
+	m := gitignore.New(t.TempDir(), gitignore.WithFS(fsys))
+	m.AddPatterns([]byte("dist/\ndist.txt/\n"), "")
+	if !m.MatchAuto("dist") || m.MatchAuto("dist.txt") {
+		t.Error("expected MatchAuto to look paths up in the WithFS filesystem")
+	}
+}
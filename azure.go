@@ -0,0 +1,233 @@
+package gitignore
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// AzureIgnore decides which files Azure DevOps leaves out, following a
+// .tfignore, as Team Foundation Version Control reads it, or an
+// .artifactignore, as the pipeline tasks publishing artifacts and
+// Universal Packages read it, for pipeline tooling that needs the file
+// set the Azure agents compute. Both are close to gitignore, with these
+// differences:
+//
+//   - A path is decided by itself, as Match on a Matcher decides it: a
+//     negation can re-include a file inside an ignored directory, so
+//     "**/*" and then "!*.exe" keep only the .exe files.
+//   - A .tfignore applies in its directory and those below it, and one
+//     in a directory below takes precedence, as with .gitignore. Its
+//     separator is a backslash, which escapes nothing; a pattern
+//     starting with one applies only in the directory of the .tfignore,
+//     one holding one applies below its directory part at any depth,
+//     and any other at any depth. Only "*" and "?" are wildcards.
+//     Matching ignores case, as on Windows, and TFVC's default
+//     exclusions, .dll files and the $tf folder of a local workspace,
+//     come first, so a .tfignore can undo them with "!*.dll".
+//   - An .artifactignore is read only in the directory being published,
+//     with gitignore syntax. A directory without one has the .git folder
+//     left out; an empty .artifactignore includes it.
+type AzureIgnore struct {
+	root  string
+	m     *Matcher
+	prune bool // no pattern is a negation, so an ignored directory's files are all ignored
+}
+
+// tfignoreDefaults lists, as patterns, what TFVC leaves out of a local
+// workspace before any .tfignore is read.
+const tfignoreDefaults = `*.dll
+/$tf/
+`
+
+// artifactignoreDefaults lists, as patterns, what is left out of a
+// published artifact without an .artifactignore.
+const artifactignoreDefaults = `/.git/
+`
+
+// LoadTFIgnore reads the .tfignore files in root and the directories
+// below it, but for the $tf folder. opts are those of LoadDirectory that
+// apply to patterns, such as WithLimits and WithInvalidPatterns; case is
+// always ignored. It returns an error if a .tfignore can't be read, or
+// as LoadDirectory does for a limit or an invalid pattern.
+func LoadTFIgnore(root string, opts ...Option) (*AzureIgnore, error) {
+	m := newMatcher(newConfig(append(slices.Clip(opts), WithIgnoreCase(true))))
+	m.root = absRoot(root)
+	m.addPatterns([]byte(tfignoreDefaults), "", "")
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			rel = ""
+		} else if rel == "$tf" {
+			return fs.SkipDir
+		}
+		source := filepath.Join(p, ".tfignore")
+		data, err := m.readSource(source)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		m.addPatterns(tfignoreToGitignore(data), rel, source)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newAzureIgnore(root, m)
+}
+
+// LoadArtifactIgnore reads the .artifactignore in root, the directory
+// being published, if there is one. opts and errors are as for
+// LoadTFIgnore, but for case, which is matched as the options say.
+func LoadArtifactIgnore(root string, opts ...Option) (*AzureIgnore, error) {
+	m := newMatcher(newConfig(opts))
+	m.root = absRoot(root)
+	source := filepath.Join(root, ".artifactignore")
+	data, err := m.readSource(source)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		m.addPatterns([]byte(artifactignoreDefaults), "", "")
+	case err != nil:
+		return nil, err
+	default:
+		m.addPatterns(data, "", source)
+	}
+	return newAzureIgnore(root, m)
+}
+
+// newAzureIgnore returns the AzureIgnore of the patterns m holds, or the
+// error LoadDirectory would return for them.
+func newAzureIgnore(root string, m *Matcher) (*AzureIgnore, error) {
+	w := newOSWalker(root, m, nil)
+	if err := w.checkLimits(0); err != nil {
+		return nil, err
+	}
+	if err := w.checkInvalid(); err != nil {
+		return nil, err
+	}
+	a := &AzureIgnore{root: root, m: m, prune: true}
+	for _, p := range m.patterns {
+		if p.negate {
+			a.prune = false
+			break
+		}
+	}
+	return a, nil
+}
+
+// tfignoreToGitignore rewrites the .tfignore data as a .gitignore, line
+// for line.
+func tfignoreToGitignore(data []byte) []byte {
+	var b strings.Builder
+	text := strings.TrimPrefix(string(data), utf8BOM)
+	for raw := range strings.SplitSeq(strings.TrimSuffix(text, "\n"), "\n") {
+		b.WriteString(tfignoreRule(strings.TrimSpace(raw)))
+		b.WriteByte('\n')
+	}
+	return []byte(b.String())
+}
+
+// tfignoreRule returns the .tfignore line as a .gitignore line.
+func tfignoreRule(line string) string {
+	if line == "" || line[0] == '#' {
+		return line
+	}
+	neg := ""
+	if line[0] == '!' {
+		neg, line = "!", line[1:]
+	}
+	line = strings.ReplaceAll(line, `\`, "/")
+	anchored := strings.HasPrefix(line, "/")
+	line = strings.TrimLeft(line, "/")
+	dirOnly := strings.HasSuffix(line, "/")
+	line = strings.TrimRight(line, "/")
+	if line == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(neg)
+	if anchored || strings.Contains(line, "/") {
+		b.WriteByte('/')
+	} else if line[0] == '#' || line[0] == '!' {
+		b.WriteByte('\\')
+	}
+	dir, name := "", line
+	if i := strings.LastIndexByte(line, '/'); i >= 0 && !anchored {
+		dir, name = line[:i], line[i+1:]
+	}
+	if dir != "" {
+		b.WriteString(tfignoreGlob(dir) + "/**/")
+	}
+	b.WriteString(tfignoreGlob(name))
+	if dirOnly {
+		b.WriteByte('/')
+	}
+	return b.String()
+}
+
+// tfignoreGlob escapes the characters of the .tfignore glob s, with
+// slashes for separators, that are special in gitignore syntax but not
+// in TFVC's.
+func tfignoreGlob(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '[' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// Match reports whether relPath is left out, deciding it by itself. The
+// path is as for Match on a Matcher, and a trailing slash marks a
+// directory.
+func (a *AzureIgnore) Match(relPath string) bool {
+	return a.m.Match(relPath)
+}
+
+// Files returns the files that aren't left out, slash-separated, relative
+// to the root and sorted. It looks inside ignored directories, where a
+// negation can re-include files, unless there is none. Symlinks and other
+// files that aren't regular are listed like files, and never followed.
+func (a *AzureIgnore) Files() ([]string, error) {
+	var files []string
+	err := fs.WalkDir(os.DirFS(a.root), ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name == "." {
+			return nil
+		}
+		if d.IsDir() {
+			if a.prune && a.m.Match(name+"/") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !a.m.Match(name) {
+			files = append(files, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(files)
+	return files, nil
+}
@@ -0,0 +1,147 @@
+package gitignore_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestTFIgnore(t *testing.T) {
+	for _, tt := range []struct {
+		tfignore string
+		want     map[string]bool
+	}{
+		// The examples of the TFVC documentation.
+		{"\\*.txt\n", map[string]bool{"a.txt": true, "sub/a.txt": false}},
+		{"*.cpp\n", map[string]bool{"a.cpp": true, "sub/deep/a.cpp": true}},
+		{"ProjA\\*.cpp\n", map[string]bool{"ProjA/a.cpp": true, "ProjA/sub/a.cpp": true, "a.cpp": false, "x/ProjA/a.cpp": false}},
+		{"ProjB\n", map[string]bool{"ProjB": true, "ProjB/x": true, "sub/ProjB/x": true}},
+		{"*.cpp\n!ProjA\\main.cpp\n", map[string]bool{"ProjA/main.cpp": false, "ProjA/util.cpp": true}},
+		// The default exclusions, and undoing them.
+		{"", map[string]bool{"a.dll": true, "bin/A.DLL": true, "$tf/x": true, "a/$tf/x": false, "a.exe": false}},
+		{"!*.dll\n", map[string]bool{"a.dll": false, "bin/a.dll": false}},
+		// Case, separators and characters special only to gitignore.
+		{"Bin\\\n", map[string]bool{"bin/x": true, "bin/": true, "bin": false, "sub/BIN/x": true}},
+		{"[x].txt\n#1\n", map[string]bool{"[x].txt": true, "x.txt": false, "#1": false}},
+		{"a?c\n  b  \n", map[string]bool{"abc": true, "b": true}},
+		// A path is decided by itself.
+		{"**/*\n!*.exe\n", map[string]bool{"a.exe": false, "bin/a.exe": false, "bin/a.pdb": true}},
+	} {
+		root := t.TempDir()
+		writeFiles(t, root, map[string]string{".tfignore": tt.tfignore})
+		a, err := gitignore.LoadTFIgnore(root)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.tfignore, err)
+		}
+		for path, want := range tt.want {
+			if got := a.Match(path); got != want {
+				t.Errorf("%q: Match(%q) = %v, want %v", tt.tfignore, path, got, want)
+			}
+		}
+	}
+}
+
+func TestTFIgnoreNested(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".tfignore":       "*.log\n\\out\\\n",
+		"src/.tfignore":   "!debug.log\n\\gen.cs\n",
+		"src/debug.log":   "",
+		"src/app.log":     "",
+		"src/gen.cs":      "",
+		"src/sub/gen.cs":  "",
+		"src/lib.dll":     "",
+		"out/a.cs":        "",
+		"$tf/.tfignore":   "!*.dll\n", // not read
+		"$tf/x":           "",
+		"readme.md":       "",
+		"src/sub/main.cs": "",
+	})
+	a, err := gitignore.LoadTFIgnore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := a.Files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{".tfignore", "readme.md", "src/.tfignore", "src/debug.log", "src/sub/gen.cs", "src/sub/main.cs"}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("Files = %q, want %q", files, want)
+	}
+}
+
+func TestArtifactIgnore(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{".git/HEAD": "", "bin/app.exe": "", "bin/app.pdb": "", "readme.md": ""})
+	a, err := gitignore.LoadArtifactIgnore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := a.Files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"bin/app.exe", "bin/app.pdb", "readme.md"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("no .artifactignore: Files = %q, want %q", files, want)
+	}
+
+	// The example of the Azure Artifacts documentation.
+	writeFiles(t, root, map[string]string{".artifactignore": "**/*\n!*.exe\n"})
+	if a, err = gitignore.LoadArtifactIgnore(root); err != nil {
+		t.Fatal(err)
+	}
+	if files, err = a.Files(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"bin/app.exe"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("Files = %q, want %q", files, want)
+	}
+
+	writeFiles(t, root, map[string]string{".artifactignore": ""})
+	if a, err = gitignore.LoadArtifactIgnore(root); err != nil {
+		t.Fatal(err)
+	}
+	if a.Match(".git/") || a.Match(".git/HEAD") {
+		t.Error("empty .artifactignore: .git/HEAD left out")
+	}
+
+	a, err = gitignore.LoadArtifactIgnore(root, gitignore.WithIgnoreCase(true))
+	if err != nil || a.Match("README.MD") {
+		t.Errorf("WithIgnoreCase: %v", err)
+	}
+}
+
+func TestAzureIgnoreErrors(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{".artifactignore": "a\n\\\n", "sub/.tfignore": "a\nb\nc\n"})
+	fail := gitignore.WithInvalidPatterns(gitignore.FailOnInvalid)
+	_, err := gitignore.LoadArtifactIgnore(root, fail)
+	var perr gitignore.PatternError
+	if !errors.As(err, &perr) || perr.Line != 2 {
+		t.Errorf("got %v, want a PatternError on line 2", err)
+	}
+	if _, err := gitignore.LoadArtifactIgnore(root); err != nil {
+		t.Errorf("SkipInvalid: %v", err)
+	}
+
+	_, err = gitignore.LoadTFIgnore(root, gitignore.WithLimits(gitignore.Limits{MaxPatterns: 3}))
+	var lerr *gitignore.LimitError
+	if !errors.As(err, &lerr) {
+		t.Errorf("got %v, want a LimitError", err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(root, "d", ".tfignore"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gitignore.LoadTFIgnore(root); err == nil {
+		t.Error("want an error for an unreadable .tfignore")
+	}
+	if _, err := gitignore.LoadTFIgnore(filepath.Join(root, "missing")); err == nil {
+		t.Error("want an error for a missing root")
+	}
+}
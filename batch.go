@@ -0,0 +1,54 @@
+package gitignore
+
+import (
+	"runtime"
+	"sync"
+)
+
+// MatchPaths reports for each of paths whether it should be ignored, as
+// Match would. The result has one entry per path, in the same order.
+func (m *Matcher) MatchPaths(paths []string) []bool {
+	out := make([]bool, len(paths))
+	m.matchInto(paths, out)
+	return out
+}
+
+// MatchPathsParallel is MatchPaths spread over workers goroutines, for
+// classifying millions of paths. Each worker takes a contiguous share of
+// paths and matches it against the same read-only patterns, so the
+// Matcher must not be modified meanwhile. A workers value of zero or less
+// means runtime.GOMAXPROCS(0).
+func (m *Matcher) MatchPathsParallel(paths []string, workers int) []bool {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	// Not worth a goroutine below this many paths each.
+	const minShare = 256
+	workers = min(workers, (len(paths)+minShare-1)/minShare)
+	out := make([]bool, len(paths))
+	if workers <= 1 {
+		m.matchInto(paths, out)
+		return out
+	}
+
+	// Load the global excludes up front rather than have every worker
+	// wait on the first one to get there.
+	m.globalMatcher()
+
+	var wg sync.WaitGroup
+	share := (len(paths) + workers - 1) / workers
+	for lo := 0; lo < len(paths); lo += share {
+		hi := min(lo+share, len(paths))
+		wg.Go(func() {
+			m.matchInto(paths[lo:hi], out[lo:hi])
+		})
+	}
+	wg.Wait()
+	return out
+}
+
+func (m *Matcher) matchInto(paths []string, out []bool) {
+	for i, p := range paths {
+		out[i] = m.Match(p)
+	}
+}
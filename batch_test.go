@@ -0,0 +1,53 @@
+package gitignore_test
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMatchPaths(t *testing.T) {
+	m := setupMatcher(t, "*.log\n!keep.log\nbuild/\n")
+	paths := []string{"a.log", "keep.log", "build/", "build", "src/main.go", "src/build/x"}
+	got := m.MatchPaths(paths)
+	if len(got) != len(paths) {
+		t.Fatalf("got %d results for %d paths", len(got), len(paths))
+	}
+	for i, p := range paths {
+		if got[i] != m.Match(p) {
+			t.Errorf("MatchPaths[%d] (%q) = %v, want %v", i, p, got[i], m.Match(p))
+		}
+	}
+}
+
+func TestMatchPathsParallel(t *testing.T) {
+	m := setupMatcher(t, "*.log\n!keep.log\nbuild/\ngen_*/\n")
+	m.AddPatterns([]byte("*.tmp\n"), "src")
+
+	var paths []string
+	for i := range 5000 {
+		paths = append(paths,
+			fmt.Sprintf("pkg%d/a.log", i),
+			fmt.Sprintf("pkg%d/keep.log", i),
+			fmt.Sprintf("src/gen_%d/x.go", i),
+			fmt.Sprintf("src/pkg%d/a.tmp", i),
+			fmt.Sprintf("lib/pkg%d/a.tmp", i),
+		)
+	}
+	want := m.MatchPaths(paths)
+	for _, workers := range []int{0, 1, 3, 8, 100000} {
+		got := m.MatchPathsParallel(paths, workers)
+		if len(got) != len(want) {
+			t.Fatalf("workers=%d: got %d results, want %d", workers, len(got), len(want))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("workers=%d: path %q = %v, want %v", workers, paths[i], got[i], want[i])
+				break
+			}
+		}
+	}
+
+	if got := m.MatchPathsParallel(nil, 4); len(got) != 0 {
+		t.Errorf("expected no results for no paths, got %v", got)
+	}
+}
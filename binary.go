@@ -0,0 +1,112 @@
+//go:build !tinygo
+
+package gitignore
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+)
+
+// encodedMatcher is the MarshalBinary form of a Matcher.
+type encodedMatcher struct {
+	Version      int
+	DFA          bool
+	IgnoreCase   bool
+	Precompose   bool
+	Strict       bool
+	Unicode      bool
+	Invalid      InvalidPatternPolicy
+	Limits       Limits
+	Windows      bool
+	Cleaning     pathCleaning
+	Arena        bool
+	Braces       bool
+	Regex        bool
+	Syntax       string
+	DirCacheSize int
+	Patterns     []cachedPattern
+	Errors       []PatternError
+	Global       []cachedPattern
+	GlobalErrors []PatternError
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, so a Matcher can be
+// built once and sent to other processes, which restore it with
+// UnmarshalBinary without reading or finding any files. The global
+// excludes are loaded if they haven't been and included, so the restored
+// Matcher matches the same paths wherever it is decoded. The WithDFA,
+// WithIgnoreCase, WithPrecomposeUnicode, WithStrictEscapes,
+// WithUnicodeClasses, WithInvalidPatterns, WithLimits, WithWindowsPaths,
+// WithCleanPaths, WithArena, WithBraceExpansion, WithRegexPatterns,
+// WithSyntax and WithDirCache settings are kept; Stats counters, the
+// files recorded for SaveCache and the root MatchAbs uses are not.
+func (m *Matcher) MarshalBinary() ([]byte, error) {
+	e := encodedMatcher{
+		Version:    cacheVersion,
+		DFA:        m.dfa,
+		IgnoreCase: m.ignoreCase,
+		Precompose: m.precompose,
+		Strict:     m.strict,
+		Unicode:    m.unicode,
+		Invalid:    m.invalid,
+		Limits:     m.limits,
+		Windows:    m.windows,
+		Cleaning:   m.cleaning,
+		Arena:      m.arena != nil,
+		Braces:     m.braces,
+		Regex:      m.regex,
+		Syntax:     m.syntax,
+		Patterns:   m.cachedPatterns(),
+		Errors:     m.errors,
+	}
+	if m.dirCache != nil {
+		e.DirCacheSize = m.dirCache.size
+	}
+	if g := m.globalMatcher(); g != nil {
+		e.Global = g.cachedPatterns()
+		e.GlobalErrors = g.errors
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&e); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing m with
+// the Matcher encoded by MarshalBinary. It recompiles the patterns, which
+// is cheap next to reading them, and fails if data was encoded by an
+// incompatible version of this package or with a pattern syntax that
+// isn't registered.
+func (m *Matcher) UnmarshalBinary(data []byte) error {
+	var e encodedMatcher
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&e); err != nil {
+		return err
+	}
+	if e.Version != cacheVersion {
+		return errors.New("gitignore: encoded Matcher has an unsupported version")
+	}
+	if e.Syntax != "" && lookupSyntax(e.Syntax) == nil {
+		return errors.New("gitignore: encoded Matcher uses unknown pattern syntax " + e.Syntax)
+	}
+
+	opts := patternOptions{ignoreCase: e.IgnoreCase, precompose: e.Precompose, strict: e.Strict, unicode: e.Unicode, invalid: e.Invalid, braces: e.Braces, regex: e.Regex, windows: e.Windows, cleaning: e.Cleaning}
+	cfg := &config{dfa: e.DFA, patternOptions: opts, limits: e.Limits, arena: e.Arena, syntax: e.Syntax, dirCacheSize: e.DirCacheSize}
+	dec := newMatcher(cfg)
+	dec.errors = e.Errors
+	if !dec.replay(e.Patterns) {
+		return errors.New("gitignore: encoded Matcher has an invalid pattern")
+	}
+	if e.Global != nil {
+		g := newMatcher(&config{dfa: e.DFA, patternOptions: opts})
+		g.errors = e.GlobalErrors
+		if !g.replay(e.Global) {
+			return errors.New("gitignore: encoded Matcher has an invalid pattern")
+		}
+		dec.global = &globalExcludes{m: g}
+		dec.global.once.Do(func() {})
+	}
+	*m = *dec
+	return nil
+}
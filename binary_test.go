@@ -0,0 +1,232 @@
+//go:build !tinygo
+
+package gitignore_test
+
+import (
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestMarshalBinary(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":     "*.log\n!keep.log\n[[:nope:]]\n",
+		"src/.gitignore": "*.tmp\n",
+		"src/x.go":       "",
+	})
+	xdg := t.TempDir()
+	writeFiles(t, xdg, map[string]string{"git/ignore": "*.global\n"})
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	for _, opts := range [][]gitignore.Option{nil, {gitignore.WithDFA(), gitignore.WithDirCache(16)}} {
+		orig := gitignore.NewFromDirectory(root, opts...)
+		data, err := orig.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Restoring must not depend on the files the Matcher was built from.
+		t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+		var m gitignore.Matcher
+		if err := m.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("XDG_CONFIG_HOME", xdg)
+
+		for _, path := range []string{"a.log", "keep.log", "src/a.tmp", "a.tmp", "src/b.log", "a.global", "src/x.go"} {
+			if got, want := m.Match(path), orig.Match(path); got != want {
+				t.Errorf("Match(%q) = %v, want %v", path, got, want)
+			}
+			if got, want := m.MatchDetail(path), orig.MatchDetail(path); got != want {
+				t.Errorf("MatchDetail(%q) = %+v, want %+v", path, got, want)
+			}
+		}
+		if !m.Match("a.global") {
+			t.Error("expected the global excludes to be encoded")
+		}
+		if got, want := len(m.Errors()), len(orig.Errors()); got != want || got != 1 {
+			t.Errorf("got %d errors, want %d", got, want)
+		}
+	}
+}
+
+func TestUnmarshalBinaryInvalid(t *testing.T) {
+	var m gitignore.Matcher
+	if err := m.UnmarshalBinary([]byte("not a matcher")); err == nil {
+		t.Error("expected an error decoding garbage")
+	}
+}
+
+func TestMarshalBinaryKeepsAddedPatterns(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	orig := gitignore.New(t.TempDir())
+	orig.AddPatterns([]byte("build/\n"), "pkg")
+	data, err := orig.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m gitignore.Matcher
+	if err := m.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Match("pkg/build/") || m.Match("build/") {
+		t.Error("expected the scoped pattern to survive the round trip")
+	}
+}
+
+// roundTrip returns m encoded with MarshalBinary and decoded again.
+func roundTrip(t *testing.T, m *gitignore.Matcher) *gitignore.Matcher {
+	t.Helper()
+	data, err := m.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dec gitignore.Matcher
+	if err := dec.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	return &dec
+}
+
+func TestMarshalBinaryNoRoot(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{".gitignore": "*.log\n"})
+	dec := roundTrip(t, gitignore.New(root))
+	if dec.MatchAbs(filepath.Join(root, "a.log")) {
+		t.Error("expected a decoded Matcher to have no root")
+	}
+	fsys := fstest.MapFS{"dist": {Mode: fs.ModeDir | 0755}}
+	m := gitignore.New(t.TempDir(), gitignore.WithFS(fsys))
+	m.AddPatterns([]byte("dist/\n"), "")
+	if dec := roundTrip(t, m); dec.MatchAuto("dist") || !dec.MatchAuto("dist/") {
+		t.Error("expected a decoded Matcher to match paths as files unless marked")
+	}
+}
+
+func TestMarshalBinaryWindowsPaths(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	win := gitignore.New(t.TempDir(), gitignore.WithWindowsPaths())
+	win.AddPatterns([]byte("src/gen/\n"), "")
+	m := roundTrip(t, win)
+	if !m.Match(`src\gen\`) || m.Match(`src\gen`) || !m.Match(`src\gen\x.go`) {
+		t.Error("expected Match to take backslashes")
+	}
+	if !m.MatchPath(`src\gen`, true) || !m.MatchFull(`.\src\gen\sub\x.go`) ||
+		m.MatchDetail(`src\gen\x.go`).Pattern != "src/gen/" || !m.MatchDirContents(`src\gen`) {
+		t.Error("expected the other methods to take backslashes")
+	}
+}
+
+func TestMarshalBinaryCleanPaths(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	clean := gitignore.New(t.TempDir(), gitignore.WithCleanPaths(true))
+	clean.AddPatterns([]byte("src/a.log\ngen/\n"), "")
+	m := roundTrip(t, clean)
+	for _, path := range []string{"src//a.log", "./src/./a.log", "/src/a.log", "src/gen/."} {
+		if !m.Match(path) || !m.MatchFull(path) || !m.MatchDetail(path).Ignored {
+			t.Errorf("decoded Match(%q) = false, want the path cleaned", path)
+		}
+	}
+}
+
+func TestMarshalBinaryBraceExpansion(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	writeFiles(t, xdg, map[string]string{"git/ignore": "*.{c,h}\n"})
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{".gitignore": "*.{js,ts}\n"})
+	dec := roundTrip(t, gitignore.New(root, gitignore.WithBraceExpansion()))
+	dec.AddPatterns([]byte("{x,y}.go\n"), "")
+	for _, path := range []string{"a.js", "a.ts", "x.go", "y.go", "a.c", "a.h"} {
+		if !dec.Match(path) {
+			t.Errorf("decoded: Match(%q) = false", path)
+		}
+	}
+}
+
+func TestMarshalBinaryRegexPatterns(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	m := gitignore.New(t.TempDir(), gitignore.WithRegexPatterns())
+	m.AddPatterns([]byte("re:[0-9]+\\.out\n"), "")
+	dec := roundTrip(t, m)
+	dec.AddPatterns([]byte("re:x+\n"), "")
+	if !dec.Match("12.out") || !dec.Match("xxx") || dec.Match("a.out") {
+		t.Error("decoded: want the expressions kept")
+	}
+}
+
+func TestMarshalBinaryStrictEscapes(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	dec := roundTrip(t, gitignore.New(t.TempDir(), gitignore.WithStrictEscapes()))
+	dec.AddPatterns([]byte("a[\n"), "")
+	if dec.Match("a[") || len(dec.Errors()) != 1 {
+		t.Errorf("decoded Matcher is not strict: Errors() = %v", dec.Errors())
+	}
+}
+
+func TestMarshalBinaryUnicodeClasses(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	dec := roundTrip(t, gitignore.New(t.TempDir(), gitignore.WithUnicodeClasses()))
+	dec.AddPatterns([]byte("[[:alpha:]]\n"), "")
+	if !dec.Match("é") {
+		t.Error("decoded Matcher lost WithUnicodeClasses")
+	}
+}
+
+func TestMarshalBinaryInvalidPatterns(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	for _, opts := range [][]gitignore.Option{nil, {gitignore.WithDFA()}} {
+		m := gitignore.New(t.TempDir(), append(opts, gitignore.WithInvalidPatterns(gitignore.LiteralInvalid))...)
+		m.AddPatterns([]byte(invalidPatterns), "")
+		dec := roundTrip(t, m)
+		for _, path := range []string{"[[:word:]].txt", "src/[[:word:]].txt", "a.txt", "foo\\", "foo", "a.log", "keep[[:x:]].log", "#[[:x:]]"} {
+			if got, want := dec.Match(path), m.Match(path); got != want {
+				t.Errorf("decoded Match(%q) = %v, want %v", path, got, want)
+			}
+		}
+	}
+}
+
+func TestMarshalBinaryLimits(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	checkPatternLimits(t, roundTrip(t, gitignore.New(t.TempDir(), patternLimits)))
+}
+
+func TestMarshalBinarySyntax(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{".gitignore": "a.log\n"})
+	dec := roundTrip(t, gitignore.NewFromDirectory(root, gitignore.WithSyntax("anchored")))
+	dec.AddPatterns([]byte("b.log\n"), "")
+	if !dec.Match("a.log") || dec.Match("sub/a.log") || !dec.Match("b.log") || dec.Match("sub/b.log") {
+		t.Error("UnmarshalBinary: want the syntax kept")
+	}
+}
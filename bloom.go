@@ -0,0 +1,57 @@
+package gitignore
+
+import "hash/maphash"
+
+// tokenFilter is a Bloom filter over the keys of a patternSet's byName
+// map. Most path segments key no list at all, and testing the filter is
+// cheaper than looking them up, so a path that shares no name with any
+// pattern is ruled out without touching the map. The filter grows with
+// its keys to keep false positives around 2%.
+type tokenFilter struct {
+	bits []uint64
+	n    int // tokens added
+}
+
+var tokenSeed = maphash.MakeSeed()
+
+// tokenBitsPerKey sets the filter size; with two probes it gives a false
+// positive rate of about 2%.
+const tokenBitsPerKey = 16
+
+func nameToken(s string) uint64 { return maphash.String(tokenSeed, s) }
+
+// mayContain reports whether token h may have been added. An empty
+// filter contains nothing.
+func (f *tokenFilter) mayContain(h uint64) bool {
+	if f.n == 0 {
+		return false
+	}
+	mask := uint64(len(f.bits)*64 - 1)
+	a, b := h&mask, (h>>32)&mask
+	return f.bits[a/64]&(1<<(a%64)) != 0 && f.bits[b/64]&(1<<(b%64)) != 0
+}
+
+// add records a new key of ps, rebuilding the filter from the keys of ps
+// when it has grown too full.
+func (f *tokenFilter) add(ps *patternSet, h uint64) {
+	f.n++
+	if f.n*tokenBitsPerKey <= len(f.bits)*64 {
+		f.set(h)
+		return
+	}
+	size := 1
+	for size*64 < f.n*tokenBitsPerKey {
+		size *= 2
+	}
+	f.bits = make([]uint64, size)
+	for name := range ps.byName {
+		f.set(nameToken(name))
+	}
+}
+
+func (f *tokenFilter) set(h uint64) {
+	mask := uint64(len(f.bits)*64 - 1)
+	a, b := h&mask, (h>>32)&mask
+	f.bits[a/64] |= 1 << (a % 64)
+	f.bits[b/64] |= 1 << (b % 64)
+}
@@ -0,0 +1,45 @@
+package gitignore_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+// The token filter grows as names and extensions are added; every one of
+// them must stay reachable through it.
+func TestTokenFilterGrowth(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	var b strings.Builder
+	for i := range 2000 {
+		fmt.Fprintf(&b, "name%d\n*.ext%d\n", i, i)
+	}
+	m := gitignore.New(t.TempDir())
+	m.AddPatterns([]byte(b.String()), "")
+	m.AddPatterns([]byte(b.String()), "sub")
+
+	for i := range 2000 {
+		for _, p := range []string{
+			fmt.Sprintf("name%d", i),
+			fmt.Sprintf("a/name%d/b", i),
+			fmt.Sprintf("a/file.ext%d", i),
+			fmt.Sprintf("sub/x/name%d", i),
+		} {
+			if !m.Match(p) {
+				t.Fatalf("expected %q to be ignored", p)
+			}
+		}
+		for _, p := range []string{
+			fmt.Sprintf("name%d.ext", i),
+			fmt.Sprintf("a/ext%d", i),
+			fmt.Sprintf("a/name%dx", i),
+		} {
+			if m.Match(p) {
+				t.Fatalf("expected %q not to be ignored", p)
+			}
+		}
+	}
+}
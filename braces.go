@@ -0,0 +1,139 @@
+package gitignore
+
+import "strings"
+
+// WithBraceExpansion makes patterns read "{a,b}" as either of its
+// alternatives, as shells and most other glob libraries do, so
+// "*.{js,ts}" ignores both a.js and a.ts. Git has no such syntax and
+// reads braces literally, as this package does without the option, so
+// the pattern then ignores only a file named "x.{js,ts}"; Lint reports
+// such patterns.
+//
+// Alternatives can hold globs and braces of their own, and can be
+// empty: "a{,.bak}" ignores a and a.bak. Braces with no comma in them,
+// like "{x}", braces nothing closes, and braces after a backslash or in
+// a bracket expression are literal either way.
+//
+// A pattern with braces is expanded into one pattern for each
+// combination of alternatives before it is compiled, and Patterns,
+// MatchDetail and the rest report each by the text it was expanded to,
+// at the line of the original. One that would expand to more than 1024
+// patterns is reported in Errors and left out.
+func WithBraceExpansion() Option {
+	return func(c *config) {
+		c.braces = true
+	}
+}
+
+// maxBraceExpansions caps the patterns one line expands to, since each
+// pair of braces multiplies them.
+const maxBraceExpansions = 1024
+
+// addBraces adds the patterns the braces of line, at lineNum in source,
+// expand to, under a source of their own holding their text. It reports
+// whether line had braces to expand, and false for more once m holds as
+// many patterns as its limits allow.
+func (m *Matcher) addBraces(line, dir string, dirSegs []string, source string, lineNum int) (expanded, more bool) {
+	alts, ok := expandBraces(line, 1)
+	if !ok {
+		m.errors = append(m.errors, PatternError{
+			Pattern: line,
+			Source:  source,
+			Line:    lineNum,
+			Message: "braces expand to more than " + itoa(maxBraceExpansions) + " patterns",
+		})
+		return true, true
+	}
+	if alts == nil {
+		return false, true
+	}
+	// Each pattern's text is what it was compiled from, so it is saved,
+	// replayed and compared like any other.
+	from := len(m.patterns)
+	src := m.addSource(source, dir, strings.Join(alts, "\n"))
+	off := 0
+	more = true
+	for _, alt := range alts {
+		if alt != "" && !m.addLine(alt, src, off, dir, dirSegs, source, lineNum) {
+			more = false
+			break
+		}
+		off += len(alt) + 1
+	}
+	if len(m.patterns) == from {
+		m.sources = m.sources[:src]
+	}
+	return true, more
+}
+
+// expandBraces returns the patterns the braces in line expand to,
+// leftmost first, or nil if it has none to expand. n is the number of
+// patterns expanding the braces around line makes; it reports false if
+// there would be more than maxBraceExpansions.
+func expandBraces(line string, n int) ([]string, bool) {
+	open, end, commas := findBraces(line)
+	if open < 0 {
+		return nil, true
+	}
+	if n *= len(commas) + 1; n > maxBraceExpansions {
+		return nil, false
+	}
+	var out []string
+	from := open + 1
+	for _, c := range append(commas, end) {
+		alt := line[:open] + line[from:c] + line[end+1:]
+		from = c + 1
+		more, ok := expandBraces(alt, n)
+		if !ok {
+			return nil, false
+		}
+		if more == nil {
+			more = []string{alt}
+		}
+		if out = append(out, more...); len(out) > maxBraceExpansions {
+			return nil, false
+		}
+	}
+	return out, true
+}
+
+// findBraces returns the index of the leftmost { in line that starts
+// braces to expand, that of the } closing them, and those of the commas
+// between their alternatives, or -1 if there are none. Escaped
+// characters and bracket expressions are skipped, read as matchSegment
+// does.
+func findBraces(line string) (open, end int, commas []int) {
+	type braces struct {
+		open   int
+		commas []int
+	}
+	var stack []braces
+	open, end = -1, -1
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '[':
+			if _, next, ok := matchBracket(line, i, 0); ok {
+				i = next - 1
+			}
+		case '{':
+			stack = append(stack, braces{open: i})
+		case ',':
+			if len(stack) > 0 {
+				top := &stack[len(stack)-1]
+				top.commas = append(top.commas, i)
+			}
+		case '}':
+			if len(stack) == 0 {
+				continue
+			}
+			b := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if len(b.commas) > 0 && (open < 0 || b.open < open) {
+				open, end, commas = b.open, i, b.commas
+			}
+		}
+	}
+	return open, end, commas
+}
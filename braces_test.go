@@ -0,0 +1,139 @@
+package gitignore_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestBraceExpansion(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tests := []struct {
+		pattern string
+		want    map[string]bool
+	}{
+		{"*.{js,ts,jsx,tsx}", map[string]bool{"a.js": true, "d/a.tsx": true, "a.c": false, "a.{js,ts,jsx,tsx}": false}},
+		{"{a,b{c,d}}.x", map[string]bool{"a.x": true, "bc.x": true, "bd.x": true, "b.x": false}},
+		{"{a,b}{1,2}", map[string]bool{"a1": true, "b2": true, "a": false, "ab": false}},
+		{"a{,.bak}", map[string]bool{"a": true, "a.bak": true, "a.b": false}},
+		{"{src,lib}/*.go", map[string]bool{"src/a.go": true, "lib/a.go": true, "x/src/a.go": false}},
+		{"{build,dist}/", map[string]bool{"build/": true, "dist/": true, "build": false}},
+		{"{single}", map[string]bool{"{single}": true, "single": false}},
+		{"{a,b", map[string]bool{"{a,b": true, "a": false}},
+		{"a,b}", map[string]bool{"a,b}": true, "a": false}},
+		{"\\{a,b}", map[string]bool{"{a,b}": true, "a": false}},
+		{"x[{,]y}", map[string]bool{"x{y}": true, "x,y}": true, "xy": false}},
+		{"{a,{b}", map[string]bool{"{a,{b}": true, "a": false}},
+		{"{a,*.[ch]}", map[string]bool{"a": true, "x.c": true, "x.o": false}},
+	}
+	for _, tt := range tests {
+		for _, opts := range [][]gitignore.Option{nil, {gitignore.WithDFA()}, {gitignore.WithIgnoreCase(true)}} {
+			m := gitignore.New(t.TempDir(), append(opts, gitignore.WithBraceExpansion())...)
+			m.AddPatterns([]byte(tt.pattern+"\n"), "")
+			for path, want := range tt.want {
+				if got := m.Match(path); got != want {
+					t.Errorf("%q: Match(%q) = %v, want %v", tt.pattern, path, got, want)
+				}
+			}
+			if errs := m.Errors(); len(errs) != 0 {
+				t.Errorf("%q: Errors() = %v", tt.pattern, errs)
+			}
+		}
+	}
+
+	// Like git, a Matcher without the option reads braces literally.
+	m := gitignore.New(t.TempDir())
+	m.AddPatterns([]byte("*.{js,ts}\n"), "")
+	if m.Match("a.js") || !m.Match("a.{js,ts}") {
+		t.Error("without the option: want braces matched literally")
+	}
+}
+
+func TestBraceExpansionDetail(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore": "# web\n*.{js,ts}\n!keep.{js,ts}\n",
+	})
+	m := gitignore.New(root, gitignore.WithBraceExpansion())
+	for path, want := range map[string]gitignore.MatchResult{
+		"a.ts":    {Ignored: true, Matched: true, Pattern: "*.ts", Line: 2},
+		"keep.js": {Matched: true, Pattern: "!keep.js", Line: 3, Negate: true},
+	} {
+		want.Source = filepath.Join(root, ".gitignore")
+		if got := m.MatchDetail(path); got != want {
+			t.Errorf("MatchDetail(%q) = %+v, want %+v", path, got, want)
+		}
+	}
+
+	// Alternatives left over once the rest are taken are still limited.
+	m = gitignore.New(t.TempDir(), gitignore.WithBraceExpansion(), gitignore.WithLimits(gitignore.Limits{MaxPatterns: 3}))
+	m.AddPatterns([]byte("{a,b}\n{c,d}\ne\n"), "")
+	if !m.Match("c") || m.Match("d") || m.Match("e") {
+		t.Error("MaxPatterns: want only the first three patterns")
+	}
+	if errs := m.Errors(); len(errs) != 1 || errs[0].Pattern != "d" || errs[0].Line != 2 {
+		t.Errorf("Errors() = %v, want the pattern limit at d", errs)
+	}
+
+	m = gitignore.New(t.TempDir(), gitignore.WithBraceExpansion())
+	m.AddPatterns([]byte(strings.Repeat("{a,b}", 11)+"\nx\n"), "")
+	if errs := m.Errors(); len(errs) != 1 || errs[0].Line != 1 || !strings.Contains(errs[0].Message, "more than 1024") {
+		t.Errorf("Errors() = %v, want an error for too many patterns", errs)
+	}
+	if !m.Match("x") || m.Match(strings.Repeat("a", 11)) {
+		t.Error("want the line expanding to too many patterns left out")
+	}
+}
+
+func TestBraceExpansionLint(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	patterns := []byte("*.{js,ts}\n{single}\n\\{a,b}\n")
+	m := gitignore.New(t.TempDir())
+	m.AddPatterns(patterns, "")
+	issues := m.Lint()
+	if len(issues) != 1 || issues[0].Pattern != "*.{js,ts}" || issues[0].Line != 1 {
+		t.Errorf("Lint() = %v, want the literal braces of line 1", issues)
+	}
+
+	m = gitignore.New(t.TempDir(), gitignore.WithBraceExpansion())
+	m.AddPatterns(patterns, "")
+	if issues := m.Lint(); len(issues) != 0 {
+		t.Errorf("WithBraceExpansion: Lint() = %v, want none", issues)
+	}
+}
+
+func TestBraceExpansionGlobal(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	writeFiles(t, xdg, map[string]string{"git/ignore": "*.{js,ts}\n"})
+	root := t.TempDir()
+
+	// The global excludes are compiled once and shared, so the Matcher
+	// without braces comes first and each expecting them after.
+	if gitignore.New(root).Match("x.js") {
+		t.Error("without WithBraceExpansion: want the braces read literally")
+	}
+	for name, opts := range map[string][]gitignore.Option{
+		"shared":     nil,
+		"WithStats":  {gitignore.WithStats()},
+		"WithVerify": {gitignore.WithVerify()},
+	} {
+		m := gitignore.New(root, append(opts, gitignore.WithBraceExpansion())...)
+		if !m.Match("x.js") || !m.Match("d/x.ts") {
+			t.Errorf("%s: want the global braces expanded", name)
+		}
+	}
+	if gitignore.New(root).Match("x.js") {
+		t.Error("without WithBraceExpansion after: want the braces read literally")
+	}
+}
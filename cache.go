@@ -0,0 +1,96 @@
+package gitignore
+
+import (
+	"container/list"
+	"sync"
+)
+
+// WithDirCache gives the Matcher an LRU cache of up to size directories.
+// For each cached directory it remembers the last pattern that applies to
+// everything inside it (or that none does), so repeated Match calls for
+// files in the same directory only try the patterns loaded after that
+// one. This helps tools that query many files per directory, such as
+// linters. The cache is cleared whenever patterns are added.
+func WithDirCache(size int) Option {
+	return func(c *config) {
+		c.dirCacheSize = size
+	}
+}
+
+// dirCache maps a directory path to the index of the last pattern that
+// matches every path beneath it, or -1. It is safe for concurrent use.
+type dirCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // front is most recently used
+	entries map[string]*list.Element
+}
+
+type dirCacheEntry struct {
+	dir     string
+	pattern int
+}
+
+func newDirCache(size int) *dirCache {
+	return &dirCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element, size),
+	}
+}
+
+// inherited returns the index of the last pattern that matches every path
+// inside the directory dirSegs, computing and caching it on a miss.
+func (c *dirCache) inherited(m *Matcher, dirSegs []string) int {
+	var buf [256]byte
+	key := appendKey(buf[:0], dirSegs)
+
+	c.mu.Lock()
+	if el, ok := c.entries[string(key)]; ok {
+		c.order.MoveToFront(el)
+		i := el.Value.(*dirCacheEntry).pattern
+		c.mu.Unlock()
+		return i
+	}
+	c.mu.Unlock()
+
+	// A pattern that covers the contents of a directory also covers the
+	// directory's subdirectories, so the parent's answer is a floor.
+	floor := -1
+	if len(dirSegs) > 1 {
+		floor = c.inherited(m, dirSegs[:len(dirSegs)-1])
+	}
+	i := m.search(dirSegs, true, false, floor)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[string(key)]; ok {
+		return i
+	}
+	c.entries[string(key)] = c.order.PushFront(&dirCacheEntry{dir: string(key), pattern: i})
+	for c.order.Len() > c.size {
+		old := c.order.Back()
+		c.order.Remove(old)
+		delete(c.entries, old.Value.(*dirCacheEntry).dir)
+	}
+	return i
+}
+
+// reset empties the cache.
+func (c *dirCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	clear(c.entries)
+}
+
+// appendKey appends the slash-joined segments to buf.
+func appendKey(buf []byte, segs []string) []byte {
+	for i, s := range segs {
+		if i > 0 {
+			buf = append(buf, '/')
+		}
+		buf = append(buf, s...)
+	}
+	return buf
+}
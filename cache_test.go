@@ -0,0 +1,73 @@
+package gitignore_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestDirCacheConsistentWithMatch(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".git", "info"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	patterns := "*.log\nbuild/\n!build/keep/\nvendor\n!vendor/*.go\n**/\ndocs/**/*.md\n!important.log\n/dist\n"
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte(patterns), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	plain := gitignore.New(root)
+	cached := gitignore.New(root, gitignore.WithDirCache(4))
+	for _, m := range []*gitignore.Matcher{plain, cached} {
+		m.AddPatterns([]byte("*.tmp\n!keep.tmp\nout/\n"), "src")
+	}
+
+	paths := []string{
+		"app.log", "src/app.log", "src/important.log",
+		"build/out.js", "build/keep/a.txt", "build/keep/", "build/keep/x.log",
+		"vendor/lib.go", "vendor/lib.c", "vendor/sub/lib.go",
+		"docs/a/b/readme.md", "docs/a/b/readme.txt", "docs/a/b/",
+		"dist/bundle.js", "src/dist/bundle.js",
+		"src/cache.tmp", "src/keep.tmp", "src/out/x", "src/out/", "src/main.go",
+		"a/b/c/d/e.txt", "a/b/c/d/", "a/b/c/d/e.log",
+	}
+	// Query twice so the second round is served from the cache, with
+	// evictions along the way since the cache only holds four directories.
+	for round := range 2 {
+		for _, p := range paths {
+			if got, want := cached.Match(p), plain.Match(p); got != want {
+				t.Errorf("round %d: cached Match(%q) = %v, uncached = %v", round, p, got, want)
+			}
+			if got, want := cached.MatchDetail(p), plain.MatchDetail(p); got != want {
+				t.Errorf("round %d: cached MatchDetail(%q) = %+v, uncached = %+v", round, p, got, want)
+			}
+		}
+	}
+
+	// Adding patterns invalidates cached decisions.
+	cached.Match("src/lib/x.c")
+	cached.AddPatterns([]byte("lib/\n"), "src")
+	if !cached.Match("src/lib/x.c") {
+		t.Error("expected pattern added after caching to take effect")
+	}
+}
+
+func TestDirCacheDoesNotAllocate(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	m := gitignore.New(t.TempDir(), gitignore.WithDirCache(16))
+	m.AddPatterns([]byte("*.log\nbuild/\nsrc/gen/\n"), "")
+
+	m.Match("src/pkg/main.go")
+	allocs := testing.AllocsPerRun(100, func() {
+		m.Match("src/pkg/main.go")
+		m.Match("src/pkg/util.log")
+	})
+	if allocs != 0 {
+		t.Errorf("cached Match allocated %v times, want 0", allocs)
+	}
+}
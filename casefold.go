@@ -0,0 +1,160 @@
+package gitignore
+
+import "strings"
+
+// WithIgnoreCase makes matching ignore case, as git does when
+// core.ignoreCase is set: the default on macOS and Windows, whose
+// filesystems are case-insensitive. Pass the value of core.ignoreCase
+// read from the repository's config.
+//
+// Like git, only ASCII letters are folded, and the quirks of git's
+// case-folded wildmatch are kept: a letter inside brackets or after a
+// backslash is compared as written against the lower-cased path, so
+// "[A]" and "\A" match nothing, while ranges such as "[A-Z]" and the
+// [:upper:] class match letters of either case.
+func WithIgnoreCase(ignoreCase bool) Option {
+	return func(c *config) {
+		c.ignoreCase = ignoreCase
+	}
+}
+
+// Case folding is done once, up front: patterns are compiled folded, and
+// paths are folded before lookup, so the index and the matchers compare
+// bytes as usual.
+
+// foldCase returns s with ASCII upper case letters lowered, and s itself
+// if it has none.
+func foldCase(s string) string {
+	for i := 0; i < len(s); i++ {
+		if isUpper(s[i]) {
+			return strings.Map(func(r rune) rune {
+				if r < 0x80 && isUpper(byte(r)) {
+					return r + 'a' - 'A'
+				}
+				return r
+			}, s)
+		}
+	}
+	return s
+}
+
+// mapSegs applies f to each of segs, copying segs only if a segment
+// changes. f must return its argument when it has nothing to change.
+func mapSegs(segs []string, f func(string) string) []string {
+	for i, s := range segs {
+		if t := f(s); t != s {
+			mapped := make([]string, len(segs))
+			copy(mapped, segs[:i])
+			mapped[i] = t
+			for j := i + 1; j < len(segs); j++ {
+				mapped[j] = f(segs[j])
+			}
+			return mapped
+		}
+	}
+	return segs
+}
+
+// foldPattern folds the segments of p, compiled from a pattern line, for
+// matching against folded paths.
+func foldPattern(p *pattern) {
+	for i := range p.segments {
+		if !p.segments[i].doubleStar {
+			p.segments[i].raw = foldGlob(p.segments[i].raw)
+		}
+	}
+}
+
+// foldGlob returns the glob segment that matches the folded form of the
+// paths glob matches with git's case folding. Letters outside brackets
+// are lowered; escaped letters are kept. Bracket expressions are kept and
+// given the lower-case counterparts of their ranges, plus [:alpha:] for
+// [:upper:], so a folded letter still falls in them.
+func foldGlob(glob string) string {
+	var b strings.Builder
+	changed := false
+	for i := 0; i < len(glob); i++ {
+		ch := glob[i]
+		switch {
+		case ch == '\\' && i+1 < len(glob):
+			b.WriteByte(ch)
+			i++
+			ch = glob[i]
+		case ch == '[':
+			extra, end, ok := foldBracket(glob, i)
+			if !ok {
+				// Not a bracket expression; [ is literal.
+				break
+			}
+			b.WriteString(glob[i : end-1])
+			b.WriteString(extra)
+			b.WriteByte(']')
+			changed = changed || extra != ""
+			i = end - 1
+			continue
+		case isUpper(ch):
+			ch += 'a' - 'A'
+			changed = true
+		}
+		b.WriteByte(ch)
+	}
+	if !changed {
+		return glob
+	}
+	return b.String()
+}
+
+// foldBracket parses the bracket expression at glob[pos] the way
+// matchBracket does, returning what foldGlob adds to it and the position
+// after its closing ]. ok is false if the expression isn't closed.
+func foldBracket(glob string, pos int) (extra string, end int, ok bool) {
+	i := pos + 1
+	if i < len(glob) && (glob[i] == '!' || glob[i] == '^') {
+		i++
+	}
+	var b strings.Builder
+	first := true
+	for i < len(glob) {
+		if glob[i] == ']' && !first {
+			return b.String(), i + 1, true
+		}
+		first = false
+
+		if glob[i] == '[' && i+1 < len(glob) && glob[i+1] == ':' {
+			if e := findPosixClassEnd(glob, i+2); e >= 0 {
+				if glob[i+2:e] == "upper" {
+					b.WriteString("[:alpha:]")
+				}
+				i = e + 2
+				continue
+			}
+		}
+
+		lo := glob[i]
+		if lo == '\\' && i+1 < len(glob) {
+			i++
+			lo = glob[i]
+		}
+		i++
+		if i+1 < len(glob) && glob[i] == '-' && glob[i+1] != ']' {
+			i++
+			hi := glob[i]
+			if hi == '\\' && i+1 < len(glob) {
+				i++
+				hi = glob[i]
+			}
+			i++
+			lo, hi = max(lo, 'A'), min(hi, 'Z')
+			if lo <= hi {
+				b.WriteByte(lo + 'a' - 'A')
+				b.WriteByte('-')
+				b.WriteByte(hi + 'a' - 'A')
+			}
+		}
+	}
+	return "", 0, false
+}
+
+func isUpper(c byte) bool {
+	return c >= 'A' && c <= 'Z'
+}
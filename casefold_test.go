@@ -0,0 +1,132 @@
+package gitignore_test
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestIgnoreCase(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	// What git check-ignore reports with core.ignoreCase set, for each
+	// pattern, among the paths listed.
+	paths := []string{"a", "A", "q", "Q", "ab", "Ab", "cB", "bx", "Bx", "ay", "Ay",
+		"bz", "Bz", "zw", "Zw", "_w", "av", "Av", "bv", "dir/", "DIR/", "x/y", "x/Y"}
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"Q", []string{"q", "Q"}},
+		{`\q`, []string{"q", "Q"}},
+		{`\Q`, nil},
+		{"[a]", []string{"a", "A"}},
+		{"[A]", nil},
+		{"A*", []string{"a", "A", "ab", "Ab", "ay", "Ay", "av", "Av"}},
+		{"*B", []string{"ab", "Ab", "cB"}},
+		{"[A-C]x", []string{"bx", "Bx"}},
+		{"[[:upper:]]y", []string{"ay", "Ay"}},
+		{"[[:lower:]]z", []string{"bz", "Bz"}},
+		{"[Z-a]w", []string{"zw", "Zw", "_w"}},
+		{"[!A]v", []string{"av", "Av", "bv"}},
+		{"Dir/", []string{"dir/", "DIR/"}},
+		{"x/Y", []string{"x/y", "x/Y"}},
+	}
+	for _, opts := range [][]gitignore.Option{nil, {gitignore.WithDFA()}, {gitignore.WithVerify()}} {
+		for _, tt := range tests {
+			m := gitignore.New(t.TempDir(), append(opts, gitignore.WithIgnoreCase(true))...)
+			m.AddPatterns([]byte(tt.pattern+"\n"), "")
+			var got []string
+			for _, p := range paths {
+				if m.Match(p) {
+					got = append(got, p)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("%q matched %q, want %q", tt.pattern, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestIgnoreCaseScopes(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":     "*.LOG\n/Build/\n",
+		"Src/.gitignore": "Gen/\n",
+		"Src/main.go":    "",
+	})
+	m, err := gitignore.LoadDirectory(root, gitignore.WithIgnoreCase(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"a.log", "A.Log", "build/", "BUILD/x", "src/gen/", "SRC/GEN/x.go"} {
+		if !m.Match(p) {
+			t.Errorf("Match(%q) = false, want true", p)
+		}
+	}
+	if !m.MatchSegments([]string{"SRC", "Gen", "x.go"}, false) {
+		t.Error("MatchSegments(SRC/Gen/x.go) = false, want true")
+	}
+	if m.Match("sub/build/") {
+		t.Error("expected /Build/ to stay anchored")
+	}
+
+	m, err = gitignore.LoadDirectory(root, gitignore.WithIgnoreCase(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Match("a.log") || !m.Match("a.LOG") || m.Match("src/gen/") || !m.Match("Src/Gen/") {
+		t.Error("expected case to matter without WithIgnoreCase")
+	}
+}
+
+func TestIgnoreCaseWalkOptions(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore": "FOO\n",
+		"foo":        "",
+		"bar":        "",
+		"a.MD":       "",
+		"b.txt":      "",
+	})
+	extra := filepath.Join(t.TempDir(), "extra-ignore")
+	if err := os.WriteFile(extra, []byte("BAR\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	walked := func(opts ...gitignore.Option) []string {
+		var got []string
+		err := gitignore.Walk(root, func(path string, d fs.DirEntry) error {
+			got = append(got, filepath.ToSlash(path))
+			return nil
+		}, append(opts, gitignore.WithIgnoreFile(extra), gitignore.WithFilesOnly())...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		slices.Sort(got)
+		return got
+	}
+
+	// The rules of an extra file fold case as the repository's do.
+	if got, want := walked(gitignore.WithIgnoreCase(true)), []string{".gitignore", "a.MD", "b.txt"}; !slices.Equal(got, want) {
+		t.Errorf("WithIgnoreFile: walked %q, want %q", got, want)
+	}
+	if got, want := walked(gitignore.WithIgnoreCase(false)), []string{".gitignore", "a.MD", "b.txt", "bar", "foo"}; !slices.Equal(got, want) {
+		t.Errorf("WithIgnoreFile without WithIgnoreCase: walked %q, want %q", got, want)
+	}
+	// So do the globs of WithOnly.
+	if got, want := walked(gitignore.WithIgnoreCase(true), gitignore.WithOnly("*.md")), []string{"a.MD"}; !slices.Equal(got, want) {
+		t.Errorf("WithOnly: walked %q, want %q", got, want)
+	}
+}
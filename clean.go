@@ -0,0 +1,162 @@
+package gitignore
+
+import (
+	"slices"
+	"strings"
+)
+
+// pathCleaning is whether a Matcher cleans the paths it is given; see
+// WithCleanPaths.
+type pathCleaning int8
+
+const (
+	cleanDefault pathCleaning = iota // only in the methods that clean by default
+	cleanAlways
+	cleanNever
+)
+
+// WithCleanPaths sets whether paths are cleaned before they are matched:
+// the empty segments left by duplicate slashes, and "." segments, are
+// dropped, so "src//a.log" and "src/./a.log", as string concatenation
+// tends to produce, match as "src/a.log". A path ending in "/." names a
+// directory, as one ending in a slash does.
+//
+// MatchDirContents and MatchAbs clean paths unless WithCleanPaths(false)
+// is given. The other methods, which have always matched such a path as
+// written, so that "a/*/b" matches "a//b", clean them only with
+// WithCleanPaths(true). Either way a leading "./" is dropped, and a path
+// with a ".." segment is cleaned all the same; see Match.
+func WithCleanPaths(clean bool) Option {
+	return func(c *config) {
+		c.cleaning = cleanNever
+		if clean {
+			c.cleaning = cleanAlways
+		}
+	}
+}
+
+// path returns relPath, as given to a method of m, with slashes for
+// separators, its ".." segments resolved and cleaned if m cleans the
+// paths given to that method, along with whether it names a directory:
+// whether it ended in a slash, now dropped, or in "/." or "/.." before
+// it was cleaned. legacy is set for the methods that only clean paths
+// with WithCleanPaths(true). It reports false if relPath climbs out of
+// the root, or has a NUL byte, which no file name can hold.
+func (m *Matcher) path(relPath string, legacy bool) (string, bool, bool) {
+	if strings.IndexByte(relPath, 0) >= 0 {
+		return "", false, false
+	}
+	relPath = m.toSlash(relPath)
+	isDir := strings.HasSuffix(relPath, "/")
+	if isDir {
+		relPath = relPath[:len(relPath)-1]
+	}
+	if hasDotDot(relPath) {
+		isDir = isDir || relPath == "." || strings.HasSuffix(relPath, "/.") || strings.HasSuffix(relPath, "/..")
+		var ok bool
+		if relPath, ok = resolvePath(relPath); !ok {
+			return "", false, false
+		}
+	} else if m.cleans(legacy) && needsCleaning(relPath) {
+		isDir = isDir || relPath == "." || strings.HasSuffix(relPath, "/.")
+		relPath = cleanPath(relPath)
+	}
+	return relPath, isDir, true
+}
+
+// cleans reports whether m cleans the paths given to a method; legacy is
+// as for path.
+func (m *Matcher) cleans(legacy bool) bool {
+	switch m.cleaning {
+	case cleanAlways:
+		return true
+	case cleanNever:
+		return false
+	}
+	return !legacy
+}
+
+// needsCleaning reports whether relPath has an empty or "." segment.
+func needsCleaning(relPath string) bool {
+	return strings.HasPrefix(relPath, "/") || strings.HasPrefix(relPath, "./") || relPath == "." ||
+		strings.Contains(relPath, "//") || strings.Contains(relPath, "/./") || strings.HasSuffix(relPath, "/.")
+}
+
+// hasDotDot reports whether relPath has a ".." segment.
+func hasDotDot(relPath string) bool {
+	return relPath == ".." || strings.HasPrefix(relPath, "../") ||
+		strings.Contains(relPath, "/../") || strings.HasSuffix(relPath, "/..")
+}
+
+// resolvePath returns relPath cleaned, with each ".." segment and the
+// one before it dropped, as path.Clean drops them. It reports false if
+// there is no segment before a "..", as in "../x" or "a/../../x".
+func resolvePath(relPath string) (string, bool) {
+	var kept []string
+	for seg := range strings.SplitSeq(relPath, "/") {
+		switch seg {
+		case "", ".":
+		case "..":
+			if len(kept) == 0 {
+				return "", false
+			}
+			kept = kept[:len(kept)-1]
+		default:
+			kept = append(kept, seg)
+		}
+	}
+	return strings.Join(kept, "/"), true
+}
+
+// cleanPath returns relPath without its empty and "." segments.
+func cleanPath(relPath string) string {
+	var b strings.Builder
+	b.Grow(len(relPath))
+	for seg := range strings.SplitSeq(relPath, "/") {
+		if seg == "" || seg == "." {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('/')
+		}
+		b.WriteString(seg)
+	}
+	return b.String()
+}
+
+// cleanSegs is path for a path given as segments to a method taking
+// them, which only cleans them with WithCleanPaths(true), or if they
+// have a ".." segment. segs is copied rather than modified.
+func (m *Matcher) cleanSegs(segs []string) ([]string, bool) {
+	for _, s := range segs {
+		if strings.IndexByte(s, 0) >= 0 {
+			return nil, false
+		}
+	}
+	if slices.Contains(segs, "..") {
+		relPath, ok := resolvePath(strings.Join(segs, "/"))
+		if !ok || relPath == "" {
+			return nil, ok
+		}
+		return strings.Split(relPath, "/"), true
+	}
+	if m.cleaning != cleanAlways {
+		return segs, true
+	}
+	n := 0
+	for _, s := range segs {
+		if s != "" && s != "." {
+			n++
+		}
+	}
+	if n == len(segs) {
+		return segs, true
+	}
+	cleaned := make([]string, 0, n)
+	for _, s := range segs {
+		if s != "" && s != "." {
+			cleaned = append(cleaned, s)
+		}
+	}
+	return cleaned, true
+}
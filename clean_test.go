@@ -0,0 +1,184 @@
+package gitignore_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestWithCleanPaths(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	patterns := "src/a.log\ngen/\na/*/b\n"
+	tests := []struct {
+		path         string
+		clean, plain bool
+	}{
+		{"src/a.log", true, true},
+		{"src//a.log", true, false},
+		{"src/./a.log", true, false},
+		{"./src/./a.log", true, false},
+		{"/src/a.log", true, false},
+		{"src/a.log//", true, true},
+		{"src/gen/.", true, true}, // "." is inside gen as written
+		{"src/gen//", true, true},
+		{"src/gen", false, false},
+		{"a/x/b", true, true},
+		{"a//b", false, true},
+		{"./.", false, false},
+	}
+	clean := gitignore.New(t.TempDir(), gitignore.WithCleanPaths(true))
+	clean.AddPatterns([]byte(patterns), "")
+	for _, opts := range [][]gitignore.Option{nil, {gitignore.WithCleanPaths(false)}} {
+		plain := gitignore.New(t.TempDir(), opts...)
+		plain.AddPatterns([]byte(patterns), "")
+		for _, tt := range tests {
+			if got := plain.Match(tt.path); got != tt.plain {
+				t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.plain)
+			}
+		}
+	}
+	for _, tt := range tests {
+		if got := clean.Match(tt.path); got != tt.clean {
+			t.Errorf("cleaned Match(%q) = %v, want %v", tt.path, got, tt.clean)
+		}
+		if got := clean.MatchFull(tt.path); got != tt.clean {
+			t.Errorf("cleaned MatchFull(%q) = %v, want %v", tt.path, got, tt.clean)
+		}
+		if got := clean.MatchDetail(tt.path).Ignored; got != tt.clean {
+			t.Errorf("cleaned MatchDetail(%q).Ignored = %v, want %v", tt.path, got, tt.clean)
+		}
+	}
+	if !clean.MatchPath("src//a.log", false) || !clean.MatchPath("src/gen/.", false) {
+		t.Error("expected MatchPath to clean paths")
+	}
+	segs := []string{"src", "", ".", "a.log"}
+	if !clean.MatchSegments(segs, false) || !slices.Equal(segs, []string{"src", "", ".", "a.log"}) {
+		t.Errorf("expected MatchSegments to clean a copy of %q", segs)
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		clean.Match("src/gen/x/y.go")
+	})
+	if allocs != 0 {
+		t.Errorf("Match of a clean path allocated %v times, want 0", allocs)
+	}
+}
+
+func TestCleanPathsNewMethods(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	for _, opts := range [][]gitignore.Option{nil, {gitignore.WithCleanPaths(true)}} {
+		m := gitignore.New(t.TempDir(), opts...)
+		m.AddPatterns([]byte("build/*\n"), "")
+		for _, dir := range []string{"build", "./build/.", "build//", "/build"} {
+			if !m.MatchDirContents(dir) {
+				t.Errorf("MatchDirContents(%q) = false, want true", dir)
+			}
+		}
+	}
+
+	m := gitignore.New(t.TempDir(), gitignore.WithCleanPaths(false))
+	m.AddPatterns([]byte("build/*\n"), "")
+	if m.MatchDirContents("/build") {
+		t.Error("MatchDirContents(/build) = true, want the path matched as written")
+	}
+}
+
+func TestDotDotSegments(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"a/../secret.log", true},
+		{"a/b/../../secret.log", true},
+		{"src/../../secret.log", false},
+		{"../secret.log", false},
+		{"..", false},
+		{"build/..", false},
+		{"src/gen/..", true}, // "src" is a directory
+		{"x/../src/", true},
+		{"x/../src", false},
+		{"x/..secret.log", false},
+	}
+	for _, opts := range [][]gitignore.Option{nil, {gitignore.WithCleanPaths(false)}, {gitignore.WithDFA()}} {
+		m := gitignore.New(t.TempDir(), opts...)
+		m.AddPatterns([]byte("/secret.log\n/src/\n"), "")
+		for _, tt := range tests {
+			if got := m.Match(tt.path); got != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+			if got := m.MatchFull(tt.path); got != tt.want {
+				t.Errorf("MatchFull(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+			if got := m.MatchDetail(tt.path).Ignored; got != tt.want {
+				t.Errorf("MatchDetail(%q).Ignored = %v, want %v", tt.path, got, tt.want)
+			}
+		}
+		segs := []string{"a", "..", "secret.log"}
+		if !m.MatchSegments(segs, false) || !slices.Equal(segs, []string{"a", "..", "secret.log"}) {
+			t.Errorf("expected MatchSegments to resolve a copy of %q", segs)
+		}
+		if m.MatchSegments([]string{"..", "secret.log"}, false) {
+			t.Error("MatchSegments outside the root = true, want false")
+		}
+		if !m.MatchDirContents("x/../src") || !m.IsCompletelyIgnored("x/../src") {
+			t.Error("expected the directory queries to resolve ..")
+		}
+		for _, dir := range []string{"..", "src/../..", "../src"} {
+			if m.MatchDirContents(dir) || m.IsCompletelyIgnored(dir) || m.CouldIgnoreWithin(dir) {
+				t.Errorf("directory queries for %q = true, want false outside the root", dir)
+			}
+		}
+	}
+}
+
+func TestPathBytes(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	// A subset of git's results for the same names.
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"a\nb", true},
+		{"a\tb", true},
+		{"a\x7fb", true},
+		{"a\xffb", true},
+		{"a\xc3\xa9b", false}, // ? matches one byte
+		{"x\ny.tmp", true},
+		{"\xff\xfe.log", true},
+		{"dir\n/x", true},
+		{"dir\n", false},
+		{"a\x00b", false},
+		{"x.log\x00.txt", false},
+		{"x\x00/y.log", false},
+		{"dir\n/\x00", false},
+	}
+	for _, opts := range [][]gitignore.Option{nil, {gitignore.WithDFA()}, {gitignore.WithPrecomposeUnicode(true)},
+		{gitignore.WithIgnoreCase(true)}, {gitignore.WithCleanPaths(true)}} {
+		m := gitignore.New(t.TempDir(), opts...)
+		m.AddPatterns([]byte("a?b\n*.log\nx*y.tmp\ndir[[:cntrl:]]/\n"), "")
+		for _, tt := range tests {
+			if got := m.Match(tt.path); got != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+			if got := m.MatchFull(tt.path); got != tt.want {
+				t.Errorf("MatchFull(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		}
+		if m.MatchSegments([]string{"x\x00", "y.log"}, false) || m.MatchDirContents("dir\n/\x00") {
+			t.Error("expected a path with a NUL byte not to be ignored")
+		}
+		if !m.MatchDirContents("dir\n") {
+			t.Error("MatchDirContents(dir\\n) = false, want true")
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package gitignore
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// Compat is the git version whose behavior a Matcher follows where git
+// has changed over time. Each level holds from the version it names up
+// to the next level.
+type Compat int
+
+const (
+	// CompatLatest follows current git, as the differential tests in
+	// gitignorediff check it. It is the default.
+	CompatLatest Compat = iota
+
+	// CompatGit2_30 follows git 2.30 and 2.31, which read a .gitignore
+	// in the tree through a symlink. Since 2.32 git doesn't, warning
+	// about the file instead, so a symlinked .gitignore contributes no
+	// patterns. The global excludes file and .git/info/exclude are read
+	// through symlinks at every level.
+	CompatGit2_30
+)
+
+// WithCompat makes a Matcher, and the walks, follow the given git
+// version where git's behavior has changed, for tools that have to agree
+// with an older git installed alongside them. The pattern syntax and
+// matching rules are the same at every level; only how .gitignore files
+// are found differs so far.
+func WithCompat(level Compat) Option {
+	return func(c *config) {
+		c.compat = level
+	}
+}
+
+// errGitignoreLink is returned for a symlinked .gitignore that m doesn't
+// follow.
+var errGitignoreLink = errors.New("gitignore: .gitignore is a symlink")
+
+// followsLinks reports whether m reads a .gitignore in the tree through
+// a symlink.
+func (m *Matcher) followsLinks() bool {
+	return m.compat == CompatGit2_30
+}
+
+// readGitignore is readSource for a .gitignore in the tree, which is
+// left unread if it is a symlink m doesn't follow.
+func (m *Matcher) readGitignore(path string) ([]byte, error) {
+	if info, err := os.Lstat(path); err == nil && m.skipsLink(info.Mode()) {
+		if m.deps != nil {
+			// Recorded as absent, so replacing the link with a file
+			// makes a saved cache stale.
+			m.deps.addFile(path, nil, errGitignoreLink, nil, errGitignoreLink)
+		}
+		return nil, errGitignoreLink
+	}
+	return m.readSource(path)
+}
+
+// skipsLink reports whether m leaves a .gitignore of the given type
+// unread: whether it is a symlink m doesn't follow.
+func (m *Matcher) skipsLink(mode fs.FileMode) bool {
+	return mode&fs.ModeSymlink != 0 && !m.followsLinks()
+}
+
+// skipsLinkFS is skipsLink for the file name in fsys.
+func (m *Matcher) skipsLinkFS(fsys fs.FS, name string) bool {
+	info, err := fs.Lstat(fsys, name)
+	return err == nil && m.skipsLink(info.Mode())
+}
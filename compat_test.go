@@ -0,0 +1,75 @@
+package gitignore_test
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestCompatSymlinkedGitignore(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"rules":     "*.log\n",
+		"sub/rules": "*.tmp\n",
+		"sub/a.tmp": "",
+		"a.log":     "",
+	})
+	for _, link := range []string{".gitignore", "sub/.gitignore"} {
+		if err := os.Symlink("rules", filepath.Join(root, link)); err != nil {
+			t.Skip("symlinks not supported:", err)
+		}
+	}
+
+	walked := func(opts ...gitignore.Option) map[string]bool {
+		seen := make(map[string]bool)
+		err := gitignore.Walk(root, func(path string, _ fs.DirEntry) error {
+			seen[path] = true
+			return nil
+		}, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return seen
+	}
+	walkedFS := func(opts ...gitignore.Option) map[string]bool {
+		seen := make(map[string]bool)
+		err := gitignore.WalkFS(os.DirFS(root), func(path string, _ fs.DirEntry) error {
+			seen[path] = true
+			return nil
+		}, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return seen
+	}
+	for _, tt := range []struct {
+		opts   []gitignore.Option
+		follow bool
+	}{
+		{nil, false},
+		{[]gitignore.Option{gitignore.WithCompat(gitignore.CompatLatest)}, false},
+		{[]gitignore.Option{gitignore.WithCompat(gitignore.CompatGit2_30)}, true},
+	} {
+		if got := gitignore.New(root, tt.opts...).Match("a.log"); got != tt.follow {
+			t.Errorf("%v: New: Match(a.log) = %v, want %v", tt.opts, got, tt.follow)
+		}
+		m, err := gitignore.LoadDirectory(root, tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := m.Match("sub/a.tmp"); got != tt.follow {
+			t.Errorf("%v: LoadDirectory: Match(sub/a.tmp) = %v, want %v", tt.opts, got, tt.follow)
+		}
+		for name, seen := range map[string]map[string]bool{"Walk": walked(tt.opts...), "WalkFS": walkedFS(tt.opts...)} {
+			if seen["a.log"] == tt.follow || seen["sub/a.tmp"] == tt.follow {
+				t.Errorf("%v: %s visited a.log %v and sub/a.tmp %v, want %v", tt.opts, name, seen["a.log"], seen["sub/a.tmp"], !tt.follow)
+			}
+		}
+	}
+}
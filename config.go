@@ -0,0 +1,295 @@
+package gitignore
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxIncludeDepth matches git's limit on nested include.path directives.
+const maxIncludeDepth = 10
+
+// gitConfigValue returns the last value set for section.name (both lower
+// case) in the system and global git config files, read in the order git
+// reads them, so later files override earlier ones. It honors
+// GIT_CONFIG_SYSTEM, GIT_CONFIG_NOSYSTEM and GIT_CONFIG_GLOBAL, and
+// follows include.path. Conditional includes (includeIf) depend on the
+// repository and are skipped. Every file it tries to read, present or
+// not, is appended to *read.
+func gitConfigValue(section, name string, read *[]string) (string, bool) {
+	var value string
+	var found bool
+	for _, path := range gitConfigFiles() {
+		readGitConfig(path, 0, read, func(sec, sub, key, val string) {
+			if sec == section && sub == "" && key == name {
+				value, found = val, true
+			}
+		})
+	}
+	return value, found
+}
+
+// gitConfigBool parses a boolean config value as git does: "true",
+// "yes", "on" and nonzero integers are true, and "false", "no", "off",
+// "0" and the empty string false, ignoring case. It reports false if
+// value is none of these.
+func gitConfigBool(value string) (bool, bool) {
+	switch strings.ToLower(value) {
+	case "true", "yes", "on":
+		return true, true
+	case "false", "no", "off", "":
+		return false, true
+	}
+	n, err := strconv.Atoi(value)
+	return n != 0, err == nil
+}
+
+// gitConfigFiles lists the system and global config files, lowest
+// priority first. Missing files are included; reading them is a no-op.
+func gitConfigFiles() []string {
+	var files []string
+	if os.Getenv("GIT_CONFIG_NOSYSTEM") == "" {
+		if sys := os.Getenv("GIT_CONFIG_SYSTEM"); sys != "" {
+			files = append(files, sys)
+		} else {
+			files = append(files, "/etc/gitconfig")
+		}
+	}
+	if global := os.Getenv("GIT_CONFIG_GLOBAL"); global != "" {
+		return append(files, global)
+	}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		files = append(files, filepath.Join(xdg, "git", "config"))
+	} else if home, err := os.UserHomeDir(); err == nil {
+		files = append(files, filepath.Join(home, ".config", "git", "config"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		files = append(files, filepath.Join(home, ".gitconfig"))
+	}
+	return files
+}
+
+// readGitConfig calls fn for each variable in the config file at path, in
+// order, with section and key names lower-cased. Included files are read
+// in place of their include.path line. An unreadable file is skipped,
+// and a malformed one is read up to the error, as far as fn can tell.
+// Each path tried is appended to *read.
+func readGitConfig(path string, depth int, read *[]string, fn func(section, subsection, key, value string)) {
+	*read = append(*read, path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	parseGitConfig(data, func(sec, sub, key, val string) {
+		if sec == "include" && sub == "" && key == "path" && depth < maxIncludeDepth {
+			inc := expandTilde(val)
+			if !filepath.IsAbs(inc) {
+				inc = filepath.Join(filepath.Dir(path), inc)
+			}
+			readGitConfig(inc, depth+1, read, fn)
+			return
+		}
+		fn(sec, sub, key, val)
+	})
+}
+
+// parseGitConfig parses git's config file syntax: [section] and
+// [section "subsection"] headers, key = value lines, bare keys (boolean
+// true), # and ; comments, double quotes, backslash escapes and line
+// continuations. It stops at the first syntax error.
+func parseGitConfig(data []byte, fn func(section, subsection, key, value string)) {
+	p := configParser{data: data}
+	var section, subsection string
+	for {
+		p.skipSpace(true)
+		if p.eof() {
+			return
+		}
+		switch c := p.data[p.pos]; {
+		case c == '#' || c == ';':
+			p.skipLine()
+		case c == '[':
+			var ok bool
+			if section, subsection, ok = p.header(); !ok {
+				return
+			}
+		case isConfigKeyChar(c) && section != "":
+			key, value, ok := p.variable()
+			if !ok {
+				return
+			}
+			fn(section, subsection, key, value)
+		default:
+			return
+		}
+	}
+}
+
+type configParser struct {
+	data []byte
+	pos  int
+}
+
+func (p *configParser) eof() bool { return p.pos >= len(p.data) }
+
+// skipSpace skips blanks, and newlines too if newlines is set.
+func (p *configParser) skipSpace(newlines bool) {
+	for !p.eof() {
+		switch p.data[p.pos] {
+		case ' ', '\t', '\r':
+		case '\n':
+			if !newlines {
+				return
+			}
+		default:
+			return
+		}
+		p.pos++
+	}
+}
+
+func (p *configParser) skipLine() {
+	for !p.eof() && p.data[p.pos] != '\n' {
+		p.pos++
+	}
+}
+
+// header parses a section header starting at '['.
+func (p *configParser) header() (section, subsection string, ok bool) {
+	p.pos++
+	start := p.pos
+	for !p.eof() && (isConfigKeyChar(p.data[p.pos]) || p.data[p.pos] == '.') {
+		p.pos++
+	}
+	section = strings.ToLower(string(p.data[start:p.pos]))
+	if section == "" || p.eof() {
+		return "", "", false
+	}
+	if p.data[p.pos] == ']' {
+		p.pos++
+		// Deprecated [section.subsection] syntax; the subsection is
+		// case-insensitive there.
+		if i := strings.IndexByte(section, '.'); i >= 0 {
+			return section[:i], section[i+1:], true
+		}
+		return section, "", true
+	}
+
+	p.skipSpace(false)
+	if p.eof() || p.data[p.pos] != '"' {
+		return "", "", false
+	}
+	p.pos++
+	var sub strings.Builder
+	for {
+		if p.eof() || p.data[p.pos] == '\n' {
+			return "", "", false
+		}
+		c := p.data[p.pos]
+		p.pos++
+		if c == '"' {
+			break
+		}
+		if c == '\\' {
+			if p.eof() || p.data[p.pos] == '\n' {
+				return "", "", false
+			}
+			c = p.data[p.pos]
+			p.pos++
+		}
+		sub.WriteByte(c)
+	}
+	if p.eof() || p.data[p.pos] != ']' {
+		return "", "", false
+	}
+	p.pos++
+	return section, sub.String(), true
+}
+
+// variable parses a "key = value" or bare "key" line.
+func (p *configParser) variable() (key, value string, ok bool) {
+	start := p.pos
+	for !p.eof() && isConfigKeyChar(p.data[p.pos]) {
+		p.pos++
+	}
+	key = strings.ToLower(string(p.data[start:p.pos]))
+	p.skipSpace(false)
+	if p.eof() || p.data[p.pos] == '\n' || p.data[p.pos] == '#' || p.data[p.pos] == ';' {
+		p.skipLine()
+		return key, "true", true
+	}
+	if p.data[p.pos] != '=' {
+		return "", "", false
+	}
+	p.pos++
+	p.skipSpace(false)
+	value, ok = p.value()
+	return key, value, ok
+}
+
+// value parses the value after '=', up to the end of the line.
+func (p *configParser) value() (string, bool) {
+	var b strings.Builder
+	quoted := false
+	var pending []byte // unquoted blanks not yet written; dropped at the end
+	for !p.eof() {
+		c := p.data[p.pos]
+		p.pos++
+		switch {
+		case c == '\n':
+			if quoted {
+				return "", false
+			}
+			return b.String(), true
+		case c == '\r' && !p.eof() && p.data[p.pos] == '\n':
+			continue
+		case (c == ' ' || c == '\t') && !quoted:
+			pending = append(pending, c)
+			continue
+		case (c == '#' || c == ';') && !quoted:
+			p.skipLine()
+			return b.String(), true
+		}
+		b.Write(pending)
+		pending = pending[:0]
+		switch c {
+		case '"':
+			quoted = !quoted
+		case '\\':
+			if p.eof() {
+				return "", false
+			}
+			e := p.data[p.pos]
+			p.pos++
+			switch e {
+			case '\n':
+				// Line continuation.
+			case '\r':
+				if !p.eof() && p.data[p.pos] == '\n' {
+					p.pos++
+				}
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'b':
+				b.WriteByte('\b')
+			case '"', '\\':
+				b.WriteByte(e)
+			default:
+				return "", false
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	if quoted {
+		return "", false
+	}
+	return b.String(), true
+}
+
+func isConfigKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-'
+}
@@ -0,0 +1,103 @@
+package gitignore_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestGlobalExcludesFromGitConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "xdg"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_CONFIG_GLOBAL", "")
+
+	write := func(path, data string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(home, "ignores", "my ignore"), "*.from-config\n")
+
+	tests := []struct {
+		name   string
+		files  map[string]string
+		global string // GIT_CONFIG_GLOBAL, if set
+	}{
+		{
+			name: "gitconfig in home",
+			files: map[string]string{
+				".gitconfig": "[core]\n\texcludesfile = ~/ignores/my ignore\n",
+			},
+		},
+		{
+			name: "quoting, comments and case",
+			files: map[string]string{
+				".gitconfig": "# user config\n[user]\n\tname = \"A ; B\"\n[Core] ; section\n" +
+					"\tExcludesFile = \"~/ignores/my ignore\" # comment\n",
+			},
+		},
+		{
+			name: "line continuation",
+			files: map[string]string{
+				".gitconfig": "[core]\n\texcludesfile = ~/ignores/\\\nmy ignore\n",
+			},
+		},
+		{
+			name: "xdg config overridden by gitconfig",
+			files: map[string]string{
+				"xdg/git/config": "[core]\n\texcludesfile = /nonexistent\n",
+				".gitconfig":     "[core]\n\texcludesfile = ~/ignores/my ignore\n",
+			},
+		},
+		{
+			name: "include.path relative to the including file",
+			files: map[string]string{
+				".gitconfig":      "[include]\n\tpath = conf/extra.inc\n",
+				"conf/extra.inc":  "[include]\n\tpath = nested.inc\n",
+				"conf/nested.inc": "[core]\n\texcludesfile = ~/ignores/my ignore\n",
+				"xdg/git/config":  "[core]\n\texcludesfile = /nonexistent\n",
+			},
+		},
+		{
+			name: "GIT_CONFIG_GLOBAL replaces the global files",
+			files: map[string]string{
+				".gitconfig": "[core]\n\texcludesfile = /nonexistent\n",
+				"other":      "[core]\n\texcludesfile = ~/ignores/my ignore\n",
+			},
+			global: "other",
+		},
+		{
+			name: "deprecated subsection syntax is not core",
+			files: map[string]string{
+				".gitconfig": "[core]\n\texcludesfile = ~/ignores/my ignore\n" +
+					"[core.sub]\n\texcludesfile = /nonexistent\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, f := range []string{".gitconfig", "other", "xdg", "conf"} {
+				_ = os.RemoveAll(filepath.Join(home, f))
+			}
+			for name, data := range tt.files {
+				write(filepath.Join(home, name), data)
+			}
+			if tt.global != "" {
+				t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(home, tt.global))
+			}
+
+			m := gitignore.New(t.TempDir())
+			if !m.Match("a.from-config") {
+				t.Error("expected core.excludesfile from git config to be used")
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package gitignore
+
+import "strings"
+
+// Patterns with more stars than costlyStars in one segment, or more **
+// segments than costlyDoubleStars, are reported by Lint as costly.
+const (
+	costlyStars       = 16
+	costlyDoubleStars = 16
+)
+
+// simplifyWildcards rewrites each run of stars and question marks in
+// glob outside escapes and brackets as its question marks followed by a
+// single star, if it has one: "a***b" is "a*b" and "*?*?" is "??*". Both
+// match the same text, but every star costs matchSegment a backtracking
+// pass, so a hostile pattern like "*?*?*?*?*?x" is cut down to "?????*x"
+// at compile time. glob is returned as is if it has nothing to rewrite.
+func simplifyWildcards(glob string) string {
+	if strings.Count(glob, "*") < 2 {
+		return glob
+	}
+	var b strings.Builder
+	b.Grow(len(glob))
+	for i := 0; i < len(glob); {
+		switch glob[i] {
+		case '\\':
+			end := min(i+2, len(glob))
+			b.WriteString(glob[i:end])
+			i = end
+		case '[':
+			next := i + 1
+			if _, end, ok := matchBracket(glob, i, 0); ok {
+				next = end
+			}
+			b.WriteString(glob[i:next])
+			i = next
+		case '*', '?':
+			j, star := i, false
+			for ; j < len(glob) && (glob[j] == '*' || glob[j] == '?'); j++ {
+				if glob[j] == '*' {
+					star = true
+				} else {
+					b.WriteByte('?')
+				}
+			}
+			if star {
+				b.WriteByte('*')
+			}
+			i = j
+		default:
+			b.WriteByte(glob[i])
+			i++
+		}
+	}
+	if b.Len() == len(glob) {
+		// Nothing was dropped, so the runs were as short as they get.
+		return glob
+	}
+	return b.String()
+}
+
+// costly returns why p, a pattern of m already simplified by
+// simplifyWildcards, is still costly to match, or "" if it isn't. Matching takes at most a pass over
+// the path per star, so a pattern like "*a*b*c*d*e*f*g*h*i*j*k*l*m*n*o*p*q"
+// costs far more on every path than one with a star or two.
+func (m *Matcher) costly(p *pattern) string {
+	if p.re != nil {
+		// Regular expressions match in time linear in the path.
+		return ""
+	}
+	segs := p.segments
+	if !p.anchored {
+		segs = segs[1:] // the implicit leading **
+	}
+	if !p.dirOnly && !m.endsInDoubleStar(p) {
+		segs = segs[:len(segs)-1] // the implicit trailing **
+	}
+	doubleStars := 0
+	for _, s := range segs {
+		if s.doubleStar {
+			doubleStars++
+			continue
+		}
+		if _, stars := countWildcards(s.raw); stars > costlyStars {
+			return "costly to match: " + itoa(stars) + " stars in one segment"
+		}
+	}
+	if doubleStars > costlyDoubleStars {
+		return "costly to match: " + itoa(doubleStars) + " ** segments"
+	}
+	return ""
+}
@@ -0,0 +1,70 @@
+package gitignore_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestMatchWildcardRuns(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	paths := []string{
+		"ab", "axb", "axxb", "a", "xy", "x1y", "*", "*x", "xx", "abc", "?1",
+		"*1z", "abcdex", "abcdx", "a*b", "a*xb", "d/ab",
+	}
+	// What git check-ignore reports for each pattern on its own.
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"a***b", []string{"ab", "axb", "axxb", "a*b", "a*xb", "d/ab"}},
+		{"*?*?", []string{"ab", "axb", "axxb", "xy", "x1y", "*x", "xx", "abc", "?1", "*1z", "abcdex", "abcdx", "a*b", "a*xb", "d/ab"}},
+		{`\**`, []string{"*", "*x", "*1z"}},
+		{"[*?]*?*", []string{"*x", "?1", "*1z"}},
+		{"x*?*y", []string{"x1y"}},
+		{"?*?*?", []string{"axb", "axxb", "x1y", "abc", "*1z", "abcdex", "abcdx", "a*b", "a*xb"}},
+		{`a\***b`, []string{"a*b", "a*xb"}},
+		{"*?*?*?*?*?x", []string{"abcdex"}},
+	}
+	for _, opts := range [][]gitignore.Option{nil, {gitignore.WithDFA()}, {gitignore.WithUnicodeClasses()}} {
+		for _, tt := range tests {
+			m := gitignore.New(t.TempDir(), opts...)
+			m.AddPatterns([]byte(tt.pattern+"\n"), "")
+			for _, path := range paths {
+				if got, want := m.Match(path), slices.Contains(tt.want, path); got != want {
+					t.Errorf("%q: Match(%q) = %v, want %v", tt.pattern, path, got, want)
+				}
+			}
+			if issues := m.Lint(); len(issues) != 0 {
+				t.Errorf("%q: Lint() = %v, want nothing", tt.pattern, issues)
+			}
+		}
+	}
+}
+
+func TestLintCostly(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	stars := strings.Repeat("*a", 17)
+	doubleStars := strings.Repeat("a/**/", 17) + "b"
+	m := gitignore.New(t.TempDir())
+	m.AddPatterns([]byte(stars+"\n"+strings.Repeat("*", 60)+"x\n"+strings.Repeat("*a", 16)+"\n"+doubleStars+"\n"), "")
+	issues := m.Lint()
+	if len(issues) != 2 {
+		t.Fatalf("Lint() = %v, want 2 issues", issues)
+	}
+	if issues[0].Pattern != stars || issues[0].Line != 1 || issues[0].Message != "costly to match: 17 stars in one segment" {
+		t.Errorf("issue 0 = %v", issues[0])
+	}
+	if issues[1].Pattern != doubleStars || issues[1].Line != 4 || issues[1].Message != "costly to match: 17 ** segments" {
+		t.Errorf("issue 1 = %v", issues[1])
+	}
+	if !m.Match(strings.Repeat("a", 17)) || m.Match(strings.Repeat("a", 16)+"b") {
+		t.Error("expected the costly pattern to match as written")
+	}
+}
@@ -0,0 +1,402 @@
+package gitignore
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// WithDFA compiles the patterns of each scope into one combined automaton
+// that matches a path in a single pass over its bytes, however many
+// patterns the scope has. States are built lazily as paths exercise them
+// and cached, so the first paths through a scope pay for construction and
+// later ones only follow transitions. It trades compile time and memory
+// for matching time on pattern sets running into the thousands, where the
+// default engine's candidate lists get long; with a few dozen patterns the
+// default engine is usually faster.
+//
+// Results are the same as the default engine's. Paths containing
+// non-ASCII or NUL bytes, and scopes whose automaton grows past
+// maxDFAStates, go through the default engine.
+func WithDFA() Option {
+	return func(c *config) {
+		c.dfa = true
+	}
+}
+
+// maxDFAStates bounds the states cached per scope. Each holds a
+// transition table of 128 pointers.
+const maxDFAStates = 4096
+
+// scopeDFA recognizes the patterns of one scope node. Its input is the
+// path relative to the scope, slash-joined, with a trailing NUL if the
+// path is a directory. A trailing slash wouldn't do: "a/*" would read it
+// as an empty segment.
+type scopeDFA struct {
+	progs []*syntax.Prog
+	idx   []int32 // pattern index of each prog
+
+	// self and selfDir are the last patterns matching the scope
+	// directory's own path as a file or directory, or -1. The automaton
+	// can't tell that empty path from an empty segment.
+	self, selfDir int
+
+	mu     sync.Mutex // guards states and building transitions
+	states map[string]*dfaState
+	start  *dfaState
+}
+
+// dfaState is a set of positions in the pattern programs, reached after
+// reading some prefix of the input.
+type dfaState struct {
+	threads []dfaThread
+	accept  int // last pattern matching if the input ends here, or -1
+	next    [0x80]atomic.Pointer[dfaState]
+}
+
+type dfaThread struct {
+	prog, pc uint32
+}
+
+// compileDFAScope adds the patterns from index from up to to, all scoped
+// to dir, to the automaton of the scope for dir.
+func (m *Matcher) compileDFAScope(dir string, from, to int) {
+	var prefix []string
+	if dir != "" {
+		prefix = strings.Split(dir, "/")
+	}
+	n := m.index.node(prefix)
+	d := n.dfa
+	if d == nil {
+		d = &scopeDFA{self: -1, selfDir: -1}
+	}
+	added := false
+	for i := from; i < to; i++ {
+		p := &m.patterns[i]
+		if p.re != nil {
+			// Regular expression lines are matched by the default
+			// engine, and so then is every pattern.
+			m.dfa = false
+			return
+		}
+		prog, ok := compileProg(patternRegexp(p))
+		if !ok {
+			// Not expected; leave matching to the default engine.
+			m.dfa = false
+			return
+		}
+		d.progs = append(d.progs, prog)
+		d.idx = append(d.idx, int32(i))
+		if tryPattern(p, nil, false) {
+			d.self = i
+		}
+		if tryPattern(p, nil, true) {
+			d.selfDir = i
+		}
+		added = true
+	}
+	if !added {
+		return
+	}
+
+	// Cached states describe the old pattern set; start over.
+	seed := make([]dfaThread, len(d.progs))
+	for j, prog := range d.progs {
+		seed[j] = dfaThread{uint32(j), uint32(prog.Start)}
+	}
+	d.states = make(map[string]*dfaState)
+	d.start = d.state(seed)
+	n.dfa = d
+}
+
+// compileProg compiles a pattern expression, rejecting any that uses
+// instructions the automaton doesn't simulate.
+func compileProg(expr string) (*syntax.Prog, bool) {
+	re, err := syntax.Parse(expr, syntax.Perl|syntax.DotNL)
+	if err != nil {
+		return nil, false
+	}
+	prog, err := syntax.Compile(re.Simplify())
+	if err != nil {
+		return nil, false
+	}
+	for _, inst := range prog.Inst {
+		if inst.Op == syntax.InstEmptyWidth {
+			return nil, false
+		}
+	}
+	return prog, true
+}
+
+// state returns the cached state for the positions reachable from seed
+// without reading input, creating it if needed. It returns nil once the
+// cache is full. The caller holds d.mu or has d to itself.
+func (d *scopeDFA) state(seed []dfaThread) *dfaState {
+	s := &dfaState{accept: -1}
+	seen := make(map[dfaThread]bool)
+	stack := slices.Clone(seed)
+	for len(stack) > 0 {
+		t := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		inst := &d.progs[t.prog].Inst[t.pc]
+		switch inst.Op {
+		case syntax.InstAlt, syntax.InstAltMatch:
+			stack = append(stack, dfaThread{t.prog, inst.Arg}, dfaThread{t.prog, inst.Out})
+		case syntax.InstNop, syntax.InstCapture:
+			stack = append(stack, dfaThread{t.prog, inst.Out})
+		case syntax.InstMatch:
+			s.accept = max(s.accept, int(d.idx[t.prog]))
+		case syntax.InstFail:
+		default:
+			s.threads = append(s.threads, t)
+		}
+	}
+	slices.SortFunc(s.threads, func(a, b dfaThread) int {
+		if a.prog != b.prog {
+			return int(a.prog) - int(b.prog)
+		}
+		return int(a.pc) - int(b.pc)
+	})
+
+	key := make([]byte, 0, 8*len(s.threads)+4)
+	for _, t := range s.threads {
+		key = append(key, byte(t.prog), byte(t.prog>>8), byte(t.prog>>16), byte(t.prog>>24),
+			byte(t.pc), byte(t.pc>>8), byte(t.pc>>16), byte(t.pc>>24))
+	}
+	acc := uint32(s.accept)
+	key = append(key, byte(acc), byte(acc>>8), byte(acc>>16), byte(acc>>24))
+	if old := d.states[string(key)]; old != nil {
+		return old
+	}
+	if len(d.states) >= maxDFAStates {
+		return nil
+	}
+	d.states[string(key)] = s
+	return s
+}
+
+// step returns the state after reading c from s, or nil if the cache is
+// full.
+func (d *scopeDFA) step(s *dfaState, c byte) *dfaState {
+	if next := s.next[c].Load(); next != nil {
+		return next
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if next := s.next[c].Load(); next != nil {
+		return next
+	}
+	var seed []dfaThread
+	for _, t := range s.threads {
+		inst := &d.progs[t.prog].Inst[t.pc]
+		if inst.MatchRune(rune(c)) {
+			seed = append(seed, dfaThread{t.prog, inst.Out})
+		}
+	}
+	next := d.state(seed)
+	if next != nil {
+		s.next[c].Store(next)
+	}
+	return next
+}
+
+// find returns the index of the last pattern in the scope matching rel,
+// or -1. It reports false if the cache filled up on the way.
+func (d *scopeDFA) find(rel []byte) (int, bool) {
+	s := d.start
+	for _, c := range rel {
+		if s = d.step(s, c); s == nil {
+			return -1, false
+		}
+		if len(s.threads) == 0 && s.accept < 0 {
+			return -1, true
+		}
+	}
+	return s.accept, true
+}
+
+// pathPool holds input buffers for paths too long for dfaLookup's stack
+// buffer.
+var pathPool = sync.Pool{New: func() any { return new([]byte) }}
+
+// dfaInputLen returns the length of the automaton input for pathSegs,
+// with room for the directory marker.
+func dfaInputLen(pathSegs []string) int {
+	n := len(pathSegs)
+	for _, s := range pathSegs {
+		n += len(s)
+	}
+	return n
+}
+
+// dfaLookup is lookup using the scope automata. It reports false if the
+// path has to go through the default engine instead.
+func (m *Matcher) dfaLookup(pathSegs []string, isDir bool) (int, bool) {
+	var buf [256]byte
+	path := buf[:0]
+	if n := dfaInputLen(pathSegs); n > len(buf) {
+		p := pathPool.Get().(*[]byte)
+		defer pathPool.Put(p)
+		if cap(*p) < n {
+			*p = make([]byte, 0, n)
+		}
+		path = (*p)[:0]
+	}
+	for i, s := range pathSegs {
+		if i > 0 {
+			path = append(path, '/')
+		}
+		path = append(path, s...)
+	}
+	for _, c := range path {
+		if c == 0 || c >= 0x80 {
+			return -1, false
+		}
+	}
+	if isDir {
+		path = append(path, 0)
+	}
+
+	best := -1
+	node := &m.index
+	off := 0
+	for d := 0; node != nil; d++ {
+		if d == len(pathSegs) {
+			switch {
+			case node.dfa == nil:
+			case isDir:
+				best = max(best, node.dfa.selfDir)
+			default:
+				best = max(best, node.dfa.self)
+			}
+			break
+		}
+		if node.dfa != nil {
+			i, ok := node.dfa.find(path[off:])
+			if !ok {
+				return -1, false
+			}
+			best = max(best, i)
+		}
+		off += len(pathSegs[d]) + 1
+		node = node.children[pathSegs[d]]
+	}
+	return best, true
+}
+
+// patternRegexp translates p, without its scope prefix, into a regular
+// expression that must match the whole scoped path (see scopeDFA).
+func patternRegexp(p *pattern) string {
+	var b strings.Builder
+	needSep := false
+	for i := 0; i < len(p.segments); i++ {
+		s := p.segments[i]
+		last := i == len(p.segments)-1
+		switch {
+		case s.glue && i+2 < len(p.segments) && !p.segments[i+2].doubleStar:
+			// Both readings of "x**/y"; see matchGlued.
+			if needSep {
+				b.WriteByte('/')
+			}
+			b.WriteString("(?:")
+			globRegexp(&b, s.raw)
+			b.WriteString(`/(?:[^/\x00]*/)*`)
+			globRegexp(&b, p.segments[i+2].raw)
+			b.WriteByte('|')
+			globRegexp(&b, strings.TrimSuffix(s.raw, "*"))
+			globRegexp(&b, p.segments[i+2].raw)
+			b.WriteByte(')')
+			needSep = true
+			i += 2
+		case !s.doubleStar:
+			if needSep {
+				b.WriteByte('/')
+			}
+			globRegexp(&b, s.raw)
+			needSep = true
+		case i == 0 && last:
+			b.WriteString(`(?:[^/\x00]*(?:/[^/\x00]*)*)?`)
+		case i == 0:
+			b.WriteString(`(?:[^/\x00]*/)*`)
+		case last:
+			b.WriteString(`(?:/[^/\x00]*)*`)
+		default:
+			b.WriteString(`/(?:[^/\x00]*/)*`)
+			needSep = false
+		}
+	}
+	switch {
+	case !p.dirOnly:
+		b.WriteString(`\x00?`)
+	case p.hasConcrete:
+		// The directory itself, or anything beneath it.
+		b.WriteString(`(?:\x00|/.*)`)
+	default:
+		b.WriteString(`\x00`)
+	}
+	return b.String()
+}
+
+// globRegexp appends the regular expression for one glob segment, with
+// the same reading of escapes and brackets as matchSegment.
+func globRegexp(b *strings.Builder, glob string) {
+	for gx := 0; gx < len(glob); {
+		ch := glob[gx]
+		switch {
+		case ch == '\\' && gx+1 < len(glob):
+			b.WriteString(regexp.QuoteMeta(glob[gx+1 : gx+2]))
+			gx += 2
+		case ch == '?':
+			b.WriteString(`[^/\x00]`)
+			gx++
+		case ch == '*':
+			b.WriteString(`[^/\x00]*`)
+			gx++
+		case ch == '[':
+			_, next, ok := matchBracket(glob, gx, 0)
+			if !ok {
+				b.WriteString(`\[`)
+				gx++
+				continue
+			}
+			bracketRegexp(b, glob, gx)
+			gx = next
+		default:
+			b.WriteString(regexp.QuoteMeta(glob[gx : gx+1]))
+			gx++
+		}
+	}
+}
+
+// bracketRegexp appends a character class listing the ASCII bytes the
+// bracket expression at glob[pos] matches. Asking matchBracket byte by
+// byte keeps ranges, negation and POSIX classes exactly as wildmatch has
+// them. Slash and the NUL directory marker are left out.
+func bracketRegexp(b *strings.Builder, glob string, pos int) {
+	const hex = "0123456789abcdef"
+	b.WriteByte('[')
+	empty := true
+	for c := 0; c < 0x80; c++ {
+		if c == '/' || c == 0 {
+			continue
+		}
+		if ok, _, _ := matchBracket(glob, pos, byte(c)); ok {
+			b.WriteString(`\x`)
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&0xf])
+			empty = false
+		}
+	}
+	if empty {
+		// Matches nothing.
+		b.WriteString(`^\x00-\x{10FFFF}`)
+	}
+	b.WriteByte(']')
+}
@@ -0,0 +1,123 @@
+package gitignore_test
+
+import (
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestDFAMatchesDefault(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	globs := []string{
+		"a", "b", "ab", "*", "?", "a*", "*b", "a?", "[ab]", "[!a]", "[a-c]*",
+		"[[:alpha:]]", "**", "*.log", `\*`, `a\`, "[", "x.log", "[]a]", "é",
+	}
+	names := []string{"a", "b", "ab", "ba", "c", "x.log", "*", "[", "]", `a\`, "é", "A"}
+
+	r := rand.New(rand.NewSource(1))
+	for round := range 200 {
+		var lines []string
+		for range 1 + r.Intn(6) {
+			var segs []string
+			for range 1 + r.Intn(3) {
+				segs = append(segs, globs[r.Intn(len(globs))])
+			}
+			line := strings.Join(segs, "/")
+			if r.Intn(4) == 0 {
+				line = "/" + line
+			}
+			if r.Intn(3) == 0 {
+				line += "/"
+			}
+			if r.Intn(4) == 0 {
+				line = "!" + line
+			}
+			lines = append(lines, line)
+		}
+		data := []byte(strings.Join(lines, "\n") + "\n")
+		scope := ""
+		if round%3 == 0 {
+			scope = "a"
+		}
+
+		root := t.TempDir()
+		def := gitignore.New(root)
+		re := gitignore.New(root, gitignore.WithDFA())
+		def.AddPatterns(data, "")
+		re.AddPatterns(data, "")
+		def.AddPatterns([]byte(lines[0]+"\n"), scope)
+		re.AddPatterns([]byte(lines[0]+"\n"), scope)
+
+		for range 50 {
+			var segs []string
+			for range 1 + r.Intn(4) {
+				segs = append(segs, names[r.Intn(len(names))])
+			}
+			path := strings.Join(segs, "/")
+			isDir := r.Intn(2) == 0
+			if got, want := re.MatchPath(path, isDir), def.MatchPath(path, isDir); got != want {
+				t.Fatalf("patterns %q, scope %q: MatchPath(%q, %v) = %v with DFA, %v by default",
+					lines, scope, path, isDir, got, want)
+			}
+		}
+	}
+}
+
+func TestDFADetail(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	m := gitignore.New(t.TempDir(), gitignore.WithDFA())
+	m.AddPatterns([]byte("*.log\n!keep.log\nbuild/\n"), "")
+	m.AddPatterns([]byte("*.tmp\n"), "src")
+
+	tests := []struct {
+		path    string
+		ignored bool
+		pattern string
+	}{
+		{"app.log", true, "*.log"},
+		{"keep.log", false, "!keep.log"},
+		{"build/", true, "build/"},
+		{"build/out.o", true, "build/"},
+		{"build", false, ""},
+		{"src/x.tmp", true, "*.tmp"},
+		{"x.tmp", false, ""},
+	}
+	for _, tt := range tests {
+		r := m.MatchDetail(tt.path)
+		if r.Ignored != tt.ignored || r.Pattern != tt.pattern {
+			t.Errorf("MatchDetail(%q) = {Ignored: %v, Pattern: %q}, want {%v, %q}",
+				tt.path, r.Ignored, r.Pattern, tt.ignored, tt.pattern)
+		}
+	}
+}
+
+func TestDFAConcurrentMatch(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	// States are built lazily during Match, so concurrent callers race to
+	// fill the same transitions.
+	m := gitignore.New(t.TempDir(), gitignore.WithDFA())
+	m.AddPatterns([]byte("*.log\n!keep.log\nbuild/\n**/tmp/*.o\n"), "")
+
+	paths := []string{"a.log", "keep.log", "build/x", "src/tmp/x.o", "src/main.go"}
+	want := []bool{true, false, true, true, false}
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for range 100 {
+				for i, p := range paths {
+					if got := m.Match(p); got != want[i] {
+						t.Errorf("Match(%q) = %v, want %v", p, got, want[i])
+						return
+					}
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
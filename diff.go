@@ -0,0 +1,170 @@
+package gitignore
+
+import (
+	"io/fs"
+	"strings"
+)
+
+// PathChange is a path, or a directory and everything under it, that two
+// sets of rules ignore differently, as Diff reports it.
+type PathChange struct {
+	// Path is slash-separated and relative to the tree's root, with a
+	// trailing slash for a directory.
+	Path string
+	// Before and After are how the first and the second Matcher decide
+	// Path, as MatchFullDetail does.
+	Before, After MatchResult
+	// Paths is how many paths of the tree the change covers: 1, or for a
+	// directory whose every path below changes as it does, the directory
+	// and those paths, which Diff then leaves out.
+	Paths int
+}
+
+// Diff reports the paths of sampleTree that a and b ignore differently,
+// for review tools explaining what an edit to an ignore file does. a and
+// b are the rules before and after the edit; a nil Matcher ignores
+// nothing, for a file added or deleted.
+//
+// Paths are decided as MatchFull decides them, with a directory's
+// exclusion covering everything below it, and a directory both ignore is
+// not entered. A directory whose whole contents change with it is
+// reported once, its Paths counting them, so "build/ is now ignored" is
+// one change rather than one per file. Changes are in the order
+// fs.WalkDir visits their paths, and a ".git" directory is skipped.
+func Diff(a, b *Matcher, sampleTree fs.FS) ([]PathChange, error) {
+	type entry struct {
+		path          string
+		before, after MatchResult
+	}
+	var entries []entry
+	err := fs.WalkDir(sampleTree, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == "." {
+			return nil
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return fs.SkipDir
+			}
+			p += "/"
+		}
+		e := entry{path: p, before: diffMatch(a, p), after: diffMatch(b, p)}
+		entries = append(entries, e)
+		if d.IsDir() && e.before.Ignored && e.after.Ignored {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []PathChange
+	for i := 0; i < len(entries); {
+		e := entries[i]
+		if e.before.Ignored == e.after.Ignored {
+			i++
+			continue
+		}
+		c := PathChange{Path: e.path, Before: e.before, After: e.after, Paths: 1}
+		if strings.HasSuffix(e.path, "/") {
+			// The paths below a directory come right after it.
+			j := i + 1
+			for j < len(entries) && strings.HasPrefix(entries[j].path, e.path) &&
+				entries[j].before.Ignored == e.before.Ignored && entries[j].after.Ignored == e.after.Ignored {
+				j++
+			}
+			if j == len(entries) || !strings.HasPrefix(entries[j].path, e.path) {
+				c.Paths = j - i
+				changes = append(changes, c)
+				i = j
+				continue
+			}
+		}
+		changes = append(changes, c)
+		i++
+	}
+	return changes, nil
+}
+
+// diffMatch is m.MatchFullDetail(p), or no match for a nil m.
+func diffMatch(m *Matcher, p string) MatchResult {
+	if m == nil {
+		return MatchResult{}
+	}
+	return m.MatchFullDetail(p)
+}
+
+// RuleChange is a rule added or removed between two ignore files, as
+// DiffRules reports it.
+type RuleChange struct {
+	Pattern string // as git reads it, trailing spaces trimmed
+	Line    int    // 1-based, in the file it was removed from or added to
+	Added   bool   // added in the second file, else removed from the first
+	Negate  bool   // the rule is a negation
+}
+
+// DiffRules reports the rules, the lines holding patterns, that differ
+// between ignore files a and b, without any paths to match: the removals
+// and additions turning the rules of a into those of b in the fewest
+// steps. Comments, blank lines, line endings and trailing spaces git
+// trims are not rules, so editing them is no change. Since the last match
+// wins, a rule that moved matters, and is reported removed where it was
+// and added where it went. Changes are in file order, a removal before an
+// addition at the same place.
+func DiffRules(a, b []byte) []RuleChange {
+	ra, rb := diffRules(a), diffRules(b)
+
+	// lcs[i][j] is the length of the longest common subsequence of ra[i:]
+	// and rb[j:].
+	lcs := make([][]int, len(ra)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(rb)+1)
+	}
+	for i := len(ra) - 1; i >= 0; i-- {
+		for j := len(rb) - 1; j >= 0; j-- {
+			if ra[i].Pattern == rb[j].Pattern {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var changes []RuleChange
+	i, j := 0, 0
+	for i < len(ra) || j < len(rb) {
+		switch {
+		case i < len(ra) && j < len(rb) && ra[i].Pattern == rb[j].Pattern:
+			i++
+			j++
+		case j == len(rb) || i < len(ra) && lcs[i+1][j] >= lcs[i][j+1]:
+			changes = append(changes, ra[i])
+			i++
+		default:
+			c := rb[j]
+			c.Added = true
+			changes = append(changes, c)
+			j++
+		}
+	}
+	return changes
+}
+
+// diffRules returns the rules of the ignore file data, in order.
+func diffRules(data []byte) []RuleChange {
+	var rules []RuleChange
+	text := strings.TrimPrefix(string(data), utf8BOM)
+	n := 0
+	for raw := range strings.SplitSeq(text, "\n") {
+		n++
+		p := trimTrailingSpaces(strings.TrimSuffix(raw, "\r"))
+		if p == "" || p[0] == '#' {
+			continue
+		}
+		rules = append(rules, RuleChange{Pattern: p, Line: n, Negate: p[0] == '!'})
+	}
+	return rules
+}
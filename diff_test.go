@@ -0,0 +1,131 @@
+package gitignore_test
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestDiff(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tree := fstest.MapFS{
+		"main.go":              {},
+		"debug.log":            {},
+		"keep.log":             {},
+		"build/out.bin":        {},
+		"build/sub/deep.bin":   {},
+		"dist/app.js":          {},
+		"dist/keep/readme.md":  {},
+		"vendor/lib/lib.go":    {},
+		"vendor/lib/lib.log":   {},
+		".git/objects/ab/cdef": {},
+	}
+	matcher := func(rules string) *gitignore.Matcher {
+		m := gitignore.New(t.TempDir())
+		m.AddPatterns([]byte(rules), "")
+		return m
+	}
+	a := matcher("*.log\n!keep.log\ndist/\nvendor/\n")
+	b := matcher("*.log\nbuild/\ndist/*\n!dist/keep/\nvendor/\n")
+
+	changes, err := gitignore.Diff(a, b, tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type change struct {
+		path          string
+		before, after bool
+		paths         int
+	}
+	var got []change
+	for _, c := range changes {
+		got = append(got, change{c.Path, c.Before.Ignored, c.After.Ignored, c.Paths})
+	}
+	want := []change{
+		{"build/", false, true, 4},
+		{"dist/", true, false, 1},
+		{"dist/keep/", true, false, 2},
+		{"keep.log", false, true, 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff = %v, want %v", got, want)
+	}
+
+	for _, c := range changes {
+		switch c.Path {
+		case "keep.log":
+			if c.Before.Pattern != "!keep.log" || c.After.Pattern != "*.log" {
+				t.Errorf("keep.log: Before %q, After %q", c.Before.Pattern, c.After.Pattern)
+			}
+		case "dist/keep/":
+			if c.Before.Ancestor != "dist" || c.After.Pattern != "!dist/keep/" {
+				t.Errorf("dist/keep/: Before.Ancestor %q, After %q", c.Before.Ancestor, c.After.Pattern)
+			}
+		}
+	}
+}
+
+func TestDiffNil(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tree := fstest.MapFS{"a.log": {}, "b.txt": {}}
+	m := gitignore.New(t.TempDir())
+	m.AddPatterns([]byte("*.log\n"), "")
+
+	changes, err := gitignore.Diff(nil, m, tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Path != "a.log" || changes[0].Before.Ignored || !changes[0].After.Ignored {
+		t.Errorf("Diff(nil, m) = %+v", changes)
+	}
+	if changes, _ := gitignore.Diff(m, m, tree); len(changes) != 0 {
+		t.Errorf("Diff(m, m) = %+v, want none", changes)
+	}
+}
+
+func TestDiffRules(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []gitignore.RuleChange
+	}{
+		{
+			name: "formatting only",
+			a:    "# logs\n*.log\n\nbuild/\n",
+			b:    "*.log   \r\n# build\nbuild/",
+		},
+		{
+			name: "added and removed",
+			a:    "*.log\nbuild/\n",
+			b:    "*.log\n!keep.log\ndist/\n",
+			want: []gitignore.RuleChange{
+				{Pattern: "build/", Line: 2},
+				{Pattern: "!keep.log", Line: 2, Added: true, Negate: true},
+				{Pattern: "dist/", Line: 3, Added: true},
+			},
+		},
+		{
+			name: "moved",
+			a:    "!keep.log\n*.log\n",
+			b:    "*.log\n!keep.log\n",
+			want: []gitignore.RuleChange{
+				{Pattern: "!keep.log", Line: 1, Negate: true},
+				{Pattern: "!keep.log", Line: 2, Added: true, Negate: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := gitignore.DiffRules([]byte(tt.a), []byte(tt.b))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffRules = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
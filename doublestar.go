@@ -0,0 +1,301 @@
+package gitignore
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// DoublestarGlobs returns globs in the syntax of
+// github.com/bmatcuk/doublestar/v4 that between them match the paths m
+// ignores, for projects standardized on doublestar that want to hand
+// m's patterns to code taking globs. Each is relative to the root, and
+// one ending in "/**" matches the path before it too, as doublestar reads
+// it.
+//
+// Not every Matcher has such a form. It is a PatternError for the first
+// pattern that is a negation, which a list of globs can't take back, a
+// regular expression, or one with a POSIX character class, which
+// doublestar has no syntax for, and for any pattern of a Matcher made
+// WithIgnoreCase. A directory-only pattern's glob matches a file of the
+// name as well, as doublestar can't tell the two apart.
+func (m *Matcher) DoublestarGlobs() ([]string, error) {
+	var globs []string
+	for o := m; o != nil; o = o.globalMatcher() {
+		for i := range o.patterns {
+			p := &o.patterns[i]
+			if o.hot[i].shadowed {
+				continue
+			}
+			gs, msg := doublestarGlobs(p, o.ignoreCase)
+			if msg != "" {
+				return nil, PatternError{
+					Pattern: o.patternText(p),
+					Source:  o.sources[p.src].path,
+					Line:    int(p.line),
+					Message: msg,
+				}
+			}
+			globs = append(globs, gs...)
+		}
+	}
+	return globs, nil
+}
+
+// doublestarGlobs returns the doublestar globs matching what p does, or
+// an error message.
+func doublestarGlobs(p *pattern, ignoreCase bool) ([]string, string) {
+	switch {
+	case p.negate:
+		return nil, "a negation has no doublestar form"
+	case p.re != nil:
+		return nil, "a regular expression has no doublestar form"
+	case ignoreCase:
+		return nil, "doublestar globs don't ignore case"
+	}
+	segs := p.segments
+	if p.dirOnly && !segs[len(segs)-1].doubleStar {
+		segs = append(segs[:len(segs):len(segs)], segment{doubleStar: true})
+	}
+	var base []string
+	for _, d := range p.prefixSegs {
+		base = append(base, doublestarEscape(d))
+	}
+	globs := []string{strings.Join(base, "/")}
+	for i := 0; i < len(segs); i++ {
+		s := segs[i]
+		var alts []string
+		switch {
+		case s.glue && i+2 < len(segs) && !segs[i+2].doubleStar:
+			// Both readings of "x**/y"; see matchGlued.
+			x, msg := doublestarSegment(s.raw)
+			if msg != "" {
+				return nil, msg
+			}
+			y, msg := doublestarSegment(segs[i+2].raw)
+			if msg != "" {
+				return nil, msg
+			}
+			alts = []string{x + "/**/" + y, strings.TrimSuffix(x, "*") + y}
+			i += 2
+		case s.doubleStar:
+			alts = []string{"**"}
+		default:
+			g, msg := doublestarSegment(s.raw)
+			if msg != "" {
+				return nil, msg
+			}
+			alts = []string{g}
+		}
+		next := make([]string, 0, len(globs)*len(alts))
+		for _, g := range globs {
+			for _, a := range alts {
+				if g != "" {
+					a = g + "/" + a
+				}
+				next = append(next, a)
+			}
+		}
+		globs = next
+	}
+	return globs, ""
+}
+
+// doublestarSegment translates a glob segment, as matchSegment reads it,
+// into doublestar's syntax, or returns an error message.
+func doublestarSegment(raw string) (string, string) {
+	var b strings.Builder
+	for i := 0; i < len(raw); {
+		switch c := raw[i]; {
+		case c == '\\' && i+1 < len(raw):
+			b.WriteString(raw[i : i+2])
+			i += 2
+		case c == '[':
+			_, next, ok := matchBracket(raw, i, 0)
+			if !ok {
+				b.WriteString(`\[`)
+				i++
+				continue
+			}
+			class := raw[i:next]
+			if at := strings.Index(class, "[:"); at >= 0 && findPosixClassEnd(class, at+2) >= 0 {
+				return "", "doublestar has no POSIX character classes"
+			}
+			b.WriteByte('[')
+			j := 1
+			if class[j] == '!' || class[j] == '^' {
+				b.WriteByte('!')
+				j++
+			}
+			if class[j] == ']' {
+				// Literal as the first member, but not to doublestar.
+				b.WriteString(`\]`)
+				j++
+			}
+			b.WriteString(class[j:])
+			i = next
+		case c == '{' || c == '}':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+			i++
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String(), ""
+}
+
+// doublestarEscape escapes the characters doublestar reads specially in
+// the directory name s.
+func doublestarEscape(s string) string {
+	if !strings.ContainsAny(s, `\*?[]{}`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(`\*?[]{}`, s[i]) >= 0 {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// FromDoublestar returns gitignore pattern lines, relative to the root,
+// that between them ignore the paths the doublestar glob matches, for
+// projects moving their globs over one at a time:
+//
+//	lines, err := gitignore.FromDoublestar("src/**/*.{js,ts}")
+//	...
+//	m.AddPatterns([]byte(strings.Join(lines, "\n")), "")
+//
+// Alternatives in braces become lines of their own. As with any
+// gitignore pattern, a line matching a directory ignores everything
+// inside it, so "build" ignores what doublestar's "build/**" matches. It
+// is a PatternError if glob has an unclosed bracket or brace, or ends in
+// a backslash, which doublestar reports as ErrBadPattern.
+func FromDoublestar(glob string) ([]string, error) {
+	if msg := validateDoublestar(glob); msg != "" {
+		return nil, PatternError{Pattern: glob, Message: msg}
+	}
+	alts, ok := expandBraces(glob, 1)
+	if !ok {
+		return nil, PatternError{Pattern: glob, Message: "braces expand to more than " + itoa(maxBraceExpansions) + " patterns"}
+	}
+	if alts == nil {
+		alts = []string{glob}
+	}
+	var lines []string
+	for _, a := range alts {
+		if line := doublestarLine(a); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// validateDoublestar returns the error message for a glob doublestar
+// can't compile, or "".
+func validateDoublestar(glob string) string {
+	depth := 0
+	for i := 0; i < len(glob); i++ {
+		switch glob[i] {
+		case '\\':
+			if i+1 == len(glob) {
+				return "trailing backslash"
+			}
+			i++
+		case '[':
+			_, next, ok := matchBracket(glob, i, 0)
+			if !ok {
+				return "unclosed bracket"
+			}
+			i = next - 1
+		case '{':
+			depth++
+		case '}':
+			if depth > 0 {
+				depth--
+			}
+		}
+	}
+	if depth > 0 {
+		return "unclosed brace"
+	}
+	return ""
+}
+
+// doublestarLine translates glob, a doublestar glob without alternatives
+// to expand, into an anchored gitignore pattern line, or "" if it matches
+// nothing.
+func doublestarLine(glob string) string {
+	glob = strings.TrimPrefix(glob, "/")
+	for strings.HasSuffix(glob, "/**") {
+		glob = strings.TrimSuffix(glob, "/**")
+	}
+	if glob == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('/')
+	for i := 0; i < len(glob); {
+		switch c := glob[i]; c {
+		case '\\':
+			b.WriteString(glob[i : i+2])
+			i += 2
+		case '[':
+			_, next, _ := matchBracket(glob, i, 0)
+			b.WriteString(glob[i:next])
+			i = next
+		case '{', '}':
+			// Braces left after expansion hold a single alternative.
+			i++
+		case '*':
+			j := i
+			for j < len(glob) && glob[j] == '*' {
+				j++
+			}
+			// A ** other than a whole segment is a * to doublestar.
+			if j-i >= 2 && (i == 0 || glob[i-1] == '/') && (j == len(glob) || glob[j] == '/') {
+				b.WriteString("**")
+			} else {
+				b.WriteByte('*')
+			}
+			i = j
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	line := b.String()
+	if strings.HasSuffix(line, " ") && !trailingBackslash(line[:len(line)-1]) {
+		// Git trims an unescaped trailing space.
+		line = line[:len(line)-1] + `\ `
+	}
+	return line
+}
+
+// PathMatcher is m as doublestar's Match and PathMatch are called with a
+// fixed glob, so code calling those can switch to m's patterns a call
+// site at a time.
+type PathMatcher struct {
+	m *Matcher
+}
+
+// PathMatcher returns m as a PathMatcher.
+func (m *Matcher) PathMatcher() PathMatcher {
+	return PathMatcher{m}
+}
+
+// Match reports whether name, slash-separated and relative to the root,
+// is ignored. The error is always nil; it is there for the signature of
+// doublestar's Match.
+func (p PathMatcher) Match(name string) (bool, error) {
+	return p.m.Match(name), nil
+}
+
+// PathMatch is Match for a name with the OS's separators, as doublestar's
+// PathMatch takes.
+func (p PathMatcher) PathMatch(name string) (bool, error) {
+	return p.m.Match(filepath.ToSlash(name)), nil
+}
@@ -0,0 +1,126 @@
+package gitignore_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestDoublestarGlobs(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tests := []struct {
+		patterns, dir string
+		want          []string
+	}{
+		{"*.log", "", []string{"**/*.log/**"}},
+		{"/build", "", []string{"build/**"}},
+		{"dist/", "", []string{"**/dist/**"}},
+		{"src/*.tmp\n**/cache", "", []string{"src/*.tmp/**", "**/cache/**"}},
+		{"a/**/b", "", []string{"a/**/b/**"}},
+		{"x**/y", "", []string{"x*/**/y/**", "xy/**"}},
+		{"[!a-c]?.{js}\n[]x]", "", []string{"**/[!a-c]?.\\{js\\}/**", "**/[\\]x]/**"}},
+		{"*.o", "lib/c{1}", []string{"lib/c\\{1\\}/**/*.o/**"}},
+	}
+	for _, tt := range tests {
+		m := gitignore.New(t.TempDir())
+		m.AddPatterns([]byte(tt.patterns+"\n"), tt.dir)
+		got, err := m.DoublestarGlobs()
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: DoublestarGlobs() = %q, %v, want %q", tt.patterns, got, err, tt.want)
+		}
+	}
+
+	for _, tt := range []struct {
+		patterns string
+		opts     []gitignore.Option
+		msg      string
+	}{
+		{"*.log\n!keep.log", nil, "a negation has no doublestar form"},
+		{"[[:digit:]]", nil, "doublestar has no POSIX character classes"},
+		{"re:a+", []gitignore.Option{gitignore.WithRegexPatterns()}, "a regular expression has no doublestar form"},
+		{"a", []gitignore.Option{gitignore.WithIgnoreCase(true)}, "doublestar globs don't ignore case"},
+	} {
+		m := gitignore.New(t.TempDir(), tt.opts...)
+		m.AddPatterns([]byte(tt.patterns+"\n"), "")
+		_, err := m.DoublestarGlobs()
+		var perr gitignore.PatternError
+		if !errors.As(err, &perr) || perr.Message != tt.msg {
+			t.Errorf("%q: DoublestarGlobs() error = %v, want %q", tt.patterns, err, tt.msg)
+		}
+	}
+}
+
+func TestFromDoublestar(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tests := []struct {
+		glob string
+		want []string
+		// paths the lines must ignore, and must not
+		match, skip []string
+	}{
+		{"*.go", []string{"/*.go"}, []string{"a.go"}, []string{"d/a.go"}},
+		{"**/*.go", []string{"/**/*.go"}, []string{"a.go", "d/e/a.go"}, []string{"a.c"}},
+		{"build/**", []string{"/build"}, []string{"build", "build/x"}, []string{"x/build"}},
+		{"src/**/*.{js,ts}", []string{"/src/**/*.js", "/src/**/*.ts"}, []string{"src/a.ts", "src/x/a.js"}, []string{"a.ts", "src/a.c"}},
+		{"{a,b/{c,d}}.txt", []string{"/a.txt", "/b/c.txt", "/b/d.txt"}, []string{"b/c.txt"}, []string{"b.txt"}},
+		{"{x}.txt", []string{"/x.txt"}, []string{"x.txt"}, []string{"{x}.txt"}},
+		{"a**b", []string{"/a*b"}, []string{"ab", "axb"}, []string{"a/b"}},
+		{"[^a]\\{", []string{"/[^a]\\{"}, []string{"b{"}, []string{"a{"}},
+		{"/#x ", []string{"/#x\\ "}, []string{"#x "}, []string{"#x"}},
+	}
+	for _, tt := range tests {
+		lines, err := gitignore.FromDoublestar(tt.glob)
+		if err != nil || !reflect.DeepEqual(lines, tt.want) {
+			t.Errorf("%q: FromDoublestar() = %q, %v, want %q", tt.glob, lines, err, tt.want)
+			continue
+		}
+		m := gitignore.New(t.TempDir())
+		m.AddPatterns([]byte(strings.Join(lines, "\n")+"\n"), "")
+		for _, path := range tt.match {
+			if !m.Match(path) {
+				t.Errorf("%q: Match(%q) = false", tt.glob, path)
+			}
+		}
+		for _, path := range tt.skip {
+			if m.Match(path) {
+				t.Errorf("%q: Match(%q) = true", tt.glob, path)
+			}
+		}
+	}
+
+	for glob, msg := range map[string]string{
+		"a[b":  "unclosed bracket",
+		"{a,b": "unclosed brace",
+		`a\`:   "trailing backslash",
+	} {
+		_, err := gitignore.FromDoublestar(glob)
+		var perr gitignore.PatternError
+		if !errors.As(err, &perr) || perr.Message != msg || perr.Pattern != glob {
+			t.Errorf("FromDoublestar(%q) error = %v, want %q", glob, err, msg)
+		}
+	}
+}
+
+func TestPathMatcher(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	m := gitignore.New(t.TempDir())
+	m.AddPatterns([]byte("*.log\n!keep.log\n"), "")
+	pm := m.PathMatcher()
+	for name, want := range map[string]bool{"a.log": true, "d/keep.log": false, "a.go": false} {
+		if got, err := pm.Match(name); got != want || err != nil {
+			t.Errorf("Match(%q) = %v, %v, want %v", name, got, err, want)
+		}
+		if got, err := pm.PathMatch(name); got != want || err != nil {
+			t.Errorf("PathMatch(%q) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package gitignore
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// EditorConfigGlob is a compiled EditorConfig section name, the glob in
+// the brackets of a section header of an .editorconfig file, for
+// EditorConfig implementations to match files against:
+//
+//   - "*" matches anything but a slash, "**" anything including slashes,
+//     and "?" one character other than a slash. "/**/" matches one
+//     slash as well, so "a/**/b" matches "a/b".
+//   - "[seq]" matches one character of seq and "[!seq]" one that isn't,
+//     ranges such as "a-z" included. A bracket holding a slash is taken
+//     literally.
+//   - "{s1,s2,s3}" matches any of its alternatives, which can hold
+//     globs and braces of their own, and "{n1..n2}" any integer from n1
+//     to n2, either of which can be negative. Other braces are taken
+//     literally, as is a special character after a backslash.
+//   - A glob without a slash matches a file's name at any depth below
+//     the directory of its .editorconfig; one with a slash matches the
+//     path from that directory, a leading slash or not.
+type EditorConfigGlob struct {
+	re     *regexp.Regexp
+	ranges []editorConfigRange // of the capturing groups of re, in order
+}
+
+// editorConfigRange is the range of integers "{lo..hi}" matches.
+type editorConfigRange struct {
+	lo, hi int64
+}
+
+// CompileEditorConfig compiles the EditorConfig section name glob. It
+// returns an error only for a bracket expression naming an unknown
+// character class.
+func CompileEditorConfig(glob string) (*EditorConfigGlob, error) {
+	g := &EditorConfigGlob{}
+	prefix := "(?s)^(?:.*/)?"
+	if strings.Contains(glob, "/") {
+		prefix = "(?s)^"
+		glob = strings.TrimPrefix(glob, "/")
+	}
+	re, err := regexp.Compile(prefix + g.translate(glob, true) + "$")
+	if err != nil {
+		return nil, err
+	}
+	g.re = re
+	return g, nil
+}
+
+// translate returns the regular expression for glob, recording the
+// ranges of its numeric braces in g. start is whether glob starts the
+// section name.
+func (g *EditorConfigGlob) translate(glob string, start bool) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+			}
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		case '*':
+			if i+1 >= len(glob) || glob[i+1] != '*' {
+				b.WriteString("[^/]*")
+				continue
+			}
+			run := i
+			for i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+			}
+			afterSlash := run == 0 && start || run > 0 && glob[run-1] == '/'
+			if afterSlash && i+1 < len(glob) && glob[i+1] == '/' {
+				// "**/" at the start can match no directory at all, and
+				// "/**/" after it, a single slash.
+				i++
+				b.WriteString("(?:.*/)?")
+				continue
+			}
+			b.WriteString(".*")
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			class, end, ok := rsyncClass(glob, i)
+			if !ok || strings.Contains(glob[i:end], "/") {
+				b.WriteString(`\[`)
+				continue
+			}
+			b.WriteString(class)
+			i = end
+		case '{':
+			end := closingBrace(glob, i)
+			if end < 0 {
+				b.WriteString(`\{`)
+				continue
+			}
+			b.WriteString(g.brace(glob[i+1 : end]))
+			i = end
+		default:
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	return b.String()
+}
+
+// brace returns the regular expression for the braces holding inner.
+func (g *EditorConfigGlob) brace(inner string) string {
+	if alts := splitAlternatives(inner); len(alts) > 1 {
+		var b strings.Builder
+		b.WriteString("(?:")
+		for i, alt := range alts {
+			if i > 0 {
+				b.WriteByte('|')
+			}
+			b.WriteString(g.translate(alt, false))
+		}
+		b.WriteByte(')')
+		return b.String()
+	}
+	if lo, hi, ok := strings.Cut(inner, ".."); ok {
+		n1, err1 := strconv.ParseInt(lo, 10, 64)
+		n2, err2 := strconv.ParseInt(hi, 10, 64)
+		if err1 == nil && err2 == nil {
+			g.ranges = append(g.ranges, editorConfigRange{min(n1, n2), max(n1, n2)})
+			return `([+-]?[0-9]+)`
+		}
+	}
+	return `\{` + g.translate(inner, false) + `\}`
+}
+
+// closingBrace returns the index of the brace closing the one at
+// glob[i], or -1 if there is none.
+func closingBrace(glob string, i int) int {
+	depth := 0
+	for ; i < len(glob); i++ {
+		switch glob[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitAlternatives splits the inside of braces at its commas, leaving
+// those in nested braces or after a backslash alone.
+func splitAlternatives(inner string) []string {
+	var alts []string
+	depth, from := 0, 0
+	for i := 0; i < len(inner); i++ {
+		switch inner[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				alts = append(alts, inner[from:i])
+				from = i + 1
+			}
+		}
+	}
+	return append(alts, inner[from:])
+}
+
+// Match reports whether g matches relPath, a file's slash-separated path
+// relative to the directory of the .editorconfig g comes from. The
+// directory itself, a path outside it and a path with a NUL byte never
+// match.
+func (g *EditorConfigGlob) Match(relPath string) bool {
+	if strings.IndexByte(relPath, 0) >= 0 {
+		return false
+	}
+	relPath, ok := resolvePath(relPath)
+	if !ok || relPath == "" {
+		return false
+	}
+	if len(g.ranges) == 0 {
+		return g.re.MatchString(relPath)
+	}
+	loc := g.re.FindStringSubmatchIndex(relPath)
+	if loc == nil {
+		return false
+	}
+	for i, r := range g.ranges {
+		start, end := loc[2*i+2], loc[2*i+3]
+		if start < 0 {
+			continue // in an alternative that didn't match
+		}
+		n, err := strconv.ParseInt(relPath[start:end], 10, 64)
+		if err != nil || n < r.lo || n > r.hi {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,69 @@
+package gitignore_test
+
+import (
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestEditorConfigGlob(t *testing.T) {
+	for _, tt := range []struct {
+		glob string
+		want map[string]bool
+	}{
+		// Globs without a slash match a file's name at any depth.
+		{"*", map[string]bool{"a.c": true, "d/a.c": true}},
+		{"*.c", map[string]bool{"a.c": true, "d/e/a.c": true, "a.h": false, "a.c/x": false}},
+		{"a?c", map[string]bool{"abc": true, "a/c": false}},
+		{"Makefile", map[string]bool{"Makefile": true, "d/Makefile": true, "xMakefile": false}},
+		// Those with one match from the directory of the .editorconfig.
+		{"lib/*.js", map[string]bool{"lib/a.js": true, "x/lib/a.js": false, "lib/d/a.js": false}},
+		{"/top.c", map[string]bool{"top.c": true, "d/top.c": false}},
+		{"lib/**.js", map[string]bool{"lib/a.js": true, "lib/d/e/a.js": true, "a.js": false}},
+		{"a/**/z.c", map[string]bool{"a/z.c": true, "a/b/c/z.c": true, "az.c": false}},
+		{"**/z.c", map[string]bool{"z.c": true, "a/z.c": true}},
+		{"a**z.c", map[string]bool{"az.c": true, "a/b/z.c": true}},
+		// Brackets.
+		{"[ab].c", map[string]bool{"a.c": true, "b.c": true, "c.c": false}},
+		{"[!ab].c", map[string]bool{"c.c": true, "a.c": false}},
+		{"[a-c].c", map[string]bool{"b.c": true, "d.c": false}},
+		{"a[/]b", map[string]bool{"a[/]b": true, "a/b": false}},
+		{"[a.c", map[string]bool{"[a.c": true, "a.c": false}},
+		// Braces.
+		{"*.{js,py}", map[string]bool{"a.js": true, "a.py": true, "a.c": false}},
+		{"{a,{b,c}d}.x", map[string]bool{"a.x": true, "bd.x": true, "cd.x": true, "b.x": false}},
+		{"{,a}b", map[string]bool{"b": true, "ab": true}},
+		{"{lib,src}/*.go", map[string]bool{"lib/a.go": true, "src/a.go": true, "x/a.go": false}},
+		{"{single}.b", map[string]bool{"{single}.b": true, "single.b": false}},
+		{"{a,b.c", map[string]bool{"{a,b.c": true, "a": false}},
+		{"\\{a,b}.c", map[string]bool{"{a,b}.c": true, "a.c": false}},
+		{"a\\*b", map[string]bool{"a*b": true, "axb": false}},
+		// Numeric ranges.
+		{"file{3..120}.txt", map[string]bool{
+			"file3.txt": true, "file120.txt": true, "file2.txt": false, "file121.txt": false, "filex.txt": false,
+		}},
+		{"{-3..3}", map[string]bool{"-3": true, "0": true, "+3": true, "4": false, "-4": false}},
+		{"{10..1}", map[string]bool{"5": true, "11": false}},
+		{"{a,{1..2}}", map[string]bool{"a": true, "2": true, "3": false}},
+		{"{1..x}", map[string]bool{"{1..x}": true, "1": false}},
+	} {
+		g, err := gitignore.CompileEditorConfig(tt.glob)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.glob, err)
+		}
+		for path, want := range tt.want {
+			if got := g.Match(path); got != want {
+				t.Errorf("%q: Match(%q) = %v, want %v", tt.glob, path, got, want)
+			}
+		}
+		for _, path := range []string{"", ".", "../a.c", "a\x00.c"} {
+			if g.Match(path) {
+				t.Errorf("%q: Match(%q) = true", tt.glob, path)
+			}
+		}
+	}
+
+	if _, err := gitignore.CompileEditorConfig("[[:nope:]]"); err == nil {
+		t.Error("want an error for an unknown character class")
+	}
+}
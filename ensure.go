@@ -0,0 +1,147 @@
+package gitignore
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// EnsurePatterns adds to the ignore file at path those of patterns it
+// doesn't already have, for generators and editors that need entries in
+// a user's .gitignore. Running it again changes nothing, and a file that
+// needs no change isn't written.
+//
+// A pattern is already there if a line of the file, anywhere, has the
+// same rule once trailing spaces are trimmed, or, for patterns without
+// wildcards, a rule Equivalent proves ignores the same paths, as
+// "docs/site" and "/docs/site" do. A negation the user wrote after it is
+// left to take effect. Missing patterns are added in order to the section
+// headed by the comment "# "+section, at the end of the lines below it
+// before a blank one, and the section is added at the end of the file if
+// there is none. With an empty section they are added at the end of the
+// file.
+//
+// The file keeps its line endings and byte order mark, and is created if
+// it doesn't exist; if path is a symbolic link, the file it links to is
+// the one edited. It is written through a temporary file renamed into
+// place, so a reader never sees part of it. It is an error for a pattern
+// to be blank, a comment or more than one line, and nothing is written.
+func EnsurePatterns(path string, patterns []string, section string) error {
+	for _, p := range patterns {
+		if strings.ContainsAny(p, "\r\n") {
+			return fmt.Errorf("gitignore: pattern %q is more than one line", p)
+		}
+		if l := NewLine(p); l.Kind != LinePattern {
+			return fmt.Errorf("gitignore: pattern %q is a %s line", p, l.Kind)
+		}
+	}
+	if strings.ContainsAny(section, "\r\n") {
+		return fmt.Errorf("gitignore: section %q is more than one line", section)
+	}
+
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+	data, err := os.ReadFile(path)
+	mode := fs.FileMode(0o644)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		if info, err := os.Stat(path); err == nil {
+			mode = info.Mode().Perm()
+		}
+	}
+	f := ParseFile(data)
+
+	var missing []Line
+	for _, p := range patterns {
+		l := NewLine(p)
+		if !hasRule(f.Lines, l) && !hasRule(missing, l) {
+			missing = append(missing, l)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	at := len(f.Lines)
+	if section != "" {
+		header := "# " + section
+		i := 0
+		for i < len(f.Lines) && (f.Lines[i].Kind != LineComment || strings.TrimRight(f.Lines[i].Text, " \t") != header) {
+			i++
+		}
+		if i < len(f.Lines) {
+			at = i + 1
+			for at < len(f.Lines) && f.Lines[at].Kind != LineBlank {
+				at++
+			}
+		} else {
+			var head []Line
+			if n := len(f.Lines); n > 0 && f.Lines[n-1].Kind != LineBlank {
+				head = append(head, NewLine(""))
+			}
+			missing = append(append(head, NewLine(header)), missing...)
+		}
+	}
+	f.Insert(at, missing...)
+	return writeFile(path, f.Bytes(), mode)
+}
+
+// hasRule reports whether one of lines has the rule l does; see
+// EnsurePatterns.
+func hasRule(lines []Line, l Line) bool {
+	for _, o := range lines {
+		if o.Kind != LinePattern || o.Invalid != "" || o.Negate != l.Negate {
+			continue
+		}
+		if o.Pattern == l.Pattern {
+			return true
+		}
+		if isLiteralRule(o.Pattern) && isLiteralRule(l.Pattern) {
+			a, b := newMatcher(&config{}), newMatcher(&config{})
+			// As rules ignoring paths, since a negation alone ignores none.
+			a.addPatterns([]byte(strings.TrimPrefix(o.Pattern, "!")), "", "")
+			b.addPatterns([]byte(strings.TrimPrefix(l.Pattern, "!")), "", "")
+			if eq := Equivalent(a, b); eq.Equivalent && eq.Proven {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isLiteralRule reports whether the rule of the pattern line p has no
+// wildcards, so Equivalent can prove what it ignores.
+func isLiteralRule(p string) bool {
+	return isLiteral(strings.TrimPrefix(p, "!"))
+}
+
+// writeFile writes data to path through a temporary file renamed into
+// place, with mode as its permissions.
+func writeFile(path string, data []byte, mode fs.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,118 @@
+package gitignore_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestEnsurePatterns(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		patterns []string
+		section  string
+		want     string
+	}{
+		{
+			name:     "new file",
+			patterns: []string{"dist/", ".env"},
+			section:  "Added by mytool",
+			want:     "# Added by mytool\ndist/\n.env\n",
+		},
+		{
+			name:     "new section",
+			in:       "*.log\n",
+			patterns: []string{"dist/"},
+			section:  "mytool",
+			want:     "*.log\n\n# mytool\ndist/\n",
+		},
+		{
+			name:     "existing section",
+			in:       "# mytool\ndist/\n\n*.log\n",
+			patterns: []string{"dist/", ".cache/"},
+			section:  "mytool",
+			want:     "# mytool\ndist/\n.cache/\n\n*.log\n",
+		},
+		{
+			name:     "equivalent rules elsewhere",
+			in:       "/docs/site\n*.log   \n",
+			patterns: []string{"docs/site", "*.log", "docs/site"},
+			section:  "mytool",
+			want:     "/docs/site\n*.log   \n",
+		},
+		{
+			name:     "not equivalent",
+			in:       "build\n",
+			patterns: []string{"/build", "build/"},
+			want:     "build\n/build\nbuild/\n",
+		},
+		{
+			name:     "negations",
+			in:       "!keep\n",
+			patterns: []string{"!keep", "!other"},
+			want:     "!keep\n!other\n",
+		},
+		{
+			name:     "keeps CRLF and a missing newline",
+			in:       "\ufeff*.log\r\n*.tmp",
+			patterns: []string{"dist/"},
+			section:  "mytool",
+			want:     "\ufeff*.log\r\n*.tmp\r\n\r\n# mytool\r\ndist/\r\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".gitignore")
+			if tt.in != "" {
+				if err := os.WriteFile(path, []byte(tt.in), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for range 2 {
+				if err := gitignore.EnsurePatterns(path, tt.patterns, tt.section); err != nil {
+					t.Fatal(err)
+				}
+				got, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(got) != tt.want {
+					t.Fatalf("got %q, want %q", got, tt.want)
+				}
+			}
+			if tt.in != "" {
+				info, err := os.Stat(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if info.Mode().Perm() != 0o600 {
+					t.Errorf("mode %v, want 0600", info.Mode().Perm())
+				}
+			}
+		})
+	}
+}
+
+func TestEnsurePatternsErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitignore")
+	for _, patterns := range [][]string{{"a\nb"}, {"# comment"}, {"  "}} {
+		if err := gitignore.EnsurePatterns(path, patterns, ""); err == nil {
+			t.Errorf("EnsurePatterns(%q) succeeded", patterns)
+		}
+	}
+	if err := gitignore.EnsurePatterns(path, []string{"a"}, "x\ny"); err == nil {
+		t.Error("EnsurePatterns with a two-line section succeeded")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("file written after errors: %v", err)
+	}
+	if err := gitignore.EnsurePatterns(path, nil, "mytool"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("file written with nothing to add: %v", err)
+	}
+}
@@ -0,0 +1,349 @@
+package gitignore
+
+import (
+	"slices"
+	"strings"
+)
+
+// EquivalenceOption configures Equivalent.
+type EquivalenceOption func(*equivalenceConfig)
+
+type equivalenceConfig struct {
+	limit int      // set by SampleLimit
+	paths []string // set by SamplePaths
+}
+
+// defaultSampleLimit is how many paths Equivalent checks without
+// SampleLimit.
+const defaultSampleLimit = 100000
+
+// SampleLimit makes Equivalent check at most n paths, 100000 by default.
+func SampleLimit(n int) EquivalenceOption {
+	return func(c *equivalenceConfig) {
+		c.limit = n
+	}
+}
+
+// SamplePaths makes Equivalent check paths, slash-separated and with a
+// trailing slash for a directory, before any it generates, such as the
+// paths of a real tree.
+func SamplePaths(paths ...string) EquivalenceOption {
+	return func(c *equivalenceConfig) {
+		c.paths = append(c.paths, paths...)
+	}
+}
+
+// Equivalence is what Equivalent found.
+type Equivalence struct {
+	// Equivalent reports that no path checked is ignored by one Matcher
+	// and not the other.
+	Equivalent bool
+	// Proven reports that the paths checked stand for every path, so the
+	// Matchers are equivalent for certain, not just for those paths.
+	Proven bool
+	// Counterexample is a path the Matchers decide differently, with a
+	// trailing slash for a directory, or "" if Equivalent; A and B are
+	// how each decides it, as MatchFullDetail does.
+	Counterexample string
+	A, B           MatchResult
+	// Checked is how many paths were checked.
+	Checked int
+}
+
+// Equivalent reports whether a and b ignore the same paths, as MatchFull
+// decides them, for validating a refactor of an ignore file: that it was
+// split, merged, reordered or pruned without changing what it ignores. A
+// nil Matcher ignores nothing.
+//
+// It generates the paths that can tell rules apart, built from the names
+// in their patterns and a name in none of them, and checks each, stopping
+// at the first the Matchers decide differently. Where every pattern is
+// literal, like "node_modules/" or "/config/local.yml", with no wildcards
+// or regular expressions, and neither Matcher ignores case or precomposes
+// Unicode, those paths stand for all others and a result that is
+// Equivalent is Proven. Otherwise wildcards are filled in with sample
+// text, and the check is a search for a counterexample that may miss one.
+// A counterexample always refutes equivalence, whichever the case.
+//
+// The search goes a path at a time, shortest first, and a directory
+// ignored by both is not entered. It gives up, unproven, after
+// SampleLimit paths.
+func Equivalent(a, b *Matcher, opts ...EquivalenceOption) Equivalence {
+	c := equivalenceConfig{limit: defaultSampleLimit}
+	for _, o := range opts {
+		o(&c)
+	}
+	e := &equivalence{a: a, b: b, limit: c.limit}
+	for _, p := range c.paths {
+		if _, _, done := e.check(p); done {
+			return e.res
+		}
+	}
+	s := newPathSpace(a, b)
+	if !e.search(s) {
+		return e.res
+	}
+	e.res.Equivalent = true
+	e.res.Proven = s.exact
+	return e.res
+}
+
+// equivalence is the state of an Equivalent search.
+type equivalence struct {
+	a, b  *Matcher
+	limit int
+	res   Equivalence
+}
+
+// check decides p with both Matchers, reporting whether each ignores it
+// and whether the search is done: the Matchers differ on p, or the limit
+// is reached, which leaves e.res not Equivalent.
+func (e *equivalence) check(p string) (ignoredA, ignoredB, done bool) {
+	if e.res.Checked >= e.limit {
+		return false, false, true
+	}
+	e.res.Checked++
+	ra, rb := diffMatch(e.a, p), diffMatch(e.b, p)
+	if ra.Ignored != rb.Ignored {
+		e.res.Counterexample, e.res.A, e.res.B = p, ra, rb
+		return ra.Ignored, rb.Ignored, true
+	}
+	return ra.Ignored, rb.Ignored, false
+}
+
+// search checks the paths of s, a level at a time, reporting whether it
+// checked them all without finding a difference. A directory that either
+// Matcher ignores is not entered: if both do, so is all below it, and if
+// only one does, it is the counterexample. Nor is one that no anchored
+// pattern leads through, bar s.fresh, since what is below it is decided
+// as what is below s.fresh is.
+func (e *equivalence) search(s *pathSpace) bool {
+	level := []string{""}
+	for depth := 0; depth <= s.depth && len(level) > 0; depth++ {
+		var next []string
+		for _, dir := range level {
+			for _, name := range s.candidates(dir) {
+				if _, _, done := e.check(dir + name); done {
+					return false
+				}
+				ia, ib, done := e.check(dir + name + "/")
+				if done {
+					return false
+				}
+				if !ia && !ib && (name == s.fresh || s.children[dir][name]) {
+					next = append(next, dir+name+"/")
+				}
+			}
+		}
+		level = next
+	}
+	return true
+}
+
+// pathSpace is the names Equivalent builds paths from.
+type pathSpace struct {
+	// children holds, for a directory with a trailing slash, or "" for
+	// the root, the names of its entries that patterns compare with
+	// where they are anchored.
+	children map[string]map[string]bool
+	names    []string // names unanchored patterns match, in any directory
+	fresh    string   // a name no pattern holds
+	depth    int      // the most segments a pattern compares with
+	exact    bool     // the names are all that patterns compare with; see Equivalent
+}
+
+// newPathSpace returns the pathSpace of the patterns of a and b.
+func newPathSpace(a, b *Matcher) *pathSpace {
+	s := &pathSpace{children: map[string]map[string]bool{}, exact: true}
+	s.fresh = freshName(a, b)
+	names := map[string]bool{}
+	for _, m := range []*Matcher{a, b} {
+		for o := m; o != nil; o = o.globalMatcher() {
+			if o.ignoreCase || o.precompose {
+				s.exact = false
+			}
+			for i := range o.patterns {
+				if !o.hot[i].shadowed {
+					s.add(&o.patterns[i], o.ignoreCase, names)
+				}
+			}
+		}
+	}
+	for n := range names {
+		s.names = append(s.names, n)
+	}
+	slices.Sort(s.names)
+	return s
+}
+
+// add adds the names p compares with to s, and to names those it
+// matches in any directory.
+func (s *pathSpace) add(p *pattern, ignoreCase bool, names map[string]bool) {
+	if p.re != nil {
+		s.exact = false
+		return
+	}
+	segs := p.segments
+	if !p.anchored {
+		// A leading ** and a name, and a trailing ** unless dirOnly.
+		if len(segs) < 2 || segs[1].doubleStar {
+			s.exact = false
+			return
+		}
+		for _, n := range s.instances(&segs[1], ignoreCase) {
+			names[n] = true
+		}
+		s.addPath(p.prefixSegs)
+		s.depth = max(s.depth, len(p.prefixSegs)+1)
+		return
+	}
+	if segs[len(segs)-1].doubleStar {
+		// A trailing ** matches what is below the path, which the search
+		// reaches through it.
+		segs = segs[:len(segs)-1]
+	}
+	// Paths through p: each filled in with the first, second or third
+	// sample of each segment, and with as many directories for a **.
+	for v := range 3 {
+		path := slices.Clone(p.prefixSegs)
+		for i := range segs {
+			if segs[i].doubleStar {
+				s.exact = false
+				for range v {
+					path = append(path, s.fresh)
+				}
+				continue
+			}
+			inst := s.instances(&segs[i], ignoreCase)
+			if len(inst) == 0 {
+				break
+			}
+			path = append(path, inst[v%len(inst)])
+		}
+		s.addPath(path)
+		s.depth = max(s.depth, len(path))
+	}
+}
+
+// addPath adds the directories leading to path, and path, to s.children.
+func (s *pathSpace) addPath(path []string) {
+	dir := ""
+	for _, n := range path {
+		if s.children[dir] == nil {
+			s.children[dir] = map[string]bool{}
+		}
+		s.children[dir][n] = true
+		dir += n + "/"
+	}
+}
+
+// candidates returns the names to try in dir, sorted: those patterns
+// give its entries, those unanchored patterns match and s.fresh.
+func (s *pathSpace) candidates(dir string) []string {
+	names := slices.Clone(s.names)
+	for n := range s.children[dir] {
+		if _, found := slices.BinarySearch(s.names, n); !found {
+			names = append(names, n)
+		}
+	}
+	names = append(names, s.fresh)
+	slices.Sort(names)
+	return names
+}
+
+// instances returns sample names the glob segment seg matches: the
+// literal itself, or, for a wildcard, its stars filled in with nothing
+// and with s.fresh, and so on, with upper-case forms if case is ignored.
+// A segment with a wildcard makes s inexact.
+func (s *pathSpace) instances(seg *segment, ignoreCase bool) []string {
+	var out []string
+	keep := func(n string) {
+		if n != "" && n != "." && n != ".." && !strings.ContainsAny(n, "/\x00") && !slices.Contains(out, n) {
+			out = append(out, n)
+		}
+	}
+	if isLiteral(seg.raw) && !seg.glue {
+		keep(seg.raw)
+	} else {
+		s.exact = false
+		for _, star := range []string{"", s.fresh} {
+			for _, one := range []string{"a", "0", "_", "A", "-", "."} {
+				if n := fillGlob(seg.raw, star, one); seg.match(n) {
+					keep(n)
+					break
+				}
+			}
+		}
+	}
+	if ignoreCase {
+		for _, n := range out {
+			keep(strings.ToUpper(n))
+		}
+	}
+	return out
+}
+
+// fillGlob returns glob with its escapes resolved, each run of stars
+// replaced with star, and each ? and bracket expression with one, or
+// the first character a plain bracket expression lists.
+func fillGlob(glob, star, one string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); {
+		switch c := glob[i]; c {
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+			}
+			b.WriteByte(glob[i])
+			i++
+		case '*':
+			for i < len(glob) && glob[i] == '*' {
+				i++
+			}
+			b.WriteString(star)
+		case '?':
+			b.WriteString(one)
+			i++
+		case '[':
+			_, next, ok := matchBracket(glob, i, 0)
+			if !ok {
+				b.WriteByte(c)
+				i++
+				continue
+			}
+			if first := glob[i+1]; first != '!' && first != '^' && first != '[' && first != '\\' {
+				b.WriteByte(first)
+			} else {
+				b.WriteString(one)
+			}
+			i = next
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// freshName returns a name that no pattern of a or b, nor any directory
+// they are scoped to, holds.
+func freshName(a, b *Matcher) string {
+	name := "other"
+	for taken := true; taken; {
+		taken = false
+		for _, m := range []*Matcher{a, b} {
+			for o := m; o != nil && !taken; o = o.globalMatcher() {
+				for _, src := range o.sources {
+					if strings.Contains(src.text, name) || strings.Contains(src.dir, name) {
+						taken = true
+						break
+					}
+				}
+			}
+		}
+		if taken {
+			name += "_"
+		}
+	}
+	return name
+}
@@ -0,0 +1,135 @@
+package gitignore_test
+
+import (
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestEquivalent(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tests := []struct {
+		name           string
+		a, b           string
+		equivalent     bool
+		proven         bool
+		counterexample string
+	}{
+		{
+			name:       "reordered literals",
+			a:          "node_modules/\n/config/local.yml\n.DS_Store\n",
+			b:          ".DS_Store\n/config/local.yml\nnode_modules/\n",
+			equivalent: true,
+			proven:     true,
+		},
+		{
+			name:       "redundant rule below an ignored directory",
+			a:          "/build/\n/build/out/\n",
+			b:          "/build/\n",
+			equivalent: true,
+			proven:     true,
+		},
+		{
+			name:           "directory-only dropped",
+			a:              "cache/\n",
+			b:              "cache\n",
+			counterexample: "cache",
+		},
+		{
+			name:           "anchoring dropped",
+			a:              "/config/local.yml\n",
+			b:              "local.yml\n",
+			counterexample: "local.yml",
+		},
+		{
+			name:           "negation moved",
+			a:              "secret\n!secret\n",
+			b:              "!secret\nsecret\n",
+			counterexample: "secret",
+		},
+		{
+			name:       "wildcards",
+			a:          "*.log\n*.tmp\n",
+			b:          "*.tmp\n*.log\n",
+			equivalent: true,
+		},
+		{
+			name:           "wildcard narrowed",
+			a:              "*.log\n",
+			b:              "debug*.log\n",
+			counterexample: ".log",
+		},
+		{
+			name:           "double star",
+			a:              "docs/**/*.pdf\n",
+			b:              "docs/*.pdf\n",
+			counterexample: "docs/other/other.pdf",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := gitignore.New(t.TempDir())
+			a.AddPatterns([]byte(tt.a), "")
+			b := gitignore.New(t.TempDir())
+			b.AddPatterns([]byte(tt.b), "")
+			got := gitignore.Equivalent(a, b)
+			if got.Equivalent != tt.equivalent || got.Proven != tt.proven || got.Counterexample != tt.counterexample {
+				t.Errorf("Equivalent = %+v, want Equivalent %v, Proven %v, Counterexample %q",
+					got, tt.equivalent, tt.proven, tt.counterexample)
+			}
+			if got.Counterexample != "" && a.MatchFull(got.Counterexample) == b.MatchFull(got.Counterexample) {
+				t.Errorf("counterexample %q is decided alike", got.Counterexample)
+			}
+		})
+	}
+}
+
+func TestEquivalentNested(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	// A nested .gitignore hoisted into the root one.
+	a := gitignore.New(t.TempDir())
+	a.AddPatterns([]byte("dist/\n"), "")
+	a.AddPatterns([]byte("gen/\n"), "pkg/api")
+	b := gitignore.New(t.TempDir())
+	b.AddPatterns([]byte("dist/\n/pkg/api/**/gen/\n"), "")
+	if got := gitignore.Equivalent(a, b); !got.Equivalent || got.Proven {
+		t.Errorf("hoisted: %+v, want unproven equivalence", got)
+	}
+
+	b = gitignore.New(t.TempDir())
+	b.AddPatterns([]byte("dist/\n/pkg/api/gen/\n"), "")
+	if got := gitignore.Equivalent(a, b); got.Equivalent || got.Counterexample != "pkg/api/other/gen/" {
+		t.Errorf("hoisted without **: %+v", got)
+	}
+}
+
+func TestEquivalentOptions(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	a := gitignore.New(t.TempDir())
+	a.AddPatterns([]byte("*.log\n"), "")
+	b := gitignore.New(t.TempDir())
+	b.AddPatterns([]byte("*.log\n!keep/important.log\n"), "")
+
+	got := gitignore.Equivalent(a, b, gitignore.SamplePaths("src/main.go", "keep/important.log"))
+	if got.Counterexample != "keep/important.log" || got.Checked != 2 {
+		t.Errorf("SamplePaths: %+v", got)
+	}
+	if !got.A.Ignored || got.A.Pattern != "*.log" || got.B.Ignored || got.B.Pattern != "!keep/important.log" {
+		t.Errorf("SamplePaths: A %+v, B %+v", got.A, got.B)
+	}
+
+	got = gitignore.Equivalent(a, a, gitignore.SampleLimit(3))
+	if got.Equivalent || got.Proven || got.Checked != 3 {
+		t.Errorf("SampleLimit: %+v, want an unfinished check of 3 paths", got)
+	}
+
+	if got := gitignore.Equivalent(nil, gitignore.New(t.TempDir())); !got.Equivalent || !got.Proven {
+		t.Errorf("nil and empty: %+v", got)
+	}
+}
@@ -0,0 +1,152 @@
+//go:build !tinygo
+
+package gitignore
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// exportVersion is the Version of a RuleSet, raised if its fields change
+// incompatibly.
+const exportVersion = 1
+
+// RuleSet is the effective rules of a Matcher, as Export returns them,
+// for dashboards, policy checkers and other tools that analyze ignore
+// rules without this package. It encodes as JSON with encoding/json, and
+// as YAML with its YAML method; the field tags also suit YAML packages.
+type RuleSet struct {
+	Version    int    `json:"version" yaml:"version"`
+	Root       string `json:"root,omitempty" yaml:"root,omitempty"` // absolute, if the Matcher has one
+	IgnoreCase bool   `json:"ignoreCase" yaml:"ignoreCase"`         // set by WithIgnoreCase
+
+	// Rules are in the order the Matcher holds them, global excludes
+	// last. Where several match a path in the same scope, the last of
+	// them decides; a rule of a deeper scope decides before the rules of
+	// those above it, and the global excludes after all others.
+	Rules []Rule `json:"rules" yaml:"rules"`
+
+	// Invalid holds the patterns that didn't compile, or were left out
+	// for exceeding a limit; see WithLimits.
+	Invalid []Rule `json:"invalid,omitempty" yaml:"invalid,omitempty"`
+}
+
+// Rule is a rule of a RuleSet.
+type Rule struct {
+	Pattern string `json:"pattern" yaml:"pattern"`                   // as written, trailing spaces trimmed
+	Source  string `json:"source,omitempty" yaml:"source,omitempty"` // file the rule came from; empty for programmatic rules
+	Line    int    `json:"line" yaml:"line"`                         // 1-based line number in Source
+	Scope   string `json:"scope" yaml:"scope"`                       // directory the rule applies in, relative to the root; "" for the root
+
+	// Normalized is the rule as the root's .gitignore would write it, as
+	// Rescope gives it, so rules from different scopes compare as text.
+	// It is the pattern for a regular expression.
+	Normalized string `json:"normalized,omitempty" yaml:"normalized,omitempty"`
+
+	Negate   bool `json:"negate" yaml:"negate"`     // the rule re-includes rather than excludes
+	DirOnly  bool `json:"dirOnly" yaml:"dirOnly"`   // the rule only matches directories
+	Anchored bool `json:"anchored" yaml:"anchored"` // the rule matches relative to Scope, not at any depth
+	Regex    bool `json:"regex" yaml:"regex"`       // the rule is a regular expression; see WithRegexPatterns
+	Global   bool `json:"global" yaml:"global"`     // the rule is from the global excludes
+
+	Error string `json:"error,omitempty" yaml:"error,omitempty"` // for an invalid pattern, why it didn't compile
+}
+
+// Export returns the effective rules of m: each pattern it holds, bar
+// those a later copy with the same text and scope takes the place of,
+// and the patterns that didn't compile.
+func (m *Matcher) Export() *RuleSet {
+	r := &RuleSet{Version: exportVersion, Root: m.root, IgnoreCase: m.ignoreCase, Rules: []Rule{}}
+	for o := m; o != nil; o = o.globalMatcher() {
+		global := o != m
+		for i := range o.patterns {
+			if o.hot[i].shadowed {
+				continue
+			}
+			p := &o.patterns[i]
+			rule := Rule{
+				Pattern:  o.patternText(p),
+				Source:   o.sources[p.src].path,
+				Line:     int(p.line),
+				Scope:    o.sources[p.src].dir,
+				Negate:   p.negate,
+				DirOnly:  p.dirOnly,
+				Anchored: p.anchored,
+				Regex:    p.re != nil,
+				Global:   global,
+			}
+			rule.Normalized = rule.Pattern
+			if !rule.Regex {
+				rule.Normalized, _ = rescopeRule(rule.Pattern, rule.Scope, "")
+			}
+			r.Rules = append(r.Rules, rule)
+		}
+		for _, e := range o.errors {
+			r.Invalid = append(r.Invalid, Rule{
+				Pattern: e.Pattern,
+				Source:  e.Source,
+				Line:    e.Line,
+				Global:  global,
+				Error:   e.Message,
+			})
+		}
+	}
+	return r
+}
+
+// YAML returns r as a YAML document, with its fields named as in JSON.
+// Strings are written as JSON strings, which YAML reads as they are.
+func (r *RuleSet) YAML() []byte {
+	var b strings.Builder
+	b.WriteString("version: " + itoa(r.Version) + "\n")
+	if r.Root != "" {
+		b.WriteString("root: " + yamlString(r.Root) + "\n")
+	}
+	b.WriteString("ignoreCase: " + strconv.FormatBool(r.IgnoreCase) + "\n")
+	if len(r.Rules) == 0 {
+		b.WriteString("rules: []\n")
+	}
+	writeYAMLRules(&b, "rules", r.Rules)
+	writeYAMLRules(&b, "invalid", r.Invalid)
+	return []byte(b.String())
+}
+
+// writeYAMLRules writes rules, if there are any, as the YAML sequence
+// key, with the fields JSON would encode.
+func writeYAMLRules(b *strings.Builder, key string, rules []Rule) {
+	if len(rules) == 0 {
+		return
+	}
+	b.WriteString(key + ":\n")
+	for _, rule := range rules {
+		prefix := "  - "
+		field := func(name, value string) {
+			b.WriteString(prefix + name + ": " + value + "\n")
+			prefix = "    "
+		}
+		field("pattern", yamlString(rule.Pattern))
+		if rule.Source != "" {
+			field("source", yamlString(rule.Source))
+		}
+		field("line", itoa(rule.Line))
+		field("scope", yamlString(rule.Scope))
+		if rule.Normalized != "" {
+			field("normalized", yamlString(rule.Normalized))
+		}
+		field("negate", strconv.FormatBool(rule.Negate))
+		field("dirOnly", strconv.FormatBool(rule.DirOnly))
+		field("anchored", strconv.FormatBool(rule.Anchored))
+		field("regex", strconv.FormatBool(rule.Regex))
+		field("global", strconv.FormatBool(rule.Global))
+		if rule.Error != "" {
+			field("error", yamlString(rule.Error))
+		}
+	}
+}
+
+// yamlString returns s as a double-quoted YAML string.
+func yamlString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
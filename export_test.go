@@ -0,0 +1,97 @@
+//go:build !tinygo
+
+package gitignore_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestExport(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte("*.log\n/build/\n*.log\na\\\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "pkg"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "pkg", ".gitignore"), []byte("!keep.log\nre:^gen/.*\\.go$\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := gitignore.NewFromDirectory(root, gitignore.WithRegexPatterns())
+	r := m.Export()
+
+	rootFile := filepath.Join(root, ".gitignore")
+	pkgFile := filepath.Join(root, "pkg", ".gitignore")
+	want := []gitignore.Rule{
+		{Pattern: "/build/", Source: rootFile, Line: 2, Normalized: "/build/", DirOnly: true, Anchored: true},
+		{Pattern: "*.log", Source: rootFile, Line: 3, Normalized: "*.log"},
+		{Pattern: "!keep.log", Source: pkgFile, Line: 1, Scope: "pkg", Normalized: "!/pkg/**/keep.log", Negate: true},
+		{Pattern: `re:^gen/.*\.go$`, Source: pkgFile, Line: 2, Scope: "pkg", Normalized: `re:^gen/.*\.go$`, Anchored: true, Regex: true},
+	}
+	if !reflect.DeepEqual(r.Rules, want) {
+		t.Errorf("Rules =\n%+v\nwant\n%+v", r.Rules, want)
+	}
+	if len(r.Invalid) != 1 || r.Invalid[0].Pattern != `a\` || r.Invalid[0].Line != 4 || r.Invalid[0].Error == "" {
+		t.Errorf("Invalid = %+v", r.Invalid)
+	}
+	if r.Version != 1 || r.Root == "" {
+		t.Errorf("Version %d, Root %q", r.Version, r.Root)
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back gitignore.RuleSet
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&back, r) {
+		t.Errorf("JSON round trip = %+v, want %+v", back, r)
+	}
+	if !strings.Contains(string(data), `"dirOnly":true`) {
+		t.Errorf("JSON field names: %s", data)
+	}
+}
+
+func TestExportYAML(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	m := gitignore.New(t.TempDir())
+	r := m.Export()
+	r.Root = ""
+	if got, want := string(r.YAML()), "version: 1\nignoreCase: false\nrules: []\n"; got != want {
+		t.Errorf("empty YAML = %q, want %q", got, want)
+	}
+
+	m.AddPatterns([]byte("# x\n\"quoted\"\n"), "src")
+	r = m.Export()
+	r.Root = ""
+	want := `version: 1
+ignoreCase: false
+rules:
+  - pattern: "\"quoted\""
+    line: 2
+    scope: "src"
+    normalized: "/src/**/\"quoted\""
+    negate: false
+    dirOnly: false
+    anchored: false
+    regex: false
+    global: false
+`
+	if got := string(r.YAML()); got != want {
+		t.Errorf("YAML =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,58 @@
+package gitignore
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+	"strings"
+)
+
+// Fingerprint returns a SHA-256 hash of everything that decides what m
+// matches: the options that change how patterns are read or paths are
+// compared, such as WithIgnoreCase, WithRegexPatterns and
+// WithWindowsPaths, then each pattern's text and the directory it is
+// scoped to, in precedence order, and the global excludes. Options that
+// only change how m finds its answers, like WithDFA, are left out. Two
+// Matchers with the same fingerprint match the same paths, so a build
+// system can use it as a cache key for file lists computed with an
+// earlier Matcher. Where the patterns were read from is not included:
+// moving a rule between .git/info/exclude and the root .gitignore keeps
+// the fingerprint.
+//
+// The hash is stable across processes and platforms, but may change
+// between releases of this package.
+func (m *Matcher) Fingerprint() [32]byte {
+	h := sha256.New()
+	writeFingerprintString(h, "gitignore fingerprint v1")
+	writeFingerprintInt(h, m.patternOptions.flags())
+	m.writeFingerprint(h)
+	if g := m.globalMatcher(); g != nil {
+		g.writeFingerprint(h)
+	} else {
+		writeFingerprintInt(h, 0)
+	}
+	var sum [32]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+func (m *Matcher) writeFingerprint(h hash.Hash) {
+	writeFingerprintInt(h, len(m.patterns))
+	for i := range m.patterns {
+		p := &m.patterns[i]
+		writeFingerprintString(h, strings.Join(p.prefixSegs, "/"))
+		writeFingerprintString(h, m.patternText(p))
+	}
+}
+
+// writeFingerprintString writes s length-prefixed, so no two pattern
+// lists hash the same input.
+func writeFingerprintString(h hash.Hash, s string) {
+	writeFingerprintInt(h, len(s))
+	h.Write([]byte(s))
+}
+
+func writeFingerprintInt(h hash.Hash, n int) {
+	var buf [binary.MaxVarintLen64]byte
+	h.Write(buf[:binary.PutUvarint(buf[:], uint64(n))])
+}
@@ -0,0 +1,88 @@
+package gitignore_test
+
+import (
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestFingerprint(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	build := func(add func(m *gitignore.Matcher)) [32]byte {
+		m := gitignore.New(t.TempDir())
+		add(m)
+		return m.Fingerprint()
+	}
+	base := build(func(m *gitignore.Matcher) {
+		m.AddPatterns([]byte("*.log\n!keep.log\n"), "")
+		m.AddPatterns([]byte("*.tmp\n"), "src")
+	})
+
+	if got := build(func(m *gitignore.Matcher) {
+		m.AddPatterns([]byte("*.log\n!keep.log\n"), "")
+		m.AddPatterns([]byte("*.tmp\n"), "src")
+	}); got != base {
+		t.Error("expected equal pattern sets to have equal fingerprints")
+	}
+
+	for name, add := range map[string]func(m *gitignore.Matcher){
+		"reordered": func(m *gitignore.Matcher) {
+			m.AddPatterns([]byte("!keep.log\n*.log\n"), "")
+			m.AddPatterns([]byte("*.tmp\n"), "src")
+		},
+		"rescoped": func(m *gitignore.Matcher) {
+			m.AddPatterns([]byte("*.log\n!keep.log\n"), "")
+			m.AddPatterns([]byte("*.tmp\n"), "lib")
+		},
+		"edited": func(m *gitignore.Matcher) {
+			m.AddPatterns([]byte("*.log\n!keep.log\n"), "")
+			m.AddPatterns([]byte("*.tmq\n"), "src")
+		},
+	} {
+		if build(add) == base {
+			t.Errorf("%s: expected a different fingerprint", name)
+		}
+	}
+}
+
+func TestFingerprintIncludesGlobalExcludes(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	root := t.TempDir()
+
+	without := gitignore.New(root).Fingerprint()
+	writeFiles(t, xdg, map[string]string{"git/ignore": "*.global\n"})
+	if gitignore.New(root).Fingerprint() == without {
+		t.Error("expected the global excludes to change the fingerprint")
+	}
+}
+
+func TestFingerprintOptions(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	build := func(opts ...gitignore.Option) *gitignore.Matcher {
+		m := gitignore.New(t.TempDir(), opts...)
+		m.AddPatterns([]byte("foo\n"), "")
+		return m
+	}
+	base := build()
+	if build(gitignore.WithDFA()).Fingerprint() != base.Fingerprint() {
+		t.Error("WithDFA: expected the same fingerprint, as it matches alike")
+	}
+	for name, m := range map[string]*gitignore.Matcher{
+		"WithWindowsPaths":      build(gitignore.WithWindowsPaths()),
+		"WithCleanPaths(true)":  build(gitignore.WithCleanPaths(true)),
+		"WithCleanPaths(false)": build(gitignore.WithCleanPaths(false)),
+		"WithStrictEscapes":     build(gitignore.WithStrictEscapes()),
+		"WithBraceExpansion":    build(gitignore.WithBraceExpansion()),
+		"WithRegexPatterns":     build(gitignore.WithRegexPatterns()),
+	} {
+		if m.Fingerprint() == base.Fingerprint() {
+			t.Errorf("%s: expected a different fingerprint", name)
+		}
+	}
+}
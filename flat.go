@@ -0,0 +1,41 @@
+package gitignore
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// LoadIgnoreFile creates a Matcher from the single ignore file at path,
+// as JavaScript tools read .eslintignore, .prettierignore and the like:
+// the patterns have gitignore syntax, negations included, and are
+// relative to the directory holding the file, which is the Matcher's
+// root. Nothing else is read: no nested ignore files, no
+// .git/info/exclude and no global excludes.
+//
+// Such tools ignore everything inside an ignored directory, so MatchFull
+// gives their answer for a path relative to the root. LoadIgnoreFile
+// returns an error if the file can't be read, or, as LoadDirectory does,
+// a *LimitError or the PatternError of an invalid pattern under
+// FailOnInvalid.
+func LoadIgnoreFile(path string, opts ...Option) (*Matcher, error) {
+	c := newConfig(opts)
+	m := newMatcher(c)
+	dir := filepath.Dir(path)
+	m.root = absRoot(dir)
+	if m.fsys == nil {
+		m.fsys = os.DirFS(dir)
+	}
+	data, err := m.readSource(path)
+	if err != nil {
+		return nil, err
+	}
+	m.addIgnoreFile(data, "", path)
+	w := newOSWalker(dir, m, nil)
+	if err := w.checkLimits(0); err != nil {
+		return nil, err
+	}
+	if err := w.checkInvalid(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,90 @@
+package gitignore_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestLoadIgnoreFile(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	writeFiles(t, xdg, map[string]string{"git/ignore": "*.global\n"})
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".git/info/exclude":  "*.exclude\n",
+		".gitignore":         "*.root\n",
+		"web/.eslintignore":  "/dist\n*.min.js\n!keep.min.js\nlib/gen/\n",
+		"web/src/.gitignore": "*.js\n",
+	})
+	path := filepath.Join(root, "web", ".eslintignore")
+	m, err := gitignore.LoadIgnoreFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for p, want := range map[string]bool{
+		"dist/":           true,
+		"dist/a.js":       true,
+		"src/dist/":       false, // anchored to the file's directory
+		"a.min.js":        true,
+		"src/a.min.js":    true,
+		"keep.min.js":     false,
+		"lib/gen/x.ts":    true,
+		"src/lib/gen/x":   false,
+		"src/a.js":        false, // nested files aren't read
+		"a.root":          false,
+		"a.exclude":       false,
+		"a.global":        false,
+		"web/dist/":       false,
+		"../dist/":        false,
+		".eslintignore":   false,
+		"src/.gitignore":  false,
+		"src/keep.min.js": false,
+	} {
+		if got := m.MatchFull(p); got != want {
+			t.Errorf("MatchFull(%q) = %v, want %v", p, got, want)
+		}
+	}
+	if !m.MatchAbs(filepath.Join(root, "web", "a.min.js")) {
+		t.Error("MatchAbs should be relative to the file's directory")
+	}
+	if m.MatchAbs(filepath.Join(root, "a.min.js")) {
+		t.Error("MatchAbs outside the file's directory should be false")
+	}
+	if errs := m.Errors(); len(errs) != 0 {
+		t.Errorf("Errors() = %v", errs)
+	}
+	if src := m.MatchDetail("a.min.js").Source; src != path {
+		t.Errorf("Source = %q, want %q", src, path)
+	}
+}
+
+func TestLoadIgnoreFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := gitignore.LoadIgnoreFile(filepath.Join(dir, ".prettierignore")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing file: got %v, want ErrNotExist", err)
+	}
+
+	path := filepath.Join(dir, ".prettierignore")
+	if err := os.WriteFile(path, []byte("a\n[[:nope:]]\nb\nc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gitignore.LoadIgnoreFile(path); err != nil {
+		t.Errorf("invalid pattern skipped by default: got %v", err)
+	}
+	_, err := gitignore.LoadIgnoreFile(path, gitignore.WithInvalidPatterns(gitignore.FailOnInvalid))
+	var perr gitignore.PatternError
+	if !errors.As(err, &perr) || perr.Line != 2 {
+		t.Errorf("FailOnInvalid: got %v, want a PatternError on line 2", err)
+	}
+	_, err = gitignore.LoadIgnoreFile(path, gitignore.WithLimits(gitignore.Limits{MaxPatterns: 2}))
+	var lerr *gitignore.LimitError
+	if !errors.As(err, &lerr) {
+		t.Errorf("MaxPatterns: got %v, want a *LimitError", err)
+	}
+}
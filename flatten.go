@@ -0,0 +1,78 @@
+package gitignore
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Flatten returns the effective rules of m as the lines of a single
+// .gitignore in the root, for systems that accept only one ignore file.
+// Each rule of a nested ignore file is rewritten from the root, as
+// Rescope rewrites it: "gen/" in pkg/api/.gitignore becomes
+// "/pkg/api/**/gen/". The rules are ordered so that those of deeper
+// files still take precedence, after the global excludes, which come
+// first, and the root's own rules.
+//
+// A rule with no gitignore form, such as a regular expression, is left
+// out, with a comment in its place naming it, where it came from and
+// why, so the exceptions show in the file and can be listed by reading
+// the comments. Patterns that didn't compile match nothing, and are left
+// out without one.
+func (m *Matcher) Flatten() []string {
+	type rule struct {
+		depth int
+		line  string
+	}
+	var global, rules []rule
+	for o := m; o != nil; o = o.globalMatcher() {
+		for i := range o.patterns {
+			if o.hot[i].shadowed {
+				continue
+			}
+			p := &o.patterns[i]
+			text := o.patternText(p)
+			src := o.sources[p.src]
+			line, msg := text, "a regular expression has no gitignore form"
+			if p.re == nil {
+				line, msg = rescopeRule(text, src.dir, "")
+			}
+			if msg != "" {
+				line = "# not flattened, " + m.flattenSource(src.path, src.dir, int(p.line)) + ": " + text + ": " + msg
+			}
+			r := rule{line: line}
+			if src.dir != "" {
+				r.depth = 1 + strings.Count(src.dir, "/")
+			}
+			if o != m {
+				global = append(global, r)
+			} else {
+				rules = append(rules, r)
+			}
+		}
+	}
+	slices.SortStableFunc(rules, func(a, b rule) int { return a.depth - b.depth })
+	lines := make([]string, 0, len(global)+len(rules))
+	for _, r := range append(global, rules...) {
+		lines = append(lines, r.line)
+	}
+	return lines
+}
+
+// flattenSource names where a rule of Flatten came from, line n of the
+// file path, relative to the root where it is below it, or of the
+// patterns added for the directory dir.
+func (m *Matcher) flattenSource(path, dir string, n int) string {
+	if path == "" {
+		if dir == "" {
+			return "line " + itoa(n) + " added for the root"
+		}
+		return "line " + itoa(n) + " added for " + dir + "/"
+	}
+	if m.root != "" {
+		if rel, err := filepath.Rel(m.root, path); err == nil && filepath.IsLocal(rel) {
+			path = filepath.ToSlash(rel)
+		}
+	}
+	return path + ":" + itoa(n)
+}
@@ -0,0 +1,71 @@
+package gitignore_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestFlatten(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	if err := os.MkdirAll(filepath.Join(xdg, "git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(xdg, "git", "ignore"), []byte(".DS_Store\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"pkg/api/.gitignore":  "gen/\n!keep.go\n/openapi.json\n",
+		".gitignore":          "*.go\n# comment\n/build/\n",
+		"pkg/.gitignore":      "!*.go\ndocs/*.html\n",
+		"web/[id]/.gitignore": "out\n",
+	})
+	m := gitignore.NewFromDirectory(root)
+	want := []string{
+		".DS_Store",
+		"*.go",
+		"/build/",
+		"!/pkg/**/*.go",
+		"/pkg/docs/*.html",
+		"/pkg/api/**/gen/",
+		"!/pkg/api/**/keep.go",
+		"/pkg/api/openapi.json",
+		"/web/\\[id]/**/out",
+	}
+	got := m.Flatten()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Flatten =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	flat := gitignore.New(t.TempDir())
+	flat.AddPatterns([]byte(strings.Join(got, "\n")), "")
+	if eq := gitignore.Equivalent(m, flat); !eq.Equivalent {
+		t.Errorf("flattened rules differ on %q: %v, %v", eq.Counterexample, eq.A, eq.B)
+	}
+}
+
+func TestFlattenExceptions(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"pkg/.gitignore": "re:^gen/.*\\.go$\n*.tmp\na\\\n"})
+	m := gitignore.NewFromDirectory(root, gitignore.WithRegexPatterns())
+	m.AddPatterns([]byte("re:x\n"), "")
+	want := []string{
+		"# not flattened, line 1 added for the root: re:x: a regular expression has no gitignore form",
+		"# not flattened, pkg/.gitignore:1: re:^gen/.*\\.go$: a regular expression has no gitignore form",
+		"/pkg/**/*.tmp",
+	}
+	if got := m.Flatten(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Flatten =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
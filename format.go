@@ -0,0 +1,132 @@
+package gitignore
+
+import (
+	"slices"
+	"strings"
+)
+
+// FormatOption configures Format.
+type FormatOption func(*formatConfig)
+
+type formatConfig struct {
+	keepOrder bool // set by KeepOrder
+	keepDups  bool // set by KeepDuplicates
+}
+
+// KeepOrder makes Format leave rules in the order written.
+func KeepOrder() FormatOption {
+	return func(c *formatConfig) {
+		c.keepOrder = true
+	}
+}
+
+// KeepDuplicates makes Format keep every copy of a rule.
+func KeepDuplicates() FormatOption {
+	return func(c *formatConfig) {
+		c.keepDups = true
+	}
+}
+
+// Format tidies an ignore file, for tools enforcing a house style:
+//
+//   - Rules are sorted within each run of them between comments and
+//     blank lines, so a file's sections stay where they are.
+//   - A rule written twice is kept once.
+//   - Trailing spaces git would trim are removed, as is a CR ending a
+//     line, blank lines are collapsed, and the file ends in one newline.
+//
+// Comments are kept as written, bar trailing spaces. Whatever it changes,
+// Format ignores the same paths as data does: sorting never moves a rule
+// past one of the other polarity, since with "*.log" and "!keep.log" it
+// is the last match that counts, and a copy of a rule is only dropped
+// where that makes no difference; where it would, the earlier copy is
+// dropped instead. A leading space is part of a pattern, so it is kept.
+func Format(data []byte, opts ...FormatOption) []byte {
+	var c formatConfig
+	for _, o := range opts {
+		o(&c)
+	}
+	type fline struct {
+		text   string
+		rule   bool
+		negate bool
+	}
+	var lines []fline
+	text := strings.TrimPrefix(string(data), utf8BOM)
+	for raw := range strings.SplitSeq(strings.TrimSuffix(text, "\n"), "\n") {
+		line := trimTrailingSpaces(strings.TrimSuffix(raw, "\r"))
+		switch {
+		case line == "":
+			lines = append(lines, fline{})
+		case line[0] == '#':
+			lines = append(lines, fline{text: strings.TrimRight(line, " \t")})
+		default:
+			lines = append(lines, fline{text: line, rule: true, negate: line[0] == '!'})
+		}
+	}
+
+	if !c.keepDups {
+		drop := make([]bool, len(lines))
+		seen := map[string]int{}
+		last := [2]int{-1, -1} // of a rule of each polarity, by negate
+		for j, l := range lines {
+			if !l.rule {
+				continue
+			}
+			pol := 0
+			if l.negate {
+				pol = 1
+			}
+			if i, ok := seen[l.text]; ok {
+				if last[1-pol] < i {
+					// Nothing between them can undo the first copy.
+					drop[j] = true
+					continue
+				}
+				// The later copy can't be dropped, but the earlier one
+				// always can.
+				drop[i] = true
+			}
+			seen[l.text] = j
+			last[pol] = j
+		}
+		kept := lines[:0]
+		for j, l := range lines {
+			if !drop[j] {
+				kept = append(kept, l)
+			}
+		}
+		lines = kept
+	}
+
+	if !c.keepOrder {
+		for i := 0; i < len(lines); {
+			j := i + 1
+			if lines[i].rule {
+				for j < len(lines) && lines[j].rule && lines[j].negate == lines[i].negate {
+					j++
+				}
+				slices.SortStableFunc(lines[i:j], func(a, b fline) int {
+					return strings.Compare(a.text, b.text)
+				})
+			}
+			i = j
+		}
+	}
+
+	var b strings.Builder
+	blank := false
+	for _, l := range lines {
+		if l.text == "" {
+			blank = true
+			continue
+		}
+		if blank && b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		blank = false
+		b.WriteString(l.text)
+		b.WriteByte('\n')
+	}
+	return []byte(b.String())
+}
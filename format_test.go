@@ -0,0 +1,76 @@
+package gitignore_test
+
+import (
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+		opts           []gitignore.FormatOption
+	}{
+		{
+			name: "sorts within sections",
+			in:   "# build\ndist/\nbuild/\n\n\n\n# logs   \n*.log\n*.err\r\n",
+			want: "# build\nbuild/\ndist/\n\n# logs\n*.err\n*.log\n",
+		},
+		{
+			name: "never sorts past a negation",
+			in:   "b\na\n!keep.b\n!keep.a\nd\nc\n",
+			want: "a\nb\n!keep.a\n!keep.b\nc\nd\n",
+		},
+		{
+			name: "drops duplicates",
+			in:   "*.o\n*.log\n\n# again\n*.o  \n",
+			want: "*.log\n*.o\n\n# again\n",
+		},
+		{
+			name: "drops the earlier copy across a negation",
+			in:   "*.log\n!keep.log\n*.log\n",
+			want: "!keep.log\n*.log\n",
+		},
+		{
+			name: "keeps significant spaces",
+			in:   "\n\n b\na\\ \nc \t\n\n",
+			want: " b\na\\ \nc \t\n",
+		},
+		{
+			name: "options",
+			in:   "b\na\nb\n",
+			want: "b\na\nb\n",
+			opts: []gitignore.FormatOption{gitignore.KeepOrder(), gitignore.KeepDuplicates()},
+		},
+		{
+			name: "empty",
+			in:   "\n\n",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		if got := string(gitignore.Format([]byte(tt.in), tt.opts...)); got != tt.want {
+			t.Errorf("%s: Format() =\n%q, want\n%q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFormatMatches(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	in := "*.log\nbuild/\n!build/\n*.tmp\n!keep.*\nkeep.tmp\n/out\n*.log\n!out/\nbuild/\nkeep.tmp\n!keep.*\n"
+	out := gitignore.Format([]byte(in))
+	a := gitignore.New(t.TempDir())
+	a.AddPatterns([]byte(in), "")
+	b := gitignore.New(t.TempDir())
+	b.AddPatterns(out, "")
+	for _, path := range []string{"a.log", "keep.log", "build/", "build/x", "a.tmp", "keep.tmp", "keep.x", "out", "out/", "out/a.log"} {
+		if a.Match(path) != b.Match(path) {
+			t.Errorf("Match(%q) = %v before, %v after formatting as\n%s", path, a.Match(path), b.Match(path), out)
+		}
+	}
+	if again := gitignore.Format(out); string(again) != string(out) {
+		t.Errorf("Format isn't stable:\n%s\nthen\n%s", out, again)
+	}
+}
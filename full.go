@@ -0,0 +1,165 @@
+package gitignore
+
+import "strings"
+
+// MatchFull reports whether git would ignore the path, taking its parent
+// directories into account. The path uses the same trailing-slash
+// convention as Match.
+//
+// Match decides a path on its own, so with "build/" and "!build/keep" it
+// reports "build/keep" as not ignored. Git never looks inside an ignored
+// directory, so a file in one stays ignored whatever its own patterns
+// say. MatchFull decides each directory above the path first, as git
+// does on its way down, and reports the path as ignored as soon as one of
+// them is. It agrees with git check-ignore for callers that ask about
+// paths without pruning ignored directories themselves; a walker that
+// skips ignored directories can keep calling Match.
+func (m *Matcher) MatchFull(relPath string) bool {
+	relPath, isDir, ok := m.path(relPath, true)
+	if !ok {
+		return false
+	}
+	if strings.Count(relPath, "/") >= stackSegs {
+		segs := getSegs(relPath)
+		defer putSegs(segs)
+		return m.matchFull(*segs, isDir)
+	}
+	var buf [stackSegs]string
+	return m.matchFull(splitPath(relPath, buf[:0]), isDir)
+}
+
+// MatchFullDetail is MatchFull reporting the deciding pattern, as
+// MatchDetail does. A path ignored because a directory above it is gets
+// the pattern that excludes the first such directory, with the
+// directory in Ancestor, which is how git check-ignore -v attributes it.
+func (m *Matcher) MatchFullDetail(relPath string) MatchResult {
+	relPath, isDir, ok := m.path(relPath, true)
+	if !ok {
+		return MatchResult{}
+	}
+	for strings.HasPrefix(relPath, "./") {
+		relPath = relPath[2:]
+	}
+	if strings.Count(relPath, "/") >= stackSegs {
+		segs := getSegs(relPath)
+		defer putSegs(segs)
+		return m.matchFullDetail(relPath, *segs, isDir)
+	}
+	var buf [stackSegs]string
+	return m.matchFullDetail(relPath, splitPath(relPath, buf[:0]), isDir)
+}
+
+// matchFullDetail is MatchFullDetail for relPath split into pathSegs.
+func (m *Matcher) matchFullDetail(relPath string, pathSegs []string, isDir bool) MatchResult {
+	pathSegs = m.normalize(pathSegs)
+	owner, i, d := m.findFull(pathSegs, isDir)
+	r := owner.result(i)
+	if d < len(pathSegs) {
+		// The first d segments of relPath, as the caller wrote them.
+		n := -1
+		for range d {
+			n += 1 + strings.IndexByte(relPath[n+1:], '/')
+		}
+		r.Ancestor = relPath[:n]
+	}
+	return r
+}
+
+func (m *Matcher) matchFull(pathSegs []string, isDir bool) bool {
+	owner, i, _ := m.findFull(m.normalize(pathSegs), isDir)
+	return ignoredBy(owner, i)
+}
+
+// findFull is find with the directories above the path decided first,
+// as git decides them: it returns the pattern that ignores the first
+// ignored directory above the path, and that directory's number of
+// segments, or else the pattern deciding the path itself, as findOwn,
+// and len(pathSegs). pathSegs is already normalized.
+func (m *Matcher) findFull(pathSegs []string, isDir bool) (*Matcher, int, int) {
+	for d := 1; d < len(pathSegs); d++ {
+		if owner, i := m.findOwn(pathSegs[:d], true); ignoredBy(owner, i) {
+			return owner, i, d
+		}
+	}
+	owner, i := m.findOwn(pathSegs, isDir)
+	return owner, i, len(pathSegs)
+}
+
+// findOwn is find for a path whose parent directories are known not to
+// be ignored: it returns the last pattern matching the path itself,
+// leaving out patterns that match it only through a parent. Those
+// decided the parent, so they cannot exclude the path; but a negation
+// that re-included a parent must not re-include a path below it that a
+// pattern of its own excludes, as with "**/a/*" and "!/a*" for "a/aa".
+// pathSegs is already normalized.
+func (m *Matcher) findOwn(pathSegs []string, isDir bool) (*Matcher, int) {
+	owner, i := m.find(pathSegs, isDir)
+	for ; owner != nil; owner, i = owner.globalMatcher(), -1 {
+		if i < 0 {
+			i = owner.lookup(pathSegs, isDir)
+		}
+		// Patterns below i are only tried once pattern i turns out to
+		// match through a parent, which is rare.
+		for ; i >= 0; i-- {
+			if !owner.hot[i].shadowed && owner.matchesItself(i, pathSegs, isDir) {
+				return owner, i
+			}
+		}
+	}
+	return nil, -1
+}
+
+// matchesItself reports whether pattern i matches pathSegs itself rather
+// than only a directory above it. Compiled patterns also match below the
+// paths they name, through the implicit trailing ** or descendants, so
+// this strips that: the last ** is dropped, and if the pattern was
+// written ending in "**", it must still stand for at least one segment,
+// as in git, where "build/**" does not match "build" itself.
+func (m *Matcher) matchesItself(i int, pathSegs []string, isDir bool) bool {
+	p := &m.patterns[i]
+	if p.dirOnly && !isDir {
+		return false
+	}
+	rel, ok := underPrefix(p, pathSegs)
+	if !ok {
+		return false
+	}
+	if p.re != nil {
+		return len(rel) > 0 && p.re.MatchString(strings.Join(rel, "/"))
+	}
+	trailing := m.endsInDoubleStar(p)
+	segs := p.segments
+	if !p.dirOnly || trailing {
+		segs = segs[:len(segs)-1]
+	}
+	if !trailing {
+		return matchSegments(segs, rel)
+	}
+	for k := range len(rel) {
+		if matchSegments(segs, rel[:k]) {
+			return true
+		}
+	}
+	return false
+}
+
+// endsInDoubleStar reports whether p was written ending in a "**"
+// segment, or one of more stars, which compilePattern doesn't tell apart
+// from the implicit trailing ** of a pattern that isn't dir-only.
+func (m *Matcher) endsInDoubleStar(p *pattern) bool {
+	if p.re != nil {
+		return false
+	}
+	if n := len(p.segments); n >= 2 && p.segments[n-2].glue {
+		// "x**/**" also reads as "x" followed by anything at all, as
+		// "x*" does; see matchGlued.
+		return false
+	}
+	text := strings.TrimSuffix(m.patternText(p), "/")
+	i := strings.LastIndexByte(text, '/')
+	last := text[i+1:]
+	if i < 0 && p.negate {
+		last = last[1:]
+	}
+	return len(last) >= 2 && strings.Trim(last, "*") == ""
+}
@@ -0,0 +1,112 @@
+package gitignore_test
+
+import (
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestMatchFull(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	patterns := "build/\n!build/keep\n**/a/*\n!/a*\nab/**\nx/**/\nlogs\n!logs/\n"
+
+	// What git check-ignore reports for each path.
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"build/", true},
+		{"build/keep", true},
+		{"a/", false},
+		{"a/aa/", true},
+		{"a/aa/f", true},
+		{"ab/", false},
+		{"ab/x/", true},
+		{"ab/f", true},
+		{"x/", false},
+		{"x/y/", true},
+		{"logs/", false},
+		{"logs/f", false},
+		{"src/main.go", false},
+	}
+	for _, opts := range [][]gitignore.Option{nil, {gitignore.WithDFA()}, {gitignore.WithDirCache(8)}} {
+		m := gitignore.New(t.TempDir(), opts...)
+		m.AddPatterns([]byte(patterns), "")
+		for _, tt := range tests {
+			if got := m.MatchFull(tt.path); got != tt.want {
+				t.Errorf("MatchFull(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		}
+		if m.Match("build/keep") {
+			t.Error("Match(build/keep) = true, want the negation to apply")
+		}
+	}
+}
+
+func TestMatchFullScopes(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":        "vendor/\n",
+		"vendor/.gitignore": "!keep.go\n",
+		"src/.gitignore":    "gen\n!gen/\n*.pb.go\n",
+	})
+	m, err := gitignore.LoadDirectory(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"vendor/keep.go", true},
+		{"src/gen/", false},
+		{"src/gen/x.go", false},
+		{"src/gen/x.pb.go", true},
+	}
+	for _, tt := range tests {
+		if got := m.MatchFull(tt.path); got != tt.want {
+			t.Errorf("MatchFull(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestMatchFullDetail(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	m := gitignore.New(t.TempDir(), gitignore.WithIgnoreCase(true))
+	m.AddPatterns([]byte("build/\n!build/keep\n*.log\n"), "")
+
+	// What git check-ignore -v reports for each path.
+	tests := []struct {
+		path string
+		want gitignore.MatchResult
+	}{
+		{"build/keep", gitignore.MatchResult{Ignored: true, Matched: true, Pattern: "build/", Line: 1, Ancestor: "build"}},
+		{"build/sub/x.log", gitignore.MatchResult{Ignored: true, Matched: true, Pattern: "build/", Line: 1, Ancestor: "build"}},
+		{"./Build/Keep", gitignore.MatchResult{Ignored: true, Matched: true, Pattern: "build/", Line: 1, Ancestor: "Build"}},
+		{"build/", gitignore.MatchResult{Ignored: true, Matched: true, Pattern: "build/", Line: 1}},
+		{"src/a.log", gitignore.MatchResult{Ignored: true, Matched: true, Pattern: "*.log", Line: 3}},
+		{"src/a.go", gitignore.MatchResult{}},
+	}
+	for _, tt := range tests {
+		if got := m.MatchFullDetail(tt.path); got != tt.want {
+			t.Errorf("MatchFullDetail(%q) = %+v, want %+v", tt.path, got, tt.want)
+		}
+	}
+	if r := m.MatchDetail("build/keep"); r.Ancestor != "" || !r.Negate {
+		t.Errorf("MatchDetail(build/keep) = %+v, want the negation and no ancestor", r)
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		m.MatchFullDetail("build/sub/x.log")
+	})
+	if allocs != 0 {
+		t.Errorf("MatchFullDetail allocated %v times, want 0", allocs)
+	}
+}
@@ -1,31 +1,75 @@
 package gitignore
 
 import (
-	"bufio"
-	"bytes"
 	"io/fs"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 type segment struct {
 	doubleStar bool
+	unicode    bool   // matched by code point; see WithUnicodeClasses
+	glue       bool   // ends the literal text of the pattern in a **; see matchGlued
 	raw        string // original glob text; empty if doubleStar
 }
 
+// match reports whether the path segment text matches s, which is not a
+// doubleStar.
+func (s *segment) match(text string) bool {
+	if s.unicode {
+		return matchSegmentRunes(s.raw, text)
+	}
+	return matchSegment(s.raw, text)
+}
+
+// pattern is kept small because big monorepos load hundreds of thousands
+// of them: the text is an offset into the shared text of its source, and
+// the directory scope is shared by every pattern from the same file.
 type pattern struct {
-	segments      []segment
-	negate        bool
-	dirOnly       bool   // trailing slash pattern
-	hasConcrete   bool   // has at least one non-** segment
-	anchored      bool
-	prefix        string // directory scope for nested .gitignore
-	text          string // original pattern text before compilation
-	source        string // file path this pattern came from, empty for programmatic
-	line          int    // 1-based line number in source file
-	literalSuffix string // fast-reject: last segment must end with this (e.g. ".log" from "*.log")
+	segments    []segment
+	prefixSegs  []string       // directory scope for nested .gitignore, nil for root-level patterns
+	re          *regexp.Regexp // for a regular expression line, which has no segments; see WithRegexPatterns
+	src         uint32         // index of the source in Matcher.sources
+	textOff     uint32         // original pattern text before compilation, as
+	textLen     uint32         // an offset into the text of the source
+	line        uint32         // 1-based line number in source file
+	exact       uint32         // anchored all-literal pattern ("/config/local.yml"): number of segments compared directly
+	negate      bool
+	dirOnly     bool // trailing slash pattern
+	hasConcrete bool // has at least one non-** segment
+	anchored    bool
+}
+
+// patternHot holds what search and scan consult for every candidate
+// pattern, in a slice parallel to Matcher.patterns. Most candidates are
+// rejected on these fields alone, so keeping them densely packed, apart
+// from the rest of the pattern, keeps the candidate loops in cache.
+type patternHot struct {
+	literalSuffix string // fast-reject: some scoped path segment must end with this (e.g. ".log" from "*.log")
+	depth         uint32 // number of segments in the directory scope
+	exact         bool   // compared segment by segment; see pattern.exact
+	dirSelf       bool   // dir-only without a concrete segment: matches directories themselves only
+	shadowed      bool   // a later pattern has the same text and scope; see appendPattern
+}
+
+// sourceText is a file, or AddPatterns call, that patterns came from.
+// Pattern text and compiled segments are substrings of text, so all the
+// patterns of a file share one allocation.
+type sourceText struct {
+	path string // empty for programmatic patterns
+	dir  string // scope directory of the patterns
+	text string
+}
+
+// patternKey identifies patterns that behave the same: the same text
+// under the same scope directory.
+type patternKey struct {
+	dir, text string
 }
 
 // Matcher checks paths against gitignore rules collected from .gitignore files,
@@ -42,7 +86,26 @@ type pattern struct {
 // concurrently with Match.
 type Matcher struct {
 	patterns []pattern
+	hot      []patternHot // parallel to patterns
+	sources  []sourceText
 	errors   []PatternError
+	index    scopeNode
+	seen     map[patternKey]int32 // latest pattern with each key
+	dirCache *dirCache            // nil unless WithDirCache is used
+	dfa      bool                 // set by WithDFA
+	limits   Limits               // set by WithLimits
+	compat   Compat               // set by WithCompat
+	syntax   string               // set by WithSyntax; empty for gitignore
+	compiler Compiler             // of syntax; nil for gitignore
+	names    []string             // set by WithIgnoreFileNames; nil for .gitignore
+	root     string               // absolute, for MatchAbs; empty for none
+	verify   bool                 // set by WithVerify
+	stats    *matchStats          // nil unless WithStats is used
+	arena    *segmentArena        // nil unless WithArena is used
+	global   *globalExcludes      // nil for no global excludes
+	deps     *sourceDeps          // nil unless built by LoadCache
+	fsys     fs.FS                // where MatchAuto looks paths up; nil for nowhere
+	patternOptions
 }
 
 // PatternError records a pattern that could not be compiled.
@@ -51,6 +114,7 @@ type PatternError struct {
 	Source  string // file path, empty for programmatic patterns
 	Line    int    // 1-based line number
 	Message string
+	Limit   *LimitError // the limit exceeded, if that is why the pattern was left out
 }
 
 func (e PatternError) Error() string {
@@ -60,6 +124,14 @@ func (e PatternError) Error() string {
 	return "invalid pattern: " + e.Pattern + ": " + e.Message
 }
 
+// Unwrap returns e.Limit, if set, so errors.As finds it.
+func (e PatternError) Unwrap() error {
+	if e.Limit == nil {
+		return nil
+	}
+	return e.Limit
+}
+
 func itoa(n int) string {
 	if n == 0 {
 		return "0"
@@ -78,6 +150,9 @@ func itoa(n int) string {
 // patterns. Invalid patterns are silently skipped during matching; this
 // method lets callers detect and report them.
 func (m *Matcher) Errors() []PatternError {
+	if g := m.globalMatcher(); g != nil && len(g.errors) > 0 {
+		return append(g.errors[:len(g.errors):len(g.errors)], m.errors...)
+	}
 	return m.errors
 }
 
@@ -86,62 +161,111 @@ func (m *Matcher) Errors() []PatternError {
 // and the root .gitignore. Patterns are loaded in priority order: global
 // excludes first (lowest priority), then .git/info/exclude, then
 // .gitignore (highest priority). Last-match-wins semantics means later
-// patterns override earlier ones.
+// patterns override earlier ones. The global excludes file is located and
+// read the first time a path isn't decided by the other patterns, since
+// finding it means reading the git config files.
 //
 // The root parameter should be the repository working directory
-// (containing .git/).
-func New(root string) *Matcher {
-	m := &Matcher{}
-
-	// Read global excludes (lowest priority)
-	if gef := globalExcludesFile(); gef != "" {
-		if data, err := os.ReadFile(gef); err == nil {
-			m.addPatterns(data, "", gef)
-		}
+// (containing .git/), or any directory with WithoutGit.
+func New(root string, opts ...Option) *Matcher {
+	c := newConfig(opts)
+	m := newMatcher(c)
+	m.root = absRoot(root)
+	if m.fsys == nil {
+		m.fsys = os.DirFS(root)
 	}
 
-	// Read .git/info/exclude
-	excludePath := filepath.Join(root, ".git", "info", "exclude")
-	if data, err := os.ReadFile(excludePath); err == nil {
-		m.addPatterns(data, "", excludePath)
+	// Global excludes (lowest priority) are read on first use.
+	m.global = newGlobalExcludes(c)
+
+	// Read .git/info/exclude, unless root isn't a repository.
+	if !c.plain {
+		excludePath := filepath.Join(root, ".git", "info", "exclude")
+		if data, err := m.readSource(excludePath); err == nil {
+			m.addPatterns(data, "", excludePath)
+		}
 	}
 
 	// Read root .gitignore (highest priority)
-	ignorePath := filepath.Join(root, ".gitignore")
-	if data, err := os.ReadFile(ignorePath); err == nil {
-		m.addPatterns(data, "", ignorePath)
+	m.addRootIgnoreFile(root)
+
+	return m
+}
+
+// addRootIgnoreFile adds the patterns of the first of m's per-directory
+// ignore files present in root.
+func (m *Matcher) addRootIgnoreFile(root string) {
+	for _, name := range m.ignoreFileNames() {
+		ignorePath := filepath.Join(root, name)
+		if data, err := m.readGitignore(ignorePath); err == nil {
+			m.addIgnoreFile(data, "", ignorePath)
+			return
+		}
 	}
+}
 
+// newMatcher returns an empty Matcher configured by c.
+func newMatcher(c *config) *Matcher {
+	m := &Matcher{}
+	if c.dirCacheSize > 0 {
+		m.dirCache = newDirCache(c.dirCacheSize)
+	}
+	m.dfa = c.dfa
+	m.patternOptions = c.patternOptions
+	m.fsys = c.fsys
+	m.limits = c.limits
+	m.compat = c.compat
+	m.syntax = c.syntax
+	if c.syntax != "" {
+		m.compiler = lookupSyntax(c.syntax)
+	}
+	m.names = c.ignoreNames
+	m.verify = c.verify
+	if c.stats {
+		m.stats = &matchStats{}
+	}
+	if c.arena {
+		m.arena = &segmentArena{}
+	}
+	if c.trackSources {
+		m.deps = &sourceDeps{limits: c.limits, plain: c.plain}
+	}
 	return m
 }
 
 // globalExcludesFile returns the path to the user's global gitignore file.
-// It checks (in order): git config core.excludesfile, $XDG_CONFIG_HOME/git/ignore,
-// ~/.config/git/ignore. Returns empty string if none found.
-func globalExcludesFile() string {
+// It checks (in order): core.excludesfile in the system or global git config, $XDG_CONFIG_HOME/git/ignore,
+// ~/.config/git/ignore. Returns empty string if none found. Every file
+// consulted along the way is appended to *read.
+func globalExcludesFile(read *[]string) string {
+	return globalGitFile("excludesfile", "ignore", read)
+}
+
+// globalGitFile returns the path to the user's global git file set by
+// the core variable key, or else called name in git's XDG directory, as
+// globalExcludesFile finds the global gitignore file.
+func globalGitFile(key, name string, read *[]string) string {
 	// Try git config first.
-	out, err := exec.Command("git", "config", "--global", "core.excludesfile").Output()
-	if err == nil {
-		path := strings.TrimSpace(string(out))
-		if path != "" {
-			return expandTilde(path)
-		}
+	if path, ok := gitConfigValue("core", key, read); ok && path != "" {
+		return expandTilde(path)
 	}
 
-	// Try XDG_CONFIG_HOME/git/ignore.
+	// Try XDG_CONFIG_HOME/git/<name>.
 	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
-		path := filepath.Join(xdg, "git", "ignore")
+		path := filepath.Join(xdg, "git", name)
+		*read = append(*read, path)
 		if _, err := os.Stat(path); err == nil {
 			return path
 		}
 	}
 
-	// Fall back to ~/.config/git/ignore.
+	// Fall back to ~/.config/git/<name>.
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return ""
 	}
-	path := filepath.Join(home, ".config", "git", "ignore")
+	path := filepath.Join(home, ".config", "git", name)
+	*read = append(*read, path)
 	if _, err := os.Stat(path); err == nil {
 		return path
 	}
@@ -165,105 +289,50 @@ func expandTilde(path string) string {
 // at root, loading every .gitignore file found along the way. Each nested
 // .gitignore is scoped to its containing directory. The .git directory is
 // skipped.
-func NewFromDirectory(root string) *Matcher {
-	m := New(root)
-	_ = walkRecursive(root, "", m, nil)
+func NewFromDirectory(root string, opts ...Option) *Matcher {
+	m := New(root, opts...)
+	w := newOSWalker(root, m, nil)
+	_ = w.walk("", 0)
 	return m
 }
 
-// Walk walks the directory tree rooted at root, calling fn for each file
-// and directory that is not ignored by gitignore rules. It loads .gitignore
-// files as it descends, so patterns from deeper directories take effect for
-// their subtrees. The .git directory is always skipped.
-//
-// Paths passed to fn are relative to root and use the OS path separator.
-// The root directory itself is not passed to fn.
-func Walk(root string, fn func(path string, d fs.DirEntry) error) error {
-	m := New(root)
-	return walkRecursive(root, "", m, fn)
-}
-
-func walkRecursive(root, rel string, m *Matcher, fn func(string, fs.DirEntry) error) error {
-	dir := root
-	if rel != "" {
-		dir = filepath.Join(root, rel)
-	}
-
-	// Load .gitignore for this directory before processing entries.
-	if rel != "" {
-		igPath := filepath.Join(dir, ".gitignore")
-		if _, err := os.Stat(igPath); err == nil {
-			m.AddFromFile(igPath, filepath.ToSlash(rel))
-		}
-	}
-
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		return err
-	}
-
-	for _, entry := range entries {
-		name := entry.Name()
-
-		// Always skip .git directories.
-		if name == ".git" && entry.IsDir() {
-			continue
-		}
-
-		entryRel := name
-		if rel != "" {
-			entryRel = filepath.Join(rel, name)
-		}
-		matchPath := filepath.ToSlash(entryRel)
-		if entry.IsDir() {
-			matchPath += "/"
-		}
-
-		if m.Match(matchPath) {
-			continue
-		}
-
-		if fn != nil {
-			if err := fn(entryRel, entry); err != nil {
-				return err
-			}
-		}
-
-		if entry.IsDir() {
-			if err := walkRecursive(root, entryRel, m, fn); err != nil {
-				return err
-			}
-		}
-	}
-
-	return nil
-}
-
-// AddPatterns parses gitignore pattern lines from data and scopes them to
-// the given relative directory. Pass an empty dir for root-level patterns.
+// AddPatterns parses gitignore pattern lines from data, or lines in the
+// syntax set by WithSyntax, and scopes them to the given relative
+// directory. Pass an empty dir for root-level patterns.
+// As in git, patterns scoped to a directory take precedence over those
+// of the directories above it, whichever were added first, and over
+// those added before them for the same directory.
 func (m *Matcher) AddPatterns(data []byte, dir string) {
-	m.addPatterns(data, dir, "")
+	m.addIgnoreFile(data, dir, "")
 }
 
 // AddFromFile reads a .gitignore file at the given absolute path and scopes
-// its patterns to the given relative directory.
+// its patterns to the given relative directory, with the precedence
+// AddPatterns gives them.
 func (m *Matcher) AddFromFile(absPath, relDir string) {
 	data, err := os.ReadFile(absPath)
 	if err != nil {
 		return
 	}
-	m.addPatterns(data, relDir, absPath)
+	m.addIgnoreFile(data, relDir, absPath)
 }
 
 // Match returns true if the given path should be ignored.
 // The path should be slash-separated and relative to the repository root.
-// For directories, append a trailing slash (e.g. "vendor/").
+// For directories, append a trailing slash (e.g. "vendor/"). A leading
+// "./" is dropped, and the root itself, as "" or ".", is never ignored.
+// A ".." segment is resolved against the one before it, so "a/../x.log"
+// matches as "x.log", and a path that climbs out of the root, as
+// "../x.log" does, is never ignored. Names are compared byte for byte,
+// as git compares them: newlines and other control bytes, and bytes that
+// aren't valid UTF-8, are part of a name like any other, and a ? or *
+// matches them. A path with a NUL byte is never ignored.
 // Uses last-match-wins semantics: iterates patterns in reverse and returns
 // on the first match.
 func (m *Matcher) Match(relPath string) bool {
-	isDir := strings.HasSuffix(relPath, "/")
-	if isDir {
-		relPath = relPath[:len(relPath)-1]
+	relPath, isDir, ok := m.path(relPath, true)
+	if !ok {
+		return false
 	}
 	return m.match(relPath, isDir)
 }
@@ -273,7 +342,19 @@ func (m *Matcher) Match(relPath string) bool {
 // a trailing slash convention. The path should be slash-separated,
 // relative to the repository root, and should not have a trailing slash.
 func (m *Matcher) MatchPath(relPath string, isDir bool) bool {
-	return m.match(relPath, isDir)
+	relPath, dir, ok := m.path(relPath, true)
+	return ok && m.match(relPath, isDir || dir)
+}
+
+// MatchSegments is MatchPath for a path already split into its
+// slash-separated components, such as the stack of directory names a
+// walker keeps, so the path need not be joined only to be split again.
+// The components must be non-empty and must not contain a slash; no
+// components at all is the root, which is never ignored. segs is not
+// modified or retained.
+func (m *Matcher) MatchSegments(segs []string, isDir bool) bool {
+	segs, ok := m.cleanSegs(segs)
+	return ok && m.matchSegs(segs, isDir)
 }
 
 // MatchResult describes which pattern matched a path and whether
@@ -285,149 +366,446 @@ type MatchResult struct {
 	Source  string // file the pattern came from (empty for programmatic patterns)
 	Line    int    // 1-based line number in Source (0 if no match)
 	Negate  bool   // true if the matching pattern was a negation (!)
+
+	// Ancestor is the directory above the path that the pattern
+	// excludes, when MatchFullDetail finds the path ignored because of
+	// it. It is empty if the pattern matched the path itself.
+	Ancestor string
 }
 
 // MatchDetail returns detailed information about which pattern matched
 // the given path. If no pattern matches, Matched is false and Ignored
 // is false. The path uses the same trailing-slash convention as Match.
 func (m *Matcher) MatchDetail(relPath string) MatchResult {
-	isDir := strings.HasSuffix(relPath, "/")
-	if isDir {
-		relPath = relPath[:len(relPath)-1]
+	relPath, isDir, ok := m.path(relPath, true)
+	if !ok {
+		return MatchResult{}
 	}
 	return m.matchDetail(relPath, isDir)
 }
 
 func (m *Matcher) match(relPath string, isDir bool) bool {
-	pathSegs := strings.Split(relPath, "/")
-	lastSeg := pathSegs[len(pathSegs)-1]
+	return ignoredBy(m.findPath(relPath, isDir))
+}
 
-	for i := len(m.patterns) - 1; i >= 0; i-- {
-		p := &m.patterns[i]
-		if p.literalSuffix != "" && !strings.HasSuffix(lastSeg, p.literalSuffix) {
-			continue
-		}
-		if !matchPattern(p, pathSegs, isDir) {
-			continue
-		}
-		return !p.negate
-	}
-	return false
+// matchSegs is match for a path already split into segments.
+func (m *Matcher) matchSegs(pathSegs []string, isDir bool) bool {
+	return ignoredBy(m.find(pathSegs, isDir))
 }
 
+// matchDetail is match reporting the deciding pattern. It takes the same
+// path as match, and like it doesn't allocate: the strings in the result
+// are the pattern's own text and source path.
 func (m *Matcher) matchDetail(relPath string, isDir bool) MatchResult {
-	pathSegs := strings.Split(relPath, "/")
-	lastSeg := pathSegs[len(pathSegs)-1]
+	owner, i := m.findPath(relPath, isDir)
+	return owner.result(i)
+}
 
-	for i := len(m.patterns) - 1; i >= 0; i-- {
-		p := &m.patterns[i]
-		if p.literalSuffix != "" && !strings.HasSuffix(lastSeg, p.literalSuffix) {
-			continue
-		}
-		if !matchPattern(p, pathSegs, isDir) {
-			continue
-		}
-		return MatchResult{
-			Ignored: !p.negate,
-			Matched: true,
-			Pattern: p.text,
-			Source:  p.source,
-			Line:    p.line,
-			Negate:  p.negate,
+// matchDetailSegs is matchDetail for a path already split into segments.
+func (m *Matcher) matchDetailSegs(pathSegs []string, isDir bool) MatchResult {
+	owner, i := m.find(pathSegs, isDir)
+	return owner.result(i)
+}
+
+// findPath splits relPath and finds the pattern deciding it, as find.
+func (m *Matcher) findPath(relPath string, isDir bool) (*Matcher, int) {
+	if strings.Count(relPath, "/") >= stackSegs {
+		segs := getSegs(relPath)
+		defer putSegs(segs)
+		return m.find(*segs, isDir)
+	}
+	var buf [stackSegs]string
+	return m.find(splitPath(relPath, buf[:0]), isDir)
+}
+
+// ignoredBy reports whether pattern i of owner, as returned by find,
+// ignores the path.
+func ignoredBy(owner *Matcher, i int) bool {
+	return i >= 0 && !owner.patterns[i].negate
+}
+
+// result describes pattern i of m, as returned by find, as a MatchResult.
+func (m *Matcher) result(i int) MatchResult {
+	if i < 0 {
+		return MatchResult{}
+	}
+	p := &m.patterns[i]
+	return MatchResult{
+		Ignored: !p.negate,
+		Matched: true,
+		Pattern: m.patternText(p),
+		Source:  m.sources[p.src].path,
+		Line:    int(p.line),
+		Negate:  p.negate,
+	}
+}
+
+// stackSegs is the number of path segments match can split into a stack
+// buffer. Deeper paths borrow a buffer from segsPool.
+const stackSegs = 32
+
+var segsPool = sync.Pool{New: func() any { return new([]string) }}
+
+// getSegs splits relPath into a pooled buffer, for paths too deep for the
+// stack. Return it with putSegs.
+func getSegs(relPath string) *[]string {
+	segs := segsPool.Get().(*[]string)
+	*segs = splitPath(relPath, (*segs)[:0])
+	return segs
+}
+
+func putSegs(segs *[]string) {
+	clear(*segs) // don't keep the caller's path alive
+	segsPool.Put(segs)
+}
+
+// fromRoot drops the "." segments that start a path like "./src", and
+// returns no segments at all for the root itself, given as "", "." or
+// "./". Git never ignores the root, so there is nothing to match.
+func fromRoot(segs []string) []string {
+	for len(segs) > 0 && segs[0] == "." {
+		segs = segs[1:]
+	}
+	if len(segs) == 1 && segs[0] == "" {
+		return nil
+	}
+	return segs
+}
+
+// splitPath splits relPath on "/" into segs, which is usually backed by a
+// caller's stack array so that matching doesn't allocate. It behaves like
+// strings.Split, including returning one empty segment for "".
+func splitPath(relPath string, segs []string) []string {
+	for {
+		i := strings.IndexByte(relPath, '/')
+		if i < 0 {
+			return append(segs, relPath)
 		}
+		segs = append(segs, relPath[:i])
+		relPath = relPath[i+1:]
 	}
-	return MatchResult{}
 }
 
 // matchPattern checks whether pathSegs matches the compiled pattern,
 // including the directory prefix scope and dirOnly handling.
 func matchPattern(p *pattern, pathSegs []string, isDir bool) bool {
-	segs := pathSegs
-	if p.prefix != "" {
-		prefixSegs := strings.Split(p.prefix, "/")
-		if len(segs) < len(prefixSegs) {
-			return false
-		}
-		for i, ps := range prefixSegs {
-			if segs[i] != ps {
-				return false
-			}
+	segs, ok := underPrefix(p, pathSegs)
+	if !ok {
+		return false
+	}
+	return matchScoped(p, segs, isDir)
+}
+
+// underPrefix checks that pathSegs lies within the directory scope of p
+// and returns the segments relative to it.
+func underPrefix(p *pattern, pathSegs []string) ([]string, bool) {
+	if len(pathSegs) < len(p.prefixSegs) {
+		return nil, false
+	}
+	for i, ps := range p.prefixSegs {
+		if pathSegs[i] != ps {
+			return nil, false
 		}
-		segs = segs[len(prefixSegs):]
 	}
+	return pathSegs[len(p.prefixSegs):], true
+}
 
+// matchScoped is matchPattern for segments already relative to the
+// pattern's directory scope.
+func matchScoped(p *pattern, segs []string, isDir bool) bool {
+	if p.re != nil {
+		return matchRegex(p.re, segs)
+	}
 	if p.dirOnly {
 		// Dir-only patterns (trailing slash): match the directory itself,
 		// or match descendants (files/dirs under the matched directory).
-		if matchSegments(p.segments, segs) {
-			// Exact match. For non-dir paths, the pattern requires a directory.
-			return isDir
+		if isDir && matchSegments(p.segments, segs) {
+			return true
 		}
+		// A file can still match through one of its parent directories:
+		// "**/a/" ignores the file "a/x/a" because it ignores "a".
 		// Only do descendant matching when the pattern identifies a specific
 		// directory (has at least one non-** segment). Pure ** patterns like
 		// "**/" only match directory paths directly.
 		if !p.hasConcrete {
 			return false
 		}
-		// Check if the path is a descendant of a matched directory by trying
-		// the pattern against every prefix of the path segments.
-		for end := len(segs) - 1; end >= 1; end-- {
-			if matchSegments(p.segments, segs[:end]) {
-				return true
-			}
-		}
-		return false
+		// Check if the path is a descendant of a matched directory in one
+		// pass, rather than trying the pattern against every prefix.
+		return matchSegments(descendants(p), segs)
 	}
 
 	return matchSegments(p.segments, segs)
 }
 
+// compileDirect is compilePattern for a pattern that matches a path
+// itself but not the paths inside a directory it matches, as git
+// matches gitattributes and sparse-checkout patterns. Match it with
+// matchDirect.
+func compileDirect(line string, dirSegs []string) (pattern, string) {
+	p, errMsg := compilePattern(line, dirSegs, nil)
+	if errMsg != "" || p.dirOnly {
+		return p, errMsg
+	}
+	n := len(p.segments)
+	if !endsInDoubleStar(line) {
+		// Drop the implicit trailing **.
+		p.segments = p.segments[:n-1]
+	} else if n > 1 {
+		// "dir/**" matches inside dir but not dir itself.
+		p.segments = append(p.segments[:n-1:n-1], segment{raw: "*"}, segment{doubleStar: true})
+	}
+	return p, ""
+}
+
+// endsInDoubleStar reports whether the last segment of the pattern line
+// is a **, which matches everything inside a directory.
+func endsInDoubleStar(line string) bool {
+	last := line[strings.LastIndexByte(line, '/')+1:]
+	return len(last) >= 2 && strings.Trim(last, "*") == ""
+}
+
+// matchDirect reports whether p, compiled by compileDirect, matches the
+// path pathSegs.
+func matchDirect(p *pattern, pathSegs []string, isDir bool) bool {
+	segs, ok := underPrefix(p, pathSegs)
+	return ok && (isDir || !p.dirOnly) && matchSegments(p.segments, segs)
+}
+
 func (m *Matcher) addPatterns(data []byte, dir, source string) {
-	scanner := bufio.NewScanner(bytes.NewReader(data))
+	m.addLines(data, dir, source, nil)
+}
+
+// addIgnoreFile is addPatterns for the text of an ignore file or an
+// AddPatterns call, written in m's pattern syntax.
+func (m *Matcher) addIgnoreFile(data []byte, dir, source string) {
+	m.addLines(data, dir, source, m.compiler)
+}
+
+// addLines adds the pattern lines of data, gitignore lines if c is nil
+// and otherwise lines c compiles into them.
+func (m *Matcher) addLines(data []byte, dir, source string, c Compiler) {
+	if m.precompose {
+		dir = norm.NFC.String(dir)
+	}
+	if m.ignoreCase {
+		dir = foldCase(dir)
+	}
+	from := len(m.patterns)
+	text := string(data)
+	// Compiled lines keep the rules they compile into instead.
+	src := uint32(len(m.sources))
+	if c == nil {
+		src = m.addSource(source, dir, text)
+	}
+	dirSegs := splitDir(dir)
+	m.arena.reserve(arenaNeeds(text))
 	lineNum := 0
-	for scanner.Scan() {
+	// Like git, skip a UTF-8 byte order mark at the start of the text, as
+	// editors on Windows tend to write. One anywhere else is kept.
+	off := len(text) - len(strings.TrimPrefix(text, utf8BOM))
+	for off < len(text) {
+		end := strings.IndexByte(text[off:], '\n')
+		next := off + end + 1
+		if end < 0 {
+			end = len(text) - off
+			next = len(text)
+		}
+		raw := text[off : off+end]
 		lineNum++
-		line := trimTrailingSpaces(scanner.Text())
-		if line == "" || line[0] == '#' {
+		if c != nil {
+			off = next
+			if !m.addCompiled(c, strings.TrimSuffix(raw, "\r"), dir, dirSegs, source, lineNum) {
+				break
+			}
 			continue
 		}
-		p, errMsg := compilePattern(line, dir)
-		if errMsg != "" {
-			m.errors = append(m.errors, PatternError{
-				Pattern: line,
-				Source:  source,
-				Line:    lineNum,
-				Message: errMsg,
-			})
+		// As in git, a CR ending the line goes before trailing spaces are
+		// trimmed, so "foo \r\n" is "foo", while an escaped space before
+		// the CR is kept.
+		line := trimTrailingSpaces(strings.TrimSuffix(raw, "\r"))
+		start := off
+		off = next
+		if line == "" || line[0] == '#' {
 			continue
 		}
-		p.text = line
-		p.source = source
-		p.line = lineNum
-		m.patterns = append(m.patterns, p)
+		if m.braces && !m.regexLine(line) {
+			expanded, more := m.addBraces(line, dir, dirSegs, source, lineNum)
+			if !more {
+				break
+			}
+			if expanded {
+				continue
+			}
+		}
+		if !m.addLine(line, src, start, dir, dirSegs, source, lineNum) {
+			break
+		}
+	}
+	if len(m.patterns) == from {
+		// Nothing to keep the text for.
+		m.sources = m.sources[:src]
+	}
+	m.patternsAdded(dir, from)
+}
+
+// addLine compiles the pattern line, text[off:off+len(line)] of source
+// src, and adds it to m, recording any error. It reports false once m
+// holds as many patterns as its limits allow.
+func (m *Matcher) addLine(line string, src uint32, off int, dir string, dirSegs []string, source string, lineNum int) bool {
+	if lerr := m.lineLimit(line, dir); lerr != nil {
+		m.errors = append(m.errors, limitError(line, source, lineNum, lerr))
+		return lerr.Limit != "patterns"
+	}
+	p, errMsg := m.compile(line, dirSegs)
+	if lerr := m.bracketLimit(&p, dir); lerr != nil {
+		m.errors = append(m.errors, limitError(line, source, lineNum, lerr))
+		return true
+	}
+	if errMsg != "" {
+		m.errors = append(m.errors, PatternError{
+			Pattern: line,
+			Source:  source,
+			Line:    lineNum,
+			Message: errMsg,
+		})
+		var ok bool
+		if p, ok = m.compileInvalid(line, dirSegs); !ok {
+			return true
+		}
+	}
+	m.appendPattern(p, src, off, len(line), lineNum)
+	return true
+}
+
+// utf8BOM is the UTF-8 encoding of U+FEFF, the byte order mark.
+const utf8BOM = "\xef\xbb\xbf"
+
+// addSource records a source of patterns and returns its index.
+func (m *Matcher) addSource(path, dir, text string) uint32 {
+	m.sources = append(m.sources, sourceText{path: path, dir: dir, text: text})
+	return uint32(len(m.sources) - 1)
+}
+
+// patternText returns the original text of p.
+func (m *Matcher) patternText(p *pattern) string {
+	return m.sources[p.src].text[p.textOff : p.textOff+p.textLen]
+}
+
+// splitDir splits a scope directory into segments, returning nil for the
+// root.
+func splitDir(dir string) []string {
+	if dir == "" {
+		return nil
+	}
+	return strings.Split(dir, "/")
+}
+
+// appendPattern adds a compiled pattern, whose text is text[off:off+n]
+// of source src, to m and its index.
+func (m *Matcher) appendPattern(p pattern, src uint32, off, n, line int) {
+	p.src = src
+	p.textOff = uint32(off)
+	p.textLen = uint32(n)
+	p.line = uint32(line)
+	h := patternHot{
+		literalSuffix: extractLiteralSuffix(p.segments),
+		depth:         uint32(len(p.prefixSegs)),
+		exact:         p.exact != 0,
+		dirSelf:       p.dirOnly && !p.hasConcrete,
+	}
+	m.patterns = append(m.patterns, p)
+	m.hot = append(m.hot, h)
+	i := len(m.patterns) - 1
+	m.index.add(i, &m.patterns[i], &h)
+
+	// The same pattern often appears in several files with one scope,
+	// such as *.log in both .git/info/exclude and the root .gitignore.
+	// Only the last copy can decide a lookup, so the earlier one leaves
+	// the index and the scan. It stays in m.patterns for Stats and the
+	// encodings, which list patterns as they were written. Global
+	// excludes are a separate Matcher and are not collapsed with these.
+	key := patternKey{m.sources[p.src].dir, m.patternText(&p)}
+	if m.seen == nil {
+		m.seen = make(map[patternKey]int32)
+	}
+	if j, ok := m.seen[key]; ok {
+		m.index.remove(int(j), &m.patterns[j], &m.hot[j])
+		m.hot[j].shadowed = true
+	}
+	m.seen[key] = int32(i)
+}
+
+// patternsAdded updates the derived state after the patterns from index
+// from onwards, all scoped to dir, were appended, moving them ahead of
+// any patterns from deeper scopes; see scopeInsert.
+func (m *Matcher) patternsAdded(dir string, from int) {
+	if m.dirCache != nil {
+		m.dirCache.reset()
+	}
+	if m.stats != nil {
+		m.stats.grow(len(m.patterns))
+	}
+	if at := m.scopeInsert(splitDir(dir), from); at < from {
+		m.moveScope(at, from)
+		return
+	}
+	if m.dfa {
+		m.compileDFAScope(dir, from, len(m.patterns))
 	}
 }
 
 // trimTrailingSpaces removes unescaped trailing spaces per gitignore spec.
 // Tabs are not stripped (git only strips spaces). A backslash before a space
-// escapes it, so "foo\ " keeps the trailing "\ ".
+// escapes it, so "foo\ " keeps the trailing "\ ", but an escaped backslash
+// doesn't, so "foo\\ " loses its space. Like git's trim_trailing_spaces,
+// it scans from the start, since only there is it known which backslashes
+// escape and which are escaped.
 func trimTrailingSpaces(s string) string {
-	i := len(s)
-	for i > 0 && s[i-1] == ' ' {
-		if i >= 2 && s[i-2] == '\\' {
-			// This space is escaped; stop stripping here.
-			break
+	end := -1 // start of the run of unescaped spaces at the end
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case ' ':
+			if end < 0 {
+				end = i
+			}
+			continue
+		case '\\':
+			i++
+			if i == len(s) {
+				return s
+			}
 		}
-		i--
+		end = -1
+	}
+	if end < 0 {
+		return s
 	}
-	return s[:i]
+	return s[:end]
 }
 
-// compilePattern compiles a gitignore pattern line into a pattern struct.
-// Returns the compiled pattern and an empty string on success, or a zero
-// pattern and an error message on failure.
-func compilePattern(line, dir string) (pattern, string) {
-	p := pattern{prefix: dir}
+// trailingBackslash reports whether s ends in a backslash that is not
+// itself escaped.
+func trailingBackslash(s string) bool {
+	n := 0
+	for n < len(s) && s[len(s)-1-n] == '\\' {
+		n++
+	}
+	return n%2 == 1
+}
+
+// segmentsSlack is how many segments a compiled pattern can take beyond
+// one per line, plus one per slash: the implicit ** at either end, or
+// the two of descendants after the leading **.
+const segmentsSlack = 4
+
+// compilePattern compiles a gitignore pattern line, scoped to the
+// directory dirSegs, into a pattern struct, taking its segments from
+// arena (which may be nil). Returns the compiled pattern
+// and an empty string on success, or a zero pattern and an error message
+// on failure. The pattern's text and source are left for the caller.
+func compilePattern(line string, dirSegs []string, arena *segmentArena) (pattern, string) {
+	p := pattern{prefixSegs: dirSegs}
 
 	// Handle negation
 	if strings.HasPrefix(line, "!") {
@@ -436,7 +814,8 @@ func compilePattern(line, dir string) (pattern, string) {
 	}
 
 	// Handle escaped leading characters (after negation is stripped)
-	if len(line) >= 2 && line[0] == '\\' && (line[1] == '#' || line[1] == '!') {
+	escapedLead := len(line) >= 2 && line[0] == '\\' && (line[1] == '#' || line[1] == '!')
+	if escapedLead {
 		line = line[1:]
 	}
 
@@ -450,6 +829,13 @@ func compilePattern(line, dir string) (pattern, string) {
 		line = line[:len(line)-1]
 	}
 
+	// A backslash escapes the next character, so one at the very end, or
+	// before the trailing slash just stripped, escapes nothing. Git's
+	// wildmatch never matches such a pattern.
+	if trailingBackslash(line) {
+		return pattern{}, "trailing backslash"
+	}
+
 	// Detect and strip leading slash (anchoring).
 	hasLeadingSlash := line[0] == '/'
 	if hasLeadingSlash {
@@ -459,25 +845,54 @@ func compilePattern(line, dir string) (pattern, string) {
 		}
 	}
 
-	// Split into segments on '/'.
-	rawSegs := strings.Split(line, "/")
+	// Segments are separated by '/'.
+	nSegs := strings.Count(line, "/") + 1
+
+	// Git compares the literal text a pattern starts with before
+	// wildmatch sees the rest, so a ** following it, as in "x**/y",
+	// starts the pattern as far as wildmatch can tell.
+	prefix := strings.IndexAny(line, "*?[\\")
+	if escapedLead {
+		prefix = 0
+	}
 
 	// Determine anchoring: leading slash, or pattern contains a slash.
-	p.anchored = hasLeadingSlash || len(rawSegs) > 1
+	p.anchored = hasLeadingSlash || nSegs > 1
 
-	// Build segment list.
-	segs := make([]segment, 0, len(rawSegs)+2)
+	// Build segment list, with room for the implicit leading and
+	// trailing ** or the two segments of descendants.
+	segs := arena.alloc(nSegs + segmentsSlack - 1)
 
 	// If not anchored, prepend ** so it matches at any directory level.
 	if !p.anchored {
 		segs = append(segs, segment{doubleStar: true})
 	}
 
-	for _, raw := range rawSegs {
-		if raw == "**" {
+	off := 0 // of raw in line
+	for raw := range strings.SplitSeq(line, "/") {
+		at := off
+		off += len(raw) + 1
+		// An escaped slash, as in "a\/b", still separates segments:
+		// git matches it against the slash in the path.
+		escaped := trailingBackslash(raw)
+		if escaped {
+			raw = raw[:len(raw)-1]
+		}
+		stem := strings.TrimRight(raw, "*")
+		switch stars := len(raw) - len(stem); {
+		case stars >= 2 && stem == "" && !escaped:
+			// Any run of two or more stars makes a ** segment.
 			segs = append(segs, segment{doubleStar: true})
-		} else {
-			segs = append(segs, segment{raw: raw})
+		case stars >= 2 && off <= len(line) && (stem == "" || at+len(stem) == prefix):
+			// A ** that wildmatch sees after a slash, or at the start,
+			// and before one. Before an escaped slash it can't match
+			// nothing, as git only looks for a plain one to skip, so
+			// "**\/b" needs a directory before b. After the literal
+			// text "x", "x**/y" reads as "x*/**/y", or else as "xy";
+			// see matchGlued.
+			segs = append(segs, segment{raw: stem + "*", glue: stem != "" && !escaped}, segment{doubleStar: true})
+		default:
+			segs = append(segs, segment{raw: simplifyWildcards(raw)})
 		}
 	}
 
@@ -517,10 +932,79 @@ func compilePattern(line, dir string) (pattern, string) {
 			break
 		}
 	}
-	p.literalSuffix = extractLiteralSuffix(segs)
+	if p.dirOnly && p.hasConcrete {
+		// Stored past the end of the segments; see descendants.
+		p.segments = append(segs, segment{raw: "*"}, segment{doubleStar: true})[:len(segs)]
+	}
+	p.exact = exactSegments(&p)
 	return p, ""
 }
 
+// compile is compilePattern for m, taking segments from m's arena,
+// normalizing line if m precomposes Unicode, compiling it with
+// compileRegex if it is a regular expression, rejecting unclosed brackets
+// if m is strict, marking wildcard segments to match by code point if m
+// has Unicode classes and folding case if m ignores it.
+func (m *Matcher) compile(line string, dirSegs []string) (pattern, string) {
+	if m.precompose {
+		line = norm.NFC.String(line)
+	}
+	if m.regexLine(line) {
+		return m.compileRegex(line, dirSegs)
+	}
+	p, errMsg := compilePattern(line, dirSegs, m.arena)
+	if errMsg == "" && m.strict {
+		for _, s := range p.segments {
+			if unclosedBracket(s.raw) {
+				return pattern{}, "unclosed bracket"
+			}
+		}
+	}
+	if errMsg == "" && m.unicode {
+		for i := range p.segments {
+			p.segments[i].unicode = !p.segments[i].doubleStar && !isLiteral(p.segments[i].raw)
+		}
+	}
+	if errMsg == "" && m.ignoreCase {
+		foldPattern(&p)
+	}
+	return p, errMsg
+}
+
+// exactSegments returns the number of segments of an anchored pattern
+// made only of literals, like "/Makefile.local" or "config/local.yml", or
+// 0 for any other pattern. Ignoring the implicit trailing **, such a
+// pattern matches exactly the paths that start with those segments.
+func exactSegments(p *pattern) uint32 {
+	if !p.anchored {
+		return 0
+	}
+	segs := p.segments
+	if !p.dirOnly {
+		segs = segs[:len(segs)-1] // drop the implicit trailing **
+	}
+	for _, s := range segs {
+		if s.doubleStar || !isLiteral(s.raw) {
+			return 0
+		}
+	}
+	return uint32(len(segs))
+}
+
+// descendants returns the segments of a dir-only pattern with a concrete
+// segment followed by "*" and "**", which match exactly the paths with at
+// least one segment below a path matching the pattern. compilePattern
+// leaves them in the spare capacity of p.segments.
+func descendants(p *pattern) []segment {
+	return p.segments[:len(p.segments)+2]
+}
+
+// isLiteral reports whether a glob segment has no wildcards, brackets, or
+// escapes, so that it only matches itself.
+func isLiteral(glob string) bool {
+	return !strings.ContainsAny(glob, "*?[\\")
+}
+
 // extractLiteralSuffix finds the literal trailing portion of the last concrete
 // segment, for fast rejection. For example, "*.log" yields ".log", "test_*.go"
 // yields ".go". Only extracts a suffix when the segment is a simple star-prefix
@@ -544,7 +1028,8 @@ func extractLiteralSuffix(segs []segment) string {
 		return ""
 	}
 	suffix := last[starIdx+1:]
-	if suffix == "" {
+	if suffix == "" || strings.IndexByte(last[:starIdx], '[') >= 0 {
+		// The * may be in a bracket expression, like "[*]".
 		return ""
 	}
 
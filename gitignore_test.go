@@ -5,6 +5,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"testing"
 
@@ -23,6 +24,15 @@ func setupMatcher(t *testing.T, gitignoreContent string) *gitignore.Matcher {
 	return gitignore.New(root)
 }
 
+// requireGit skips t where git can't be run, as under js/wasm and wasip1,
+// which can't start processes.
+func requireGit(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available:", err)
+	}
+}
+
 func TestMatchBasicPatterns(t *testing.T) {
 	m := setupMatcher(t, "vendor/\n*.log\nbuild\n")
 
@@ -717,6 +727,8 @@ func TestMatchAgainstGitCheckIgnore(t *testing.T) {
 // command to verify correctness. Each case creates a git repo, writes a .gitignore,
 // and compares our result with git's.
 func TestMatchVsGitCheckIgnore(t *testing.T) {
+	requireGit(t)
+
 	type checkPath struct {
 		path  string
 		isDir bool
@@ -1211,8 +1223,8 @@ func TestMatchPOSIXCharacterClasses(t *testing.T) {
 		{"[[:digit:][:upper:][:space:]]", "1", true},
 		{"[[:digit:][:upper:][:space:]]", " ", true},
 		{"[[:digit:][:upper:][:space:]]", "a", false},
-		{"[[:digit:][:upper:][:space:]]", ".", false},
-		{"[[:digit:][:punct:][:space:]]", ".", true},
+		{"[[:digit:][:upper:][:space:]]", ",", false},
+		{"[[:digit:][:punct:][:space:]]", ",", true},
 		{"[[:xdigit:]]", "5", true},
 		{"[[:xdigit:]]", "f", true},
 		{"[[:xdigit:]]", "D", true},
@@ -1298,6 +1310,92 @@ func TestMatchUnclosedBracket(t *testing.T) {
 	}
 }
 
+func TestMatchEscapesLikeGit(t *testing.T) {
+	// What git check-ignore reports for each pattern and path.
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{`x\/y`, "x/y", true},
+		{`x\/y`, "xy", false},
+		{`a\/**\/b`, "a/c/b", true},
+		{`[*]`, "*", true},
+		{`[*]`, "a", false},
+		{`*[*]`, "a*", true},
+		{`[?]`, "?", true},
+		{`[?]`, "a", false},
+		{`[c-a]`, "c", true}, // the start of a range is a character too
+		{`[c-a]`, "b", false},
+		{`[c-a]`, "a", false},
+		{`[\c-a]`, "c", true},
+		{`[a\-c]`, "-", true},
+		{`[a\-c]`, "b", false},
+		{`[a-\c]`, "b", true},
+		{`[\]-a]`, "^", true},
+		{`[\]-a]`, "\\", false},
+		{`[\\-a]`, "]", true},
+		{`\[ab]`, "[ab]", true},
+		{`\[ab]`, "a", false},
+		{`[a\]`, "a", false}, // \] doesn't close the bracket
+		{`a\*b`, "a*b", true},
+		{`a\*b`, "axb", false},
+		{`\\*`, "\\a", true},
+		{`\x\y`, "xy", true},
+	}
+	for _, opts := range [][]gitignore.Option{nil, {gitignore.WithDFA()}} {
+		for _, tt := range tests {
+			m := gitignore.New(t.TempDir(), opts...)
+			m.AddPatterns([]byte(tt.pattern+"\n"), "")
+			if got := m.Match(tt.path); got != tt.want {
+				t.Errorf("pattern %q: Match(%q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestMatchDoubleStarInSegment(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	paths := []string{
+		"xy", "x/y", "xa/y", "xa/b/y", "x/a/y", "a/bc", "a/b/x/c", "a/bx/y/c",
+		"a/b", "a/x/b", "b", "x/b", "q", "a/x/q", "mxn", "m/x/n", "k/ay",
+		"k/a/by", "r", "r/a", "s", "sa", "s/a", "t/s",
+	}
+	// What git check-ignore reports for each pattern on its own. A ** after
+	// a literal start is also read as git's prefix comparison reads it, so
+	// "x**/y" matches "xy" too, but ** only spans directories on its own
+	// between slashes.
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"x**/y", []string{"xy", "x/y", "xa/y", "xa/b/y", "x/a/y"}},
+		{"a/b**/c", []string{"a/bc", "a/b/x/c", "a/bx/y/c"}},
+		{`x**\/y`, []string{"x/y", "xa/y", "xa/b/y", "x/a/y"}},
+		{`**\/b`, []string{"xa/b/y", "a/b/x/c", "a/b", "a/x/b", "x/b"}},
+		{`a/**\/b`, []string{"a/x/b"}},
+		{"a/***/b", []string{"a/b/x/c", "a/b", "a/x/b"}},
+		{"***/q", []string{"q", "a/x/q"}},
+		{"m**n", []string{"mxn"}},
+		{"k/**y", []string{"k/ay"}},
+		{"r/***", []string{"r/a"}},
+		{"s**/**", []string{"s", "sa", "s/a"}},
+	}
+	for _, opts := range [][]gitignore.Option{nil, {gitignore.WithDFA()}} {
+		for _, tt := range tests {
+			m := gitignore.New(t.TempDir(), opts...)
+			m.AddPatterns([]byte(tt.pattern+"\n"), "")
+			for _, path := range paths {
+				if got, want := m.MatchFull(path), slices.Contains(tt.want, path); got != want {
+					t.Errorf("%q: MatchFull(%q) = %v, want %v", tt.pattern, path, got, want)
+				}
+			}
+		}
+	}
+}
+
 func TestMatchTrailingSpacesStripped(t *testing.T) {
 	// Unescaped trailing spaces should be stripped from patterns
 	m := setupMatcher(t, "hello   \n")
@@ -1392,6 +1490,8 @@ func TestMatchBlankLines(t *testing.T) {
 
 // Verify edge cases against git check-ignore
 func TestMatchEdgeCasesVsGitCheckIgnore(t *testing.T) {
+	requireGit(t)
+
 	type checkPath struct {
 		path  string
 		isDir bool
@@ -1412,6 +1512,26 @@ func TestMatchEdgeCasesVsGitCheckIgnore(t *testing.T) {
 				{"helloworld", false},
 			},
 		},
+		{
+			name:     "suffix pattern on a parent directory",
+			patterns: "*.log\n*.tar.gz\n!keep.log\n",
+			paths: []checkPath{
+				{"old.log/trace.txt", false},
+				{"dist.tar.gz/inner/file", false},
+				{"keep.log/file", false},
+				{"src/app.log", false},
+				{"src/main.go", false},
+			},
+		},
+		{
+			name:     "dir-only pattern naming a file under a matched directory",
+			patterns: "**/a/\n",
+			paths: []checkPath{
+				{"a/x/a", false},
+				{"b/a/c", false},
+				{"c/a", false},
+			},
+		},
 		{
 			name:     "bracket with closing bracket first",
 			patterns: "[]abc]\n",
@@ -1686,7 +1806,7 @@ func TestWildmatchCharacterClassesExpanded(t *testing.T) {
 		// [:alnum:]
 		{"[[:alnum:]]", "a", true},
 		{"[[:alnum:]]", "5", true},
-		{"[[:alnum:]]", ".", false},
+		{"[[:alnum:]]", ",", false},
 
 		// [:blank:] (space and tab)
 		{"[[:blank:]]", " ", true},
@@ -1699,8 +1819,8 @@ func TestWildmatchCharacterClassesExpanded(t *testing.T) {
 		// Underscore matches many classes
 		{"[[:alnum:][:alpha:][:blank:][:cntrl:][:digit:][:graph:][:lower:][:print:][:punct:][:space:][:upper:][:xdigit:]]", "_", true},
 
-		// Negated combination: period is not alnum/alpha/blank/cntrl/digit/lower/space/upper/xdigit
-		{"[^[:alnum:][:alpha:][:blank:][:cntrl:][:digit:][:lower:][:space:][:upper:][:xdigit:]]", ".", true},
+		// Negated combination: comma is not alnum/alpha/blank/cntrl/digit/lower/space/upper/xdigit
+		{"[^[:alnum:][:alpha:][:blank:][:cntrl:][:digit:][:lower:][:space:][:upper:][:xdigit:]]", ",", true},
 
 		// Invalid POSIX class name causes regex compilation failure (no match)
 		{"[[:digit:][:upper:][:spaci:]]", "1", false},
@@ -1804,6 +1924,8 @@ func TestWildmatchSlashHandling(t *testing.T) {
 }
 
 func TestWildmatchVsGitCheckIgnore(t *testing.T) {
+	requireGit(t)
+
 	type checkPath struct {
 		path  string
 		isDir bool
@@ -1999,6 +2121,82 @@ func TestGlobalExcludesFilePriority(t *testing.T) {
 	}
 }
 
+func TestGlobalExcludesLoadedLazily(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".git", "info"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte("*.local\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	xdgDir := t.TempDir()
+	gitConfigDir := filepath.Join(xdgDir, "git")
+	if err := os.MkdirAll(gitConfigDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	ignorePath := filepath.Join(gitConfigDir, "ignore")
+	if err := os.WriteFile(ignorePath, []byte("*.before\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", xdgDir)
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	m := gitignore.New(root)
+
+	// A path decided by the repository's own patterns doesn't need the
+	// global file, so it isn't read yet.
+	if !m.Match("a.local") {
+		t.Error("expected a.local to be ignored")
+	}
+	if err := os.WriteFile(ignorePath, []byte("*.after\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if !m.Match("a.after") {
+		t.Error("expected global excludes to be read on first undecided path")
+	}
+	if m.Match("a.before") {
+		t.Error("expected the global excludes file to be read after construction")
+	}
+}
+
+func TestGlobalExcludesCacheNoticesChanges(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first")
+	second := filepath.Join(dir, "second")
+	config := filepath.Join(dir, "config")
+	write := func(path, data string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(first, "*.one\n")
+	write(second, "*.two\n")
+	write(config, "[core]\n\texcludesfile = "+first+"\n")
+	t.Setenv("GIT_CONFIG_GLOBAL", config)
+
+	if !gitignore.New(t.TempDir()).Match("a.one") {
+		t.Fatal("expected *.one from the global excludes")
+	}
+
+	// A Matcher built after the file changes sees the new patterns.
+	write(first, "*.one-changed\n")
+	m := gitignore.New(t.TempDir())
+	if m.Match("a.one") || !m.Match("a.one-changed") {
+		t.Error("expected the changed global excludes file to be reloaded")
+	}
+
+	// Pointing core.excludesfile elsewhere is noticed too.
+	write(config, "[core]\n\texcludesfile = "+second+"\n")
+	m = gitignore.New(t.TempDir())
+	if m.Match("a.one-changed") || !m.Match("a.two") {
+		t.Error("expected the new core.excludesfile to be used")
+	}
+}
+
 func TestExpandTilde(t *testing.T) {
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -2120,6 +2318,23 @@ func TestMatchPath(t *testing.T) {
 	}
 }
 
+func TestMatchSegments(t *testing.T) {
+	m := setupMatcher(t, "vendor/\n*.log\n/dist\nfoo/**/bar\n!keep.log\n")
+
+	paths := []string{
+		"vendor/", "vendor/a.go", "app.log", "logs/app.log", "keep.log",
+		"dist", "src/dist", "foo/bar", "foo/a/b/bar", "src/main.go",
+	}
+	for _, p := range paths {
+		isDir := strings.HasSuffix(p, "/")
+		clean := strings.TrimSuffix(p, "/")
+		want := m.Match(p)
+		if got := m.MatchSegments(strings.Split(clean, "/"), isDir); got != want {
+			t.Errorf("MatchSegments(%q, %v) = %v, Match(%q) = %v", clean, isDir, got, p, want)
+		}
+	}
+}
+
 func TestMatchPathConsistentWithMatch(t *testing.T) {
 	m := setupMatcher(t, "*.log\nbuild/\n/dist\nfoo/**/bar\n")
 
@@ -2459,6 +2674,29 @@ func TestMatchDetailSource(t *testing.T) {
 	}
 }
 
+func TestMatchDetailGlobalSource(t *testing.T) {
+	xdgDir := t.TempDir()
+	writeFiles(t, xdgDir, map[string]string{"git/ignore": "# editors\n*.swp\n"})
+	t.Setenv("XDG_CONFIG_HOME", xdgDir)
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{".gitignore": "*.log\n"})
+	m := gitignore.New(root)
+
+	r := m.MatchDetail("a.swp")
+	want := gitignore.MatchResult{
+		Ignored: true,
+		Matched: true,
+		Pattern: "*.swp",
+		Source:  filepath.Join(xdgDir, "git", "ignore"),
+		Line:    2,
+	}
+	if r != want {
+		t.Errorf("MatchDetail(a.swp) = %+v, want %+v", r, want)
+	}
+}
+
 func TestMatchDetailConsistentWithMatch(t *testing.T) {
 	m := setupMatcher(t, "*.log\n!important.log\nbuild/\n/dist\n")
 
@@ -2510,3 +2748,483 @@ func TestAddPatterns(t *testing.T) {
 		}
 	}
 }
+
+func TestMatchDoesNotAllocate(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".git", "info"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte("*.log\nbuild/\n/dist\nfoo/**/bar\n!keep.log\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := gitignore.New(root)
+	m.AddPatterns([]byte("*.tmp\ncache/\n"), "src/lib")
+
+	for _, p := range []string{"src/app.log", "src/main.go", "build/", "a/b/c/d/e/f/g/file.txt", "src/lib/cache/x", "keep.log"} {
+		allocs := testing.AllocsPerRun(100, func() {
+			m.Match(p)
+		})
+		if allocs != 0 {
+			t.Errorf("Match(%q) allocated %v times, want 0", p, allocs)
+		}
+		allocs = testing.AllocsPerRun(100, func() {
+			m.MatchDetail(p)
+		})
+		if allocs != 0 {
+			t.Errorf("MatchDetail(%q) allocated %v times, want 0", p, allocs)
+		}
+	}
+}
+
+// raceEnabled is set when testing with -race; see race_test.go.
+var raceEnabled bool
+
+func TestMatchDeepPathDoesNotAllocate(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool drops items under the race detector")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	deep := strings.Repeat("very-long-directory-name/", 40)
+	for _, opts := range [][]gitignore.Option{nil, {gitignore.WithDFA()}} {
+		m := gitignore.New(t.TempDir(), opts...)
+		m.AddPatterns([]byte("*.log\nbuild/\n"), "")
+		for _, p := range []string{deep + "app.log", deep + "main.go", deep + "build/"} {
+			m.Match(p) // warm the pool
+			allocs := testing.AllocsPerRun(100, func() {
+				m.Match(p)
+				m.MatchDetail(p)
+			})
+			if allocs != 0 {
+				t.Errorf("Match(%q) allocated %v times, want 0", p, allocs)
+			}
+			if got, want := m.Match(p), !strings.HasSuffix(p, "main.go"); got != want {
+				t.Errorf("Match(%q) = %v, want %v", p, got, want)
+			}
+		}
+	}
+}
+
+func TestMatchLiteralIndexPreservesOrder(t *testing.T) {
+	// Literal-named patterns are looked up by name while the rest are
+	// scanned; interleaving them must still give last-match-wins.
+	m := setupMatcher(t, "node_modules\n*\n!node_modules\n!*.go\nvendor/\n!src/\nsrc/**/gen\n.DS_Store\n!keep/.DS_Store\n")
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"node_modules", false},      // !node_modules after *
+		{"main.go", false},           // !*.go
+		{"vendor/", true},            // vendor/ after !*.go
+		{"vendor/lib.go", true},      // descendant of vendor/
+		{"src/", false},              // !src/
+		{"src/a/gen", true},          // src/**/gen
+		{"src/a/gen/b/c.go", true},   // descendant of gen
+		{"x/.DS_Store", true},        // .DS_Store
+		{"keep/.DS_Store", false},    // !keep/.DS_Store
+		{"a/vendor/a/vendor/", true}, // repeated segment name
+		{"README", true},             // *
+	}
+
+	for _, tt := range tests {
+		got := m.Match(tt.path)
+		if got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestMatchExtensionIndex(t *testing.T) {
+	m := setupMatcher(t, "*.log\ntest_*.go\n!important.log\n*.tar.gz\n*~\nlogs/*.txt\n")
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"app.log", true},
+		{"important.log", false},
+		{"sub/important.log", false},
+		{"test_main.go", true},
+		{"main.go", false},
+		{"archive.tar.gz", true},
+		{"archive.gz", false},
+		{"notes.txt~", true},
+		{"logs/a.txt", true},
+		{"src/logs/a.txt", false}, // logs/*.txt is anchored
+		{"x.log/y.txt", true},     // suffix on an ancestor segment
+		{"Makefile", false},
+	}
+
+	for _, tt := range tests {
+		got := m.Match(tt.path)
+		if got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestMatchScopeGroups(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".git", "info"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	m := gitignore.New(root)
+	m.AddPatterns([]byte("*.tmp\n"), "")
+	for _, dir := range []string{"pkg/a", "pkg/b", "pkg"} {
+		m.AddPatterns([]byte("!keep.tmp\nout/\n"), dir)
+	}
+	m.AddPatterns([]byte("*.tmp\n"), "pkg/a/deep")
+	m.AddPatterns([]byte("**\n"), "trash")
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"keep.tmp", true},            // root scope only
+		{"pkg/keep.tmp", false},       // pkg scope re-includes
+		{"pkg/a/keep.tmp", false},     // pkg/a scope re-includes
+		{"pkg/a/deep/keep.tmp", true}, // deeper scope wins again
+		{"pkg/c/keep.tmp", false},     // pkg scope covers pkg/c
+		{"pkgx/keep.tmp", true},       // not under pkg
+		{"pkg/a/out/", true},          // pkg/a/out/ via pkg/a scope
+		{"pkg/b/out/x.js", true},      // descendant of pkg/b/out/
+		{"out/", false},               // out/ is scoped
+		{"trash/anything", true},      // ** in trash scope
+		{"pkg/a/deep/other.txt", false},
+	}
+
+	for _, tt := range tests {
+		got := m.Match(tt.path)
+		if got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestMatchDirOnlyPartition(t *testing.T) {
+	m := setupMatcher(t, "build/\n**/\n*.d/\nlogs\n!build/keep/\n")
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"build", false, false},            // a file named build
+		{"build", true, true},              // via build/ and **/
+		{"build/out.js", false, true},      // descendant of build/
+		{"build/keep", true, false},        // !build/keep/
+		{"build/keep/a.txt", false, false}, // descendant of !build/keep/
+		{"x.d", false, false},              // *.d/ needs a directory
+		{"x.d/conf", false, true},          // descendant of *.d/
+		{"src", true, true},                // **/ matches any directory
+		{"main.go", false, false},          // **/ never matches files
+		{"src/main.go", false, false},      // **/ has no descendant matching
+		{"logs", false, true},              // regular pattern still matches files
+	}
+
+	for _, tt := range tests {
+		got := m.MatchPath(tt.path, tt.isDir)
+		if got != tt.want {
+			t.Errorf("MatchPath(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestMatchExactLiteralPaths(t *testing.T) {
+	m := setupMatcher(t, "/Makefile.local\n/config/local.yml\nconfig/secrets/\n!/config/secrets/public.key\n/docs\n*.yml\n!/config/keep.yml\n")
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"Makefile.local", false, true},
+		{"sub/Makefile.local", false, false}, // anchored
+		{"config/local.yml", false, true},
+		{"config/local.yml/x", false, true}, // descendant
+		{"config/keep.yml", false, false},   // negated after *.yml
+		{"config/other.yml", false, true},   // *.yml
+		{"config/secrets", false, false},    // dir-only, not a dir
+		{"config/secrets", true, true},
+		{"config/secrets/db.pem", false, true},
+		{"config/secrets/public.key", false, false},
+		{"docs", false, true},
+		{"docs/guide.md", false, true},
+		{"docsx", false, false},
+		{"a/docs", true, false},
+	}
+
+	for _, tt := range tests {
+		got := m.MatchPath(tt.path, tt.isDir)
+		if got != tt.want {
+			t.Errorf("MatchPath(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestMatchAdversarialPatterns(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	// Inputs that make naive recursive glob matchers explode. These
+	// should all finish instantly.
+	long := strings.Repeat("a", 4096)
+	deep := strings.Repeat("a/", 512) + "a"
+	stars := strings.Repeat("*a", 64) + "*b"
+	doubleStars := strings.Repeat("**/a/", 64) + "b"
+
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{stars, long, false},
+		{stars, long + "b", true},
+		{doubleStars, deep, false},
+		{doubleStars, deep + "/b", true},
+		{strings.Repeat("a/**/", 64) + "a/", strings.Repeat("a/", 63) + "a", false},
+		{strings.Repeat("a/**/", 64) + "a/", deep + "/x", true},
+	}
+	for _, tt := range tests {
+		m := gitignore.New(t.TempDir())
+		m.AddPatterns([]byte(tt.pattern+"\n"), "")
+		if got := m.Match(tt.path); got != tt.want {
+			t.Errorf("pattern of %d bytes against path of %d bytes: got %v, want %v",
+				len(tt.pattern), len(tt.path), got, tt.want)
+		}
+	}
+}
+
+func TestDuplicatePatterns(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".git/info/exclude": "*.log\nfoo*\n",
+		".gitignore":        "build/\n*.log\nfoo*\n",
+		"src/.gitignore":    "*.log\n",
+	})
+	m := gitignore.New(root, gitignore.WithStats(), gitignore.WithVerify())
+	m.AddFromFile(filepath.Join(root, "src", ".gitignore"), "src")
+
+	r := m.MatchDetail("a.log")
+	if !r.Ignored || r.Source != filepath.Join(root, ".gitignore") || r.Line != 2 {
+		t.Errorf("MatchDetail(a.log) = %+v, want the root .gitignore line 2", r)
+	}
+	r = m.MatchDetail("src/a.log")
+	if r.Source != filepath.Join(root, "src", ".gitignore") || r.Line != 1 {
+		t.Errorf("MatchDetail(src/a.log) = %+v, want src/.gitignore line 1", r)
+	}
+
+	// The copies in .git/info/exclude are kept for Stats but not tried.
+	m.Match("main.go")
+	s := m.Stats()
+	if len(s.Patterns) != 6 {
+		t.Fatalf("got %d pattern stats, want 6", len(s.Patterns))
+	}
+	if got := s.Patterns[1]; got.Pattern != "foo*" || got.Tried != 0 {
+		t.Errorf("shadowed pattern stats = %+v, want it untried", got)
+	}
+	if got := s.Patterns[4]; got.Pattern != "foo*" || got.Tried == 0 {
+		t.Errorf("pattern stats = %+v, want it tried", got)
+	}
+
+	// A negation between two copies doesn't change which one wins.
+	for _, tt := range []struct {
+		patterns string
+		want     bool
+	}{
+		{"a\n!a\na\n", true},
+		{"!a\na\n!a\n", false},
+		{"a\n!a\n", false},
+	} {
+		m := gitignore.New(t.TempDir(), gitignore.WithVerify())
+		m.AddPatterns([]byte("*\n"), "")
+		m.AddPatterns([]byte(tt.patterns), "")
+		if got := m.Match("a"); got != tt.want {
+			t.Errorf("%q: Match(a) = %v, want %v", tt.patterns, got, tt.want)
+		}
+	}
+}
+
+func TestUTF8BOM(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore": "\ufeff*.log\n\ufeffb.txt\n",
+	})
+	m, err := gitignore.LoadDirectory(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Match("a.log") {
+		t.Error("expected the pattern after the BOM to match")
+	}
+	if r := m.MatchDetail("a.log"); r.Pattern != "*.log" || r.Line != 1 {
+		t.Errorf("MatchDetail(a.log) = %+v, want *.log on line 1", r)
+	}
+	// Only a leading BOM is skipped, as in git.
+	if m.Match("b.txt") || !m.Match("\ufeffb.txt") {
+		t.Error("expected a BOM after the first line to be kept")
+	}
+}
+
+func TestCRLF(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	m := gitignore.New(t.TempDir())
+	m.AddPatterns([]byte("*.log\r\n# c\r\nbuild/\r\nsp  \r\nesc\\ \r\n\r\n!keep.log\r\ntab\t\r\nlast\r"), "")
+
+	// What git check-ignore reports for the same file.
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"a.log", true},
+		{"keep.log", false},
+		{"build/", true},
+		{"sp", true},
+		{"sp  ", false},
+		{"esc ", true},
+		{"esc", false},
+		{"tab\t", true},
+		{"tab", false},
+		{"last", true},
+		{"# c", false},
+	}
+	for _, tt := range tests {
+		if got := m.Match(tt.path); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+	if r := m.MatchDetail("build/"); r.Pattern != "build/" || r.Line != 3 {
+		t.Errorf("MatchDetail(build/) = %+v, want build/ on line 3", r)
+	}
+	if len(m.Errors()) != 0 {
+		t.Errorf("unexpected errors: %v", m.Errors())
+	}
+}
+
+func TestTrailingBackslash(t *testing.T) {
+	// None of these match anything in git, whose wildmatch gives up on a
+	// backslash with nothing after it to escape.
+	patterns := []string{`foo\`, `\`, `bar/\`, `[a\`, `c[\`, `foo\/`, `!\`, `foo\\\`}
+	paths := []string{"foo", `foo\`, `\`, "bar", `bar/\`, "a", `[a\`, "c[", `c[\`, `foo\\`}
+
+	m := setupMatcher(t, strings.Join(patterns, "\n")+"\n")
+	errs := m.Errors()
+	if len(errs) != len(patterns) {
+		t.Fatalf("got %d errors, want %d: %v", len(errs), len(patterns), errs)
+	}
+	for i, e := range errs {
+		if e.Pattern != patterns[i] || e.Line != i+1 || e.Message != "trailing backslash" {
+			t.Errorf("error %d = %+v, want %q on line %d", i, e, patterns[i], i+1)
+		}
+	}
+	for _, p := range paths {
+		if m.Match(p) {
+			t.Errorf("Match(%q) = true, want false", p)
+		}
+	}
+
+	// An escaped backslash at the end is a literal one.
+	m = setupMatcher(t, `q\\`+"\n")
+	if len(m.Errors()) != 0 || !m.Match(`q\`) || m.Match("q") {
+		t.Errorf(`expected q\\ to match only q\, errors %v`, m.Errors())
+	}
+}
+
+func TestWhitespaceLines(t *testing.T) {
+	// What git check-ignore reports for each line as the only pattern.
+	// Only spaces are trimmed, so lines of tabs and other blanks are
+	// patterns naming files made of them.
+	paths := []string{" ", "  ", "\t", "\t\t", " \t", "\v", `\`, `\ `, "#", "!", " #", " !x", "x"}
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"   ", nil},
+		{"\t", []string{"\t"}},
+		{"\t  ", []string{"\t"}},
+		{" \t", []string{" \t"}},
+		{"\t\t", []string{"\t\t"}},
+		{"\v", []string{"\v"}},
+		{`\ `, []string{" "}},
+		{` \ `, []string{"  "}},
+		{`\ \ `, []string{"  "}},
+		{`\\ `, []string{`\`}},
+		{`\\\ `, []string{`\ `}},
+		{`\t`, nil},
+		{"!", nil},
+		{"! ", nil},
+		{`!\ `, nil},
+		{" #", []string{" #"}},
+		{" !x", []string{" !x"}},
+	}
+	for _, tt := range tests {
+		m := setupMatcher(t, tt.line+"\n")
+		var got []string
+		for _, p := range paths {
+			if m.Match(p) {
+				got = append(got, p)
+			}
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%q matched %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestMatchRootAndDotPaths(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	for _, opts := range [][]gitignore.Option{nil, {gitignore.WithDFA()}} {
+		m := gitignore.New(t.TempDir(), opts...)
+		m.AddPatterns([]byte("*\n!/src/\n/src/*.log\n"), "")
+
+		// The root is never ignored, however it is written: git has no
+		// way to skip it.
+		for _, p := range []string{"", ".", "./", "././"} {
+			if m.Match(p) || m.MatchFull(p) {
+				t.Errorf("Match(%q) = true, want the root not to be ignored", p)
+			}
+			if r := m.MatchDetail(p); r.Matched {
+				t.Errorf("MatchDetail(%q) = %+v, want no match", p, r)
+			}
+		}
+		if m.MatchPath("", true) || m.MatchPath(".", true) {
+			t.Error("MatchPath on the root = true, want false")
+		}
+		if m.MatchSegments(nil, true) || m.MatchSegments([]string{"."}, true) {
+			t.Error("MatchSegments on the root = true, want false")
+		}
+
+		// A leading "./" is dropped.
+		tests := []struct {
+			path string
+			want bool
+		}{
+			{"./src/", false},
+			{"./src/a.log", true},
+			{"././src/a.go", false},
+			{"./b.go", true},
+			{".hidden", true},
+			{"./.hidden", true},
+		}
+		for _, tt := range tests {
+			if got := m.Match(tt.path); got != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		}
+		if r := m.MatchDetail("./src/a.log"); r.Pattern != "/src/*.log" {
+			t.Errorf("MatchDetail(./src/a.log) = %+v, want /src/*.log", r)
+		}
+		if !m.MatchSegments([]string{".", "src", "a.log"}, false) {
+			t.Error("MatchSegments(./src/a.log) = false, want true")
+		}
+	}
+}
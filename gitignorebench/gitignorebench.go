@@ -0,0 +1,326 @@
+// Package gitignorebench generates synthetic repositories shaped like
+// real monorepos and runs a standard set of benchmarks over them, so the
+// performance of github.com/git-pkgs/gitignore can be compared across
+// releases and machines.
+//
+// Trees are generated deterministically from a Profile: the same profile
+// always yields the same files and .gitignore contents. To benchmark a
+// release, call RunAll from a benchmark in your own module:
+//
+//	func BenchmarkGitignore(b *testing.B) {
+//		gitignorebench.RunAll(b)
+//	}
+package gitignorebench
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+// Profile describes the shape of a generated repository: a root
+// .gitignore, and packages under one directory that each have their own
+// .gitignore and a tree of directories and files below it.
+type Profile struct {
+	Name string
+
+	Prefix   string // directory holding the packages, such as "packages"
+	Packages int    // number of packages
+	Depth    int    // directory levels below each package
+	Fanout   int    // subdirectories in each directory above Depth
+	Files    int    // files in each directory
+
+	Dirs []string // directory names, used in turn
+	Exts []string // file name suffixes, used in turn
+
+	RootIgnore    string // contents of the root .gitignore
+	PackageIgnore string // contents of each package's .gitignore
+}
+
+// Node is a JavaScript workspace monorepo with build output, caches and
+// installed dependencies inside its packages.
+var Node = Profile{
+	Name:     "node",
+	Prefix:   "packages",
+	Packages: 50,
+	Depth:    3,
+	Fanout:   3,
+	Files:    8,
+	Dirs:     []string{"src", "node_modules", "components", "dist", "lib", "__tests__", ".next", "coverage", "utils"},
+	Exts:     []string{".ts", ".tsx", ".js", ".json", ".map", ".log", ".css", ".min.js", ".d.ts"},
+	RootIgnore: `node_modules/
+dist/
+build/
+coverage/
+.next/
+.turbo/
+*.log
+npm-debug.log*
+yarn-error.log*
+.env
+.env.*
+!.env.example
+.DS_Store
+*.tsbuildinfo
+`,
+	PackageIgnore: `/dist
+/build
+*.map
+!/public/*.map
+*.min.js
+.cache/
+`,
+}
+
+// Go is a Go monorepo of services with generated code, vendored
+// dependencies and build artifacts.
+var Go = Profile{
+	Name:     "go",
+	Prefix:   "services",
+	Packages: 40,
+	Depth:    3,
+	Fanout:   3,
+	Files:    8,
+	Dirs:     []string{"internal", "cmd", "pkg", "vendor", "testdata", "bin", "api", "gen"},
+	Exts:     []string{".go", "_test.go", ".pb.go", ".out", ".prof", ".test", ".yaml", ".sql"},
+	RootIgnore: `/bin/
+vendor/
+*.exe
+*.test
+*.out
+*.prof
+coverage.*
+go.work.sum
+.idea/
+.vscode/
+`,
+	PackageIgnore: `/bin
+*.pb.go
+!api/**/*.pb.go
+gen/
+testdata/**/*.golden
+`,
+}
+
+// Python is a Python monorepo of projects with virtual environments,
+// bytecode caches and packaging output.
+var Python = Profile{
+	Name:     "python",
+	Prefix:   "projects",
+	Packages: 40,
+	Depth:    3,
+	Fanout:   3,
+	Files:    8,
+	Dirs:     []string{"src", "tests", "__pycache__", ".venv", "build", "docs", ".pytest_cache", "notebooks", "dist"},
+	Exts:     []string{".py", ".pyc", ".pyi", ".ipynb", ".cfg", ".log", ".so", ".txt"},
+	RootIgnore: `__pycache__/
+*.py[cod]
+*$py.class
+*.so
+.venv/
+venv/
+build/
+dist/
+*.egg-info/
+.pytest_cache/
+.mypy_cache/
+.coverage
+htmlcov/
+`,
+	PackageIgnore: `/build
+/dist
+*.log
+.ipynb_checkpoints/
+docs/_build/
+!docs/_build/.keep
+`,
+}
+
+// Profiles returns the built-in profiles.
+func Profiles() []Profile {
+	return []Profile{Node, Go, Python}
+}
+
+// Tree is a repository generated from a Profile.
+type Tree struct {
+	// Gitignores holds the contents of each .gitignore by the directory
+	// it is in, "" for the root.
+	Gitignores map[string]string
+
+	// Paths lists every file and directory, .gitignore files included,
+	// slash-separated and depth first. Directories end with a slash, as
+	// gitignore.Matcher.Match expects.
+	Paths []string
+}
+
+// Tree generates the repository described by p.
+func (p Profile) Tree() *Tree {
+	t := &Tree{Gitignores: map[string]string{"": p.RootIgnore}}
+	t.Paths = append(t.Paths, ".gitignore", p.Prefix+"/")
+	for i := range p.Packages {
+		dir := fmt.Sprintf("%s/pkg%d", p.Prefix, i)
+		t.Gitignores[dir] = p.PackageIgnore
+		t.Paths = append(t.Paths, dir+"/", dir+"/.gitignore")
+		p.fill(t, dir, 0, i)
+	}
+	return t
+}
+
+// fill adds the files and subdirectories of dir, at the given depth
+// below its package. seed varies the names from one directory to the
+// next.
+func (p Profile) fill(t *Tree, dir string, depth, seed int) {
+	for j := range p.Files {
+		name := fmt.Sprintf("file%d%s", j, pick(p.Exts, seed+j))
+		t.Paths = append(t.Paths, dir+"/"+name)
+	}
+	if depth == p.Depth {
+		return
+	}
+	for k := range p.Fanout {
+		name := pick(p.Dirs, seed+k)
+		if k >= len(p.Dirs) {
+			name += fmt.Sprint(k)
+		}
+		sub := dir + "/" + name
+		t.Paths = append(t.Paths, sub+"/")
+		p.fill(t, sub, depth+1, seed*p.Fanout+k+1)
+	}
+}
+
+func pick(names []string, i int) string {
+	if len(names) == 0 {
+		return ""
+	}
+	return names[i%len(names)]
+}
+
+// WriteTo creates the tree under root, with an empty .git directory so
+// that root is treated as a repository. Files other than .gitignore
+// files are empty.
+func (t *Tree) WriteTo(root string) error {
+	if err := os.MkdirAll(filepath.Join(root, ".git", "info"), 0755); err != nil {
+		return err
+	}
+	for _, p := range t.Paths {
+		full := filepath.Join(root, filepath.FromSlash(p))
+		if strings.HasSuffix(p, "/") {
+			if err := os.MkdirAll(full, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.WriteFile(full, []byte(t.content(p)), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FS returns the tree as an in-memory filesystem, for gitignore.WalkFS.
+// fstest.MapFS lists a directory by scanning every file, so it suits
+// tests better than benchmarks.
+func (t *Tree) FS() fstest.MapFS {
+	fsys := fstest.MapFS{".git/info": {Mode: fs.ModeDir | 0755}}
+	for _, p := range t.Paths {
+		if strings.HasSuffix(p, "/") {
+			fsys[strings.TrimSuffix(p, "/")] = &fstest.MapFile{Mode: fs.ModeDir | 0755}
+			continue
+		}
+		fsys[p] = &fstest.MapFile{Data: []byte(t.content(p)), Mode: 0644}
+	}
+	return fsys
+}
+
+// content returns the contents of the file at p.
+func (t *Tree) content(p string) string {
+	if path.Base(p) != ".gitignore" {
+		return ""
+	}
+	dir := path.Dir(p)
+	if dir == "." {
+		dir = ""
+	}
+	return t.Gitignores[dir]
+}
+
+// Run runs the standard benchmarks for p, passing opts to the Matcher or
+// walk under test:
+//
+//   - load: LoadDirectory on the tree
+//   - match: Match on every path in the tree, reported per path
+//   - walk: Walk over the tree on disk
+//   - walkfs: WalkFS over the tree on disk, through os.DirFS
+//
+// The tree is written to a temporary directory first. As with New, the
+// user's global excludes apply; set GIT_CONFIG_GLOBAL and XDG_CONFIG_HOME
+// to leave them out of the results.
+func Run(b *testing.B, p Profile, opts ...gitignore.Option) {
+	t := p.Tree()
+	root := b.TempDir()
+	if err := t.WriteTo(root); err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("load", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := gitignore.LoadDirectory(root, opts...); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("match", func(b *testing.B) {
+		m, err := gitignore.LoadDirectory(root, opts...)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for b.Loop() {
+			for _, p := range t.Paths {
+				m.Match(p)
+			}
+		}
+		b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*len(t.Paths)), "ns/path")
+	})
+
+	b.Run("walk", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if err := gitignore.Walk(root, skip, opts...); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	fsys := os.DirFS(root)
+	b.Run("walkfs", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if err := gitignore.WalkFS(fsys, skip, opts...); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// RunAll runs Run for each of the built-in profiles, as sub-benchmarks
+// named after them.
+func RunAll(b *testing.B, opts ...gitignore.Option) {
+	for _, p := range Profiles() {
+		b.Run(p.Name, func(b *testing.B) {
+			Run(b, p, opts...)
+		})
+	}
+}
+
+func skip(string, fs.DirEntry) error { return nil }
@@ -0,0 +1,91 @@
+package gitignorebench_test
+
+import (
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+	"github.com/git-pkgs/gitignore/gitignorebench"
+)
+
+// small shrinks p so the tests stay quick.
+func small(p gitignorebench.Profile) gitignorebench.Profile {
+	p.Packages = 3
+	p.Depth = 2
+	return p
+}
+
+func TestTree(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	for _, p := range gitignorebench.Profiles() {
+		t.Run(p.Name, func(t *testing.T) {
+			p := small(p)
+			tree := p.Tree()
+			if !slices.Equal(tree.Paths, p.Tree().Paths) {
+				t.Fatal("Tree is not deterministic")
+			}
+			if len(tree.Gitignores) != p.Packages+1 {
+				t.Errorf("got %d .gitignore files, want %d", len(tree.Gitignores), p.Packages+1)
+			}
+
+			root := t.TempDir()
+			if err := tree.WriteTo(root); err != nil {
+				t.Fatal(err)
+			}
+			m, err := gitignore.LoadDirectory(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want []string
+			for _, path := range tree.Paths {
+				if !m.Match(path) {
+					want = append(want, path)
+				}
+			}
+			if len(want) == len(tree.Paths) {
+				t.Error("expected the profile's patterns to ignore some paths")
+			}
+
+			// A walk, on disk or in memory, visits what Match leaves in,
+			// apart from paths under an ignored directory.
+			got := walkPaths(t, func(fn func(string, fs.DirEntry) error) error {
+				return gitignore.Walk(root, fn)
+			})
+			gotFS := walkPaths(t, func(fn func(string, fs.DirEntry) error) error {
+				return gitignore.WalkFS(tree.FS(), fn)
+			})
+			if !slices.Equal(got, gotFS) {
+				t.Errorf("Walk and WalkFS disagree:\n%v\n%v", got, gotFS)
+			}
+			for _, path := range got {
+				if !slices.Contains(want, path) {
+					t.Errorf("Walk visited %s, which Match ignores", path)
+				}
+			}
+		})
+	}
+}
+
+// walkPaths collects the paths visited by walk in the form of
+// Tree.Paths.
+func walkPaths(t *testing.T, walk func(func(string, fs.DirEntry) error) error) []string {
+	t.Helper()
+	var paths []string
+	err := walk(func(path string, d fs.DirEntry) error {
+		path = filepath.ToSlash(path)
+		if d.IsDir() {
+			path += "/"
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(paths)
+	return paths
+}
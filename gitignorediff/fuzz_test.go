@@ -0,0 +1,28 @@
+//go:build gitdiff
+
+package gitignorediff_test
+
+import (
+	"testing"
+
+	"github.com/git-pkgs/gitignore/gitignorediff"
+)
+
+// FuzzDiff checks the case generated from each seed against git.
+func FuzzDiff(f *testing.F) {
+	for seed := range uint64(16) {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, seed uint64) {
+		t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+		t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+		t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+		ds, err := gitignorediff.Check(gitignorediff.NewGenerator(seed).Case())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range ds {
+			t.Error(d)
+		}
+	})
+}
@@ -0,0 +1,259 @@
+// Package gitignorediff checks github.com/git-pkgs/gitignore against git
+// itself. It generates random .gitignore files and trees of paths, asks
+// both git check-ignore and a gitignore.Matcher which paths are ignored,
+// and reports every path they disagree on.
+//
+// Run needs a git binary on PATH. Both sides see the user's git config
+// and global excludes, so they agree on those; set GIT_CONFIG_GLOBAL to
+// /dev/null and GIT_CONFIG_NOSYSTEM to 1 to leave them out. The Matcher
+// is built at the default gitignore.CompatLatest; the generated trees
+// have no symlinks, the one thing the levels differ on, so a git older
+// than 2.32 on PATH is as good a reference. A fuzz target
+// that drives the generator is in this package's tests, behind the
+// gitdiff build tag:
+//
+//	go test -tags gitdiff -fuzz FuzzDiff github.com/git-pkgs/gitignore/gitignorediff
+package gitignorediff
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+// Case is a .gitignore and the paths to check against it.
+type Case struct {
+	Patterns string   // contents of the root .gitignore
+	Paths    []string // slash-separated; directories end with a slash
+}
+
+// Divergence is a path that git and the Matcher disagree on.
+type Divergence struct {
+	Patterns string
+	Path     string
+	Git      bool // whether git check-ignore reports Path as ignored
+	Matcher  bool // whether the Matcher does
+}
+
+func (d Divergence) String() string {
+	return fmt.Sprintf("%s: git ignored=%v, gitignore ignored=%v, patterns:\n%s",
+		d.Path, d.Git, d.Matcher, d.Patterns)
+}
+
+// Generator produces random cases. The same seed always produces the
+// same sequence of cases.
+type Generator struct {
+	rand *rand.Rand
+}
+
+// NewGenerator returns a Generator seeded with seed.
+func NewGenerator(seed uint64) *Generator {
+	return &Generator{rand: rand.New(rand.NewPCG(seed, seed))}
+}
+
+// names are the path components of generated trees. They overlap so
+// that patterns built from the pieces below match some of them.
+var names = []string{"a", "b", "ab", "ba", "a.b", "b.a", "aa", "c"}
+
+// pieces are the segments of generated patterns.
+var pieces = []string{
+	"a", "b", "ab", "a.b", "c",
+	"*", "?", "**", "a*", "*b", "*.b", "a?", "[ab]", "[!a]", "[a-b]*", `\a`,
+}
+
+// Case returns the next random case.
+func (g *Generator) Case() Case {
+	var b strings.Builder
+	for range 1 + g.rand.IntN(5) {
+		b.WriteString(g.pattern())
+		b.WriteByte('\n')
+	}
+	return Case{Patterns: b.String(), Paths: g.tree()}
+}
+
+func (g *Generator) pattern() string {
+	segs := make([]string, 1+g.rand.IntN(3))
+	for i := range segs {
+		segs[i] = pieces[g.rand.IntN(len(pieces))]
+	}
+	p := strings.Join(segs, "/")
+	switch g.rand.IntN(5) {
+	case 0:
+		p = "/" + p
+	case 1:
+		p = "**/" + p
+	}
+	switch g.rand.IntN(6) {
+	case 0, 1:
+		p += "/"
+	case 2:
+		p += "/**"
+	}
+	if g.rand.IntN(4) == 0 {
+		p = "!" + p
+	}
+	return p
+}
+
+// tree returns a random set of paths, with every directory that has
+// entries listed and marked as a directory. Leaves are files or empty
+// directories.
+func (g *Generator) tree() []string {
+	dirs := map[string]bool{}
+	leaves := map[string]bool{}
+	for range 4 + g.rand.IntN(12) {
+		var p string
+		for range 1 + g.rand.IntN(4) {
+			p = path.Join(p, names[g.rand.IntN(len(names))])
+		}
+		leaves[p] = true
+		for d := path.Dir(p); d != "."; d = path.Dir(d) {
+			dirs[d] = true
+		}
+	}
+	var paths []string
+	for d := range dirs {
+		paths = append(paths, d+"/")
+	}
+	// Leaves are visited in order so that the same seed gives the same
+	// tree.
+	for _, p := range slices.Sorted(maps.Keys(leaves)) {
+		if dirs[p] {
+			continue
+		}
+		if g.rand.IntN(4) == 0 {
+			p += "/"
+		}
+		paths = append(paths, p)
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+// Check runs c through git check-ignore, in a new repository, and through
+// a Matcher created with opts for the same tree, and returns the paths on
+// which they differ.
+//
+// Like git, a path counts as ignored if it or any directory above it
+// is, so the Matcher is asked with MatchFull.
+func Check(c Case, opts ...gitignore.Option) ([]Divergence, error) {
+	root, err := os.MkdirTemp("", "gitignorediff")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(root)
+	if err := writeCase(root, c); err != nil {
+		return nil, err
+	}
+
+	ignored, err := checkIgnore(root, c.Paths)
+	if err != nil {
+		return nil, err
+	}
+	m := gitignore.New(root, opts...)
+	var ds []Divergence
+	for _, p := range c.Paths {
+		got := m.MatchFull(p)
+		if want := ignored[strings.TrimSuffix(p, "/")]; got != want {
+			ds = append(ds, Divergence{Patterns: c.Patterns, Path: p, Git: want, Matcher: got})
+		}
+	}
+	return ds, nil
+}
+
+// Run checks n cases from NewGenerator(seed) and returns the divergences
+// found in all of them.
+func Run(seed uint64, n int, opts ...gitignore.Option) ([]Divergence, error) {
+	g := NewGenerator(seed)
+	var ds []Divergence
+	for range n {
+		d, err := Check(g.Case(), opts...)
+		if err != nil {
+			return ds, err
+		}
+		ds = append(ds, d...)
+	}
+	return ds, nil
+}
+
+// writeCase creates a git repository at root holding c.
+func writeCase(root string, c Case) error {
+	if err := git(root, nil, nil, "init", "-q"); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte(c.Patterns), 0644); err != nil {
+		return err
+	}
+	for _, p := range c.Paths {
+		full := filepath.Join(root, filepath.FromSlash(p))
+		if strings.HasSuffix(p, "/") {
+			if err := os.MkdirAll(full, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(full, nil, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkIgnore returns the paths git check-ignore reports as ignored,
+// without trailing slashes.
+func checkIgnore(root string, paths []string) (map[string]bool, error) {
+	var in bytes.Buffer
+	for _, p := range paths {
+		in.WriteString(strings.TrimSuffix(p, "/"))
+		in.WriteByte(0)
+	}
+	var out bytes.Buffer
+	err := git(root, &in, &out, "check-ignore", "-z", "--stdin")
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && exit.ExitCode() == 1 {
+		// Exit status 1 means that no path is ignored.
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ignored := map[string]bool{}
+	for p := range strings.SplitSeq(out.String(), "\x00") {
+		if p != "" {
+			ignored[p] = true
+		}
+	}
+	return ignored, nil
+}
+
+// git runs git with args in dir. Its error includes what git wrote to
+// standard error.
+func git(dir string, stdin io.Reader, stdout io.Writer, args ...string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			return fmt.Errorf("git %s: %w: %s", args[0], err, bytes.TrimSpace(stderr.Bytes()))
+		}
+		return err
+	}
+	return nil
+}
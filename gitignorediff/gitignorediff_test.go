@@ -0,0 +1,59 @@
+package gitignorediff_test
+
+import (
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/git-pkgs/gitignore/gitignorediff"
+)
+
+func TestGenerator(t *testing.T) {
+	a, b := gitignorediff.NewGenerator(7), gitignorediff.NewGenerator(7)
+	for range 20 {
+		ca, cb := a.Case(), b.Case()
+		if ca.Patterns != cb.Patterns || !slices.Equal(ca.Paths, cb.Paths) {
+			t.Fatal("the same seed gave different cases")
+		}
+		for _, p := range ca.Paths {
+			if p == "" || p[0] == '/' {
+				t.Errorf("bad path %q", p)
+			}
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	c := gitignorediff.Case{
+		Patterns: "*.log\n!keep.log\nbuild/\n/dist\n",
+		Paths: []string{
+			"a.log", "keep.log", "build/", "build/x.go", "dist/",
+			"src/", "src/a.log", "src/dist/", "src/main.go",
+		},
+	}
+	crlf := c
+	crlf.Patterns = strings.ReplaceAll(c.Patterns, "\n", "\r\n")
+	// Lines of blanks and escaped spaces, which only git's own trimming
+	// decides.
+	blank := gitignorediff.Case{
+		Patterns: "   \n\t\n\\ \n \\ \n\\\\ \n!\\ \n",
+		Paths:    []string{" ", "  ", "\t", " \t", "\\", "x"},
+	}
+	for _, c := range []gitignorediff.Case{c, crlf, blank} {
+		ds, err := gitignorediff.Check(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range ds {
+			t.Error(d)
+		}
+	}
+}
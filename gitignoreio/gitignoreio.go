@@ -0,0 +1,205 @@
+// Package gitignoreio fetches .gitignore templates from the gitignore.io
+// API, which Toptal hosts, for init-style commands in CLIs built on
+// github.com/git-pkgs/gitignore. It is a package of its own so that
+// programs that only match paths don't link in an HTTP client.
+//
+// A Client keeps what it fetches in a cache directory, and when the API
+// can't be reached it falls back to a stale cache entry, then to the
+// templates embedded in the gitignore package, so a command keeps
+// working offline:
+//
+//	c := &gitignoreio.Client{CacheDir: filepath.Join(cacheDir, "gitignoreio")}
+//	data, err := c.Generate(ctx, "go", "node", "macos")
+package gitignoreio
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+// DefaultBaseURL is the API a Client with no BaseURL uses.
+const DefaultBaseURL = "https://www.toptal.com/developers/gitignore/api"
+
+// DefaultMaxAge is how long a cache entry stays fresh for a Client with
+// no MaxAge.
+const DefaultMaxAge = 24 * time.Hour
+
+// maxResponse bounds the bytes read from the API for one request.
+const maxResponse = 8 << 20
+
+// Client fetches templates from the API. Its zero value fetches from
+// DefaultBaseURL with http.DefaultClient and caches nothing.
+type Client struct {
+	BaseURL    string        // without a trailing slash; DefaultBaseURL if empty
+	HTTPClient *http.Client  // http.DefaultClient if nil
+	CacheDir   string        // directory for cached responses; none if empty
+	MaxAge     time.Duration // how long a cached response is used without asking the API; DefaultMaxAge if zero
+}
+
+// Generate returns the .gitignore the API generates for the named
+// templates, such as "go", "node" and "macos", as its list names them.
+// Names are sent in lower case, in the order given.
+//
+// A fresh cache entry is used without asking the API. If the API can't
+// be reached or fails, Generate returns a stale cache entry, if there is
+// one, or else gitignore.Generate's output for the names, which knows
+// fewer templates and names them differently, as "macOS" for "macos",
+// though in any case. The error is returned only if all of them fail.
+func (c *Client) Generate(ctx context.Context, names ...string) ([]byte, error) {
+	if len(names) == 0 {
+		return nil, errors.New("gitignoreio: no template names")
+	}
+	lower := make([]string, len(names))
+	for i, n := range names {
+		if n == "" || strings.ContainsAny(n, ",/\\") {
+			return nil, fmt.Errorf("gitignoreio: invalid template name %q", n)
+		}
+		lower[i] = url.PathEscape(strings.ToLower(n))
+	}
+	key := strings.Join(lower, ",")
+	data, err := c.get(ctx, key)
+	if err == nil {
+		return data, nil
+	}
+	data, ferr := gitignore.Generate(names...)
+	if ferr != nil {
+		return nil, errors.Join(err, ferr)
+	}
+	return data, nil
+}
+
+// List returns the names of the templates the API has. If the API can't
+// be reached, it falls back as Generate does, to the lower-cased names of
+// gitignore.Templates last, so it doesn't fail.
+func (c *Client) List(ctx context.Context) []string {
+	data, err := c.get(ctx, "list?format=lines")
+	if err != nil {
+		names := gitignore.Templates()
+		for i, n := range names {
+			names[i] = strings.ToLower(n)
+		}
+		return names
+	}
+	return strings.FieldsFunc(string(data), func(r rune) bool {
+		return r == ',' || r == '\n' || r == '\r' || r == ' '
+	})
+}
+
+// get returns the API's response for path, from the cache if fresh
+// enough, or from the API, or from the cache however old.
+func (c *Client) get(ctx context.Context, path string) ([]byte, error) {
+	cached, age, cerr := c.readCache(path)
+	maxAge := c.MaxAge
+	if maxAge == 0 {
+		maxAge = DefaultMaxAge
+	}
+	if cerr == nil && age < maxAge {
+		return cached, nil
+	}
+	data, err := c.fetch(ctx, path)
+	if err != nil {
+		if cerr == nil {
+			return cached, nil
+		}
+		return nil, err
+	}
+	_ = c.writeCache(path, data)
+	return data, nil
+}
+
+// fetch asks the API for path.
+func (c *Client) fetch(ctx context.Context, path string) ([]byte, error) {
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponse))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("gitignoreio: GET %s: %s", req.URL, resp.Status)
+	}
+	// An unknown name is reported in the body, which older deployments
+	// send with a 200.
+	if bytes.Contains(data, []byte("#!! ERROR: ")) {
+		return nil, fmt.Errorf("gitignoreio: GET %s: %s", req.URL, bytes.TrimSpace(data))
+	}
+	return data, nil
+}
+
+// cachePath returns the file caching the response for path, or "".
+func (c *Client) cachePath(path string) string {
+	if c.CacheDir == "" {
+		return ""
+	}
+	name := strings.NewReplacer("?", "_", "=", "_").Replace(path)
+	return filepath.Join(c.CacheDir, name+".gitignore")
+}
+
+// readCache returns the cached response for path and its age.
+func (c *Client) readCache(path string) ([]byte, time.Duration, error) {
+	file := c.cachePath(path)
+	if file == "" {
+		return nil, 0, os.ErrNotExist
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, 0, err
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, time.Since(info.ModTime()), nil
+}
+
+// writeCache caches data as the response for path, through a temporary
+// file renamed into place so a reader never sees part of it.
+func (c *Client) writeCache(path string, data []byte) error {
+	file := c.cachePath(path)
+	if file == "" {
+		return nil
+	}
+	if err := os.MkdirAll(c.CacheDir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.CacheDir, filepath.Base(file)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
@@ -0,0 +1,112 @@
+package gitignoreio_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/git-pkgs/gitignore/gitignoreio"
+)
+
+// newAPI returns a server answering as the API does, and a count of the
+// requests it gets.
+func newAPI(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	var n atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n.Add(1)
+		name := strings.TrimPrefix(r.URL.Path, "/api/")
+		switch {
+		case name == "list":
+			w.Write([]byte("c,go\nnode,macos\n"))
+		case name == "bogus":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("#!! ERROR: bogus is undefined. Use list command to see defined gitignore types !!#\n"))
+		default:
+			w.Write([]byte("# Created by test/api/" + name + "\n\n### " + name + " ###\n*.x\n"))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &n
+}
+
+func TestGenerate(t *testing.T) {
+	srv, n := newAPI(t)
+	dir := t.TempDir()
+	c := &gitignoreio.Client{BaseURL: srv.URL + "/api", CacheDir: dir}
+	ctx := context.Background()
+
+	data, err := c.Generate(ctx, "Go", "node")
+	if err != nil || !strings.Contains(string(data), "### go,node ###") {
+		t.Fatalf("Generate = %q, %v", data, err)
+	}
+	// Cached, so the API isn't asked again.
+	if data, err = c.Generate(ctx, "go", "NODE"); err != nil || !strings.Contains(string(data), "### go,node ###") || n.Load() != 1 {
+		t.Errorf("cached Generate = %q, %v after %d requests", data, err, n.Load())
+	}
+
+	// A stale entry is fetched again.
+	file := filepath.Join(dir, "go,node.gitignore")
+	old := time.Now().Add(-2 * gitignoreio.DefaultMaxAge)
+	if err := os.Chtimes(file, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Generate(ctx, "go", "node"); err != nil || n.Load() != 2 {
+		t.Errorf("stale Generate: %v after %d requests", err, n.Load())
+	}
+
+	if _, err := c.Generate(ctx, "a/b"); err == nil {
+		t.Error("Generate(a/b): want an error")
+	}
+	if _, err := c.Generate(ctx); err == nil {
+		t.Error("Generate(): want an error")
+	}
+}
+
+func TestGenerateOffline(t *testing.T) {
+	srv, _ := newAPI(t)
+	dir := t.TempDir()
+	c := &gitignoreio.Client{BaseURL: srv.URL + "/api", CacheDir: dir, MaxAge: time.Nanosecond}
+	ctx := context.Background()
+	if _, err := c.Generate(ctx, "rust"); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	// A stale entry does when the API is down.
+	data, err := c.Generate(ctx, "rust")
+	if err != nil || !strings.Contains(string(data), "### rust ###") {
+		t.Errorf("Generate(rust) = %q, %v, want the cached entry", data, err)
+	}
+	// Then the embedded templates.
+	data, err = c.Generate(ctx, "go", "macos")
+	if err != nil || !strings.Contains(string(data), "### Go ###") || !strings.Contains(string(data), ".DS_Store") {
+		t.Errorf("Generate(go, macos) = %q, %v, want the embedded templates", data, err)
+	}
+	if _, err := c.Generate(ctx, "nosuchlanguage"); err == nil {
+		t.Error("Generate(nosuchlanguage): want an error with nothing to fall back on")
+	}
+	if names := c.List(ctx); len(names) == 0 || names[0] != "c" {
+		t.Errorf("List() = %v, want the embedded templates", names)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	srv, _ := newAPI(t)
+	c := &gitignoreio.Client{BaseURL: srv.URL + "/api"}
+	ctx := context.Background()
+	// The API doesn't know it and there is no embedded template.
+	_, err := c.Generate(ctx, "bogus")
+	if err == nil || !strings.Contains(err.Error(), "404") || !strings.Contains(err.Error(), "no template named bogus") {
+		t.Errorf("Generate(bogus) error = %v", err)
+	}
+
+	if names := c.List(ctx); strings.Join(names, " ") != "c go node macos" {
+		t.Errorf("List() = %v", names)
+	}
+}
@@ -0,0 +1,201 @@
+package gitignore
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// globalExcludes loads the user's global excludes file the first time a
+// lookup needs it. Finding the file means reading the git config files,
+// which costs more than a short-lived caller checking a couple of paths
+// spends on everything else. Being lowest priority, the global patterns
+// only matter for paths no other pattern matches, so they live in their
+// own Matcher consulted as a fallback.
+type globalExcludes struct {
+	once sync.Once
+	cfg  *config
+	m    *Matcher // nil if there is no global excludes file
+}
+
+// matcher returns the Matcher holding the global patterns, loading it on
+// first use.
+func (g *globalExcludes) matcher() *Matcher {
+	g.once.Do(func() {
+		g.m = sharedGlobalExcludes.matcher(g.cfg)
+	})
+	return g.m
+}
+
+// sharedGlobalExcludes caches the global excludes across Matchers, so a
+// tool creating one Matcher per repository resolves and compiles the
+// file once. Entries are checked against the size and modification time
+// of every file they were built from, and rebuilt when any changed.
+var sharedGlobalExcludes = globalCache{compiled: make(map[compiledKey]*compiledExcludes)}
+
+type globalCache struct {
+	mu       sync.Mutex
+	resolved *resolvedExcludes
+	compiled map[compiledKey]*compiledExcludes
+}
+
+// resolvedExcludes records where the global excludes file was found.
+type resolvedExcludes struct {
+	env   string // environment variables the lookup depends on
+	files []fileStamp
+	path  string
+}
+
+// compiledKey identifies a compiled global Matcher: the same file
+// compiles differently with WithDFA and with each of the patternOptions.
+type compiledKey struct {
+	path string
+	dfa  bool
+	patternOptions
+}
+
+type compiledExcludes struct {
+	file fileStamp
+	m    *Matcher // nil if the file couldn't be read
+}
+
+// fileStamp is what a cache entry knows about a file it depends on.
+type fileStamp struct {
+	path    string
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func stampFile(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{path: path}
+	}
+	return fileStamp{path: path, exists: true, size: info.Size(), modTime: info.ModTime()}
+}
+
+// current reports whether the file still looks as it did when stamped.
+func (s fileStamp) current() bool {
+	now := stampFile(s.path)
+	return now.exists == s.exists && now.size == s.size && now.modTime.Equal(s.modTime)
+}
+
+// matcher returns the Matcher for the global excludes file under cfg, or
+// nil if there is none. Stats are per Matcher, so a config asking for them
+// gets a private copy, as does one asking for verification.
+func (c *globalCache) matcher(cfg *config) *Matcher {
+	path := c.path()
+	if path == "" {
+		return nil
+	}
+	if cfg.stats || cfg.verify {
+		return compileGlobal(path, cfg)
+	}
+
+	key := compiledKey{path: path, dfa: cfg.dfa, patternOptions: cfg.patternOptions}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e := c.compiled[key]; e != nil && e.file.current() {
+		return e.m
+	}
+	e := &compiledExcludes{file: stampFile(path)}
+	e.m = compileGlobal(path, &config{dfa: cfg.dfa, patternOptions: cfg.patternOptions})
+	c.compiled[key] = e
+	return e.m
+}
+
+// path returns the global excludes file, resolving it again only if the
+// environment or a file consulted last time has changed.
+func (c *globalCache) path() string {
+	env := globalEnv()
+	c.mu.Lock()
+	r := c.resolved
+	c.mu.Unlock()
+	if r != nil && r.env == env && allCurrent(r.files) {
+		return r.path
+	}
+
+	var read []string
+	r = &resolvedExcludes{env: env, path: globalExcludesFile(&read)}
+	for _, f := range read {
+		r.files = append(r.files, stampFile(f))
+	}
+	c.mu.Lock()
+	c.resolved = r
+	c.mu.Unlock()
+	return r.path
+}
+
+func allCurrent(files []fileStamp) bool {
+	for _, f := range files {
+		if !f.current() {
+			return false
+		}
+	}
+	return true
+}
+
+// globalEnv joins the environment variables that decide which files
+// globalExcludesFile reads.
+func globalEnv() string {
+	var b strings.Builder
+	for _, v := range []string{"GIT_CONFIG_NOSYSTEM", "GIT_CONFIG_SYSTEM", "GIT_CONFIG_GLOBAL",
+		"XDG_CONFIG_HOME", "HOME", "USERPROFILE"} {
+		b.WriteString(os.Getenv(v))
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+// compileGlobal builds a Matcher from the global excludes file at path.
+func compileGlobal(path string, cfg *config) *Matcher {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	m := newMatcher(cfg)
+	m.addPatterns(data, "", path)
+	return m
+}
+
+// globalMatcher returns the Matcher for m's global excludes, or nil.
+func (m *Matcher) globalMatcher() *Matcher {
+	if m.global == nil {
+		return nil
+	}
+	return m.global.matcher()
+}
+
+// normalize returns pathSegs as patterns are compared against them:
+// relative to the root, as fromRoot returns them, and precomposed and
+// case-folded if m is set to.
+func (m *Matcher) normalize(pathSegs []string) []string {
+	pathSegs = fromRoot(pathSegs)
+	if m.precompose {
+		pathSegs = mapSegs(pathSegs, norm.NFC.String)
+	}
+	if m.ignoreCase {
+		pathSegs = mapSegs(pathSegs, foldCase)
+	}
+	return pathSegs
+}
+
+// find returns the last pattern matching pathSegs, falling back to the
+// global excludes, as the Matcher holding it and its index there. The
+// index is -1 if no pattern matches, as it is for the root.
+func (m *Matcher) find(pathSegs []string, isDir bool) (*Matcher, int) {
+	if pathSegs = m.normalize(pathSegs); len(pathSegs) == 0 {
+		return nil, -1
+	}
+	if i := m.lookup(pathSegs, isDir); i >= 0 {
+		return m, i
+	}
+	if g := m.globalMatcher(); g != nil {
+		return g.find(pathSegs, isDir)
+	}
+	return nil, -1
+}
@@ -1,3 +1,5 @@
 module github.com/git-pkgs/gitignore
 
 go 1.25.5
+
+require golang.org/x/text v0.41.0
@@ -0,0 +1,66 @@
+package gitignore
+
+import "strings"
+
+// GoGitMatcher is a Matcher as go-git's plumbing/format/gitignore package
+// takes one: it has the Match(path []string, isDir bool) bool method of
+// that package's Matcher interface, so a project on go-git can use it
+// wherever it used the result of go-git's NewMatcher. It needs no import
+// of go-git.
+type GoGitMatcher struct {
+	m *Matcher
+}
+
+// GoGit returns m as a go-git Matcher.
+func (m *Matcher) GoGit() GoGitMatcher {
+	return GoGitMatcher{m}
+}
+
+// Match reports whether the path, split into its components from the
+// repository root, is ignored, as MatchSegments does.
+func (g GoGitMatcher) Match(path []string, isDir bool) bool {
+	return g.m.MatchSegments(path, isDir)
+}
+
+// A GoGitPattern is a pattern line as go-git's gitignore.ParsePattern
+// takes one: Text is the line as written in its file and Domain the
+// directory of that file, split into its components from the repository
+// root. go-git's own Pattern keeps neither, so callers adapt the lines
+// they read to it.
+type GoGitPattern interface {
+	Text() string
+	Domain() []string
+}
+
+// NewFromGoGit returns a Matcher for the pattern lines a go-git project
+// gives ParsePattern, so call sites built around go-git's patterns can
+// switch matchers and keep the result working where a go-git Matcher is
+// wanted:
+//
+//	m := gitignore.NewFromGoGit(lines)
+//	var matcher gogitignore.Matcher = m.GoGit()
+//
+// Each line is scoped to its domain. They are read as this package reads
+// patterns, which is as git does: lines of deeper directories should come
+// later, as ReadPatterns orders them, and wildcards, escapes, comments
+// and negations follow git where go-git's own matcher doesn't. Nothing
+// else is read, so there are no global excludes beyond those in ps.
+func NewFromGoGit[P GoGitPattern](ps []P, opts ...Option) *Matcher {
+	m := newMatcher(newConfig(opts))
+	var b strings.Builder
+	dir := ""
+	for i, p := range ps {
+		domain := strings.Join(p.Domain(), "/")
+		if i > 0 && domain != dir {
+			m.AddPatterns([]byte(b.String()), dir)
+			b.Reset()
+		}
+		dir = domain
+		b.WriteString(p.Text())
+		b.WriteByte('\n')
+	}
+	if b.Len() > 0 {
+		m.AddPatterns([]byte(b.String()), dir)
+	}
+	return m
+}
@@ -0,0 +1,65 @@
+package gitignore_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+// goGitLine is a pattern line read from the .gitignore of domain.
+type goGitLine struct {
+	text   string
+	domain []string
+}
+
+func (l goGitLine) Text() string     { return l.text }
+func (l goGitLine) Domain() []string { return l.domain }
+
+func TestNewFromGoGit(t *testing.T) {
+	ps := []goGitLine{
+		{"*.log", nil},
+		{"build/", nil},
+		{"/vendor", nil},
+		{"#comment", nil},
+		{"\\#escaped", nil},
+		{"!keep.log", []string{"src"}},
+		{"gen/**/*.go", []string{"src"}},
+		{"!#kept", []string{"src"}},
+		{"*.tmp", []string{"src", "lib"}},
+	}
+	m := gitignore.NewFromGoGit(ps)
+	var matcher interface {
+		Match(path []string, isDir bool) bool
+	} = m.GoGit()
+	for path, want := range map[string]bool{
+		"a.log":            true,
+		"src/keep.log":     false,
+		"keep.log":         true,
+		"build":            false,
+		"build/":           true,
+		"x/build/y":        true,
+		"vendor/a":         true,
+		"src/vendor":       false,
+		"#comment":         false,
+		"#escaped":         true,
+		"src/gen/a/b/c.go": true,
+		"gen/c.go":         false,
+		"src/lib/a.tmp":    true,
+		"src/a.tmp":        false,
+		"src/#kept":        false,
+	} {
+		isDir := strings.HasSuffix(path, "/")
+		segs := strings.Split(strings.TrimSuffix(path, "/"), "/")
+		if got := matcher.Match(segs, isDir); got != want {
+			t.Errorf("Match(%q) = %v, want %v", path, got, want)
+		}
+	}
+	if errs := m.Errors(); len(errs) != 0 {
+		t.Errorf("Errors() = %v", errs)
+	}
+
+	if m := gitignore.NewFromGoGit([]goGitLine(nil)); m.Match("a") {
+		t.Error("no patterns: want nothing ignored")
+	}
+}
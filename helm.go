@@ -0,0 +1,186 @@
+package gitignore
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// HelmChart decides which files Helm loads into a chart from a
+// directory, following the .helmignore rules of Helm's chart loader
+// rather than git's, for chart-packaging tools that need to agree with
+// "helm package":
+//
+//   - Only the .helmignore in the chart root is read, and its patterns
+//     are relative to the chart root. Helm's default, "templates/.?*",
+//     is always added.
+//   - A pattern is matched with path.Match. One without a slash is
+//     matched against the last segment of a path, one with a slash
+//     against the whole path; a leading slash is dropped. A trailing
+//     slash matches directories only.
+//   - "**" isn't supported: a pattern holding it is an error, as in Helm.
+//   - A path is ignored if any positive pattern matches it or any
+//     negation doesn't, so the order of the patterns doesn't matter.
+//     Helm's negations are known not to re-include anything; "!a.txt"
+//     ignores every other path, including the directories leading to
+//     a.txt.
+//   - The chart loader doesn't descend into an ignored directory.
+//
+// Unlike Helm, which follows symlinks when loading a chart, Files never
+// does, as Walk doesn't.
+type HelmChart struct {
+	root  string
+	rules []helmRule
+}
+
+// helmRule is a parsed .helmignore pattern.
+type helmRule struct {
+	glob    string // for path.Match, without the leading "!" or slashes
+	negate  bool
+	dirOnly bool
+	full    bool // matched against the whole path, not its last segment
+}
+
+// helmDefaults is the pattern Helm adds to every chart's rules.
+const helmDefaults = "templates/.?*"
+
+// errHelmDoubleStar is the error for a .helmignore pattern holding "**".
+var errHelmDoubleStar = errors.New("double-star (**) syntax is not supported")
+
+// LoadHelmChart reads the .helmignore in root, if there is one. It
+// returns a PatternError for a pattern Helm rejects, one with "**" or
+// one path.Match can't compile, as Helm fails to load the chart.
+func LoadHelmChart(root string) (*HelmChart, error) {
+	h := &HelmChart{root: root}
+	source := filepath.Join(root, ".helmignore")
+	data, err := os.ReadFile(source)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if err := h.addRules(data, source); err != nil {
+		return nil, err
+	}
+	if err := h.addRules([]byte(helmDefaults), ""); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// addRules parses the .helmignore content data, from source.
+func (h *HelmChart) addRules(data []byte, source string) error {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		perr := PatternError{Pattern: text, Source: source, Line: line}
+		if strings.Contains(text, "**") {
+			perr.Message = errHelmDoubleStar.Error()
+			return perr
+		}
+		if _, err := path.Match(text, "abc"); err != nil {
+			perr.Message = err.Error()
+			return perr
+		}
+		r := helmRule{glob: text}
+		if strings.HasPrefix(r.glob, "!") {
+			r.negate = true
+			r.glob = r.glob[1:]
+		}
+		if strings.HasSuffix(r.glob, "/") {
+			r.dirOnly = true
+			r.glob = strings.TrimSuffix(r.glob, "/")
+		}
+		if strings.HasPrefix(r.glob, "/") {
+			r.full = true
+			r.glob = strings.TrimPrefix(r.glob, "/")
+		} else {
+			r.full = strings.Contains(r.glob, "/")
+		}
+		h.rules = append(h.rules, r)
+	}
+	return s.Err()
+}
+
+// ignored reports whether Helm's rules ignore the path relPath, cleaned
+// and slash-separated, by itself.
+func (h *HelmChart) ignored(relPath string, isDir bool) bool {
+	name := relPath
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	for _, r := range h.rules {
+		if r.dirOnly && !isDir {
+			if r.negate {
+				return true
+			}
+			continue
+		}
+		subject := name
+		if r.full {
+			subject = relPath
+		}
+		if matched, _ := path.Match(r.glob, subject); matched != r.negate {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether Helm leaves relPath out of the chart. The path
+// is slash-separated and relative to the chart root, with a trailing
+// slash for a directory. Its parent directories are taken into account,
+// since the loader doesn't look inside an ignored one. The root itself,
+// and a path outside it, are never ignored.
+func (h *HelmChart) Match(relPath string) bool {
+	isDir := strings.HasSuffix(relPath, "/")
+	relPath, ok := resolvePath(relPath)
+	if !ok || relPath == "" {
+		return false
+	}
+	for i := range len(relPath) {
+		if relPath[i] == '/' && h.ignored(relPath[:i], true) {
+			return true
+		}
+	}
+	return h.ignored(relPath, isDir)
+}
+
+// Files returns the files Helm loads into the chart, slash-separated,
+// relative to the chart root and sorted. Symlinks and other files that
+// aren't regular are listed like files; Helm would follow a symlink and
+// refuses to load any other irregular file.
+func (h *HelmChart) Files() ([]string, error) {
+	var files []string
+	err := fs.WalkDir(os.DirFS(h.root), ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name == "." {
+			return nil
+		}
+		if d.IsDir() {
+			if h.ignored(name, true) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !h.ignored(name, false) {
+			files = append(files, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(files)
+	return files, nil
+}
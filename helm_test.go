@@ -0,0 +1,148 @@
+package gitignore_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestHelmChart(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".helmignore": "\xef\xbb\xbf# comment\n" +
+			"  *.tgz  \n" +
+			"/ci\n" +
+			"docs/*.md\n" +
+			"tmp/\n" +
+			".git/\n",
+		"Chart.yaml":              "",
+		"values.yaml":             "",
+		"chart.tgz":               "",
+		"sub/chart.tgz":           "", // no slash: any depth
+		"ci/values.yaml":          "",
+		"sub/ci":                  "", // a leading slash anchors to the root
+		"docs/a.md":               "",
+		"docs/deep/a.md":          "", // * doesn't cross a slash
+		"tmp/x":                   "",
+		"templates/deploy.yaml":   "",
+		"templates/.hidden":       "", // Helm's default
+		"templates/sub/.hidden":   "",
+		"templates/tmp":           "", // tmp/ only matches directories
+		"charts/dep/Chart.yaml":   "",
+		"charts/dep/.helmignore":  "Chart.yaml\n", // never read
+		"charts/dep/x.tgz":        "",
+		"charts/dep/templates/.x": "", // not the chart root's templates
+	})
+	h, err := gitignore.LoadHelmChart(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]bool{
+		"Chart.yaml":              false,
+		"chart.tgz":               true,
+		"sub/chart.tgz":           true,
+		"ci/":                     true,
+		"ci/values.yaml":          true,
+		"sub/ci":                  false,
+		"docs/a.md":               true,
+		"docs/deep/a.md":          false,
+		"tmp/":                    true,
+		"tmp/x":                   true,
+		"templates/tmp":           false,
+		"templates/.hidden":       true,
+		"templates/sub/.hidden":   false,
+		"charts/dep/Chart.yaml":   false,
+		"charts/dep/x.tgz":        true,
+		"charts/dep/templates/.x": false,
+		"":                        false,
+		"../chart.tgz":            false,
+	} {
+		if got := h.Match(path); got != want {
+			t.Errorf("Match(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	files, err := h.Files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		".helmignore",
+		"Chart.yaml",
+		"charts/dep/.helmignore",
+		"charts/dep/Chart.yaml",
+		"charts/dep/templates/.x",
+		"docs/deep/a.md",
+		"sub/ci",
+		"templates/deploy.yaml",
+		"templates/sub/.hidden",
+		"templates/tmp",
+		"values.yaml",
+	}
+	if !slices.Equal(files, want) {
+		t.Errorf("Files() = %q, want %q", files, want)
+	}
+}
+
+func TestHelmChartNegation(t *testing.T) {
+	// Helm's negations ignore every path they don't match, rather than
+	// re-including the ones they do.
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".helmignore":  "*.txt\n!keep.txt\n",
+		"keep.txt":     "",
+		"a.txt":        "",
+		"Chart.yaml":   "",
+		"sub/keep.txt": "",
+	})
+	h, err := gitignore.LoadHelmChart(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]bool{
+		"keep.txt":     true, // *.txt matches
+		"a.txt":        true,
+		"Chart.yaml":   true, // !keep.txt doesn't match
+		"sub/":         true,
+		"sub/keep.txt": true,
+	} {
+		if got := h.Match(path); got != want {
+			t.Errorf("Match(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestHelmChartErrors(t *testing.T) {
+	for _, tt := range []struct {
+		content string
+		line    int
+	}{
+		{"*.tgz\nsrc/**/*.go\n", 2},
+		{"[a-\n", 1},
+	} {
+		root := t.TempDir()
+		writeFiles(t, root, map[string]string{".helmignore": tt.content})
+		_, err := gitignore.LoadHelmChart(root)
+		var perr gitignore.PatternError
+		if !errors.As(err, &perr) {
+			t.Errorf("%q: got %v, want a PatternError", tt.content, err)
+			continue
+		}
+		if perr.Line != tt.line {
+			t.Errorf("%q: error on line %d, want %d", tt.content, perr.Line, tt.line)
+		}
+	}
+
+	// A chart without .helmignore only has the default.
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"templates/.x": "", "a.tgz": ""})
+	h, err := gitignore.LoadHelmChart(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !h.Match("templates/.x") || h.Match("a.tgz") {
+		t.Error("want only the default pattern without .helmignore")
+	}
+}
@@ -0,0 +1,446 @@
+package gitignore
+
+import (
+	"slices"
+	"strings"
+)
+
+// patternIndex narrows down which patterns need to be tried for a path.
+// Every pattern index appears in exactly one list, and lists are kept in
+// ascending order so the lookup can merge them and still visit candidates
+// from last to first, preserving last-match-wins.
+//
+// Dir-only patterns (trailing slash) are kept apart from the rest. They
+// can only match a file through one of its parent directories, so file
+// queries look them up by the parent segments alone, and a file directly
+// in the scope directory skips them entirely.
+type patternIndex struct {
+	regular patternSet
+	dirOnly patternSet
+
+	// dirSelf holds dir-only patterns with no concrete segment, like
+	// "**/". They never match descendants, only directories themselves.
+	dirSelf []int32
+}
+
+// patternSet files patterns of one class by the cheapest key that
+// rules them out.
+type patternSet struct {
+	// byPath holds anchored all-literal patterns keyed by their slash-joined
+	// segments, e.g. "config/local.yml". A path can only match if it
+	// starts with those segments. exactDepth is the most segments of any
+	// key, bounding how many prefixes a lookup tries.
+	byPath     map[string][]int32
+	exactDepth int
+
+	// byName holds patterns whose last concrete segment is a plain
+	// literal such as "node_modules" or ".DS_Store". Such a pattern can
+	// only match a path that has that literal as one of its segments.
+	byName map[string][]int32
+
+	// bySuffix holds patterns whose last concrete segment ends in a
+	// literal after its last *, such as "*.log" or "*~". A path can only
+	// match if one of its segments has that suffix.
+	bySuffix *suffixTrie
+
+	// generic holds every pattern that doesn't fit a more specific list.
+	generic []int32
+
+	// tokens filters the segments worth looking up in byName.
+	tokens tokenFilter
+}
+
+// add records pattern i, p with hot fields h, in the index.
+func (ix *patternIndex) add(i int, p *pattern, h *patternHot) {
+	switch {
+	case !p.dirOnly:
+		ix.regular.add(i, p, h)
+	case h.dirSelf:
+		ix.dirSelf = append(ix.dirSelf, int32(i))
+	default:
+		ix.dirOnly.add(i, p, h)
+	}
+}
+
+// remove takes pattern i, added with the same p and h, out of the index.
+func (ix *patternIndex) remove(i int, p *pattern, h *patternHot) {
+	switch {
+	case !p.dirOnly:
+		ix.regular.remove(i, p, h)
+	case h.dirSelf:
+		ix.dirSelf = deleteIndex(ix.dirSelf, i)
+	default:
+		ix.dirOnly.remove(i, p, h)
+	}
+}
+
+// patternList names the list of a patternSet that holds a pattern.
+type patternList uint8
+
+const (
+	inByPath patternList = iota
+	inByName
+	inBySuffix
+	inGeneric
+)
+
+// classify returns the list of a patternSet for p and its key there.
+func classify(p *pattern, h *patternHot) (patternList, string) {
+	if p.exact != 0 {
+		return inByPath, exactKey(p)
+	}
+	name, ok := literalName(p)
+	if !ok && h.literalSuffix == "" {
+		name, ok = anchorName(p)
+	}
+	switch {
+	case ok:
+		return inByName, name
+	case h.literalSuffix != "":
+		return inBySuffix, h.literalSuffix
+	}
+	return inGeneric, ""
+}
+
+func (ps *patternSet) add(i int, p *pattern, h *patternHot) {
+	switch list, key := classify(p, h); list {
+	case inByPath:
+		if ps.byPath == nil {
+			ps.byPath = make(map[string][]int32)
+		}
+		ps.byPath[key] = append(ps.byPath[key], int32(i))
+		ps.exactDepth = max(ps.exactDepth, int(p.exact))
+	case inByName:
+		if ps.byName == nil {
+			ps.byName = make(map[string][]int32)
+		}
+		if _, ok := ps.byName[key]; !ok {
+			ps.byName[key] = nil
+			ps.tokens.add(ps, nameToken(key))
+		}
+		ps.byName[key] = append(ps.byName[key], int32(i))
+	case inBySuffix:
+		if ps.bySuffix == nil {
+			ps.bySuffix = &suffixTrie{}
+		}
+		ps.bySuffix.add(key, i)
+	default:
+		ps.generic = append(ps.generic, int32(i))
+	}
+}
+
+func (ps *patternSet) remove(i int, p *pattern, h *patternHot) {
+	switch list, key := classify(p, h); list {
+	case inByPath:
+		ps.byPath[key] = deleteIndex(ps.byPath[key], i)
+	case inByName:
+		ps.byName[key] = deleteIndex(ps.byName[key], i)
+	case inBySuffix:
+		ps.bySuffix.remove(key, i)
+	default:
+		ps.generic = deleteIndex(ps.generic, i)
+	}
+}
+
+// deleteIndex removes i from the ascending list l.
+func deleteIndex(l []int32, i int) []int32 {
+	if j, ok := slices.BinarySearch(l, int32(i)); ok {
+		return slices.Delete(l, j, j+1)
+	}
+	return l
+}
+
+// anchorName returns the last concrete segment of p that contains no glob
+// syntax or escapes. Concrete segments each match exactly one path
+// segment, so a pattern like "vendor/*" can only match a path with a
+// "vendor" segment and can be filed under that name. The lookup merges
+// the lists of every name the path has in pattern order, so filing a
+// pattern away from the generic list never changes which one wins.
+func anchorName(p *pattern) (string, bool) {
+	for i := len(p.segments) - 1; i >= 0; i-- {
+		s := p.segments[i]
+		if !s.doubleStar && isLiteral(s.raw) && !gluedOn(p, i) {
+			return s.raw, true
+		}
+	}
+	return "", false
+}
+
+// gluedOn reports whether segment i of p can be read as part of the one
+// before the ** ahead of it, as "y" is in "x**/y"; see matchGlued. The
+// path then needn't have it as a segment of its own.
+func gluedOn(p *pattern, i int) bool {
+	return i >= 2 && p.segments[i-2].glue
+}
+
+// literalName returns the last concrete segment of p if it contains no
+// glob syntax or escapes.
+func literalName(p *pattern) (string, bool) {
+	for i := len(p.segments) - 1; i >= 0; i-- {
+		s := p.segments[i]
+		if s.doubleStar {
+			continue
+		}
+		if !isLiteral(s.raw) || gluedOn(p, i) {
+			return "", false
+		}
+		return s.raw, true
+	}
+	return "", false
+}
+
+// scopeNode groups the patterns loaded from one directory's .gitignore
+// (or added with that directory as their scope). Nodes form a tree keyed
+// by path segment, so a lookup only visits the scopes along the queried
+// path and never compares prefixes pattern by pattern.
+type scopeNode struct {
+	ix       patternIndex
+	children map[string]*scopeNode
+	dfa      *scopeDFA // set with WithDFA
+}
+
+// add records pattern i under the scope node for its prefix.
+func (n *scopeNode) add(i int, p *pattern, h *patternHot) {
+	n.node(p.prefixSegs).ix.add(i, p, h)
+}
+
+// remove takes pattern i out of the scope node for its prefix.
+func (n *scopeNode) remove(i int, p *pattern, h *patternHot) {
+	n.node(p.prefixSegs).ix.remove(i, p, h)
+}
+
+// node returns the scope node for the directory with the given segments,
+// creating it and any missing parents.
+func (n *scopeNode) node(prefix []string) *scopeNode {
+	for _, seg := range prefix {
+		child := n.children[seg]
+		if child == nil {
+			if n.children == nil {
+				n.children = make(map[string]*scopeNode)
+			}
+			child = &scopeNode{}
+			n.children[seg] = child
+		}
+		n = child
+	}
+	return n
+}
+
+// maxLists caps the candidate lists a lookup gathers on the stack. Paths
+// needing more fall back to scanning every pattern.
+const maxLists = 64
+
+// appendLists appends the lists of ix that could hold a match for a path
+// whose segments below this scope are rel, leaving out dirSelf unless self
+// is set. It reports false if the lists don't fit in maxLists.
+func (ix *patternIndex) appendLists(lists [][]int32, rel []string, isDir, self bool) ([][]int32, bool) {
+	lists = ix.regular.appendLists(lists, rel)
+	switch {
+	case isDir:
+		if self && len(ix.dirSelf) > 0 {
+			lists = append(lists, ix.dirSelf)
+		}
+		lists = ix.dirOnly.appendLists(lists, rel)
+	case len(rel) > 1:
+		lists = ix.dirOnly.appendLists(lists, rel[:len(rel)-1])
+	}
+	return lists, len(lists) <= maxLists
+}
+
+// appendLists appends the lists of ps keyed by any of the segments in
+// rel. It stops early once maxLists is exceeded; the caller checks.
+func (ps *patternSet) appendLists(lists [][]int32, rel []string) [][]int32 {
+	if len(ps.generic) > 0 {
+		lists = append(lists, ps.generic)
+	}
+	if ps.byPath != nil {
+		var buf [256]byte
+		key := buf[:0]
+		for k := 0; k < len(rel) && k < ps.exactDepth; k++ {
+			if k > 0 {
+				key = append(key, '/')
+			}
+			key = append(key, rel[k]...)
+			if l := ps.byPath[string(key)]; len(l) > 0 {
+				lists = append(lists, l)
+			}
+		}
+	}
+	if ps.tokens.n == 0 && ps.bySuffix == nil {
+		return lists
+	}
+	for _, s := range rel {
+		if ps.tokens.mayContain(nameToken(s)) {
+			if l := ps.byName[s]; len(l) > 0 {
+				lists = append(lists, l)
+			}
+		}
+		if ps.bySuffix != nil {
+			lists = ps.bySuffix.appendLists(lists, s)
+		}
+		if len(lists) > maxLists {
+			return lists
+		}
+	}
+	return lists
+}
+
+// lookup returns the index of the last pattern matching pathSegs, or -1.
+func (m *Matcher) lookup(pathSegs []string, isDir bool) int {
+	i := m.lookupIndex(pathSegs, isDir)
+	if m.verify {
+		m.verifyLookup(pathSegs, isDir, i)
+	}
+	if m.stats != nil {
+		m.stats.record(i)
+	}
+	return i
+}
+
+// lookupIndex is lookup without the stats bookkeeping.
+//
+// search tries candidates from the highest index down, so remembering
+// the last match in a directory and trying it first never shortens it;
+// BenchmarkMatchSameDirectory measured such a hint as a slowdown.
+func (m *Matcher) lookupIndex(pathSegs []string, isDir bool) int {
+	if m.dfa {
+		if i, ok := m.dfaLookup(pathSegs, isDir); ok {
+			return i
+		}
+	}
+	floor := -1
+	if m.dirCache != nil && len(pathSegs) > 1 {
+		floor = m.dirCache.inherited(m, pathSegs[:len(pathSegs)-1])
+	}
+	return m.search(pathSegs, isDir, true, floor)
+}
+
+// search returns the index of the last pattern above floor matching
+// pathSegs, or floor if there is none. The caller guarantees that pattern
+// floor (if not -1) matches the path, so patterns below it needn't be
+// tried. With self false, dir-only patterns that only match directories
+// themselves, and never their contents, are left out.
+func (m *Matcher) search(pathSegs []string, isDir, self bool, floor int) int {
+	// Gather the candidate lists for this path from every scope along
+	// it. The array keeps them on the stack.
+	var buf [maxLists][]int32
+	lists := buf[:0]
+	node := &m.index
+	for d := 0; node != nil; d++ {
+		var ok bool
+		if lists, ok = node.ix.appendLists(lists, pathSegs[d:], isDir, self); !ok {
+			return m.scan(pathSegs, isDir, self, floor)
+		}
+		if d == len(pathSegs) {
+			break
+		}
+		node = node.children[pathSegs[d]]
+	}
+
+	// Merge the lists from the highest index down. A path that repeats a
+	// segment name or extension contributes the same list twice, so skip
+	// repeats.
+	prev := -1
+	for {
+		best, from := -1, -1
+		for j, l := range lists {
+			if len(l) > 0 && int(l[len(l)-1]) > best {
+				best, from = int(l[len(l)-1]), j
+			}
+		}
+		if best <= floor {
+			return floor
+		}
+		lists[from] = lists[from][:len(lists[from])-1]
+		if best == prev {
+			continue
+		}
+		prev = best
+		// The scope tree already established that the path is under
+		// the pattern's prefix.
+		if m.try(best, pathSegs[m.hot[best].depth:], isDir) {
+			return best
+		}
+	}
+}
+
+// scan is the unindexed search, trying every pattern from last to first.
+func (m *Matcher) scan(pathSegs []string, isDir, self bool, floor int) int {
+	for i := len(m.patterns) - 1; i > floor; i-- {
+		h := &m.hot[i]
+		if h.shadowed || !self && h.dirSelf || int(h.depth) > len(pathSegs) {
+			continue
+		}
+		if rel, ok := underPrefix(&m.patterns[i], pathSegs); ok && m.try(i, rel, isDir) {
+			return i
+		}
+	}
+	return floor
+}
+
+// try reports whether pattern i of m matches the path segments rel,
+// which are relative to the pattern's scope, counting the attempt when
+// stats are enabled.
+func (m *Matcher) try(i int, rel []string, isDir bool) bool {
+	h := &m.hot[i]
+	if m.stats != nil {
+		m.stats.patterns[i].tried.Add(1)
+	}
+	if !h.exact && h.literalSuffix != "" && !anyHasSuffix(rel, h.literalSuffix) {
+		if m.stats != nil {
+			m.stats.patterns[i].fastRejects.Add(1)
+		}
+		return false
+	}
+	return tryPattern(&m.patterns[i], rel, isDir)
+}
+
+// tryPattern reports whether p matches the path segments rel, which are
+// relative to the pattern's scope.
+func tryPattern(p *pattern, rel []string, isDir bool) bool {
+	if p.exact != 0 {
+		return matchExact(p, rel, isDir)
+	}
+	return matchScoped(p, rel, isDir)
+}
+
+// matchExact matches an anchored all-literal pattern by comparing
+// segments: the path must start with them, and a dir-only pattern that
+// names the path itself needs the path to be a directory.
+func matchExact(p *pattern, rel []string, isDir bool) bool {
+	n := int(p.exact)
+	if len(rel) < n {
+		return false
+	}
+	for i, s := range p.segments[:n] {
+		if rel[i] != s.raw {
+			return false
+		}
+	}
+	return !p.dirOnly || isDir || len(rel) > n
+}
+
+// exactKey joins the literal segments of an exact pattern with "/".
+func exactKey(p *pattern) string {
+	var b strings.Builder
+	for i, s := range p.segments[:p.exact] {
+		if i > 0 {
+			b.WriteByte('/')
+		}
+		b.WriteString(s.raw)
+	}
+	return b.String()
+}
+
+// anyHasSuffix reports whether any segment ends with suffix. The segment
+// carrying the suffix need not be the last one: "*.log" also matches
+// "foo.log/bar" through the matched directory. The last segment is
+// checked first since it is the usual match.
+func anyHasSuffix(segs []string, suffix string) bool {
+	for i := len(segs) - 1; i >= 0; i-- {
+		if strings.HasSuffix(segs[i], suffix) {
+			return true
+		}
+	}
+	return false
+}
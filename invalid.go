@@ -0,0 +1,86 @@
+package gitignore
+
+import "strings"
+
+// InvalidPatternPolicy is what a Matcher does with a pattern that doesn't
+// compile, such as one naming an unknown POSIX class or ending in a
+// backslash. Whatever the policy, the pattern is listed by Errors.
+type InvalidPatternPolicy int
+
+const (
+	// SkipInvalid leaves the pattern out, so it matches nothing, as in
+	// git. It is the default.
+	SkipInvalid InvalidPatternPolicy = iota
+
+	// LiteralInvalid reads the pattern as the literal name it spells,
+	// each *, ?, [ and \ matching only itself: "[[:word:]].txt" matches
+	// the file of that name. A leading ! or \ escape, and the slashes,
+	// keep their meaning. A pattern with no name, like "!", is skipped.
+	LiteralInvalid
+
+	// FailOnInvalid makes LoadDirectory, LoadCache and the walks stop
+	// with the PatternError of the first invalid pattern read, including
+	// one in the global excludes. New, NewFromDirectory and AddPatterns,
+	// which return no error, skip the pattern as with SkipInvalid.
+	FailOnInvalid
+)
+
+// WithInvalidPatterns sets what to do with patterns that don't compile:
+// a linter may want to fail on them with FailOnInvalid, and a lenient
+// tool to match them as written with LiteralInvalid. The default is
+// SkipInvalid.
+func WithInvalidPatterns(policy InvalidPatternPolicy) Option {
+	return func(c *config) {
+		c.invalid = policy
+	}
+}
+
+// compileInvalid compiles line, which failed to compile, as m's policy
+// says should be done instead. It reports false if the pattern is to be
+// left out.
+func (m *Matcher) compileInvalid(line string, dirSegs []string) (pattern, bool) {
+	if m.invalid != LiteralInvalid {
+		return pattern{}, false
+	}
+	p, errMsg := m.compile(literalGlob(line), dirSegs)
+	return p, errMsg == ""
+}
+
+// literalGlob returns the pattern line with every glob character and
+// backslash escaped, past a leading ! and \ escape.
+func literalGlob(line string) string {
+	var b strings.Builder
+	if strings.HasPrefix(line, "!") {
+		b.WriteByte('!')
+		line = line[1:]
+	}
+	if len(line) >= 2 && line[0] == '\\' && (line[1] == '#' || line[1] == '!') {
+		b.WriteString(line[:2])
+		line = line[2:]
+	}
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '*', '?', '[', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(line[i])
+	}
+	return b.String()
+}
+
+// checkInvalid returns the first pattern error of the walk's Matchers if
+// the policy of its Matcher is FailOnInvalid.
+func (w *walker) checkInvalid() error {
+	if w.m.invalid != FailOnInvalid {
+		return nil
+	}
+	for _, l := range w.layers {
+		if errs := l.Errors(); len(errs) > 0 {
+			return errs[0]
+		}
+	}
+	if errs := w.m.Errors(); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
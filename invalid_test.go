@@ -0,0 +1,121 @@
+package gitignore_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+const invalidPatterns = "[[:word:]].txt\nfoo\\\n*.log\n!\n!keep[[:x:]].log\n\\#[[:x:]]\n"
+
+func TestInvalidPatternsSkip(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	for _, opts := range [][]gitignore.Option{nil, {gitignore.WithInvalidPatterns(gitignore.SkipInvalid)}} {
+		m := gitignore.New(t.TempDir(), opts...)
+		m.AddPatterns([]byte(invalidPatterns), "")
+		if len(m.Errors()) != 5 {
+			t.Errorf("Errors() = %v, want 5", m.Errors())
+		}
+		for _, p := range []string{"[[:word:]].txt", "foo\\", "keep[[:x:]].log"} {
+			if got, want := m.Match(p), p == "keep[[:x:]].log"; got != want {
+				t.Errorf("Match(%q) = %v, want %v", p, got, want)
+			}
+		}
+	}
+}
+
+func TestInvalidPatternsLiteral(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"[[:word:]].txt", true},
+		{"src/[[:word:]].txt", true},
+		{"a.txt", false},
+		{"foo\\", true},
+		{"foo", false},
+		{"a.log", true},
+		{"keep[[:x:]].log", false},
+		{"#[[:x:]]", true},
+	}
+	for _, opts := range [][]gitignore.Option{nil, {gitignore.WithDFA()}} {
+		m := gitignore.New(t.TempDir(), append(opts, gitignore.WithInvalidPatterns(gitignore.LiteralInvalid))...)
+		m.AddPatterns([]byte(invalidPatterns), "")
+		if len(m.Errors()) != 5 {
+			t.Errorf("Errors() = %v, want all 5 still listed", m.Errors())
+		}
+		for _, tt := range tests {
+			if got := m.Match(tt.path); got != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		}
+		if r := m.MatchDetail("foo\\"); r.Pattern != "foo\\" || r.Line != 2 {
+			t.Errorf("MatchDetail(foo\\) = %+v, want the pattern as written", r)
+		}
+	}
+
+	skip := gitignore.New(t.TempDir())
+	skip.AddPatterns([]byte(invalidPatterns), "")
+	lit := gitignore.New(t.TempDir(), gitignore.WithInvalidPatterns(gitignore.LiteralInvalid))
+	lit.AddPatterns([]byte(invalidPatterns), "")
+	if skip.Fingerprint() == lit.Fingerprint() {
+		t.Error("expected LiteralInvalid to change the fingerprint")
+	}
+}
+
+func TestInvalidPatternsFail(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	fail := gitignore.WithInvalidPatterns(gitignore.FailOnInvalid)
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":     "*.log\n",
+		"a/.gitignore":   "*.tmp\n",
+		"b/.gitignore":   "ok\nbad[[:x:]]\n",
+		"b/c/.gitignore": "also\\\n",
+	})
+	if _, err := gitignore.LoadDirectory(root); err != nil {
+		t.Fatalf("LoadDirectory without FailOnInvalid: %v", err)
+	}
+
+	var pe gitignore.PatternError
+	_, err := gitignore.LoadDirectory(root, fail)
+	if !errors.As(err, &pe) || pe.Pattern != "bad[[:x:]]" || pe.Line != 2 ||
+		pe.Source != filepath.Join(root, "b", ".gitignore") {
+		t.Errorf("LoadDirectory = %v, want the error for bad[[:x:]]", err)
+	}
+
+	for _, walk := range []func(func(string, fs.DirEntry) error) error{
+		func(fn func(string, fs.DirEntry) error) error { return gitignore.Walk(root, fn, fail) },
+		func(fn func(string, fs.DirEntry) error) error { return gitignore.WalkFS(os.DirFS(root), fn, fail) },
+	} {
+		err := walk(func(string, fs.DirEntry) error { return nil })
+		if !errors.As(err, &pe) || pe.Pattern != "bad[[:x:]]" {
+			t.Errorf("walk = %v, want the error for bad[[:x:]]", err)
+		}
+	}
+
+	// An invalid root pattern stops the walk before it starts.
+	writeFiles(t, root, map[string]string{".gitignore": "[[:x:]]\n"})
+	visited := 0
+	err = gitignore.Walk(root, func(string, fs.DirEntry) error {
+		visited++
+		return nil
+	}, fail)
+	if !errors.As(err, &pe) || pe.Pattern != "[[:x:]]" || visited != 0 {
+		t.Errorf("Walk = %v after %d entries, want the root pattern's error first", err, visited)
+	}
+	if m := gitignore.New(root, fail); len(m.Errors()) != 1 {
+		t.Errorf("New Errors() = %v, want the pattern skipped and listed", m.Errors())
+	}
+}
@@ -0,0 +1,169 @@
+package gitignore
+
+// Limits bounds the work done while loading patterns, so an adversarial
+// or corrupted tree, or .gitignore content uploaded by a user, can't
+// consume unbounded memory or CPU. A zero field means no limit.
+//
+// MaxFiles and MaxDepth bound walks. The others bound every Matcher
+// built with them, including the patterns given to New and AddPatterns:
+// a pattern over one of them is left out, and Errors reports it with a
+// PatternError whose Limit is set. Past MaxPatterns, the rest of the
+// file is left out as well. Bracket expressions can't nest in git's
+// syntax, so MaxBrackets bounds how many a pattern has instead. The
+// global excludes, which are the user's own, aren't limited.
+type Limits struct {
+	MaxFiles         int // nested .gitignore files loaded during the walk
+	MaxPatterns      int // total patterns held by the Matcher
+	MaxDepth         int // levels of directories entered below the root
+	MaxPatternLength int // bytes in one pattern, trailing spaces trimmed
+	MaxBrackets      int // bracket expressions in one pattern
+}
+
+// LimitError reports that loading stopped, or a pattern was left out,
+// because a limit set with WithLimits was exceeded.
+type LimitError struct {
+	Limit string // "files", "patterns", "depth", "pattern length", or "brackets"
+	Max   int    // the configured limit
+	Path  string // directory being processed, relative to the root
+}
+
+func (e *LimitError) Error() string {
+	msg := "gitignore: " + e.message()
+	if e.Path != "" {
+		msg += " at " + e.Path
+	}
+	return msg
+}
+
+func (e *LimitError) message() string {
+	return e.Limit + " limit of " + itoa(e.Max) + " exceeded"
+}
+
+// WithLimits caps the number of .gitignore files, patterns, and directory
+// levels a walk or LoadDirectory will process, and the size of each
+// pattern. Exceeding a limit stops the walk with a *LimitError.
+func WithLimits(l Limits) Option {
+	return func(c *config) {
+		c.limits = l
+	}
+}
+
+// LoadDirectory is like NewFromDirectory but reports errors instead of
+// ignoring them, including a *LimitError when a limit set with WithLimits
+// is exceeded. It returns a nil Matcher on error.
+func LoadDirectory(root string, opts ...Option) (*Matcher, error) {
+	m := New(root, opts...)
+	w := newOSWalker(root, m, nil)
+	if err := w.configure(opts); err != nil {
+		return nil, err
+	}
+	if err := w.checkLimits(0); err != nil {
+		return nil, err
+	}
+	if err := w.checkInvalid(); err != nil {
+		return nil, err
+	}
+	if err := w.walk("", 0); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// loadGitignore adds the patterns from the ignore file called file, such
+// as .gitignore, in directory rel to m, enforcing the file and pattern
+// limits.
+func (w *walker) loadGitignore(m *Matcher, rel, file string) error {
+	data, err := w.readGitignore(m, rel, file)
+	if err != nil {
+		return nil
+	}
+	w.files++
+	if limit := w.cfg.limits.MaxFiles; limit > 0 && w.files > limit {
+		return &LimitError{Limit: "files", Max: limit, Path: rel}
+	}
+	from := len(m.errors)
+	m.addIgnoreFile(data, rel, w.sourcePath(rel, file))
+	if err := firstLimit(m.errors[from:]); err != nil {
+		return err
+	}
+	return w.checkInvalid()
+}
+
+// checkLimits returns the *LimitError of the first pattern left out for
+// exceeding a limit, among the walk's Matcher's errors from index from on.
+func (w *walker) checkLimits(from int) error {
+	return firstLimit(w.m.errors[from:])
+}
+
+// firstLimit returns the *LimitError of the first of errs left out for
+// exceeding a limit, or nil.
+func firstLimit(errs []PatternError) error {
+	for _, e := range errs {
+		if e.Limit != nil {
+			return e.Limit
+		}
+	}
+	return nil
+}
+
+// lineLimit returns the limit that the pattern line, read in the scope
+// directory dir, exceeds before it is compiled, or nil.
+func (m *Matcher) lineLimit(line, dir string) *LimitError {
+	if limit := m.limits.MaxPatterns; limit > 0 && len(m.patterns) >= limit {
+		return &LimitError{Limit: "patterns", Max: limit, Path: dir}
+	}
+	if limit := m.limits.MaxPatternLength; limit > 0 && len(line) > limit {
+		return &LimitError{Limit: "pattern length", Max: limit, Path: dir}
+	}
+	return nil
+}
+
+// bracketLimit returns the limit on bracket expressions that the
+// compiled pattern p, read in the scope directory dir, exceeds, or nil.
+func (m *Matcher) bracketLimit(p *pattern, dir string) *LimitError {
+	limit := m.limits.MaxBrackets
+	if limit <= 0 {
+		return nil
+	}
+	n := 0
+	for _, s := range p.segments {
+		brackets, _ := countWildcards(s.raw)
+		n += brackets
+	}
+	if n > limit {
+		return &LimitError{Limit: "brackets", Max: limit, Path: dir}
+	}
+	return nil
+}
+
+// limitError returns the PatternError for the pattern line, at lineNum
+// in source, left out for exceeding lerr.
+func limitError(line, source string, lineNum int, lerr *LimitError) PatternError {
+	return PatternError{Pattern: line, Source: source, Line: lineNum, Message: lerr.message(), Limit: lerr}
+}
+
+// countWildcards returns the number of bracket expressions in glob, and
+// of stars outside them, read as matchSegment reads it.
+func countWildcards(glob string) (brackets, stars int) {
+	for i := 0; i < len(glob); i++ {
+		switch glob[i] {
+		case '\\':
+			i++
+		case '*':
+			stars++
+		case '[':
+			if _, next, ok := matchBracket(glob, i, 0); ok {
+				brackets++
+				i = next - 1
+			}
+		}
+	}
+	return brackets, stars
+}
+
+func (w *walker) checkDepth(rel string, depth int) error {
+	if limit := w.cfg.limits.MaxDepth; limit > 0 && depth > limit {
+		return &LimitError{Limit: "depth", Max: limit, Path: rel}
+	}
+	return nil
+}
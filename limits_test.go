@@ -0,0 +1,194 @@
+package gitignore_test
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestLoadDirectoryLimits(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":           "*.log\n",
+		"a/.gitignore":         "*.tmp\n*.bak\n",
+		"a/b/.gitignore":       "*.gen\n",
+		"a/b/c/d/.gitignore":   "*.out\n",
+		"a/b/c/d/e/f/file.txt": "x",
+	})
+
+	m, err := gitignore.LoadDirectory(root)
+	if err != nil {
+		t.Fatalf("LoadDirectory without limits: %v", err)
+	}
+	if !m.Match("a/b/c/d/x.out") {
+		t.Error("expected nested pattern to be loaded")
+	}
+
+	tests := []struct {
+		name   string
+		limits gitignore.Limits
+		limit  string
+	}{
+		{"files", gitignore.Limits{MaxFiles: 2}, "files"},
+		{"patterns", gitignore.Limits{MaxPatterns: 3}, "patterns"},
+		{"depth", gitignore.Limits{MaxDepth: 3}, "depth"},
+		{"within limits", gitignore.Limits{MaxFiles: 3, MaxPatterns: 5, MaxDepth: 6}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := gitignore.LoadDirectory(root, gitignore.WithLimits(tt.limits))
+			if tt.limit == "" {
+				if err != nil || m == nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var lerr *gitignore.LimitError
+			if !errors.As(err, &lerr) {
+				t.Fatalf("err = %v, want *LimitError", err)
+			}
+			if lerr.Limit != tt.limit {
+				t.Errorf("Limit = %q, want %q", lerr.Limit, tt.limit)
+			}
+			if m != nil {
+				t.Error("expected nil Matcher on error")
+			}
+			if !strings.Contains(err.Error(), tt.limit) {
+				t.Errorf("error %q should name the limit", err)
+			}
+		})
+	}
+}
+
+func TestWalkLimits(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	root := t.TempDir()
+	files := map[string]string{}
+	for i := range 5 {
+		files[fmt.Sprintf("d%d/.gitignore", i)] = "*.tmp\n"
+	}
+	writeFiles(t, root, files)
+
+	err := gitignore.Walk(root, func(string, fs.DirEntry) error { return nil },
+		gitignore.WithLimits(gitignore.Limits{MaxFiles: 4}))
+	var lerr *gitignore.LimitError
+	if !errors.As(err, &lerr) || lerr.Limit != "files" || lerr.Max != 4 {
+		t.Errorf("err = %v, want files LimitError with Max 4", err)
+	}
+}
+
+func TestPatternLimits(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	checkPatternLimits(t, gitignore.New(t.TempDir(), patternLimits))
+
+	var lerr *gitignore.LimitError
+	if errors.As(gitignore.PatternError{Pattern: "[", Message: "unclosed"}, &lerr) {
+		t.Error("a PatternError without a limit unwrapped to a LimitError")
+	}
+}
+
+// patternLimits sets the limits checkPatternLimits expects a Matcher to have.
+var patternLimits = gitignore.WithLimits(gitignore.Limits{MaxPatterns: 4, MaxPatternLength: 16, MaxBrackets: 2})
+
+// checkPatternLimits adds patterns exceeding each of patternLimits to m
+// and checks that they are left out and reported.
+func checkPatternLimits(t *testing.T, m *gitignore.Matcher) {
+	t.Helper()
+	long := strings.Repeat("x", 17)
+	patterns := "*.log\n" + long + "\n[ab][cd][ef]\n[ab][cd]\n\\[a]\\[b]\\[c]\n[[]\n*.tmp\n*.bak\n"
+	m.AddPatterns([]byte(patterns), "src")
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"src/a.log", true},
+		{"src/" + long, false},
+		{"src/ace", false},
+		{"src/ac", true},
+		{"src/[a][b][c]", true},
+		{"src/[", true},
+		{"src/a.tmp", false},
+	}
+	for _, tt := range tests {
+		if got := m.Match(tt.path); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	want := []struct {
+		pattern string
+		limit   string
+		max     int
+	}{
+		{long, "pattern length", 16},
+		{"[ab][cd][ef]", "brackets", 2},
+		{"*.tmp", "patterns", 4},
+	}
+	errs := m.Errors()
+	if len(errs) != len(want) {
+		t.Fatalf("Errors() = %v, want %d", errs, len(want))
+	}
+	for i, w := range want {
+		var lerr *gitignore.LimitError
+		if errs[i].Pattern != w.pattern || !errors.As(errs[i], &lerr) ||
+			lerr.Limit != w.limit || lerr.Max != w.max || lerr.Path != "src" {
+			t.Errorf("Errors()[%d] = %v, want the %s limit for %q", i, errs[i], w.limit, w.pattern)
+		}
+	}
+}
+
+func TestLoadDirectoryPatternLimits(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":   "*.log\n",
+		"a/.gitignore": "[ab][cd]\n" + strings.Repeat("?", 100) + "\n",
+	})
+	tests := []struct {
+		limits gitignore.Limits
+		limit  string
+		path   string
+	}{
+		{gitignore.Limits{MaxPatternLength: 64}, "pattern length", "a"},
+		{gitignore.Limits{MaxBrackets: 1}, "brackets", "a"},
+		{gitignore.Limits{MaxPatterns: 1}, "patterns", "a"},
+		{gitignore.Limits{MaxPatternLength: 100, MaxBrackets: 2}, "", ""},
+	}
+	for _, tt := range tests {
+		opts := []gitignore.Option{gitignore.WithLimits(tt.limits), gitignore.WithInvalidPatterns(gitignore.FailOnInvalid)}
+		_, err := gitignore.LoadDirectory(root, opts...)
+		walkErr := gitignore.WalkFS(os.DirFS(root), func(string, fs.DirEntry) error { return nil }, opts...)
+		for _, err := range []error{err, walkErr} {
+			var lerr *gitignore.LimitError
+			if tt.limit == "" {
+				if err != nil {
+					t.Errorf("%+v: unexpected error: %v", tt.limits, err)
+				}
+				continue
+			}
+			if !errors.As(err, &lerr) || lerr.Limit != tt.limit || lerr.Path != tt.path {
+				t.Errorf("%+v: err = %v, want the %s limit at %q", tt.limits, err, tt.limit, tt.path)
+			}
+		}
+	}
+
+	// The root .gitignore is limited too, before the walk starts.
+	_, err := gitignore.LoadDirectory(root, gitignore.WithLimits(gitignore.Limits{MaxPatternLength: 4}))
+	var lerr *gitignore.LimitError
+	if !errors.As(err, &lerr) || lerr.Limit != "pattern length" || lerr.Path != "" {
+		t.Errorf("err = %v, want the pattern length limit at the root", err)
+	}
+}
@@ -0,0 +1,120 @@
+package gitignore
+
+import "strings"
+
+// LintIssue is a pattern that loads without error but can't do what it
+// appears to, or does it at a cost, as reported by Lint.
+type LintIssue struct {
+	Pattern string // original pattern text
+	Source  string // file the pattern came from (empty for programmatic patterns)
+	Line    int    // 1-based line number in Source
+	Message string // what is wrong with it
+
+	// Cause is the pattern responsible, such as the one excluding the
+	// directory a negation is in. It is zero for a costly pattern.
+	Cause MatchResult
+}
+
+func (l LintIssue) String() string {
+	if l.Source != "" {
+		return l.Source + ":" + itoa(l.Line) + ": " + l.Pattern + ": " + l.Message
+	}
+	return l.Pattern + ": " + l.Message
+}
+
+// Lint reports the negations that can never take effect, because the
+// directory holding every path they match is excluded: git never looks
+// inside an excluded directory, so with "build/" and "!build/keep",
+// build/keep stays ignored. Match honors such negations, as it decides
+// each path on its own; MatchFull and git don't. Issues are in pattern
+// order, global excludes last.
+//
+// Only negations whose paths all lie under literally named directories,
+// like "!build/keep" or "!docs/api/*.md", are checked. Lint considers
+// the patterns loaded so far, so a .gitignore that would re-include the
+// directory, but hasn't been read, isn't taken into account.
+//
+// Lint also reports patterns costly enough to match that they are
+// likely hostile, like "*a*b*c*...*q" with dozens of stars in one
+// segment. Runs of stars and question marks are already cut down when a
+// pattern is compiled, so "a***b" isn't one of them.
+//
+// And it reports patterns like "*.{js,ts}" that only match literally
+// what they appear to offer alternatives for, unless the Matcher was
+// made WithBraceExpansion.
+func (m *Matcher) Lint() []LintIssue {
+	var issues []LintIssue
+	for o := m; o != nil; o = o.globalMatcher() {
+		for i := range o.patterns {
+			p := &o.patterns[i]
+			if o.hot[i].shadowed {
+				continue
+			}
+			if msg := o.costly(p); msg != "" {
+				issues = append(issues, LintIssue{
+					Pattern: o.patternText(p),
+					Source:  o.sources[p.src].path,
+					Line:    int(p.line),
+					Message: msg,
+				})
+			}
+			if open, _, _ := findBraces(o.patternText(p)); open >= 0 && !o.braces && p.re == nil {
+				issues = append(issues, LintIssue{
+					Pattern: o.patternText(p),
+					Source:  o.sources[p.src].path,
+					Line:    int(p.line),
+					Message: "braces are literal, as in git; WithBraceExpansion reads {a,b} as alternatives",
+				})
+			}
+			if !p.negate {
+				continue
+			}
+			dir := o.negationDir(p)
+			if len(dir) == 0 {
+				continue
+			}
+			// The negation itself only matches below dir, so it has no
+			// say in the directories checked.
+			owner, j, d := m.findFull(dir, true)
+			if !ignoredBy(owner, j) {
+				continue
+			}
+			issues = append(issues, LintIssue{
+				Pattern: o.patternText(p),
+				Source:  o.sources[p.src].path,
+				Line:    int(p.line),
+				Message: "never applies: directory " + strings.Join(dir[:d], "/") + " is excluded",
+				Cause:   owner.result(j),
+			})
+		}
+	}
+	return issues
+}
+
+// negationDir returns the directory that every path p matches lies
+// below: its scope, followed by the literal segments it starts with,
+// short of the one naming the path itself. It is nil if p can match
+// anywhere.
+func (m *Matcher) negationDir(p *pattern) []string {
+	segs := p.segments
+	// The segment naming the path itself: the last, or the one before
+	// the implicit trailing **. A ** written at the end stops the
+	// literals short of it anyway.
+	name := len(segs) - 1
+	if !p.dirOnly && !m.endsInDoubleStar(p) {
+		name--
+	}
+	n := 0
+	for n < name && !segs[n].doubleStar && isLiteral(segs[n].raw) {
+		n++
+	}
+	if len(p.prefixSegs) == 0 && n == 0 {
+		return nil
+	}
+	dir := make([]string, 0, len(p.prefixSegs)+n)
+	dir = append(dir, p.prefixSegs...)
+	for _, s := range segs[:n] {
+		dir = append(dir, s.raw)
+	}
+	return dir
+}
@@ -0,0 +1,73 @@
+package gitignore_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestLint(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tests := []struct {
+		patterns string
+		want     []string // each issue's pattern and excluded directory
+	}{
+		{"build/\n!build/keep\n", []string{"!build/keep", "build"}},
+		{"!build/keep\nbuild/\n", []string{"!build/keep", "build"}},
+		{"build\n!build/keep/\n", []string{"!build/keep/", "build"}},
+		{"/docs/\n!docs/api/*.md\n", []string{"!docs/api/*.md", "docs"}},
+		{"a/b/\n!a/b/**\n!a/b/c/**/d\n", []string{"!a/b/**", "a/b", "!a/b/c/**/d", "a/b"}},
+		{"build/\n!build/\n!build/keep\n", nil},
+		{"build/**\n!build/keep\n", nil},
+		{"build/*\n!build/keep\n", nil},
+		{"*.log\n!keep.log\n!**/a/b\n", nil},
+		{"build/\n!*/keep\n", nil},
+	}
+	for _, tt := range tests {
+		m := gitignore.New(t.TempDir())
+		m.AddPatterns([]byte(tt.patterns), "")
+		var got []string
+		for _, l := range m.Lint() {
+			got = append(got, l.Pattern, l.Message)
+		}
+		var want []string
+		for i := 0; i < len(tt.want); i += 2 {
+			want = append(want, tt.want[i], "never applies: directory "+tt.want[i+1]+" is excluded")
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%q: Lint() = %q, want %q", tt.patterns, got, want)
+		}
+	}
+}
+
+func TestLintSources(t *testing.T) {
+	xdgDir := t.TempDir()
+	writeFiles(t, xdgDir, map[string]string{"git/ignore": "!node_modules/.keep\n"})
+	t.Setenv("XDG_CONFIG_HOME", xdgDir)
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore": "# deps\nvendor/\nnode_modules/\n",
+	})
+	m := gitignore.New(root)
+	m.AddPatterns([]byte("!keep.go\n"), "vendor")
+
+	issues := m.Lint()
+	if len(issues) != 2 {
+		t.Fatalf("Lint() = %v, want 2 issues", issues)
+	}
+	if l := issues[0]; l.Pattern != "!keep.go" || l.Source != "" || l.Cause.Pattern != "vendor/" || l.Cause.Line != 2 {
+		t.Errorf("issue = %+v, want !keep.go excluded by vendor/ on line 2", l)
+	}
+	l := issues[1]
+	if l.Source == "" || l.Line != 1 || l.Cause.Pattern != "node_modules/" || l.Cause.Line != 3 {
+		t.Errorf("issue = %+v, want the global !node_modules/.keep excluded by node_modules/", l)
+	}
+	if got, want := l.String(), l.Source+":1: !node_modules/.keep: never applies: directory node_modules is excluded"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,83 @@
+package gitignore
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreMatcher is the interface of github.com/monochromegane/go-gitignore,
+// which NewGitIgnore and NewGitIgnoreFromReader return, so a tool on that
+// package can move to this one by changing its import:
+//
+//	import gitignore "github.com/git-pkgs/gitignore"
+//
+// Match takes a path as the tool walking the tree has it, joined to the
+// base directory the patterns are relative to, and reports whether it is
+// ignored. The patterns are read as git reads them, which that package
+// doesn't always do.
+type IgnoreMatcher interface {
+	Match(path string, isDir bool) bool
+}
+
+// DummyIgnoreMatcher is an IgnoreMatcher whose Match always returns its
+// value, for a directory without an ignore file.
+type DummyIgnoreMatcher bool
+
+// Match returns d.
+func (d DummyIgnoreMatcher) Match(path string, isDir bool) bool {
+	return bool(d)
+}
+
+// NewGitIgnore compiles the ignore file at path. Its patterns are
+// relative to base, if given, or the file's directory. Nothing else, not
+// even .gitignore files below it, is read.
+func NewGitIgnore(path string, base ...string) (IgnoreMatcher, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	dir := filepath.Dir(path)
+	if len(base) > 0 {
+		dir = base[0]
+	}
+	b, err := newBaseMatcher(dir, path, f)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// NewGitIgnoreFromReader compiles the patterns read from r, relative to
+// base. A read error leaves out what hadn't been read.
+func NewGitIgnoreFromReader(base string, r io.Reader) IgnoreMatcher {
+	b, _ := newBaseMatcher(base, "", r)
+	return b
+}
+
+// newBaseMatcher compiles the patterns read from r, read from source,
+// relative to base.
+func newBaseMatcher(base, source string, r io.Reader) (baseMatcher, error) {
+	data, err := io.ReadAll(r)
+	m := newMatcher(&config{})
+	m.addPatterns(data, "", source)
+	return baseMatcher{m: m, base: filepath.Clean(base)}, err
+}
+
+// baseMatcher is a Matcher for paths joined to base.
+type baseMatcher struct {
+	m    *Matcher
+	base string
+}
+
+// Match reports whether path, joined to the base directory, is ignored.
+// A path outside the base directory, or the directory itself, is not.
+func (b baseMatcher) Match(path string, isDir bool) bool {
+	rel, err := filepath.Rel(b.base, filepath.Clean(path))
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	return b.m.MatchSegments(strings.Split(filepath.ToSlash(rel), "/"), isDir)
+}
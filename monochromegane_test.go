@@ -0,0 +1,77 @@
+package gitignore_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestNewGitIgnore(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, ".gitignore")
+	if err := os.WriteFile(path, []byte("*.o\n/build/\n!keep.o\nsrc/*.tmp\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gi, err := gitignore.NewGitIgnore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"a.o", false, true},
+		{"d/a.o", false, true},
+		{"keep.o", false, false},
+		{"build", true, true},
+		{"build", false, false},
+		{"build/x.c", false, true},
+		{"d/build", true, false},
+		{"src/a.tmp", false, true},
+		{"d/src/a.tmp", false, false},
+		{".", true, false},
+		{"../a.o", false, false},
+	}
+	for _, tt := range tests {
+		if got := gi.Match(filepath.Join(root, tt.path), tt.isDir); got != tt.want {
+			t.Errorf("Match(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+
+	// The patterns can be relative to another directory.
+	gi, err = gitignore.NewGitIgnore(path, filepath.Join(root, "sub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !gi.Match(filepath.Join(root, "sub", "build"), true) || gi.Match(filepath.Join(root, "build"), true) {
+		t.Error("base: want patterns relative to the given directory")
+	}
+
+	if _, err := gitignore.NewGitIgnore(filepath.Join(root, "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("NewGitIgnore(missing) error = %v, want not exist", err)
+	}
+}
+
+func TestNewGitIgnoreFromReader(t *testing.T) {
+	var gi gitignore.IgnoreMatcher = gitignore.NewGitIgnoreFromReader("proj", strings.NewReader("vendor/\n*.log\n"))
+	for path, want := range map[string]bool{
+		filepath.Join("proj", "vendor"):      true,
+		filepath.Join("proj", "a", "x.log"):  true,
+		filepath.Join("proj", "main.go"):     false,
+		filepath.Join("other", "x.log"):      false,
+		filepath.Join("proj", "..", "x.log"): false,
+	} {
+		if got := gi.Match(path, true); got != want {
+			t.Errorf("Match(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	if !gitignore.DummyIgnoreMatcher(true).Match("a", false) || gitignore.DummyIgnoreMatcher(false).Match("a", false) {
+		t.Error("DummyIgnoreMatcher: want its value")
+	}
+}
@@ -0,0 +1,243 @@
+package gitignore
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// NodeIgnore is a list of patterns matched as the npm package "ignore"
+// matches them, in its 5.x and later releases, for tools whose Node and
+// Go components have to agree on what is ignored. Its methods follow
+// that package's API: Add is ig.add, Ignores ig.ignores, Filter
+// ig.filter and Test ig.test. As with that package, nothing but the
+// given patterns is read.
+//
+// Most patterns mean what they mean to git: anchoring, "**", trailing
+// slashes and negation work alike, and a path below an ignored directory
+// stays ignored. Where the package diverges from git, NodeIgnore follows
+// the package:
+//
+//   - Case is ignored unless WithIgnoreCase(false) is given, as with
+//     the package's ignorecase option.
+//   - A line of whitespace is blank, and trailing whitespace of any kind,
+//     such as tabs, is trimmed; an escaped trailing tab stands for a
+//     space.
+//   - In a bracket expression, a leading "!" or "^" matches itself
+//     rather than negating it, "[:alpha:]" and the other classes aren't
+//     recognized, and an empty or unclosed one, such as "[]", makes the
+//     pattern match nothing.
+//   - Outside a bracket expression, "\d", "\w" and "\s" match a digit, a
+//     word character and ASCII whitespace, and "\D", "\W" and "\S" any
+//     other character but a slash, as in a JavaScript regular
+//     expression. Other escapes match the character escaped, as in git.
+//
+// Paths are checked as the package checks them: one that isn't
+// relative, such as "/a", "./a" or "../a", or is empty, is rejected.
+type NodeIgnore struct {
+	m *Matcher
+}
+
+// NodeIgnoreResult is what Test reports about a path, as ig.test does.
+type NodeIgnoreResult struct {
+	Ignored   bool // a pattern excludes the path or a directory above it
+	Unignored bool // a negation decided the path
+}
+
+// NewNodeIgnore returns a NodeIgnore without patterns, as ignore() does.
+// opts are those of New that apply to patterns, such as WithIgnoreCase
+// and WithLimits; case is ignored by default.
+func NewNodeIgnore(opts ...Option) *NodeIgnore {
+	return &NodeIgnore{m: newMatcher(newConfig(append([]Option{WithIgnoreCase(true)}, opts...)))}
+}
+
+// Add adds patterns, each a line or several separated by newlines, and
+// returns ig, as ig.add does. Patterns added later take precedence.
+func (ig *NodeIgnore) Add(patterns ...string) *NodeIgnore {
+	var b strings.Builder
+	for _, p := range patterns {
+		for line := range strings.SplitSeq(p, "\n") {
+			b.WriteString(nodeIgnoreRule(strings.TrimSuffix(line, "\r")))
+			b.WriteByte('\n')
+		}
+	}
+	ig.m.addPatterns([]byte(b.String()), "", "")
+	return ig
+}
+
+// Ignores reports whether path, slash-separated and relative, is
+// ignored. A directory is given with a trailing slash. A path the
+// package would reject is reported as not ignored; Test returns the
+// error for it.
+func (ig *NodeIgnore) Ignores(path string) bool {
+	return IsNodeIgnorePathValid(path) && ig.m.MatchFull(path)
+}
+
+// Test reports whether path is ignored, and whether a negation decided
+// it. It returns an error for a path the package would reject.
+func (ig *NodeIgnore) Test(path string) (NodeIgnoreResult, error) {
+	if err := checkNodeIgnorePath(path); err != nil {
+		return NodeIgnoreResult{}, err
+	}
+	r := ig.m.MatchFullDetail(path)
+	return NodeIgnoreResult{Ignored: r.Ignored, Unignored: r.Matched && !r.Ignored}, nil
+}
+
+// Filter returns the paths that aren't ignored, in order. It returns an
+// error for the first path the package would reject.
+func (ig *NodeIgnore) Filter(paths []string) ([]string, error) {
+	var kept []string
+	for _, p := range paths {
+		if err := checkNodeIgnorePath(p); err != nil {
+			return nil, err
+		}
+		if !ig.m.MatchFull(p) {
+			kept = append(kept, p)
+		}
+	}
+	return kept, nil
+}
+
+// IsNodeIgnorePathValid reports whether the package accepts path, as
+// ignore.isPathValid does: whether it is relative, neither empty nor
+// starting with "/", "./" or "../", and not "." or "..".
+func IsNodeIgnorePathValid(path string) bool {
+	return checkNodeIgnorePath(path) == nil
+}
+
+// nodeIgnoreNotRelative matches the paths the package rejects as not
+// relative.
+var nodeIgnoreNotRelative = regexp.MustCompile(`^\.*/|^\.+$`)
+
+// checkNodeIgnorePath returns the error the package throws for path, or
+// nil if it accepts it.
+func checkNodeIgnorePath(path string) error {
+	if path == "" {
+		return errors.New("gitignore: path must not be empty")
+	}
+	if nodeIgnoreNotRelative.MatchString(path) {
+		return fmt.Errorf("gitignore: path must be relative, but got %q", path)
+	}
+	return nil
+}
+
+// nodeIgnoreClasses spells the JavaScript classes "\d", "\w" and "\s" as
+// the contents of a bracket expression.
+var nodeIgnoreClasses = map[byte]string{
+	'd': "0-9",
+	'w': "0-9A-Za-z_",
+	's': " \t\v\f\r",
+}
+
+// nodeIgnoreRule returns the line as a .gitignore line matching what the
+// package matches with it: empty for a line that is no pattern, or a
+// pattern that matches nothing.
+func nodeIgnoreRule(line string) string {
+	line = strings.TrimPrefix(line, utf8BOM)
+	if line == "" || line[0] == '#' || strings.TrimFunc(line, isJSSpace) == "" {
+		return ""
+	}
+	if n := len(line) - len(strings.TrimRight(line, `\`)); n%2 == 1 {
+		return "" // a trailing backslash escapes nothing
+	}
+	neg := ""
+	if line[0] == '!' {
+		neg, line = "!", line[1:]
+	}
+	body := strings.TrimRightFunc(line, isJSSpace)
+	if body != line {
+		if n := len(body) - len(strings.TrimRight(body, `\`)); n%2 == 1 {
+			body = body[:len(body)-1] + `\ `
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(neg)
+	for i := 0; i < len(body); i++ {
+		switch c := body[i]; {
+		case c == '\\' && i+1 < len(body):
+			i++
+			if class, ok := nodeIgnoreClasses[body[i]|0x20]; ok {
+				if body[i] < 'a' {
+					class = "!" + class
+				}
+				b.WriteString("[" + class + "]")
+			} else {
+				b.WriteByte('\\')
+				b.WriteByte(body[i])
+			}
+		case c == '[':
+			end := strings.IndexAny(body[i+1:], "]/")
+			if end >= 0 && body[i+1+end] == '/' {
+				b.WriteByte(c)
+				continue
+			}
+			if end < 0 {
+				return ""
+			}
+			set, ok := nodeIgnoreSet(body[i+1 : i+1+end])
+			if !ok {
+				return ""
+			}
+			b.WriteString("[" + set + "]")
+			i += 1 + end
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// nodeIgnoreReversedRange matches a range of a bracket expression, which
+// the package drops if its ends are reversed.
+var nodeIgnoreReversedRange = regexp.MustCompile(`([0-z])-([0-z])`)
+
+// nodeIgnoreSet returns the contents s of a bracket expression as the
+// contents of one with git's syntax, and false if the expression matches
+// nothing.
+func nodeIgnoreSet(s string) (string, bool) {
+	if n := len(s) - len(strings.TrimRight(s, `\`)); n%2 == 1 {
+		return "", false // the closing bracket is escaped
+	}
+	s = nodeIgnoreReversedRange.ReplaceAllStringFunc(s, func(r string) string {
+		if r[0] > r[2] {
+			return ""
+		}
+		return r
+	})
+	if s == "" {
+		return "", false
+	}
+	var b strings.Builder
+	if s[0] == '!' || s[0] == '^' {
+		b.WriteByte('\\')
+	}
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s):
+			i++
+			if class, ok := nodeIgnoreClasses[s[i]]; ok {
+				b.WriteString(class)
+			} else {
+				b.WriteByte('\\')
+				b.WriteByte(s[i])
+			}
+		case c == '[':
+			b.WriteString(`\[`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), true
+}
+
+// isJSSpace reports whether r is whitespace to a JavaScript regular
+// expression's "\s".
+func isJSSpace(r rune) bool {
+	switch r {
+	case '\t', '\n', '\v', '\f', '\r', ' ', 0xa0, 0x1680, 0x2028, 0x2029, 0x202f, 0x205f, 0x3000, 0xfeff:
+		return true
+	}
+	return r >= 0x2000 && r <= 0x200a
+}
@@ -0,0 +1,118 @@
+package gitignore_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+// nodeIgnoreCases are test vectors for the npm package "ignore", after
+// the fixtures of its test suite: the patterns, and whether the package
+// ignores each path.
+var nodeIgnoreCases = []struct {
+	name     string
+	patterns []string
+	paths    map[string]bool
+}{
+	// Cases where the package agrees with git.
+	{"simple", []string{"abc"}, map[string]bool{"abc": true, "abc/": true, "a/abc": true, "abc/x.js": true, "abcd": false}},
+	{"leading slash", []string{"/abc"}, map[string]bool{"abc": true, "a/abc": false}},
+	{"inner slash", []string{"a/b"}, map[string]bool{"a/b": true, "x/a/b": false, "a/b/c": true}},
+	{"trailing slash", []string{"abc/"}, map[string]bool{"abc": false, "abc/": true, "abc/x.js": true, "a/abc/x.js": true}},
+	{"wildcard", []string{"*.js"}, map[string]bool{"a.js": true, "a/b.js": true, "a.jsx": false}},
+	{"wildcard in directory", []string{"abc/*"}, map[string]bool{"abc/": false, "abc/a.js": true, "abc/d/e.js": true, "a/abc/b.js": false}},
+	{"question mark", []string{"a?c"}, map[string]bool{"abc": true, "ac": false, "a/c": false}},
+	{"leading **", []string{"**/foo"}, map[string]bool{"foo": true, "a/b/foo": true}},
+	{"trailing **", []string{"abc/**"}, map[string]bool{"abc/": false, "abc/a": true, "abc/a/b": true}},
+	{"inner **", []string{"a/**/b"}, map[string]bool{"a/b": true, "a/x/b": true, "a/x/y/b": true, "x/a/b": false}},
+	{"negation", []string{"*.js", "!a/a.js"}, map[string]bool{"a/a.js": false, "a/b.js": true}},
+	{"negation below an ignored directory", []string{"abc", "!abc/b"}, map[string]bool{"abc/a.js": true, "abc/b": true, "abc/b/b.js": true}},
+	{"negation of a directory", []string{".abc/*", "!.abc/d/"}, map[string]bool{".abc/a.js": true, ".abc/d/": false, ".abc/d/e.js": false}},
+	{"negation first", []string{"!abc", "abc"}, map[string]bool{"abc": true}},
+	{"escapes", []string{`\#abc`, `\!abc`, `\*`}, map[string]bool{"#abc": true, "!abc": true, "*": true, "x": false}},
+	{"comments and blanks", []string{"#abc", "", "ab\\ ", " abc"}, map[string]bool{"#abc": false, "abc": false, "ab ": true, " abc": true}},
+	{"trailing spaces", []string{"abc  ", "def\\  "}, map[string]bool{"abc": true, "abc ": false, "def ": true}},
+	{"trailing backslash", []string{"abc\\"}, map[string]bool{"abc": false, "abc\\": false}},
+	{"range", []string{"[a-c].js", "x[z-a]y"}, map[string]bool{"b.js": true, "d.js": false, "xby": false, "xy": false}},
+	{"newlines", []string{"a\nb\r\n!c"}, map[string]bool{"a": true, "b": true, "c": false}},
+
+	// Cases where the package diverges from git.
+	{"case", []string{"*.JS"}, map[string]bool{"a.js": true, "A.Js": true}},
+	{"whitespace line", []string{"\t", " \t "}, map[string]bool{"\t": false, " ": false}},
+	{"trailing tab", []string{"abc\t", "def\\\t"}, map[string]bool{"abc": true, "abc\t": false, "def ": true, "def\t": false}},
+	{"bracket ! and ^", []string{"[!a].js", "[^b].txt"}, map[string]bool{"!.js": true, "a.js": true, "c.js": false, "^.txt": true, "c.txt": false}},
+	{"posix class", []string{"[[:digit:]]x"}, map[string]bool{"1x": false, "d]x": true, "[]x": true}},
+	{"empty and unclosed bracket", []string{"[]a", "b[c"}, map[string]bool{"]a": false, "a": false, "b[c": false, "bc": false}},
+	{"escaped bracket", []string{`a\[b]`}, map[string]bool{"a[b]": true, "ab": false}},
+	{"classes", []string{`v\d.txt`, `\w\D`}, map[string]bool{"v1.txt": true, "vd.txt": false, "a-": true, "a1": false}},
+	{"classes in a bracket", []string{`[\s_]x`}, map[string]bool{" x": true, "_x": true, "sx": false}},
+}
+
+func TestNodeIgnore(t *testing.T) {
+	for _, tt := range nodeIgnoreCases {
+		t.Run(tt.name, func(t *testing.T) {
+			ig := gitignore.NewNodeIgnore().Add(tt.patterns...)
+			for path, want := range tt.paths {
+				if got := ig.Ignores(path); got != want {
+					t.Errorf("%q: Ignores(%q) = %v, want %v", tt.patterns, path, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNodeIgnoreOptions(t *testing.T) {
+	ig := gitignore.NewNodeIgnore(gitignore.WithIgnoreCase(false)).Add("*.JS")
+	if ig.Ignores("a.js") || !ig.Ignores("a.JS") {
+		t.Error("WithIgnoreCase(false): want patterns that keep case")
+	}
+
+	// Add is chained, and later patterns take precedence.
+	ig = gitignore.NewNodeIgnore().Add("*.log").Add("!keep.log")
+	if ig.Ignores("keep.log") || !ig.Ignores("a.log") {
+		t.Error("Add: want later patterns to take precedence")
+	}
+}
+
+func TestNodeIgnoreTest(t *testing.T) {
+	ig := gitignore.NewNodeIgnore().Add("*.log", "!keep.log", "abc/", "!x")
+	for path, want := range map[string]gitignore.NodeIgnoreResult{
+		"a.log":     {Ignored: true},
+		"keep.log":  {Unignored: true},
+		"x":         {Unignored: true},
+		"abc/x":     {Ignored: true},
+		"readme.md": {},
+	} {
+		got, err := ig.Test(path)
+		if err != nil || got != want {
+			t.Errorf("Test(%q) = %+v, %v, want %+v", path, got, err, want)
+		}
+	}
+
+	got, err := ig.Filter([]string{"a.log", "keep.log", "abc/d", "main.js"})
+	if want := []string{"keep.log", "main.js"}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Filter = %q, %v, want %q", got, err, want)
+	}
+}
+
+func TestNodeIgnorePaths(t *testing.T) {
+	ig := gitignore.NewNodeIgnore().Add("*")
+	for path, valid := range map[string]bool{
+		"a": true, "a/b": true, "..a": true, ".a": true, "a/../b": true,
+		"": false, "/a": false, "./a": false, "../a": false, ".../a": false, ".": false, "..": false,
+	} {
+		if got := gitignore.IsNodeIgnorePathValid(path); got != valid {
+			t.Errorf("IsNodeIgnorePathValid(%q) = %v, want %v", path, got, valid)
+		}
+		if _, err := ig.Test(path); (err == nil) != valid {
+			t.Errorf("Test(%q) error = %v", path, err)
+		}
+		if !valid && ig.Ignores(path) {
+			t.Errorf("Ignores(%q) = true for a path the package rejects", path)
+		}
+	}
+	if _, err := ig.Filter([]string{"a", "./b"}); err == nil {
+		t.Error("Filter: want an error for ./b")
+	}
+}
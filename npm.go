@@ -0,0 +1,220 @@
+//go:build !tinygo
+
+package gitignore
+
+import (
+	"encoding/json"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// NPMPackage decides which files npm puts in the package published from
+// a directory, for Node tooling that needs to know what "npm pack" would
+// include. It applies npm's rules rather than git's:
+//
+//   - Each directory's .npmignore is read in place of its .gitignore; a
+//     directory without one falls back to its .gitignore. Neither
+//     .git/info/exclude nor the global excludes apply.
+//   - Some files are always left out, whatever the ignore files say:
+//     the .npmignore and .gitignore files themselves, .git, CVS, .svn, .hg, .lock-wscript, .wafpickle-N, .*.swp,
+//     .DS_Store, ._*, npm-debug.log, .npmrc, node_modules, config.gypi,
+//     *.orig, and package-lock.json in the package root.
+//   - Some files are always included: package.json, README and LICENSE
+//     (or LICENCE) in the package root, in any case and with any
+//     extension, and the files named by the "main" and "bin" fields of
+//     package.json.
+//
+// The lists are npm's documented ones. The "files" field of package.json
+// and bundled dependencies are not taken into account.
+type NPMPackage struct {
+	root   string
+	m      *Matcher // the ignore files
+	forced *Matcher // the files always left out or included
+	text   string   // the patterns of forced
+	always []string // main and bin, which a walk may not reach
+	opts   []Option
+}
+
+// npmExcluded lists, as patterns, the files npm always leaves out of a
+// package.
+const npmExcluded = `.npmignore
+.gitignore
+.git
+CVS
+.svn
+.hg
+.lock-wscript
+.wafpickle-[0-9]*
+.*.swp
+.DS_Store
+._*
+npm-debug.log
+.npmrc
+node_modules
+config.gypi
+*.orig
+/package-lock.json
+`
+
+// npmIncluded lists, as negations, the files in the package root npm
+// always includes besides main and bin.
+const npmIncluded = `!/package.json
+!/[Rr][Ee][Aa][Dd][Mm][Ee]
+!/[Rr][Ee][Aa][Dd][Mm][Ee].*
+!/[Ll][Ii][Cc][Ee][Nn][Ss][Ee]
+!/[Ll][Ii][Cc][Ee][Nn][Ss][Ee].*
+!/[Ll][Ii][Cc][Ee][Nn][Cc][Ee]
+!/[Ll][Ii][Cc][Ee][Nn][Cc][Ee].*
+`
+
+// LoadNPMPackage reads the package.json and the ignore files of the
+// package in root, as LoadDirectory reads a repository. It returns an
+// error if package.json can't be read or parsed, or if LoadDirectory
+// would. opts are those of LoadDirectory; the options choosing ignore
+// files and global excludes are set by npm's rules.
+func LoadNPMPackage(root string, opts ...Option) (*NPMPackage, error) {
+	data, err := os.ReadFile(filepath.Join(root, "package.json"))
+	if err != nil {
+		return nil, err
+	}
+	var pkg struct {
+		Main string          `json:"main"`
+		Bin  json.RawMessage `json:"bin"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, err
+	}
+	p := &NPMPackage{root: root}
+	p.always = appendPackagePath(p.always, pkg.Main)
+	// bin is either one path or a map from command names to paths.
+	var bin string
+	var bins map[string]string
+	if json.Unmarshal(pkg.Bin, &bin) == nil {
+		p.always = appendPackagePath(p.always, bin)
+	} else if json.Unmarshal(pkg.Bin, &bins) == nil {
+		for _, name := range slices.Sorted(maps.Keys(bins)) {
+			p.always = appendPackagePath(p.always, bins[name])
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(npmExcluded)
+	b.WriteString(npmIncluded)
+	for _, f := range p.always {
+		b.WriteString("!" + literalPattern(f) + "\n")
+	}
+	p.text = b.String()
+	p.forced = &Matcher{}
+	p.forced.addPatterns([]byte(p.text), "", "")
+
+	p.opts = append(slices.Clip(opts), WithoutGit(), WithGlobalExcludes(false),
+		WithIgnoreFileNames(".npmignore", ".gitignore"))
+	// Loaded as LoadDirectory loads a Matcher, but without looking into
+	// node_modules and the other directories npm leaves out.
+	w, err := p.walker(nil)
+	if err != nil {
+		return nil, err
+	}
+	p.m = w.m
+	return p, nil
+}
+
+// appendPackagePath appends the path f from package.json, relative to
+// the package root, in the form Match takes, unless it is empty or
+// outside the root.
+func appendPackagePath(paths []string, f string) []string {
+	f = path.Clean(strings.ReplaceAll(f, `\`, "/"))
+	if f == "." || f == ".." || strings.HasPrefix(f, "../") || strings.HasPrefix(f, "/") {
+		return paths
+	}
+	return append(paths, f)
+}
+
+// literalPattern returns the anchored pattern matching exactly the path
+// relPath.
+func literalPattern(relPath string) string {
+	var b strings.Builder
+	b.WriteByte('/')
+	for i := 0; i < len(relPath); i++ {
+		switch c := relPath[i]; c {
+		case '\\', '*', '?', '[':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case ' ':
+			// Escaped, so a trailing space isn't trimmed.
+			b.WriteString(`\ `)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// Match reports whether npm leaves relPath out of the package. The path
+// is as for Match on a Matcher, with its parent directories taken into
+// account as MatchFull does: npm doesn't look inside a directory it
+// leaves out, except for main and bin.
+func (p *NPMPackage) Match(relPath string) bool {
+	if r := p.forced.MatchDetail(relPath); r.Matched {
+		return r.Ignored
+	}
+	return p.m.MatchFull(relPath)
+}
+
+// Files returns the files npm puts in the package, slash-separated,
+// relative to the package root and sorted, by walking the package as
+// Walk does with its options.
+func (p *NPMPackage) Files() ([]string, error) {
+	var files []string
+	w, err := p.walker(func(e Entry) error {
+		if !e.DirEntry.IsDir() {
+			files = append(files, filepath.ToSlash(e.Path))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// main and bin are included even in a directory the walk skipped.
+	for _, f := range p.always {
+		if slices.Contains(files, f) {
+			continue
+		}
+		info, err := os.Lstat(filepath.Join(p.root, filepath.FromSlash(f)))
+		if err == nil && !info.IsDir() && w.wants(strings.Split(f, "/"), fs.FileInfoToDirEntry(info)) {
+			files = append(files, f)
+		}
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+// walker walks the package, passing the entries npm includes to fn, and
+// returns the walker used once the walk is done.
+func (p *NPMPackage) walker(fn func(Entry) error) (*walker, error) {
+	w := newOSWalker(p.root, New(p.root, p.opts...), fn)
+	if err := w.configure(p.opts); err != nil {
+		return nil, err
+	}
+	// Added after any WithIgnoreFile patterns, so the forced files beat
+	// those too.
+	if w.extra == nil {
+		w.extra = &Matcher{}
+	}
+	w.extra.addPatterns([]byte(p.text), "", "")
+	if err := w.checkLimits(0); err != nil {
+		return nil, err
+	}
+	if err := w.checkInvalid(); err != nil {
+		return nil, err
+	}
+	if err := w.walk("", 0); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
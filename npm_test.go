@@ -0,0 +1,140 @@
+//go:build !tinygo
+
+package gitignore_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestNPMPackage(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"package.json": `{"name": "pkg", "main": "./dist/index.js", "bin": {"b": "bin/cli.js", "a": "bin/a.js"}}`,
+		".gitignore":   "dist/\n*.log\n",
+		".npmignore":   "*.md\n!.npmrc\n!node_modules/\nbin/\nLICENSE\n",
+		".npmrc":       "",
+		".git/HEAD":    "",
+		"README.md":    "",
+		"LICENSE":      "",
+		"notes.md":     "",
+		"debug.log":    "",
+		"index.js":     "",
+		"x.js.orig":    "",
+		// A directory without .npmignore falls back to its .gitignore.
+		"lib/.gitignore":          "*.tmp\n",
+		"lib/a.js":                "",
+		"lib/a.tmp":               "",
+		"lib/package-lock.json":   "",
+		"package-lock.json":       "",
+		"dist/index.js":           "",
+		"dist/index.js.map":       "",
+		"bin/cli.js":              "",
+		"bin/a.js":                "",
+		"bin/other.js":            "",
+		"node_modules/dep/a.js":   "",
+		"src/.npmignore":          "*.ts\n",
+		"src/.gitignore":          "*.js\n",
+		"src/a.ts":                "",
+		"src/a.js":                "",
+		"src/node_modules/b/b.js": "",
+	})
+
+	p, err := gitignore.LoadNPMPackage(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]bool{
+		"package.json":          false,
+		"README.md":             false, // included despite *.md
+		"LICENSE":               false, // included despite .npmignore
+		"notes.md":              true,
+		"debug.log":             false, // the root's .gitignore isn't read
+		"index.js":              false,
+		".npmrc":                true, // excluded despite !.npmrc
+		"x.js.orig":             true,
+		"node_modules/dep/a.js": true,
+		"lib/a.js":              false,
+		"lib/a.tmp":             true,
+		"lib/package-lock.json": false, // only the root's is excluded
+		"package-lock.json":     true,
+		"dist/index.js":         false, // main, though dist is ignored
+		"dist/index.js.map":     false, // the root's .gitignore isn't read
+		"bin/cli.js":            false,
+		"bin/a.js":              false,
+		"bin/other.js":          true,
+		"src/a.ts":              true,
+		"src/a.js":              false,
+	} {
+		if got := p.Match(path); got != want {
+			t.Errorf("Match(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	files, err := p.Files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"LICENSE",
+		"README.md",
+		"bin/a.js",
+		"bin/cli.js",
+		"debug.log",
+		"dist/index.js",
+		"dist/index.js.map",
+		"index.js",
+		"lib/a.js",
+		"lib/package-lock.json",
+		"package.json",
+		"src/a.js",
+	}
+	if !slices.Equal(files, want) {
+		t.Errorf("Files() = %q, want %q", files, want)
+	}
+}
+
+func TestNPMPackageMainInIgnoredDir(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"package.json":      `{"main": "build/main [1].js", "bin": "cli.js"}`,
+		".npmignore":        "build/\ncli.js\n",
+		"build/main [1]":    "",
+		"build/main 1.js":   "",
+		"build/main [1].js": "",
+		"cli.js":            "",
+	})
+	p, err := gitignore.LoadNPMPackage(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := p.Files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"build/main [1].js", "cli.js", "package.json"}; !slices.Equal(files, want) {
+		t.Errorf("Files() = %q, want %q", files, want)
+	}
+	if !p.Match("build/main 1.js") {
+		t.Error("main's pattern should match only main")
+	}
+}
+
+func TestNPMPackageErrors(t *testing.T) {
+	root := t.TempDir()
+	if _, err := gitignore.LoadNPMPackage(root); err == nil {
+		t.Error("expected an error without package.json")
+	}
+	writeFiles(t, root, map[string]string{"package.json": "{"})
+	if _, err := gitignore.LoadNPMPackage(root); err == nil {
+		t.Error("expected an error for an invalid package.json")
+	}
+}
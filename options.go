@@ -0,0 +1,193 @@
+package gitignore
+
+import (
+	"io/fs"
+	"strings"
+)
+
+// Option configures optional behavior of New, Walk, and the other
+// constructors and walkers. Options that don't apply to a call are
+// ignored.
+type Option func(*config)
+
+type config struct {
+	filesOnly  bool
+	dirsOnly   bool
+	extensions map[string]bool
+	submodules bool
+	extraFiles []string
+	only       []string
+	noLstat    bool
+	plain      bool   // set by WithoutGit
+	compat     Compat // set by WithCompat
+	syntax     string // set by WithSyntax; empty for gitignore
+	global     bool   // set by WithGlobalExcludes, if globalSet
+	globalSet  bool
+	limits     Limits
+	fsys       fs.FS
+	patternOptions
+
+	dirCacheSize int
+	ignoreNames  []string
+	dfa          bool
+	arena        bool
+	verify       bool
+	stats        bool
+	trackSources bool
+}
+
+// patternOptions are the settings that decide what the patterns of a
+// Matcher compile to and which paths they match. The global excludes are
+// compiled, and shared, once for each combination of them; Fingerprint
+// hashes them and LoadCache checks them through flags, so a new one is
+// added here and in flags.
+type patternOptions struct {
+	ignoreCase bool                 // set by WithIgnoreCase
+	precompose bool                 // set by WithPrecomposeUnicode
+	strict     bool                 // set by WithStrictEscapes
+	unicode    bool                 // set by WithUnicodeClasses
+	invalid    InvalidPatternPolicy // set by WithInvalidPatterns
+	braces     bool                 // set by WithBraceExpansion
+	regex      bool                 // set by WithRegexPatterns
+	windows    bool                 // set by WithWindowsPaths
+	cleaning   pathCleaning         // set by WithCleanPaths
+}
+
+// flags packs o into an int, each setting into bits of its own.
+func (o patternOptions) flags() int {
+	flags := int(o.invalid) | int(o.cleaning)<<2
+	for i, set := range []bool{o.ignoreCase, o.precompose, o.strict, o.unicode, o.braces, o.regex, o.windows} {
+		if set {
+			flags |= 1 << (4 + i)
+		}
+	}
+	return flags
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithFilesOnly restricts the entries passed to the walk callback to
+// regular files. Directories are still descended into.
+func WithFilesOnly() Option {
+	return func(c *config) {
+		c.filesOnly = true
+		c.dirsOnly = false
+	}
+}
+
+// WithDirsOnly restricts the entries passed to the walk callback to
+// directories.
+func WithDirsOnly() Option {
+	return func(c *config) {
+		c.dirsOnly = true
+		c.filesOnly = false
+	}
+}
+
+// WithExtensions restricts the entries passed to the walk callback to
+// non-directory entries whose name ends in one of the given extensions.
+// Extensions may be given with or without the leading dot (".go" or "go").
+// Directories are still descended into but are not passed to the callback.
+// Repeated calls add to the set.
+func WithExtensions(exts ...string) Option {
+	return func(c *config) {
+		if c.extensions == nil {
+			c.extensions = make(map[string]bool, len(exts))
+		}
+		for _, ext := range exts {
+			if !strings.HasPrefix(ext, ".") {
+				ext = "." + ext
+			}
+			c.extensions[ext] = true
+		}
+	}
+}
+
+// WithSubmodules makes the walk report a directory that contains its own
+// .git (a submodule or nested repository) as a single entry with
+// Entry.Submodule set, instead of descending into it. This matches how
+// git status treats such directories.
+func WithSubmodules() Option {
+	return func(c *config) {
+		c.submodules = true
+	}
+}
+
+// WithIgnoreFile loads additional ignore files for the duration of one
+// walk. Their patterns are relative to the walk root. Where they rank
+// depends on the entry point: in Walk and the other walks they take
+// precedence over every other rule, so when an extra pattern matches a
+// path its decision stands regardless of any .gitignore or the global
+// excludes. LoadRipgrep ranks them last, below the global excludes, as
+// ripgrep's --ignore-file. The Matcher built from the repository is not
+// modified. A file that cannot be read makes the walk return an error.
+func WithIgnoreFile(paths ...string) Option {
+	return func(c *config) {
+		c.extraFiles = append(c.extraFiles, paths...)
+	}
+}
+
+// WithIgnoreFileNames sets the per-directory ignore files read in place
+// of .gitignore, in the root and, for NewFromDirectory, LoadDirectory and
+// the walks, in every directory below it. In each directory only the
+// first of names present is read, so ".npmignore", ".gitignore" reads a
+// directory's .gitignore only if it has no .npmignore, as npm does.
+// Their patterns have gitignore syntax and the same scoping.
+func WithIgnoreFileNames(names ...string) Option {
+	return func(c *config) {
+		c.ignoreNames = names
+	}
+}
+
+// WithOnly restricts the entries passed to the walk callback to paths that
+// match at least one of the given globs, in addition to not being ignored.
+// Globs use gitignore syntax and are relative to the walk root, so
+// "**/*.md" selects Markdown files anywhere and "docs/**" selects
+// everything under docs. A glob prefixed with "!" removes paths matched by
+// earlier globs. Directories are still descended into whether or not they
+// match. An invalid glob makes the walk return its PatternError.
+func WithOnly(globs ...string) Option {
+	return func(c *config) {
+		c.only = append(c.only, globs...)
+	}
+}
+
+// WithoutLstat makes WalkFS trust the entry types reported by ReadDir,
+// skipping the fs.Lstat check it otherwise performs on directory entries
+// when the filesystem implements fs.ReadLinkFS. With this option a
+// filesystem that reports symlinks with their target's type will have
+// symlinked directories descended into. Walk never needs the check, so
+// the option has no effect there.
+func WithoutLstat() Option {
+	return func(c *config) {
+		c.noLstat = true
+	}
+}
+
+// wants reports whether an entry that survived ignore matching should be
+// passed to the walk callback.
+func (c *config) wants(d fs.DirEntry) bool {
+	if c.filesOnly && !d.Type().IsRegular() {
+		return false
+	}
+	if c.dirsOnly && !d.IsDir() {
+		return false
+	}
+	if c.extensions != nil {
+		if d.IsDir() {
+			return false
+		}
+		name := d.Name()
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 || !c.extensions[name[i:]] {
+			return false
+		}
+	}
+	return true
+}
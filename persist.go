@@ -0,0 +1,240 @@
+//go:build !tinygo
+
+package gitignore
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// cacheVersion is bumped whenever the layout of cache files and
+// MarshalBinary output or the meaning of a compiled pattern changes,
+// invalidating older encodings.
+const cacheVersion = 2
+
+// LoadCache returns a Matcher for the repository at root, like
+// LoadDirectory, reusing the cache file at cachePath if every file and
+// directory it was built from is unchanged. Files are compared by size
+// and modification time, then by SHA-256 when only the time differs;
+// directories by modification time, which changes when a .gitignore is
+// created or removed in them. A missing, corrupt or stale cache is not an
+// error: LoadCache builds the Matcher afresh, and the caller can store it
+// with SaveCache for next time.
+//
+// Global excludes are not cached here; they are loaded as for New.
+func LoadCache(cachePath, root string, opts ...Option) (*Matcher, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts[:len(opts):len(opts)], trackSources)
+	cfg := newConfig(opts)
+	if m := loadCacheFile(cachePath, root, cfg); m != nil {
+		return m, nil
+	}
+	m, err := LoadDirectory(root, opts...)
+	if err != nil {
+		return nil, err
+	}
+	m.deps.root = root
+	return m, nil
+}
+
+// SaveCache writes m to cachePath for LoadCache. m must come from
+// LoadCache, which records the files it is built from; patterns added
+// afterwards with AddPatterns or AddFromFile are saved too but not
+// tracked.
+func (m *Matcher) SaveCache(cachePath string) error {
+	if m.deps == nil || m.deps.root == "" {
+		return errors.New("gitignore: SaveCache needs a Matcher returned by LoadCache")
+	}
+	c := cacheFile{
+		Version:  cacheVersion,
+		Root:     m.deps.root,
+		Limits:   m.deps.limits,
+		Options:  m.patternOptions.flags(),
+		Plain:    m.deps.plain,
+		Compat:   m.compat,
+		Syntax:   m.syntax,
+		Names:    m.names,
+		Files:    m.deps.files,
+		Dirs:     m.deps.dirs,
+		Errors:   m.errors,
+		Patterns: m.cachedPatterns(),
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&c); err != nil {
+		return err
+	}
+
+	// Write to a temporary file and rename it into place so a reader
+	// never sees a partial cache.
+	tmp, err := os.CreateTemp(filepath.Dir(cachePath), filepath.Base(cachePath)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), cachePath)
+}
+
+type cacheFile struct {
+	Version  int
+	Root     string
+	Limits   Limits
+	Options  int  // patternOptions.flags() of the patterns and errors saved
+	Plain    bool // .git/info/exclude wasn't read
+	Compat   Compat
+	Syntax   string   // set by WithSyntax
+	Names    []string // per-directory ignore files; nil for .gitignore
+	Files    []cachedFile
+	Dirs     []cachedDir
+	Patterns []cachedPattern
+	Errors   []PatternError
+}
+
+// cachedPattern is enough to recompile a pattern: compilation is cheap
+// next to finding and reading the files the patterns came from.
+type cachedPattern struct {
+	Text   string
+	Dir    string
+	Source string
+	Line   int
+}
+
+// loadCacheFile returns the Matcher stored at cachePath, or nil if there
+// is no usable cache for root and cfg.
+func loadCacheFile(cachePath, root string, cfg *config) *Matcher {
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		return nil
+	}
+	var c cacheFile
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&c); err != nil {
+		return nil
+	}
+	if c.Version != cacheVersion || c.Root != root || c.Limits != cfg.limits ||
+		c.Options != cfg.patternOptions.flags() || c.Plain != cfg.plain || c.Compat != cfg.compat || c.Syntax != cfg.syntax ||
+		!slices.Equal(c.Names, cfg.ignoreNames) || !c.current() {
+		return nil
+	}
+
+	m := newMatcher(cfg)
+	m.global = newGlobalExcludes(cfg)
+	if m.fsys == nil {
+		m.fsys = os.DirFS(root)
+	}
+	m.root = root
+	m.deps.root = root
+	m.deps.limits = c.Limits
+	m.deps.plain = c.Plain
+	m.deps.files = c.Files
+	m.deps.dirs = c.Dirs
+	m.errors = c.Errors
+	if !m.replay(c.Patterns) {
+		return nil
+	}
+	return m
+}
+
+// cachedPatterns returns the patterns of m in the form they are saved.
+func (m *Matcher) cachedPatterns() []cachedPattern {
+	ps := make([]cachedPattern, len(m.patterns))
+	for i := range m.patterns {
+		p := &m.patterns[i]
+		ps[i] = cachedPattern{
+			Text:   m.patternText(p),
+			Dir:    strings.Join(p.prefixSegs, "/"),
+			Source: m.sources[p.src].path,
+			Line:   int(p.line),
+		}
+	}
+	return ps
+}
+
+// replay recompiles saved patterns into m. It reports false if one no
+// longer compiles, meaning they were saved by a different version of the
+// package.
+func (m *Matcher) replay(ps []cachedPattern) bool {
+	for len(ps) > 0 {
+		// Each run of patterns from the same file gets one source, as
+		// when the file was read.
+		n := 1
+		for n < len(ps) && ps[n].Dir == ps[0].Dir && ps[n].Source == ps[0].Source {
+			n++
+		}
+		run := ps[:n]
+		ps = ps[n:]
+
+		var b strings.Builder
+		for _, cp := range run {
+			b.WriteString(cp.Text)
+			b.WriteByte('\n')
+		}
+		text := b.String()
+		src := m.addSource(run[0].Source, run[0].Dir, text)
+		dirSegs := splitDir(run[0].Dir)
+		m.arena.reserve(arenaNeeds(text))
+		from := len(m.patterns)
+		off := 0
+		for _, cp := range run {
+			line := text[off : off+len(cp.Text)]
+			p, errMsg := m.compile(line, dirSegs)
+			if errMsg != "" {
+				// Kept by LiteralInvalid, and recorded in the errors.
+				var ok bool
+				if p, ok = m.compileInvalid(line, dirSegs); !ok {
+					return false
+				}
+			}
+			m.appendPattern(p, src, off, len(cp.Text), cp.Line)
+			off += len(cp.Text) + 1
+		}
+		m.patternsAdded(run[0].Dir, from)
+	}
+	return true
+}
+
+// current reports whether every file and directory recorded in c is
+// unchanged.
+func (c *cacheFile) current() bool {
+	for _, d := range c.Dirs {
+		info, err := os.Stat(d.Path)
+		if err != nil || info.ModTime().UnixNano() != d.ModTime {
+			return false
+		}
+	}
+	for _, f := range c.Files {
+		info, err := os.Stat(f.Path)
+		if err != nil {
+			if f.Exists {
+				return false
+			}
+			continue
+		}
+		if !f.Exists || info.Size() != f.Size {
+			return false
+		}
+		if info.ModTime().UnixNano() == f.ModTime {
+			continue
+		}
+		data, err := os.ReadFile(f.Path)
+		if err != nil || sha256.Sum256(data) != f.Hash {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,333 @@
+//go:build !tinygo
+
+package gitignore_test
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+// ageTree sets every modification time under root well into the past,
+// so a change made by the test is sure to move it.
+func ageTree(t *testing.T, root string) {
+	t.Helper()
+	old := time.Unix(1_000_000_000, 0)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Chtimes(path, old, old)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadCache(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	newTree := func(t *testing.T) (root, cache string) {
+		root = t.TempDir()
+		writeFiles(t, root, map[string]string{
+			".gitignore":     "*.log\n",
+			"src/.gitignore": "*.tmp\n",
+			"src/lib/x.go":   "",
+		})
+		ageTree(t, root)
+		cache = filepath.Join(t.TempDir(), "matcher.cache")
+		m, err := gitignore.LoadCache(cache, root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := m.SaveCache(cache); err != nil {
+			t.Fatal(err)
+		}
+		return root, cache
+	}
+	load := func(t *testing.T, cache, root string) *gitignore.Matcher {
+		t.Helper()
+		m, err := gitignore.LoadCache(cache, root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+
+	t.Run("unchanged", func(t *testing.T) {
+		root, cache := newTree(t)
+		m := load(t, cache, root)
+		if !m.Match("a.log") || !m.Match("src/a.tmp") || m.Match("a.tmp") {
+			t.Error("cached matcher lost patterns or scopes")
+		}
+		r := m.MatchDetail("src/a.tmp")
+		if r.Source != filepath.Join(root, "src", ".gitignore") || r.Line != 1 {
+			t.Errorf("MatchDetail source = %s:%d", r.Source, r.Line)
+		}
+	})
+
+	t.Run("edited gitignore", func(t *testing.T) {
+		root, cache := newTree(t)
+		writeFiles(t, root, map[string]string{"src/.gitignore": "*.bak\n"})
+		m := load(t, cache, root)
+		if m.Match("src/a.tmp") || !m.Match("src/a.bak") {
+			t.Error("expected the edited .gitignore to be reloaded")
+		}
+	})
+
+	t.Run("same size edit", func(t *testing.T) {
+		root, cache := newTree(t)
+		writeFiles(t, root, map[string]string{"src/.gitignore": "*.tmq\n"})
+		m := load(t, cache, root)
+		if m.Match("src/a.tmp") || !m.Match("src/a.tmq") {
+			t.Error("expected the hash to catch a same-size edit")
+		}
+	})
+
+	t.Run("touched but unchanged", func(t *testing.T) {
+		root, cache := newTree(t)
+		now := time.Now()
+		if err := os.Chtimes(filepath.Join(root, "src", ".gitignore"), now, now); err != nil {
+			t.Fatal(err)
+		}
+		m := load(t, cache, root)
+		if !m.Match("src/a.tmp") {
+			t.Error("expected a touched .gitignore to keep its patterns")
+		}
+	})
+
+	t.Run("new nested gitignore", func(t *testing.T) {
+		root, cache := newTree(t)
+		writeFiles(t, root, map[string]string{"src/lib/.gitignore": "*.go\n"})
+		m := load(t, cache, root)
+		if !m.Match("src/lib/x.go") {
+			t.Error("expected a new .gitignore to be found")
+		}
+	})
+
+	t.Run("new info exclude", func(t *testing.T) {
+		root, cache := newTree(t)
+		writeFiles(t, root, map[string]string{".git/info/exclude": "*.go\n"})
+		m := load(t, cache, root)
+		if !m.Match("main.go") {
+			t.Error("expected a new .git/info/exclude to be read")
+		}
+	})
+
+	t.Run("corrupt cache", func(t *testing.T) {
+		root, cache := newTree(t)
+		if err := os.WriteFile(cache, []byte("not a cache"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		m := load(t, cache, root)
+		if !m.Match("src/a.tmp") {
+			t.Error("expected a fresh build")
+		}
+	})
+
+	t.Run("other root", func(t *testing.T) {
+		_, cache := newTree(t)
+		other := t.TempDir()
+		writeFiles(t, other, map[string]string{".gitignore": "*.other\n"})
+		m := load(t, cache, other)
+		if m.Match("a.log") || !m.Match("a.other") {
+			t.Error("expected a cache for another root to be ignored")
+		}
+	})
+}
+
+func TestSaveCacheNeedsLoadCache(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	m := gitignore.NewFromDirectory(t.TempDir())
+	if err := m.SaveCache(filepath.Join(t.TempDir(), "cache")); err == nil {
+		t.Error("expected an error saving a Matcher not built by LoadCache")
+	}
+}
+
+func TestLoadCacheBraceExpansion(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{".gitignore": "*.{js,ts}\n"})
+	ageTree(t, root)
+	cache := filepath.Join(t.TempDir(), "matcher.cache")
+	m, err := gitignore.LoadCache(cache, root, gitignore.WithBraceExpansion())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SaveCache(cache); err != nil {
+		t.Fatal(err)
+	}
+	if m, err = gitignore.LoadCache(cache, root, gitignore.WithBraceExpansion()); err != nil || !m.Match("a.ts") {
+		t.Errorf("cached: Match(a.ts) = false, %v", err)
+	}
+	// A cache saved with the option doesn't do for a Matcher without it.
+	if m, err = gitignore.LoadCache(cache, root); err != nil || m.Match("a.ts") {
+		t.Errorf("without the option: Match(a.ts) = true, %v", err)
+	}
+}
+
+func TestInvalidPatternsLiteralCache(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"src/.gitignore": "gen[[:x:]]/\n"})
+	cache := filepath.Join(t.TempDir(), "cache")
+	lit := gitignore.WithInvalidPatterns(gitignore.LiteralInvalid)
+	for range 2 {
+		m, err := gitignore.LoadCache(cache, root, lit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !m.Match("src/gen[[:x:]]/") || len(m.Errors()) != 1 {
+			t.Errorf("Match = false or Errors() = %v, want the literal pattern and its error", m.Errors())
+		}
+		if err := m.SaveCache(cache); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m, err := gitignore.LoadCache(cache, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Match("src/gen[[:x:]]/") {
+		t.Error("cache saved with LiteralInvalid used without it")
+	}
+}
+
+func TestLoadCacheFailOnInvalid(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"b/.gitignore": "ok\nbad[[:x:]]\n"})
+	var pe gitignore.PatternError
+	fail := gitignore.WithInvalidPatterns(gitignore.FailOnInvalid)
+	if _, err := gitignore.LoadCache(filepath.Join(t.TempDir(), "cache"), root, fail); !errors.As(err, &pe) {
+		t.Errorf("LoadCache = %v, want a PatternError", err)
+	}
+}
+
+func TestLoadCacheSymlinkedGitignore(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"rules":     "*.log\n",
+		"sub/rules": "*.tmp\n",
+	})
+	for _, link := range []string{".gitignore", "sub/.gitignore"} {
+		if err := os.Symlink("rules", filepath.Join(root, link)); err != nil {
+			t.Skip("symlinks not supported:", err)
+		}
+	}
+	cache := filepath.Join(t.TempDir(), "cache")
+	for _, tt := range []struct {
+		opts   []gitignore.Option
+		follow bool
+	}{
+		{nil, false},
+		{[]gitignore.Option{gitignore.WithCompat(gitignore.CompatGit2_30)}, true},
+		{nil, false}, // a cache saved following the links isn't reused
+	} {
+		c, err := gitignore.LoadCache(cache, root, tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := c.Match("sub/a.tmp"); got != tt.follow {
+			t.Errorf("%v: Match(sub/a.tmp) = %v, want %v", tt.opts, got, tt.follow)
+		}
+		if err := c.SaveCache(cache); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Replacing the link with a file makes the cache stale.
+	if err := os.Remove(filepath.Join(root, ".gitignore")); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, root, map[string]string{".gitignore": "*.log\n"})
+	c, err := gitignore.LoadCache(cache, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Match("a.log") {
+		t.Error("LoadCache used a cache saved before the link was replaced")
+	}
+}
+
+func TestLoadCacheWithoutGit(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{".git/info/exclude": "*.exclude\n"})
+	cache := filepath.Join(t.TempDir(), "cache")
+	m, err := gitignore.LoadCache(cache, root, gitignore.WithoutGit())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SaveCache(cache); err != nil {
+		t.Fatal(err)
+	}
+	if m, err = gitignore.LoadCache(cache, root); err != nil || !m.Match("src/a.exclude") {
+		t.Errorf("LoadCache = %v, want the plain cache left unused", err)
+	}
+}
+
+func TestLoadCacheSyntax(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{".gitignore": "a.log\n"})
+	cache := filepath.Join(t.TempDir(), "cache")
+	c, err := gitignore.LoadCache(cache, root, gitignore.WithSyntax("anchored"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SaveCache(cache); err != nil {
+		t.Fatal(err)
+	}
+	if c, err = gitignore.LoadCache(cache, root); err != nil || !c.Match("sub/a.log") {
+		t.Errorf("LoadCache = %v, want the cache of another syntax left unused", err)
+	}
+}
+
+func BenchmarkLoadCache(b *testing.B) {
+	b.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	root := b.TempDir()
+	for i := range 50 {
+		dir := filepath.Join(root, fmt.Sprintf("pkg%d", i), "src")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			b.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte(realisticPatterns()), 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	cache := filepath.Join(b.TempDir(), "cache")
+	m, err := gitignore.LoadCache(cache, root)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := m.SaveCache(cache); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for b.Loop() {
+		if _, err := gitignore.LoadCache(cache, root); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
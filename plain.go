@@ -0,0 +1,32 @@
+package gitignore
+
+// WithoutGit treats root as a plain directory rather than a git working
+// tree, for linters, formatters and backup tools that want gitignore
+// semantics for any folder: .git/info/exclude isn't looked for, and the
+// user's global excludes are left out unless WithGlobalExcludes(true) is
+// given too. The .gitignore files are read as usual, and walks still skip
+// any .git directory they come across.
+func WithoutGit() Option {
+	return func(c *config) {
+		c.plain = true
+	}
+}
+
+// WithGlobalExcludes sets whether the user's global excludes file
+// (core.excludesfile, or git/ignore under the XDG config directory) is
+// read. It is by default, except with WithoutGit.
+func WithGlobalExcludes(read bool) Option {
+	return func(c *config) {
+		c.global = read
+		c.globalSet = true
+	}
+}
+
+// newGlobalExcludes returns the global excludes for a Matcher configured
+// by c, or nil if c leaves them out.
+func newGlobalExcludes(c *config) *globalExcludes {
+	if c.globalSet && !c.global || !c.globalSet && c.plain {
+		return nil
+	}
+	return &globalExcludes{cfg: c}
+}
@@ -0,0 +1,74 @@
+package gitignore_test
+
+import (
+	"io/fs"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestWithoutGit(t *testing.T) {
+	xdgDir := t.TempDir()
+	writeFiles(t, xdgDir, map[string]string{"git/ignore": "*.global\n"})
+	t.Setenv("XDG_CONFIG_HOME", xdgDir)
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".git/info/exclude": "*.exclude\n",
+		".gitignore":        "*.log\n",
+		"src/.gitignore":    "*.tmp\n",
+		"src/a.global":      "",
+		"src/a.exclude":     "",
+		"src/a.log":         "",
+		"src/a.tmp":         "",
+		"src/a.go":          "",
+	})
+	tests := []struct {
+		name    string
+		opts    []gitignore.Option
+		ignored map[string]bool
+	}{
+		{"repository", nil, map[string]bool{"global": true, "exclude": true}},
+		{"plain", []gitignore.Option{gitignore.WithoutGit()}, nil},
+		{"plain with global", []gitignore.Option{gitignore.WithoutGit(), gitignore.WithGlobalExcludes(true)}, map[string]bool{"global": true}},
+		{"repository without global", []gitignore.Option{gitignore.WithGlobalExcludes(false)}, map[string]bool{"exclude": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := gitignore.NewFromDirectory(root, tt.opts...)
+			for _, ext := range []string{"global", "exclude"} {
+				if got := m.Match("src/a." + ext); got != tt.ignored[ext] {
+					t.Errorf("Match(src/a.%s) = %v, want %v", ext, got, tt.ignored[ext])
+				}
+			}
+			if !m.Match("src/a.log") || !m.Match("src/a.tmp") || m.Match("src/a.go") {
+				t.Error("expected the .gitignore files to apply")
+			}
+
+			var walked []string
+			err := gitignore.WalkFS(os.DirFS(root), func(path string, d fs.DirEntry) error {
+				if !d.IsDir() {
+					walked = append(walked, path)
+				}
+				return nil
+			}, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []string{".gitignore", "src/.gitignore", "src/a.go"}
+			for _, ext := range []string{"exclude", "global"} {
+				if !tt.ignored[ext] {
+					want = append(want, "src/a."+ext)
+				}
+			}
+			slices.Sort(walked)
+			slices.Sort(want)
+			if !slices.Equal(walked, want) {
+				t.Errorf("walked %v, want %v", walked, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,7 @@
+//go:build race
+
+package gitignore_test
+
+// The race detector makes sync.Pool drop items at random, so tests of
+// pooled buffers can't count allocations.
+func init() { raceEnabled = true }
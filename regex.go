@@ -0,0 +1,95 @@
+package gitignore
+
+import (
+	"errors"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+)
+
+// WithRegexPatterns makes pattern lines starting with "re:", or "!re:"
+// for a negation, regular expressions in the syntax of the regexp
+// package, for rules wildmatch can't express: "re:v[0-9]+(\.[0-9]+)*"
+// ignores v1, v1.2 and v10.0.3 but not version or v1.x. Git has no such
+// syntax and reads the line as a glob, as this package does without the
+// option.
+//
+// The expression must match the whole path relative to the directory of
+// the ignore file it is in, slash-separated and without a trailing
+// slash, as if it were written between ^ and $. As with a glob, one
+// matching a directory matches everything inside it, files and
+// directories are matched alike, and the last pattern matching a path
+// decides it, whether a glob or an expression. With WithIgnoreCase,
+// expressions ignore case. One that doesn't compile is reported by
+// Errors and left out, whatever the InvalidPatternPolicy.
+//
+// WithDFA doesn't apply to a Matcher with expressions: its patterns are
+// all matched by the default engine.
+func WithRegexPatterns() Option {
+	return func(c *config) {
+		c.regex = true
+	}
+}
+
+// regexMarker starts the pattern lines WithRegexPatterns reads as
+// regular expressions.
+const regexMarker = "re:"
+
+// regexLine reports whether m reads line as a regular expression.
+func (m *Matcher) regexLine(line string) bool {
+	return m.regex && strings.HasPrefix(strings.TrimPrefix(line, "!"), regexMarker)
+}
+
+// compileRegex compiles line, a regular expression line, scoped to the
+// directory dirSegs. It returns an error message as compilePattern does.
+func (m *Matcher) compileRegex(line string, dirSegs []string) (pattern, string) {
+	p := pattern{prefixSegs: dirSegs, anchored: true, hasConcrete: true}
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	}
+	expr := strings.TrimPrefix(line, regexMarker)
+	if expr == "" {
+		return pattern{}, "empty pattern"
+	}
+	// Parsed on its own first, so the expression can't close the group
+	// it is wrapped in.
+	if _, err := syntax.Parse(expr, syntax.Perl); err != nil {
+		return pattern{}, regexError(err)
+	}
+	flags := "(?s)"
+	if m.ignoreCase {
+		flags = "(?is)"
+	}
+	re, err := regexp.Compile(flags + "^(?:" + expr + ")$")
+	if err != nil {
+		return pattern{}, regexError(err)
+	}
+	p.re = re
+	return p, ""
+}
+
+// regexError returns the error message for an expression that doesn't
+// compile.
+func regexError(err error) string {
+	var serr *syntax.Error
+	if errors.As(err, &serr) {
+		return "invalid regular expression: " + serr.Code.String()
+	}
+	return "invalid regular expression"
+}
+
+// matchRegex reports whether re, compiled from a regular expression
+// line, matches the path segs, relative to its scope, or a directory
+// above it.
+func matchRegex(re *regexp.Regexp, segs []string) bool {
+	path := strings.Join(segs, "/")
+	end := -1
+	for _, s := range segs {
+		end += len(s) + 1
+		if re.MatchString(path[:end]) {
+			return true
+		}
+	}
+	return false
+}
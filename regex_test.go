@@ -0,0 +1,195 @@
+package gitignore_test
+
+import (
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestRegexPatterns(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tests := []struct {
+		patterns string
+		want     map[string]bool
+	}{
+		{`re:v[0-9]+(\.[0-9]+)*`, map[string]bool{"v1": true, "v1.2": true, "v10.0.3": true, "version": false, "v1.x": false, "d/v1": false}},
+		{`re:(.*/)?v[0-9]+`, map[string]bool{"v1": true, "d/e/v2": true, "d/v": false}},
+		{`re:a|b`, map[string]bool{"a": true, "b": true, "ab": false}},
+		// A match ignores what is inside the directory too.
+		{`re:build`, map[string]bool{"build": true, "build/": true, "build/x/y": true, "x/build": false, "builds": false}},
+		{`re:x/.*\.js`, map[string]bool{"x/a.js": true, "x/a/b.js": true, "x/a.jsx": false}},
+		// Expressions and globs take part in the same last-match-wins.
+		{"*.log\n" + `!re:keep[0-9]\.log`, map[string]bool{"a.log": true, "keep1.log": false, "keepx.log": true}},
+		{`!re:.*\.log` + "\n*.log", map[string]bool{"a.log": true}},
+		{`re:.*\.tmp` + "\n!x.tmp", map[string]bool{"a.tmp": true, "x.tmp": false}},
+		// Only a marker at the start makes an expression.
+		{`\re:a`, map[string]bool{"re:a": true, "a": false}},
+		{`a/re:b`, map[string]bool{"a/re:b": true, "b": false}},
+	}
+	for _, tt := range tests {
+		for _, opts := range [][]gitignore.Option{nil, {gitignore.WithDFA()}, {gitignore.WithDirCache(8)}} {
+			m := gitignore.New(t.TempDir(), append(opts, gitignore.WithRegexPatterns())...)
+			m.AddPatterns([]byte(tt.patterns+"\n"), "")
+			for path, want := range tt.want {
+				if got := m.Match(path); got != want {
+					t.Errorf("%q: Match(%q) = %v, want %v", tt.patterns, path, got, want)
+				}
+			}
+			if errs := m.Errors(); len(errs) != 0 {
+				t.Errorf("%q: Errors() = %v", tt.patterns, errs)
+			}
+		}
+	}
+
+	// Like git, a Matcher without the option reads the line as a glob.
+	m := gitignore.New(t.TempDir())
+	m.AddPatterns([]byte("re:a*\n"), "")
+	if m.Match("a") || !m.Match("re:abc") {
+		t.Error("without the option: want the line read as a glob")
+	}
+}
+
+func TestRegexPatternsScope(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":     "# generated\n" + `re:gen/.*_test\.go` + "\n",
+		"src/.gitignore": `re:(?i)[a-z]+\.BAK` + "\n",
+	})
+	m := gitignore.NewFromDirectory(root, gitignore.WithRegexPatterns())
+	for path, want := range map[string]bool{
+		"gen/a_test.go":     true,
+		"src/gen/a_test.go": false,
+		"src/a.bak":         true,
+		"src/d/a.bak":       false,
+		"a.bak":             false,
+	} {
+		if got := m.Match(path); got != want {
+			t.Errorf("Match(%q) = %v, want %v", path, got, want)
+		}
+	}
+	r := m.MatchDetail("src/x.Bak")
+	if !r.Ignored || r.Pattern != `re:(?i)[a-z]+\.BAK` || r.Line != 1 {
+		t.Errorf("MatchDetail(src/x.Bak) = %+v", r)
+	}
+
+	m = gitignore.New(t.TempDir(), gitignore.WithRegexPatterns(), gitignore.WithIgnoreCase(true))
+	m.AddPatterns([]byte("re:README(\\.md)?\n"), "docs")
+	if !m.Match("docs/readme") || !m.Match("Docs/ReadMe.MD") || m.Match("readme") {
+		t.Error("WithIgnoreCase: want the expression to ignore case under its scope")
+	}
+}
+
+func TestRegexPatternsFull(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	m := gitignore.New(t.TempDir(), gitignore.WithRegexPatterns())
+	m.AddPatterns([]byte("re:build\n!re:build/keep\nre:out/.*\n!out/keep\n"), "")
+	for path, want := range map[string]bool{
+		"build/keep": true, // build itself is excluded
+		"build/x":    true,
+		"out/keep":   false,
+		"out/x":      true,
+	} {
+		if got := m.MatchFull(path); got != want {
+			t.Errorf("MatchFull(%q) = %v, want %v", path, got, want)
+		}
+	}
+	if m.Match("build/keep") {
+		t.Error("Match(build/keep) = true, want the negation to decide it")
+	}
+	if m.IsCompletelyIgnored("build") {
+		t.Error("IsCompletelyIgnored(build) = true, want the negation to count")
+	}
+	if !m.CouldIgnoreWithin("src") || !m.CouldReincludeWithin("src") {
+		t.Error("want expressions to match anywhere for the Could checks")
+	}
+	if m.MatchDirContents("out") {
+		t.Error("MatchDirContents(out) = true, want the negation to count")
+	}
+}
+
+func TestRegexPatternsErrors(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	for _, policy := range []gitignore.InvalidPatternPolicy{gitignore.SkipInvalid, gitignore.LiteralInvalid} {
+		m := gitignore.New(t.TempDir(), gitignore.WithRegexPatterns(), gitignore.WithInvalidPatterns(policy))
+		m.AddPatterns([]byte("re:(\nre:a)|(b\nre:\n!re:\nre:x{2}\n"), "")
+		want := []string{
+			"invalid regular expression: missing closing )",
+			"invalid regular expression: unexpected )",
+			"empty pattern",
+			"empty pattern",
+		}
+		errs := m.Errors()
+		if len(errs) != len(want) {
+			t.Fatalf("Errors() = %v, want %d", errs, len(want))
+		}
+		for i, e := range errs {
+			if e.Message != want[i] || e.Line != i+1 {
+				t.Errorf("error %d = %+v, want %q", i, e, want[i])
+			}
+		}
+		for _, path := range []string{"re:(", "(", "b", "a)|(b"} {
+			if m.Match(path) {
+				t.Errorf("Match(%q) = true, want invalid expressions left out", path)
+			}
+		}
+		if !m.Match("xx") {
+			t.Error("Match(xx) = false")
+		}
+	}
+
+	// Brace expansion leaves expressions alone.
+	m := gitignore.New(t.TempDir(), gitignore.WithRegexPatterns(), gitignore.WithBraceExpansion())
+	m.AddPatterns([]byte("re:a{2,3}\n"), "")
+	if !m.Match("aaa") || m.Match("a") || len(m.Errors()) != 0 {
+		t.Errorf("WithBraceExpansion: want the expression kept whole, got %v", m.Errors())
+	}
+}
+
+func TestRegexPatternsFingerprint(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	m := gitignore.New(t.TempDir(), gitignore.WithRegexPatterns())
+	m.AddPatterns([]byte("re:[0-9]+\\.out\n"), "")
+	plain := gitignore.New(t.TempDir())
+	plain.AddPatterns([]byte("re:[0-9]+\\.out\n"), "")
+	if plain.Fingerprint() == m.Fingerprint() {
+		t.Error("Fingerprint: want the option to count")
+	}
+}
+
+func TestRegexPatternsGlobal(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	writeFiles(t, xdg, map[string]string{"git/ignore": "re:v[0-9]+\n"})
+	root := t.TempDir()
+
+	// The global excludes are compiled once and shared, so the Matcher
+	// reading globs comes first and each expecting expressions after.
+	if gitignore.New(root).Match("v1") {
+		t.Error("without WithRegexPatterns: want the line read as a glob")
+	}
+	for name, opts := range map[string][]gitignore.Option{
+		"shared":     nil,
+		"WithStats":  {gitignore.WithStats()},
+		"WithVerify": {gitignore.WithVerify()},
+	} {
+		m := gitignore.New(root, append(opts, gitignore.WithRegexPatterns())...)
+		if !m.Match("v1") || !m.Match("v10") || m.Match("re:v[0-9]+") || m.Match("version") {
+			t.Errorf("%s: want the global line read as an expression", name)
+		}
+	}
+	if !gitignore.New(root).Match("re:v1+") {
+		t.Error("without WithRegexPatterns after: want the line read as a glob")
+	}
+}
@@ -0,0 +1,309 @@
+package gitignore
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Rescope rewrites data, the ignore file of the directory from, as rules
+// for the ignore file of the directory to, so that each ignores there the
+// paths it ignored where it was, for monorepos consolidating scattered
+// ignore files. Directories are slash-separated and relative to the
+// root, which is "".
+//
+// Hoisting rules into a directory above from always works: "gen/" in
+// pkg/api/.gitignore is "/pkg/api/**/gen/" in the root .gitignore, and
+// "/openapi.json" is "/pkg/api/openapi.json". Moving rules into a
+// directory below from works for rules anchored under it, so the root's
+// "/pkg/api/gen/" is "/gen/" in pkg/api/.gitignore; the rest, like
+// "*.log", which applies outside pkg/api too, or a rule for pkg/api
+// itself, are reported and left out. A rule that doesn't compile, and so
+// matches nothing, is left out too. Comments and blank lines are kept,
+// and issues are in line order.
+//
+// Rescope rewrites rules one at a time. Where the rewritten rules go in
+// the file at to still matters, since git gives the rules of a deeper
+// file precedence; MoveRules places them and checks the result.
+func Rescope(data []byte, from, to string) ([]byte, []TranslateIssue) {
+	from, to = cleanDir(from), cleanDir(to)
+	var issues []TranslateIssue
+	var b strings.Builder
+	text := strings.TrimPrefix(string(data), utf8BOM)
+	if text == "" {
+		return nil, nil
+	}
+	n := 0
+	for raw := range strings.SplitSeq(strings.TrimSuffix(text, "\n"), "\n") {
+		n++
+		line := strings.TrimSuffix(raw, "\r")
+		p := trimTrailingSpaces(line)
+		if p == "" || p[0] == '#' {
+			b.WriteString(line)
+			b.WriteByte('\n')
+			continue
+		}
+		out, msg := rescopeRule(p, from, to)
+		if msg != "" {
+			issues = append(issues, TranslateIssue{Pattern: p, Line: n, Message: msg, Dropped: true})
+			continue
+		}
+		b.WriteString(out)
+		b.WriteByte('\n')
+	}
+	return []byte(b.String()), issues
+}
+
+// rescopeRule returns the rule p of the ignore file of from as a rule of
+// that of to, or why it has none.
+func rescopeRule(p, from, to string) (string, string) {
+	if _, msg := compilePattern(p, nil, nil); msg != "" {
+		return "", "invalid pattern, which matches nothing: " + msg
+	}
+	neg := ""
+	if p[0] == '!' {
+		neg, p = "!", p[1:]
+	}
+	body := strings.TrimPrefix(p, "/")
+	anchored := body != p || strings.Contains(strings.TrimSuffix(body, "/"), "/")
+
+	switch {
+	case from == to:
+		return neg + p, ""
+	case to == "" || strings.HasPrefix(from, to+"/"):
+		rel := from
+		if to != "" {
+			rel = from[len(to)+1:]
+		}
+		if !anchored {
+			body = "**/" + body
+		}
+		return neg + "/" + escapeDir(rel) + "/" + body, ""
+	case from != "" && !strings.HasPrefix(to, from+"/"):
+		return "", "applies in " + from + "/, outside " + to + "/"
+	}
+
+	rel := to
+	if from != "" {
+		rel = to[len(from)+1:]
+	}
+	if !anchored {
+		return "", "applies outside " + to + "/"
+	}
+	segs := strings.Split(body, "/")
+	dirs := strings.Split(rel, "/")
+	for i, d := range dirs {
+		if i == len(segs) || !literalSegment(segs[i], d) {
+			return "", "applies outside " + to + "/"
+		}
+	}
+	rest := strings.Join(segs[len(dirs):], "/")
+	if rest == "" {
+		return "", "matches " + to + "/ itself, which its own ignore file can't"
+	}
+	return neg + "/" + rest, ""
+}
+
+// literalSegment reports whether the glob segment seg matches the name
+// d and nothing else.
+func literalSegment(seg, d string) bool {
+	var b strings.Builder
+	for i := 0; i < len(seg); i++ {
+		c := seg[i]
+		if c == '\\' {
+			if i++; i == len(seg) {
+				return false
+			}
+			c = seg[i]
+		} else if isGlobSpecial(c) {
+			return false
+		}
+		b.WriteByte(c)
+	}
+	return b.String() == d
+}
+
+// escapeDir escapes the characters of the directory dir that are special
+// in a pattern.
+func escapeDir(dir string) string {
+	var b strings.Builder
+	for i := 0; i < len(dir); i++ {
+		if isGlobSpecial(dir[i]) {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(dir[i])
+	}
+	return b.String()
+}
+
+// cleanDir returns dir, slash-separated, as Rescope reads it: relative to
+// the root, which is "", without a leading or trailing slash.
+func cleanDir(dir string) string {
+	return path.Clean("/" + dir)[1:]
+}
+
+// MoveRules moves the rules of the .gitignore of the directory from into
+// that of the directory to, rewritten by Rescope, in the tree at root,
+// for consolidating a monorepo's ignore files a directory at a time. One
+// of from and to must be inside the other; both are slash-separated and
+// relative to root, which is "".
+//
+// Hoisted rules are appended to the file at to, after a comment naming
+// the file they came from, so they still take precedence over its own
+// rules, and rules moved down are put first, so they still give way to
+// them. A rule
+// Rescope can't move, such as "*.log" moved from the root into pkg/api,
+// stays where it was and is returned as an issue. If every rule moves,
+// comments and blank lines move with them and the file at from is
+// removed; otherwise its comments stay.
+//
+// Before writing, MoveRules checks with Equivalent that the ignore files
+// from and to and of the directories between them ignore the same paths
+// after the move as before. They can differ where an ignore file between
+// the two has rules of its own, which the hoisted rules took precedence
+// over and now give way to. It is then an error naming such a path, and
+// nothing is written. The file at to is written before that at from is
+// changed, so a failure between the two leaves rules in both rather than
+// in neither.
+func MoveRules(root, from, to string) ([]TranslateIssue, error) {
+	from, to = cleanDir(from), cleanDir(to)
+	hoist := to == "" && from != "" || strings.HasPrefix(from, to+"/")
+	if from == to || !hoist && !(from == "" || strings.HasPrefix(to, from+"/")) {
+		return nil, fmt.Errorf("gitignore: MoveRules needs one of %q and %q inside the other", from, to)
+	}
+	file := func(dir string) string {
+		return filepath.Join(root, filepath.FromSlash(dir), ".gitignore")
+	}
+	fromData, err := os.ReadFile(file(from))
+	if err != nil {
+		return nil, err
+	}
+	toData, toMode, err := readIgnoreFile(file(to))
+	if err != nil {
+		return nil, err
+	}
+
+	out, issues := Rescope(fromData, from, to)
+	src := ParseFile(fromData)
+	moved := ParseFile(out).Lines
+	removeFrom := len(issues) == 0
+	if !removeFrom {
+		// Only the rules that can move do; the comments stay.
+		stays := map[int]bool{}
+		for _, is := range issues {
+			stays[is.Line-1] = true
+		}
+		moved = nil
+		var gone []int
+		for i, l := range src.Lines {
+			if l.Kind == LinePattern && !stays[i] {
+				r, _ := rescopeRule(l.Pattern, from, to)
+				moved = append(moved, NewLine(r))
+				gone = append(gone, i)
+			}
+		}
+		if len(moved) == 0 {
+			return issues, nil
+		}
+		for _, i := range slices.Backward(gone) {
+			src.Remove(i)
+		}
+	}
+
+	dst := ParseFile(toData)
+	header := NewLine("# Moved from " + path.Join(from, ".gitignore"))
+	if hoist {
+		var lines []Line
+		if n := len(dst.Lines); n > 0 && dst.Lines[n-1].Kind != LineBlank {
+			lines = append(lines, NewLine(""))
+		}
+		dst.Insert(len(dst.Lines), append(append(lines, header), moved...)...)
+	} else {
+		lines := append([]Line{header}, moved...)
+		if len(dst.Lines) > 0 {
+			lines = append(lines, NewLine(""))
+		}
+		dst.Insert(0, lines...)
+	}
+
+	// The files whose rules can decide a path below from.
+	top, bottom := to, from
+	if !hoist {
+		top, bottom = from, to
+	}
+	before, after := newMatcher(&config{}), newMatcher(&config{})
+	for _, d := range chainDirs(top, bottom) {
+		var old, cur []byte
+		switch d {
+		case from:
+			old, cur = fromData, nil
+			if !removeFrom {
+				cur = src.Bytes()
+			}
+		case to:
+			old, cur = toData, dst.Bytes()
+		default:
+			old, err = os.ReadFile(file(d))
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+			cur = old
+		}
+		before.AddPatterns(old, d)
+		after.AddPatterns(cur, d)
+	}
+	if eq := Equivalent(before, after); !eq.Equivalent && eq.Counterexample != "" {
+		return nil, fmt.Errorf("gitignore: moving the rules of %s to %s would change whether %s is ignored",
+			path.Join(from, ".gitignore"), path.Join(to, ".gitignore"), eq.Counterexample)
+	}
+
+	if err := writeFile(file(to), dst.Bytes(), toMode); err != nil {
+		return nil, err
+	}
+	if removeFrom {
+		return issues, os.Remove(file(from))
+	}
+	info, err := os.Stat(file(from))
+	if err != nil {
+		return nil, err
+	}
+	return issues, writeFile(file(from), src.Bytes(), info.Mode().Perm())
+}
+
+// readIgnoreFile returns the ignore file at path and its permissions, or
+// nothing and 0644 if there is none.
+func readIgnoreFile(path string) ([]byte, fs.FileMode, error) {
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, 0o644, nil
+	case err != nil:
+		return nil, 0, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, info.Mode().Perm(), nil
+}
+
+// chainDirs returns top, the directories below it leading to bottom, and
+// bottom, which is inside top.
+func chainDirs(top, bottom string) []string {
+	dirs := []string{top}
+	rest := bottom
+	if top != "" {
+		rest = bottom[len(top)+1:]
+	}
+	d := top
+	for name := range strings.SplitSeq(rest, "/") {
+		d = path.Join(d, name)
+		dirs = append(dirs, d)
+	}
+	return dirs
+}
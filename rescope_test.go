@@ -0,0 +1,181 @@
+package gitignore_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestRescope(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		from, to string
+		want     string
+		dropped  []int
+	}{
+		{
+			name: "hoist to the root",
+			in:   "# generated\ngen/\n/openapi.json\ndocs/*.html\n!keep.go\n",
+			from: "pkg/api",
+			want: "# generated\n/pkg/api/**/gen/\n/pkg/api/openapi.json\n/pkg/api/docs/*.html\n!/pkg/api/**/keep.go\n",
+		},
+		{
+			name: "hoist one level",
+			in:   "*.log\n",
+			from: "pkg/api/",
+			to:   "pkg",
+			want: "/api/**/*.log\n",
+		},
+		{
+			name: "hoist escapes directory names",
+			in:   "out\n",
+			from: "web/[id]",
+			want: "/web/\\[id]/**/out\n",
+		},
+		{
+			name:    "push down",
+			in:      "*.log\n/pkg/api/gen/\npkg/api/**/*.pb.go\n!pkg/api/keep\npkg/*/tmp\n/pkg/api/\n",
+			to:      "pkg/api",
+			want:    "/gen/\n/**/*.pb.go\n!/keep\n",
+			dropped: []int{1, 5, 6},
+		},
+		{
+			name:    "siblings",
+			in:      "/a\n",
+			from:    "x",
+			to:      "y",
+			want:    "",
+			dropped: []int{1},
+		},
+		{
+			name:    "invalid",
+			in:      "a\\\n",
+			from:    "x",
+			want:    "",
+			dropped: []int{1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, issues := gitignore.Rescope([]byte(tt.in), tt.from, tt.to)
+			if string(got) != tt.want {
+				t.Errorf("Rescope = %q, want %q", got, tt.want)
+			}
+			var dropped []int
+			for _, is := range issues {
+				if !is.Dropped {
+					t.Errorf("issue not dropped: %v", is)
+				}
+				dropped = append(dropped, is.Line)
+			}
+			if !reflect.DeepEqual(dropped, tt.dropped) {
+				t.Errorf("dropped lines %v, want %v (%v)", dropped, tt.dropped, issues)
+			}
+		})
+	}
+}
+
+func TestRescopeSemantics(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	rules := "gen/\n/openapi.json\n*.log\n!keep.log\ndocs/**/*.html\n"
+	nested := gitignore.New(t.TempDir())
+	nested.AddPatterns([]byte(rules), "pkg/api")
+	out, issues := gitignore.Rescope([]byte(rules), "pkg/api", "")
+	if len(issues) != 0 {
+		t.Fatal(issues)
+	}
+	hoisted := gitignore.New(t.TempDir())
+	hoisted.AddPatterns(out, "")
+	if eq := gitignore.Equivalent(nested, hoisted); !eq.Equivalent {
+		t.Errorf("hoisted rules differ on %q", eq.Counterexample)
+	}
+}
+
+func TestMoveRules(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	write := func(root, name, data string) {
+		t.Helper()
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read := func(root, name string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+		if err != nil {
+			return "<" + err.Error() + ">"
+		}
+		return string(data)
+	}
+
+	t.Run("hoist", func(t *testing.T) {
+		root := t.TempDir()
+		write(root, ".gitignore", "*.log\n")
+		write(root, "pkg/api/.gitignore", "# codegen\ngen/\n!debug.log\n")
+		issues, err := gitignore.MoveRules(root, "pkg/api", "")
+		if err != nil || len(issues) != 0 {
+			t.Fatal(issues, err)
+		}
+		want := "*.log\n\n# Moved from pkg/api/.gitignore\n# codegen\n/pkg/api/**/gen/\n!/pkg/api/**/debug.log\n"
+		if got := read(root, ".gitignore"); got != want {
+			t.Errorf("root .gitignore = %q, want %q", got, want)
+		}
+		if _, err := os.Stat(filepath.Join(root, "pkg/api/.gitignore")); !os.IsNotExist(err) {
+			t.Errorf("pkg/api/.gitignore not removed: %v", err)
+		}
+	})
+
+	t.Run("push down", func(t *testing.T) {
+		root := t.TempDir()
+		write(root, ".gitignore", "# all\n*.log\n/pkg/api/gen/\n")
+		write(root, "pkg/api/.gitignore", "!gen/keep\n")
+		issues, err := gitignore.MoveRules(root, "", "pkg/api")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(issues) != 1 || issues[0].Pattern != "*.log" {
+			t.Errorf("issues = %v, want *.log", issues)
+		}
+		if got, want := read(root, ".gitignore"), "# all\n*.log\n"; got != want {
+			t.Errorf("root .gitignore = %q, want %q", got, want)
+		}
+		if got, want := read(root, "pkg/api/.gitignore"), "# Moved from .gitignore\n/gen/\n\n!gen/keep\n"; got != want {
+			t.Errorf("pkg/api/.gitignore = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("precedence changes", func(t *testing.T) {
+		root := t.TempDir()
+		write(root, "pkg/.gitignore", "!*.gen.go\n")
+		write(root, "pkg/api/.gitignore", "*.go\n")
+		_, err := gitignore.MoveRules(root, "pkg/api", "")
+		if err == nil || !strings.Contains(err.Error(), "pkg/api/") {
+			t.Fatalf("MoveRules past a conflicting file: %v", err)
+		}
+		if got := read(root, "pkg/api/.gitignore"); got != "*.go\n" {
+			t.Errorf("pkg/api/.gitignore changed: %q", got)
+		}
+		if _, err := os.Stat(filepath.Join(root, ".gitignore")); !os.IsNotExist(err) {
+			t.Errorf(".gitignore written: %v", err)
+		}
+	})
+
+	t.Run("unrelated directories", func(t *testing.T) {
+		if _, err := gitignore.MoveRules(t.TempDir(), "a", "b"); err == nil {
+			t.Error("MoveRules between siblings succeeded")
+		}
+	})
+}
@@ -0,0 +1,144 @@
+package gitignore
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Ripgrep decides which files ripgrep searches in a directory, following
+// the rules of the ignore crate ripgrep is built on rather than git's,
+// for Go search tools that need to select the same files:
+//
+//   - Each directory's .rgignore, .ignore and .gitignore are all read,
+//     with gitignore syntax and scoping.
+//   - They rank by kind rather than by depth. A path any .rgignore
+//     pattern matches is decided by the .rgignore files, the deepest one
+//     first, whatever the others say; failing that by the .ignore files;
+//     then by the .gitignore files and .git/info/exclude, as git decides
+//     it; then by the global excludes; and last by the files given with
+//     WithIgnoreFile, as ripgrep's --ignore-file. So "!build/" in the
+//     root .ignore re-includes a directory a nested .gitignore ignores,
+//     and "!a.log" in an --ignore-file doesn't re-include what the
+//     global excludes ignore.
+//   - .gitignore, .git/info/exclude and the global excludes apply only
+//     inside a git repository, when root or a directory above it holds
+//     .git. With WithoutGit the .gitignore files apply anyway, as with
+//     ripgrep's --no-require-git, and the global excludes only with
+//     WithGlobalExcludes(true).
+//
+// Ignore files in the directories above root aren't read, and ripgrep's
+// other filters, such as those for hidden and binary files, aren't
+// applied.
+type Ripgrep struct {
+	root string
+	opts []Option
+	w    *walker // with the whole tree loaded, for Match
+}
+
+// LoadRipgrep reads the ignore files of the tree in root, as LoadDirectory
+// reads a repository, and returns errors as it does. opts are those of
+// LoadDirectory and the walks, except that WithIgnoreFileNames doesn't
+// apply and the files of WithIgnoreFile rank last, not first.
+func LoadRipgrep(root string, opts ...Option) (*Ripgrep, error) {
+	r := &Ripgrep{root: root, opts: opts}
+	w, err := r.walker(nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.walk("", 0); err != nil {
+		return nil, err
+	}
+	r.w = w
+	return r, nil
+}
+
+// Match reports whether ripgrep skips relPath. The path is as for Match
+// on a Matcher, with its parent directories taken into account as
+// MatchFull does, since ripgrep doesn't look inside a directory it skips.
+func (r *Ripgrep) Match(relPath string) bool {
+	relPath, isDir, ok := r.w.m.path(relPath, true)
+	if !ok || relPath == "" {
+		return false
+	}
+	segs := strings.Split(relPath, "/")
+	for i := 1; i < len(segs); i++ {
+		if r.w.ignored(segs[:i], true) {
+			return true
+		}
+	}
+	return r.w.ignored(segs, isDir)
+}
+
+// Walk traverses the tree as Walk does, with the options given to
+// LoadRipgrep, passing fn the entries ripgrep searches. The ignore files
+// are read afresh as the walk reaches them.
+func (r *Ripgrep) Walk(fn func(path string, d fs.DirEntry) error) error {
+	w, err := r.walker(pathFunc(fn))
+	if err != nil {
+		return err
+	}
+	return w.walk("", 0)
+}
+
+// walker returns a walker of the tree passing fn the entries ripgrep
+// searches, with the ignore files in root loaded.
+func (r *Ripgrep) walker(fn func(Entry) error) (*walker, error) {
+	c := newConfig(r.opts)
+	layer := func(name string) *Matcher {
+		m := newMatcher(c)
+		m.root = absRoot(r.root)
+		m.names = []string{name}
+		m.addRootIgnoreFile(r.root)
+		return m
+	}
+	rg, ignore := layer(".rgignore"), layer(".ignore")
+
+	// The global excludes come before any WithIgnoreFile patterns, so
+	// they are a layer of their own, and the walker's Matcher, consulted
+	// last, holds nothing.
+	git, global, none := newMatcher(c), newMatcher(c), newMatcher(c)
+	git.names, global.names, none.names = []string{}, []string{}, []string{}
+	if c.plain || inGitRepo(r.root) {
+		git = New(r.root, append(slices.Clip(r.opts), WithIgnoreFileNames(".gitignore"), WithGlobalExcludes(false))...)
+		global.global = newGlobalExcludes(c)
+	}
+
+	w := newOSWalker(r.root, none, fn)
+	if err := w.configure(r.opts); err != nil {
+		return nil, err
+	}
+	w.layers = []*Matcher{rg, ignore, git, global}
+	if w.extra != nil {
+		// As a layer it would otherwise read the .gitignore files too.
+		w.extra.names = []string{}
+		w.layers = append(w.layers, w.extra)
+		w.extra = nil
+	}
+	for _, l := range w.layers {
+		if err := firstLimit(l.errors); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.checkInvalid(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// inGitRepo reports whether root, or a directory above it, holds .git.
+func inGitRepo(root string) bool {
+	for dir := absRoot(root); dir != ""; {
+		if _, err := os.Lstat(filepath.Join(dir, ".git")); err == nil {
+			return true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+	return false
+}
@@ -0,0 +1,214 @@
+package gitignore_test
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestRipgrep(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	writeFiles(t, xdg, map[string]string{"git/ignore": "*.global\n"})
+
+	root := t.TempDir()
+	extra := filepath.Join(t.TempDir(), "extra")
+	if err := os.WriteFile(extra, []byte("*.extra\n!keep.global\n*.exclude2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, root, map[string]string{
+		".git/info/exclude": "*.exclude\n*.exclude2\n!keep.extra\n",
+		".gitignore":        "*.log\nbuild/\n",
+		".ignore":           "!build/\n*.tmp\n!keep.log\n",
+		".rgignore":         "!keep.tmp\n",
+		"a.log":             "",
+		"keep.log":          "",
+		"a.tmp":             "",
+		"keep.tmp":          "",
+		"a.exclude":         "",
+		"a.exclude2":        "",
+		"a.extra":           "",
+		"keep.extra":        "",
+		"a.global":          "",
+		"keep.global":       "",
+		"build/out":         "",
+		"sub/.gitignore":    "!*.tmp\n*.md\n",
+		"sub/.ignore":       "!a.md\n",
+		"sub/a.tmp":         "",
+		"sub/a.md":          "",
+		"sub/b.md":          "",
+		"sub/.rgignore":     "x.txt\n",
+		"sub/x.txt":         "",
+		"other/x.txt":       "",
+	})
+
+	want := map[string]bool{
+		"a.log":       true,
+		"keep.log":    false, // .ignore beats .gitignore
+		"a.tmp":       true,
+		"keep.tmp":    false, // .rgignore beats .ignore
+		"a.exclude":   true,
+		"a.exclude2":  true, // .git/info/exclude beats the extra file
+		"a.extra":     true,
+		"keep.extra":  false, // negated by .git/info/exclude
+		"a.global":    true,
+		"keep.global": true,  // the global excludes beat the extra file
+		"build/":      false, // re-included by the root .ignore
+		"build/out":   false,
+		"sub/a.tmp":   true, // the root .ignore beats sub/.gitignore
+		"sub/a.md":    false,
+		"sub/b.md":    true,
+		"sub/x.txt":   true,
+		"other/x.txt": false,
+	}
+	r, err := gitignore.LoadRipgrep(root, gitignore.WithIgnoreFile(extra))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, ignored := range want {
+		if got := r.Match(path); got != ignored {
+			t.Errorf("Match(%q) = %v, want %v", path, got, ignored)
+		}
+	}
+	walked := make(map[string]bool)
+	err = r.Walk(func(path string, _ fs.DirEntry) error {
+		walked[filepath.ToSlash(path)] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, ignored := range want {
+		if walked[strings.TrimSuffix(path, "/")] == ignored {
+			t.Errorf("Walk visited %s = %v, want %v", path, ignored, !ignored)
+		}
+	}
+}
+
+func TestRipgrepOutsideRepo(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	writeFiles(t, xdg, map[string]string{"git/ignore": "*.global\n"})
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":     "*.log\n",
+		".ignore":        "*.tmp\n",
+		"sub/.gitignore": "*.md\n",
+		"a.log":          "",
+		"a.tmp":          "",
+		"a.global":       "",
+		"sub/a.md":       "",
+	})
+	if err := os.RemoveAll(filepath.Join(root, ".git")); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		opts []gitignore.Option
+		want map[string]bool
+	}{
+		{nil, map[string]bool{"a.log": false, "a.tmp": true, "a.global": false, "sub/a.md": false}},
+		{
+			[]gitignore.Option{gitignore.WithoutGit()},
+			map[string]bool{"a.log": true, "a.tmp": true, "a.global": false, "sub/a.md": true},
+		},
+		{
+			[]gitignore.Option{gitignore.WithoutGit(), gitignore.WithGlobalExcludes(true)},
+			map[string]bool{"a.log": true, "a.tmp": true, "a.global": true, "sub/a.md": true},
+		},
+	} {
+		r, err := gitignore.LoadRipgrep(root, tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for path, want := range tt.want {
+			if got := r.Match(path); got != want {
+				t.Errorf("%d options: Match(%q) = %v, want %v", len(tt.opts), path, got, want)
+			}
+		}
+	}
+}
+
+func TestRipgrepIgnoreFileBelowGlobal(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	writeFiles(t, xdg, map[string]string{"git/ignore": "*.log\n"})
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"a.log": "", "b.txt": ""})
+	extra := filepath.Join(t.TempDir(), "extra")
+	if err := os.WriteFile(extra, []byte("!a.log\n*.txt\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := gitignore.LoadRipgrep(root, gitignore.WithIgnoreFile(extra))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The ignore crate ranks --ignore-file below the global excludes, so
+	// its negation doesn't re-include a.log.
+	if !r.Match("a.log") || !r.Match("b.txt") {
+		t.Errorf("Match(a.log) = %v, Match(b.txt) = %v, want both ignored", r.Match("a.log"), r.Match("b.txt"))
+	}
+	var got []string
+	err = r.Walk(func(path string, d fs.DirEntry) error {
+		if !d.IsDir() {
+			got = append(got, path)
+		}
+		return nil
+	})
+	if err != nil || len(got) != 0 {
+		t.Errorf("Walk = %q, %v, want nothing", got, err)
+	}
+}
+
+func TestIgnoreFilePrecedence(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	writeFiles(t, xdg, map[string]string{"git/ignore": "*.log\n"})
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"a.log": "", "b.log": ""})
+	extra := filepath.Join(t.TempDir(), "extra")
+	if err := os.WriteFile(extra, []byte("!a.log\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files := func(got *[]string) func(string, fs.DirEntry) error {
+		return func(path string, d fs.DirEntry) error {
+			if !d.IsDir() {
+				*got = append(*got, path)
+			}
+			return nil
+		}
+	}
+
+	// Walk ranks the extra file above the global excludes.
+	var walked []string
+	if err := gitignore.Walk(root, files(&walked), gitignore.WithIgnoreFile(extra)); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(walked, []string{"a.log"}) {
+		t.Errorf("Walk = %q, want the extra file's negation to re-include a.log", walked)
+	}
+
+	// LoadRipgrep ranks it below them, as ripgrep does.
+	r, err := gitignore.LoadRipgrep(root, gitignore.WithIgnoreFile(extra))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var searched []string
+	if err := r.Walk(files(&searched)); err != nil {
+		t.Fatal(err)
+	}
+	if len(searched) != 0 || !r.Match("a.log") {
+		t.Errorf("Ripgrep Walk = %q, want a.log left to the global excludes", searched)
+	}
+}
@@ -0,0 +1,409 @@
+package gitignore
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// RsyncFilter decides which paths rsync leaves out of a transfer, given
+// filter rules in the syntax of rsync's --filter and --filter-from, for
+// backup tools that take rsync-style filters. rsync's rules aren't
+// gitignore's:
+//
+//   - Each line is a rule: "- PATTERN" (or "exclude PATTERN") excludes,
+//     "+ PATTERN" (or "include PATTERN") includes, and "!" (or "clear")
+//     drops the rules before it. ". FILE" (or "merge FILE") reads the
+//     rules in FILE in its place, and ": FILE" (or "dir-merge FILE")
+//     the rules in the file called FILE in each directory, for the paths
+//     below it. Blank lines, and lines starting with "#" or ";", are
+//     skipped. Rule modifiers aren't supported.
+//   - The first rule matching a path decides it, and a path no rule
+//     matches is included. The rules of a dir-merge file come ahead of
+//     those inherited from the directories above it.
+//   - A pattern starting with a slash is anchored to the root of the
+//     transfer, or to the directory of its dir-merge file; any other
+//     matches the end of a path, at a segment boundary. A trailing slash
+//     matches directories only.
+//   - "*" matches anything but a slash, "**" anything including
+//     slashes, wherever it is, and "?" and brackets one character other
+//     than a slash. A trailing "/***" matches a directory and everything
+//     in it.
+//   - rsync doesn't look inside an excluded directory, so Match ignores
+//     whatever is inside one, whatever an include says.
+type RsyncFilter struct {
+	root  string
+	rules rsyncList
+
+	// merged holds the rules of the dir-merge files read, by the index
+	// of the dir-merge rule in rules and then by directory.
+	merged map[int]map[string]*rsyncList
+}
+
+// rsyncList is a list of filter rules, as read from one source.
+type rsyncList struct {
+	rules   []rsyncRule
+	cleared bool // a "!" drops the rules inherited from above
+}
+
+// rsyncRule is a parsed filter rule.
+type rsyncRule struct {
+	re       *regexp.Regexp // nil for a dir-merge rule
+	exclude  bool
+	dirOnly  bool
+	dirMerge string // the per-directory file name, for a dir-merge rule
+}
+
+// ParseRsyncFilter parses rules, as given to rsync's --filter-from, for
+// a transfer of the directory root, reading the files they merge as it
+// goes: a relative merge file from the working directory, as rsync does,
+// and the dir-merge files from every directory under root that isn't
+// excluded. It returns the PatternError of the first rule it can't
+// parse, or the error of a merge file it can't read.
+func ParseRsyncFilter(root string, rules []byte) (*RsyncFilter, error) {
+	f := &RsyncFilter{root: root}
+	if err := f.parse(&f.rules, rules, "", "", true, nil); err != nil {
+		return nil, err
+	}
+	for i, r := range f.rules.rules {
+		if r.dirMerge != "" {
+			if f.merged == nil {
+				f.merged = make(map[int]map[string]*rsyncList)
+			}
+			f.merged[i] = make(map[string]*rsyncList)
+		}
+	}
+	if f.merged != nil {
+		if err := f.loadDirMerges("", true); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// parse adds the rules in data, read from source, to list. Relative merge
+// files are read from the directory dir. dirMerges is whether dir-merge
+// rules are allowed; reading is the set of merge files being read, to
+// stop a file merging itself.
+func (f *RsyncFilter) parse(list *rsyncList, data []byte, source, dir string, dirMerges bool, reading map[string]bool) error {
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; s.Scan(); line++ {
+		text := s.Text()
+		if text == "" || text[0] == '#' || text[0] == ';' {
+			continue
+		}
+		perr := PatternError{Pattern: text, Source: source, Line: line}
+		kind, arg, ok := splitRsyncRule(text)
+		switch {
+		case !ok:
+			perr.Message = "unknown filter rule"
+			return perr
+		case kind == "!":
+			list.rules = list.rules[:0]
+			list.cleared = true
+		case kind == ".":
+			path := arg
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+			if reading[path] {
+				perr.Message = "merge file includes itself"
+				return perr
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if reading == nil {
+				reading = make(map[string]bool)
+			}
+			reading[path] = true
+			err = f.parse(list, data, path, filepath.Dir(path), dirMerges, reading)
+			delete(reading, path)
+			if err != nil {
+				return err
+			}
+		case kind == ":":
+			if !dirMerges {
+				perr.Message = "dir-merge rules are only supported at the top level"
+				return perr
+			}
+			if arg == "" || strings.Contains(arg, "/") {
+				perr.Message = "dir-merge takes a file name"
+				return perr
+			}
+			list.rules = append(list.rules, rsyncRule{dirMerge: arg})
+		default:
+			rules, err := compileRsyncPattern(arg, kind == "-")
+			if err != nil {
+				perr.Message = err.Error()
+				return perr
+			}
+			list.rules = append(list.rules, rules...)
+		}
+	}
+	return s.Err()
+}
+
+// splitRsyncRule splits a filter rule into its kind, one of "-", "+",
+// "!", "." and ":", and its argument.
+func splitRsyncRule(text string) (kind, arg string, ok bool) {
+	for _, long := range [...]struct{ name, kind string }{
+		{"exclude", "-"}, {"include", "+"}, {"clear", "!"}, {"merge", "."}, {"dir-merge", ":"},
+	} {
+		if rest, found := strings.CutPrefix(text, long.name); found {
+			if long.kind == "!" {
+				return "!", "", rest == ""
+			}
+			if rest, found = strings.CutPrefix(rest, " "); found {
+				return long.kind, rest, true
+			}
+		}
+	}
+	switch text[0] {
+	case '!':
+		return "!", "", text == "!"
+	case '-', '+', '.', ':':
+		// The rule is separated from its argument by a space or an
+		// underscore.
+		if len(text) > 1 && (text[1] == ' ' || text[1] == '_') {
+			return text[:1], text[2:], true
+		}
+	}
+	return "", "", false
+}
+
+// compileRsyncPattern compiles an include or exclude pattern into the
+// rules matching what it does.
+func compileRsyncPattern(pattern string, exclude bool) ([]rsyncRule, error) {
+	if pattern == "" {
+		return nil, errEmptyRsyncPattern
+	}
+	anchored := strings.HasPrefix(pattern, "/")
+	body := strings.TrimPrefix(pattern, "/")
+	prefix := "(?s)^(?:.*/)?"
+	if anchored {
+		prefix = "(?s)^"
+	}
+	compile := func(body, suffix string, dirOnly bool) (rsyncRule, error) {
+		re, err := regexp.Compile(prefix + rsyncRegexp(body) + suffix + "$")
+		return rsyncRule{re: re, exclude: exclude, dirOnly: dirOnly}, err
+	}
+
+	if base, ok := strings.CutSuffix(body, "/***"); ok && base != "" {
+		// The directory itself, as if written "base/", and everything in
+		// it.
+		dir, err := compile(base, "", true)
+		if err != nil {
+			return nil, err
+		}
+		inside, err := compile(base, "/.*", false)
+		return []rsyncRule{dir, inside}, err
+	}
+	dirOnly := strings.HasSuffix(body, "/")
+	body = strings.TrimSuffix(body, "/")
+	r, err := compile(body, "", dirOnly)
+	return []rsyncRule{r}, err
+}
+
+// errEmptyRsyncPattern is the error for an include or exclude rule with
+// no pattern.
+var errEmptyRsyncPattern = errors.New("empty pattern")
+
+// rsyncRegexp returns the regular expression matching what the rsync
+// wildcard pattern glob does.
+func rsyncRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+			}
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				for i+1 < len(glob) && glob[i+1] == '*' {
+					i++
+				}
+				b.WriteString(".*")
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			class, end, ok := rsyncClass(glob, i)
+			if !ok {
+				b.WriteString(`\[`)
+				continue
+			}
+			b.WriteString(class)
+			i = end
+		default:
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	return b.String()
+}
+
+// rsyncClass returns the regular expression for the bracket expression
+// at glob[i], and the index of its closing bracket. It reports false if
+// the bracket isn't closed, in which case it is a literal "[".
+func rsyncClass(glob string, i int) (string, int, bool) {
+	var b strings.Builder
+	b.WriteByte('[')
+	j := i + 1
+	if j < len(glob) && (glob[j] == '!' || glob[j] == '^') {
+		// A negated class doesn't match a slash either.
+		b.WriteString("^/")
+		j++
+	}
+	for start := j; j < len(glob); j++ {
+		c := glob[j]
+		switch {
+		case c == ']' && j > start:
+			b.WriteByte(']')
+			return b.String(), j, true
+		case c == '[' && strings.HasPrefix(glob[j:], "[:"):
+			if end := strings.Index(glob[j+2:], ":]"); end >= 0 {
+				b.WriteString(glob[j : j+2+end+2])
+				j += 2 + end + 1
+				continue
+			}
+		case c == '-' && j > start && j+1 < len(glob) && glob[j+1] != ']':
+			b.WriteByte('-')
+			continue
+		case c == '\\' && j+1 < len(glob):
+			j++
+			c = glob[j]
+		}
+		if c < 0x80 && !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9') {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	return "", 0, false
+}
+
+// loadDirMerges reads the dir-merge files in directory rel, a directory
+// f doesn't exclude, and in the directories below it that f doesn't
+// exclude either. top is set for the root.
+func (f *RsyncFilter) loadDirMerges(rel string, top bool) error {
+	dir := filepath.Join(f.root, filepath.FromSlash(rel))
+	for i, r := range f.rules.rules {
+		if r.dirMerge == "" {
+			continue
+		}
+		path := filepath.Join(dir, r.dirMerge)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		list := &rsyncList{}
+		if err := f.parse(list, data, path, dir, false, nil); err != nil {
+			return err
+		}
+		f.merged[i][rel] = list
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if top {
+			return err
+		}
+		return nil
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		child := joinRel(rel, e.Name())
+		if f.excludes(child, true) {
+			continue
+		}
+		if err := f.loadDirMerges(child, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Match reports whether rsync leaves relPath out of the transfer. The
+// path is slash-separated and relative to the root, with a trailing
+// slash for a directory. A path inside an excluded directory is
+// excluded. The root itself, and a path outside it or with a NUL byte,
+// are never excluded.
+func (f *RsyncFilter) Match(relPath string) bool {
+	if strings.IndexByte(relPath, 0) >= 0 {
+		return false
+	}
+	isDir := strings.HasSuffix(relPath, "/")
+	relPath, ok := resolvePath(relPath)
+	if !ok || relPath == "" {
+		return false
+	}
+	for i := range len(relPath) {
+		if relPath[i] == '/' {
+			if f.excludes(relPath[:i], true) {
+				return true
+			}
+		}
+	}
+	return f.excludes(relPath, isDir)
+}
+
+// excludes reports whether the first rule matching relPath by itself
+// excludes it.
+func (f *RsyncFilter) excludes(relPath string, isDir bool) bool {
+	for i := range f.rules.rules {
+		r := &f.rules.rules[i]
+		if r.dirMerge == "" {
+			if r.matches(relPath, isDir) {
+				return r.exclude
+			}
+			continue
+		}
+		// The dir-merge files of the directories above relPath, the
+		// deepest first.
+		byDir := f.merged[i]
+		dir := relPath
+		for dir != "" {
+			dir = parentRel(dir)
+			list := byDir[dir]
+			if list == nil {
+				continue
+			}
+			within := relPath
+			if dir != "" {
+				within = relPath[len(dir)+1:]
+			}
+			for j := range list.rules {
+				if list.rules[j].matches(within, isDir) {
+					return list.rules[j].exclude
+				}
+			}
+			if list.cleared {
+				break
+			}
+		}
+	}
+	return false
+}
+
+// matches reports whether r, which isn't a dir-merge rule, matches the
+// path relPath.
+func (r *rsyncRule) matches(relPath string, isDir bool) bool {
+	return (isDir || !r.dirOnly) && r.re.MatchString(relPath)
+}
+
+// parentRel returns the directory holding relPath, "" for the root.
+func parentRel(relPath string) string {
+	if i := strings.LastIndexByte(relPath, '/'); i >= 0 {
+		return relPath[:i]
+	}
+	return ""
+}
@@ -0,0 +1,135 @@
+package gitignore_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestRsyncFilter(t *testing.T) {
+	for _, tt := range []struct {
+		rules string
+		want  map[string]bool
+	}{
+		// The examples of the rsync manual.
+		{"- *.o\n", map[string]bool{"foo.o": true, "a/b/foo.o": true, "foo.c": false}},
+		{"- /foo\n", map[string]bool{"foo": true, "foo/": true, "a/foo": false}},
+		{"- foo/\n", map[string]bool{"foo/": true, "a/foo/": true, "foo": false, "foo/x": true}},
+		{"- /foo/*/bar\n", map[string]bool{"foo/x/bar": true, "foo/x/y/bar": false, "a/foo/x/bar": false}},
+		{"- /foo/**/bar\n", map[string]bool{"foo/x/bar": true, "foo/x/y/bar": true, "foo/bar": false}},
+		{"- foo/bar\n", map[string]bool{"foo/bar": true, "a/foo/bar": true, "afoo/bar": false}},
+		{"+ */\n+ *.c\n- *\n", map[string]bool{"a/": false, "a/x.c": false, "a/x.h": true, "x.h": true}},
+		{"+ foo/***\n- *\n", map[string]bool{"foo/": false, "foo": true, "foo/a/b": false, "bar": true}},
+		{"- foo/***\n", map[string]bool{"foo/": true, "foo": false, "x/foo/a": true}},
+
+		// The first match wins.
+		{"+ keep.o\n- *.o\n", map[string]bool{"keep.o": false, "a.o": true}},
+		{"- *.o\n+ keep.o\n", map[string]bool{"keep.o": true}},
+		// An include can't reach inside an excluded directory.
+		{"- build/\n+ build/keep\n", map[string]bool{"build/keep": true}},
+		// ** matches slashes wherever it is, and * and ? don't.
+		{"- /a**z\n", map[string]bool{"a/b/z": true, "az": true, "b/az": false}},
+		{"- a*z\n", map[string]bool{"a/z": false, "abz": true}},
+		{"- a?z\n", map[string]bool{"a/z": false, "abz": true}},
+		{"- [!a]x\n", map[string]bool{"bx": true, "ax": false, "b/x": false}},
+		{"- [[:digit:]]x\n", map[string]bool{"1x": true, "ax": false}},
+		{"- \\*x\n", map[string]bool{"*x": true, "ax": false}},
+		{"- a.b\n", map[string]bool{"a.b": true, "axb": false}},
+		// Long names, underscores, comments and clearing.
+		{"# comment\n; comment\n\nexclude *.o\ninclude x\n", map[string]bool{"a.o": true}},
+		{"-_*.o\n", map[string]bool{"a.o": true}},
+		{"- *.o\n!\n- *.h\n", map[string]bool{"a.o": false, "a.h": true}},
+		{"- *.o\nclear\n", map[string]bool{"a.o": false}},
+	} {
+		f, err := gitignore.ParseRsyncFilter(t.TempDir(), []byte(tt.rules))
+		if err != nil {
+			t.Fatalf("%q: %v", tt.rules, err)
+		}
+		for path, want := range tt.want {
+			if got := f.Match(path); got != want {
+				t.Errorf("%q: Match(%q) = %v, want %v", tt.rules, path, got, want)
+			}
+		}
+		for _, path := range []string{"", ".", "../a.o", "a\x00.o"} {
+			if f.Match(path) {
+				t.Errorf("%q: Match(%q) = true", tt.rules, path)
+			}
+		}
+	}
+}
+
+func TestRsyncFilterMerge(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".rsync-filter":          "- *.tmp\n",
+		"sub/.rsync-filter":      "+ keep.tmp\n- /local\n",
+		"sub/deep/.rsync-filter": "!\n- *.log\n",
+		"skip/.rsync-filter":     "bad rule\n",
+	})
+	rulesFile := filepath.Join(t.TempDir(), "rules")
+	if err := os.WriteFile(rulesFile, []byte("- *.o\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rules := "- /skip/\n. " + rulesFile + "\n: .rsync-filter\n- *.bak\n"
+	f, err := gitignore.ParseRsyncFilter(root, []byte(rules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]bool{
+		"a.o":             true, // from the merge file
+		"a.tmp":           true,
+		"sub/a.tmp":       true, // inherited
+		"sub/keep.tmp":    false,
+		"keep.tmp":        true, // sub's rules only apply below sub
+		"sub/local":       true, // anchored to sub
+		"local":           false,
+		"sub/x/local":     false,
+		"sub/deep/a.tmp":  false, // the inherited rules are cleared
+		"sub/deep/a.log":  true,
+		"sub/deep/a.bak":  true, // the top-level rules still apply
+		"sub/deep/x/a.o":  true,
+		"sub/deep/keep.o": true,
+	} {
+		if got := f.Match(path); got != want {
+			t.Errorf("Match(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestRsyncFilterErrors(t *testing.T) {
+	for _, tt := range []struct {
+		rules string
+		line  int
+	}{
+		{"- a\nfoo\n", 2},
+		{"-! a\n", 1},
+		{"- \n", 1},
+		{": sub/.rules\n", 1},
+		{"- [[:nope:]]\n", 1},
+	} {
+		_, err := gitignore.ParseRsyncFilter(t.TempDir(), []byte(tt.rules))
+		var perr gitignore.PatternError
+		if !errors.As(err, &perr) || perr.Line != tt.line {
+			t.Errorf("%q: got %v, want a PatternError on line %d", tt.rules, err, tt.line)
+		}
+	}
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"sub/.rules": "- a\n: .other\n"})
+	if _, err := gitignore.ParseRsyncFilter(root, []byte(": .rules\n")); err == nil {
+		t.Error("want an error for a dir-merge rule in a dir-merge file")
+	}
+	if _, err := gitignore.ParseRsyncFilter(root, []byte(". "+filepath.Join(root, "missing")+"\n")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing merge file: got %v", err)
+	}
+	self := filepath.Join(root, "self")
+	if err := os.WriteFile(self, []byte(". "+self+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gitignore.ParseRsyncFilter(root, []byte(". "+self+"\n")); err == nil {
+		t.Error("want an error for a merge file merging itself")
+	}
+}
@@ -0,0 +1,102 @@
+package gitignore
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// GitIgnore is a list of gitignore lines compiled with the API of
+// github.com/sabhiram/go-gitignore, so a project on that package can move
+// to this one by changing its import:
+//
+//	import ignore "github.com/git-pkgs/gitignore"
+//
+// The lines are read as git reads them, which that package doesn't
+// always do: leading spaces, escapes, bracket expressions and "**" are
+// read as in git, and a negation can re-include a path below a directory
+// another line matches. As with that package, nothing but the given
+// lines and file is read.
+type GitIgnore struct {
+	m     *Matcher
+	lines []*IgnorePattern // by line number; nil for a line that is no pattern
+}
+
+// IgnoreParser is the interface sabhiram/go-gitignore has GitIgnore
+// implement.
+type IgnoreParser interface {
+	MatchesPath(f string) bool
+	MatchesPathHow(f string) (bool, *IgnorePattern)
+}
+
+// IgnorePattern is a line of a GitIgnore holding a pattern, as
+// MatchesPathHow reports the one that decided a path.
+type IgnorePattern struct {
+	// Pattern matches the paths the line matches, slash-separated and
+	// relative to the root. It is for code that reads it; MatchesPath
+	// doesn't use it.
+	Pattern *regexp.Regexp
+	Negate  bool
+	LineNo  int    // 1-based, counting the lines of the file first
+	Line    string // as given
+}
+
+// CompileIgnoreLines compiles lines, one pattern each, into a GitIgnore.
+// Comments, blanks and patterns that don't compile are skipped, and a
+// line holding a newline counts as the lines it separates.
+func CompileIgnoreLines(lines ...string) *GitIgnore {
+	return compileIgnore("", lines)
+}
+
+// CompileIgnoreFile compiles the lines of the file at fpath. It returns
+// an error only if the file can't be read.
+func CompileIgnoreFile(fpath string) (*GitIgnore, error) {
+	return CompileIgnoreFileAndLines(fpath)
+}
+
+// CompileIgnoreFileAndLines compiles the lines of the file at fpath
+// followed by lines, which take precedence over them.
+func CompileIgnoreFileAndLines(fpath string, lines ...string) (*GitIgnore, error) {
+	data, err := os.ReadFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+	return compileIgnore(fpath, append(strings.Split(string(data), "\n"), lines...)), nil
+}
+
+// compileIgnore compiles lines, read from source.
+func compileIgnore(source string, lines []string) *GitIgnore {
+	text := strings.Join(lines, "\n")
+	lines = strings.Split(text, "\n")
+	g := &GitIgnore{m: newMatcher(&config{}), lines: make([]*IgnorePattern, len(lines))}
+	g.m.addPatterns([]byte(text), "", source)
+	for i := range g.m.patterns {
+		p := &g.m.patterns[i]
+		n := int(p.line)
+		// patternRegexp marks a directory with a NUL where the paths a
+		// GitIgnore matches have a slash, which the expression takes as
+		// the start of the directory's contents. It always compiles.
+		re, _ := regexp.Compile(`(?s)^(?:` + patternRegexp(p) + `)$`)
+		g.lines[n-1] = &IgnorePattern{Pattern: re, Negate: p.negate, LineNo: n, Line: lines[n-1]}
+	}
+	return g
+}
+
+// MatchesPath reports whether f, a path relative to the root with either
+// slashes or the OS's separators, is ignored. A directory is given with
+// a trailing separator.
+func (g *GitIgnore) MatchesPath(f string) bool {
+	return g.m.Match(filepath.ToSlash(f))
+}
+
+// MatchesPathHow is MatchesPath, also returning the line that decided f:
+// the last one matching it, which is a negation if f isn't ignored. It
+// returns nil if no line matches f.
+func (g *GitIgnore) MatchesPathHow(f string) (bool, *IgnorePattern) {
+	r := g.m.MatchDetail(filepath.ToSlash(f))
+	if !r.Matched {
+		return false, nil
+	}
+	return r.Ignored, g.lines[r.Line-1]
+}
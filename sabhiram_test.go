@@ -0,0 +1,88 @@
+package gitignore_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestCompileIgnoreLines(t *testing.T) {
+	var g gitignore.IgnoreParser = gitignore.CompileIgnoreLines("node_modules", "*.out", "foo/*.c", "# comment", "", "!keep.out", "build/")
+	for path, want := range map[string]bool{
+		"node_modules/test/foo.js":  true,
+		"a.out":                     true,
+		"d/a.out":                   true,
+		"keep.out":                  false,
+		"foo/a.c":                   true,
+		"foo/bar/a.c":               false,
+		"x/foo/a.c":                 false,
+		"build/":                    true,
+		"build/x":                   true,
+		"build":                     false,
+		"other.c":                   false,
+		filepath.Join("d", "a.out"): true,
+	} {
+		if got := g.MatchesPath(path); got != want {
+			t.Errorf("MatchesPath(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	for _, tt := range []struct {
+		path    string
+		ignored bool
+		lineNo  int
+	}{
+		{"a.out", true, 2},
+		{"keep.out", false, 6},
+		{"src/node_modules/", true, 1},
+		{"a.txt", false, 0},
+	} {
+		ignored, how := g.MatchesPathHow(tt.path)
+		if ignored != tt.ignored {
+			t.Errorf("MatchesPathHow(%q) = %v, want %v", tt.path, ignored, tt.ignored)
+		}
+		if tt.lineNo == 0 {
+			if how != nil {
+				t.Errorf("MatchesPathHow(%q): got line %d, want none", tt.path, how.LineNo)
+			}
+			continue
+		}
+		if how == nil || how.LineNo != tt.lineNo || how.Negate != !tt.ignored {
+			t.Errorf("MatchesPathHow(%q) = %+v, want line %d", tt.path, how, tt.lineNo)
+			continue
+		}
+		if !how.Pattern.MatchString(tt.path) {
+			t.Errorf("MatchesPathHow(%q): Pattern %v doesn't match it", tt.path, how.Pattern)
+		}
+	}
+}
+
+func TestCompileIgnoreFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".gitignore")
+	if err := os.WriteFile(path, []byte("*.log\r\n\\#hash\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	g, err := gitignore.CompileIgnoreFileAndLines(path, "!keep.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.MatchesPath("a.log") || g.MatchesPath("keep.log") || !g.MatchesPath("#hash") {
+		t.Error("want the file's lines followed by the extra lines")
+	}
+	if _, how := g.MatchesPathHow("keep.log"); how == nil || how.LineNo != 4 || how.Line != "!keep.log" {
+		t.Errorf("MatchesPathHow(keep.log) = %+v, want line 4", how)
+	}
+	if _, how := g.MatchesPathHow("a.log"); how == nil || how.Line != "*.log\r" {
+		t.Errorf("MatchesPathHow(a.log) = %+v, want line 1 as written", how)
+	}
+
+	if _, err := gitignore.CompileIgnoreFile(filepath.Join(dir, "missing")); err == nil {
+		t.Error("want an error for a missing file")
+	}
+	if g, err := gitignore.CompileIgnoreFile(path); err != nil || !g.MatchesPath("x.log") {
+		t.Errorf("CompileIgnoreFile: got %v", err)
+	}
+}
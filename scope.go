@@ -0,0 +1,97 @@
+package gitignore
+
+import (
+	"slices"
+	"strings"
+)
+
+// Patterns are kept in the order git gives them precedence, so that the
+// last match still wins however the sources were added: a .gitignore
+// deeper in the tree beats one above it for the paths in its scope,
+// whichever was loaded first, and two sources for the same directory
+// keep the order they were added in. Sources for unrelated directories
+// never decide the same path, so their relative order doesn't matter,
+// and a walk, which loads each directory before the ones below it, only
+// ever appends.
+
+// scopeInsert returns where the patterns from index from onwards, all
+// scoped to dirSegs, belong: before the first pattern scoped below
+// dirSegs, or at from if there is none.
+func (m *Matcher) scopeInsert(dirSegs []string, from int) int {
+	if from == len(m.patterns) {
+		return from
+	}
+	// Patterns below dirSegs have scope nodes below its node, so most
+	// additions are settled without looking at the patterns.
+	n := &m.index
+	for _, seg := range dirSegs {
+		if n = n.children[seg]; n == nil {
+			return from
+		}
+	}
+	if len(n.children) == 0 {
+		return from
+	}
+	for i := range from {
+		p := m.patterns[i].prefixSegs
+		if len(p) > len(dirSegs) && slices.Equal(p[:len(dirSegs)], dirSegs) {
+			return i
+		}
+	}
+	return from
+}
+
+// moveScope moves the patterns from index from onwards to index at, and
+// redoes the index, automata and counters that refer to patterns by
+// position.
+func (m *Matcher) moveScope(at, from int) {
+	moved := len(m.patterns) - from
+	newIndex := func(i int) int {
+		switch {
+		case i < at:
+			return i
+		case i < from:
+			return i + moved
+		}
+		return at + i - from
+	}
+	rotate(m.patterns, at, from)
+	rotate(m.hot, at, from)
+	for k, j := range m.seen {
+		m.seen[k] = int32(newIndex(int(j)))
+	}
+	if m.stats != nil {
+		old := m.stats.patterns
+		m.stats.patterns = make([]patternCounters, len(old))
+		for i := range old {
+			m.stats.patterns[newIndex(i)].copyFrom(&old[i])
+		}
+	}
+
+	m.index = scopeNode{}
+	for i := range m.patterns {
+		if !m.hot[i].shadowed {
+			m.index.add(i, &m.patterns[i], &m.hot[i])
+		}
+	}
+	if !m.dfa {
+		return
+	}
+	// Each run of patterns with one scope joins that scope's automaton.
+	for i := 0; i < len(m.patterns) && m.dfa; {
+		prefix := m.patterns[i].prefixSegs
+		end := i + 1
+		for end < len(m.patterns) && slices.Equal(m.patterns[end].prefixSegs, prefix) {
+			end++
+		}
+		m.compileDFAScope(strings.Join(prefix, "/"), i, end)
+		i = end
+	}
+}
+
+// rotate moves s[from:] to s[at:], ahead of s[at:from].
+func rotate[T any](s []T, at, from int) {
+	slices.Reverse(s[at:from])
+	slices.Reverse(s[from:])
+	slices.Reverse(s[at:])
+}
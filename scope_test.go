@@ -0,0 +1,105 @@
+package gitignore_test
+
+import (
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+// scopeSources are the sources of one tree, shallowest first, each
+// overriding the one above it for the paths it scopes.
+var scopeSources = []struct{ patterns, dir string }{
+	{"*.log\n!keep/\n", ""},
+	{"!*.log\nbuild/\n*.tmp\n", "src"},
+	{"*.log\n!build/\n", "src/app"},
+	{"!debug.log\n", "src/app"},
+	{"!*.tmp\n", "src/app/deep"},
+	{"*.tmp\n", "docs"},
+}
+
+var scopeTests = []struct {
+	path string
+	want bool
+}{
+	{"a.log", true},
+	{"src/a.log", false},
+	{"src/app/a.log", true},
+	{"src/app/debug.log", false},
+	{"src/build/", true},
+	{"src/app/build/", false},
+	{"src/app/build/x.go", false},
+	{"src/x.tmp", true},
+	{"src/app/deep/x.tmp", false},
+	{"src/app/deep/build/x.tmp", false},
+	{"docs/src/x.tmp", true},
+}
+
+func TestScopeOrderIndependent(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	// Sources for the same directory keep the order they were added in,
+	// so 3 always follows 2.
+	orders := [][]int{
+		{0, 1, 2, 3, 4, 5},
+		{5, 4, 2, 3, 1, 0},
+		{4, 2, 5, 0, 3, 1},
+		{2, 4, 3, 1, 5, 0},
+	}
+	optionSets := [][]gitignore.Option{
+		{gitignore.WithVerify()},
+		{gitignore.WithVerify(), gitignore.WithDFA()},
+		{gitignore.WithVerify(), gitignore.WithDirCache(8)},
+		{gitignore.WithStats(), gitignore.WithArena()},
+	}
+	for _, opts := range optionSets {
+		for _, order := range orders {
+			m := gitignore.New(t.TempDir(), opts...)
+			for _, i := range order {
+				m.AddPatterns([]byte(scopeSources[i].patterns), scopeSources[i].dir)
+			}
+			for _, tt := range scopeTests {
+				if got := m.Match(tt.path); got != tt.want {
+					t.Errorf("order %v: Match(%q) = %v, want %v", order, tt.path, got, tt.want)
+				}
+				if got := m.MatchFull(tt.path); got != tt.want {
+					t.Errorf("order %v: MatchFull(%q) = %v, want %v", order, tt.path, got, tt.want)
+				}
+			}
+			if r := m.MatchDetail("src/app/x.log"); r.Pattern != "*.log" || r.Line != 1 {
+				t.Errorf("order %v: MatchDetail = %+v, want src/app's *.log", order, r)
+			}
+			if m.MatchDirContents("src/app/build") || !m.CouldReincludeWithin("src") {
+				t.Errorf("order %v: expected the directory queries to follow scope order", order)
+			}
+		}
+	}
+
+	// Only the order of unrelated scopes is left to how they were added.
+	a := gitignore.New(t.TempDir())
+	a.AddPatterns([]byte("*.log\n"), "")
+	a.AddPatterns([]byte("!*.log\n"), "src")
+	b := gitignore.New(t.TempDir())
+	b.AddPatterns([]byte("!*.log\n"), "src")
+	b.AddPatterns([]byte("*.log\n"), "")
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Error("expected the fingerprint not to depend on load order")
+	}
+}
+
+func TestScopeOrderStats(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	m := gitignore.New(t.TempDir(), gitignore.WithStats())
+	m.AddPatterns([]byte("!*.log\n"), "src")
+	m.Match("src/a.log")
+	m.AddPatterns([]byte("*.log\n"), "")
+	if !m.Match("a.log") || m.Match("src/a.log") {
+		t.Fatal("expected src's negation to beat the root pattern added after it")
+	}
+	s := m.Stats()
+	if len(s.Patterns) != 2 || s.Patterns[0].Pattern != "*.log" || s.Patterns[1].Matches != 2 {
+		t.Errorf("Stats().Patterns = %+v, want the root pattern first and src's counts kept", s.Patterns)
+	}
+}
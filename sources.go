@@ -0,0 +1,80 @@
+package gitignore
+
+import (
+	"crypto/sha256"
+	"io/fs"
+	"os"
+)
+
+// trackSources makes New and the directory walk record the files and
+// directories the Matcher is built from, for SaveCache.
+func trackSources(c *config) {
+	c.trackSources = true
+}
+
+// cachedFile fingerprints a pattern file, or records that it was absent.
+type cachedFile struct {
+	Path    string
+	Exists  bool
+	Size    int64
+	ModTime int64 // UnixNano
+	Hash    [sha256.Size]byte
+}
+
+type cachedDir struct {
+	Path    string
+	ModTime int64 // UnixNano
+}
+
+// sourceDeps collects what a Matcher was built from.
+type sourceDeps struct {
+	root   string
+	limits Limits
+	plain  bool // set by WithoutGit
+	files  []cachedFile
+	dirs   []cachedDir
+}
+
+// readOS reads the file at path, recording its fingerprint. The file is
+// stat'ed before it is read, so a change racing with the read leaves a
+// stale modification time rather than a stale hash, and is caught by the
+// hash comparison.
+func (d *sourceDeps) readOS(path string) ([]byte, error) {
+	info, statErr := os.Stat(path)
+	data, err := os.ReadFile(path)
+	d.addFile(path, info, statErr, data, err)
+	return data, err
+}
+
+// readFS is readOS for the file name in fsys, recorded as path.
+func (d *sourceDeps) readFS(fsys fs.FS, name, path string) ([]byte, error) {
+	info, statErr := fs.Stat(fsys, name)
+	data, err := fs.ReadFile(fsys, name)
+	d.addFile(path, info, statErr, data, err)
+	return data, err
+}
+
+func (d *sourceDeps) addFile(path string, info fs.FileInfo, statErr error, data []byte, err error) {
+	f := cachedFile{Path: path}
+	if statErr == nil && err == nil {
+		f.Exists = true
+		f.Size = info.Size()
+		f.ModTime = info.ModTime().UnixNano()
+		f.Hash = sha256.Sum256(data)
+	}
+	d.files = append(d.files, f)
+}
+
+// addDir records the modification time of a directory about to be read.
+func (d *sourceDeps) addDir(path string, info fs.FileInfo) {
+	d.dirs = append(d.dirs, cachedDir{Path: path, ModTime: info.ModTime().UnixNano()})
+}
+
+// readSource reads a pattern file for m, recording it if m tracks its
+// sources.
+func (m *Matcher) readSource(path string) ([]byte, error) {
+	if m.deps == nil {
+		return os.ReadFile(path)
+	}
+	return m.deps.readOS(path)
+}
@@ -0,0 +1,219 @@
+package gitignore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SparseCheckout holds the patterns of a sparse checkout, to answer
+// which paths git checks out in a partially checked out repository.
+//
+// Outside cone mode the patterns have gitignore syntax, but select the
+// paths to check out rather than those to ignore: the last pattern
+// matching a path decides it, and a path no pattern matches is decided
+// as its directory is. In cone mode, which git uses when
+// core.sparseCheckoutCone is set and every pattern is of the restricted
+// form git sparse-checkout writes, the patterns name directories: the
+// files at the root are always checked out, and each directory named is
+// checked out whole or, if a "!/dir/*/" line follows it, only the files
+// directly inside it. If a pattern isn't of that form git warns and
+// matches all of them outside cone mode, and so does SparseCheckout.
+type SparseCheckout struct {
+	cone      bool
+	full      bool            // in cone mode, "/*" without "!/*/": everything
+	recursive map[string]bool // in cone mode, directories checked out whole
+	parents   map[string]bool // in cone mode, directories with only their files
+
+	patterns []pattern // outside cone mode
+	errors   []PatternError
+}
+
+// LoadSparseCheckout reads the sparse-checkout patterns of the
+// repository in root from .git/info/sparse-checkout, in cone mode if
+// core.sparseCheckoutCone is set in the repository's config, its
+// config.worktree, or the system or global config. It returns an error
+// if the file can't be read.
+func LoadSparseCheckout(root string) (*SparseCheckout, error) {
+	path := filepath.Join(root, ".git", "info", "sparse-checkout")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var read []string
+	value, _ := gitConfigValue("core", "sparsecheckoutcone", &read)
+	for _, name := range []string{"config", "config.worktree"} {
+		readGitConfig(filepath.Join(root, ".git", name), 0, &read, func(sec, sub, key, val string) {
+			if sec == "core" && sub == "" && key == "sparsecheckoutcone" {
+				value = val
+			}
+		})
+	}
+	cone, _ := gitConfigBool(value)
+	return parseSparseCheckout(data, cone, path), nil
+}
+
+// ParseSparseCheckout parses sparse-checkout patterns, as git reads them
+// from .git/info/sparse-checkout, in cone mode if cone is true.
+func ParseSparseCheckout(data []byte, cone bool) *SparseCheckout {
+	return parseSparseCheckout(data, cone, "")
+}
+
+func parseSparseCheckout(data []byte, cone bool, source string) *SparseCheckout {
+	s := &SparseCheckout{cone: cone, recursive: make(map[string]bool), parents: make(map[string]bool)}
+	text := strings.TrimPrefix(string(data), utf8BOM)
+	for lineNum, raw := range strings.Split(text, "\n") {
+		line := trimTrailingSpaces(strings.TrimSuffix(raw, "\r"))
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		perr := PatternError{Pattern: line, Source: source, Line: lineNum + 1}
+		if s.cone {
+			if msg := s.addCone(line); msg != "" {
+				perr.Message = msg + "; cone mode disabled"
+				s.errors = append(s.errors, perr)
+				s.cone = false
+			}
+		}
+		p, msg := compileDirect(line, nil)
+		if msg != "" {
+			perr.Message = msg
+			s.errors = append(s.errors, perr)
+			continue
+		}
+		s.patterns = append(s.patterns, p)
+	}
+	if !s.cone {
+		s.full, s.recursive, s.parents = false, nil, nil
+	}
+	return s
+}
+
+// addCone adds the cone-mode pattern line, or returns why it isn't one,
+// checking it as git's add_pattern_to_hashsets does.
+func (s *SparseCheckout) addCone(line string) string {
+	p, negate := strings.CutPrefix(line, "!")
+	p, dirOnly := strings.CutSuffix(p, "/")
+	switch {
+	case p == "/*" && negate && dirOnly:
+		s.full = false
+		return ""
+	case p == "/*" && !negate && !dirOnly:
+		s.full = true
+		return ""
+	case len(p) < 2 || p[0] != '/' || strings.Contains(p, "**") || !dirOnly:
+		return "not a cone-mode pattern"
+	}
+	for i := 1; i < len(p); i++ {
+		next := byte(0)
+		if i+1 < len(p) {
+			next = p[i+1]
+		}
+		switch c := p[i]; {
+		case !isGlobSpecial(c), p[i-1] == '\\':
+		case c == '\\' && isGlobSpecial(next):
+		case c == '*' && p[i-1] == '/' && next == 0:
+		default:
+			return "not a cone-mode pattern"
+		}
+	}
+	if dir, ok := strings.CutSuffix(p, "/*"); ok {
+		dir = unescapeCone(dir)
+		if !negate {
+			return "not a cone-mode pattern"
+		}
+		if !s.recursive[dir] {
+			return "negative pattern without its directory"
+		}
+		s.parents[dir] = true
+		delete(s.recursive, dir)
+		return ""
+	}
+	if negate {
+		return "not a cone-mode pattern"
+	}
+	dir := unescapeCone(p)
+	if s.parents[dir] {
+		return "directory is repeated"
+	}
+	s.recursive[dir] = true
+	return ""
+}
+
+// isGlobSpecial reports whether c is special in a glob, to git.
+func isGlobSpecial(c byte) bool {
+	return c == '*' || c == '?' || c == '[' || c == '\\'
+}
+
+// unescapeCone returns the directory a cone-mode pattern names: without
+// its leading slash and with its backslash escapes removed.
+func unescapeCone(p string) string {
+	var b strings.Builder
+	for i := 1; i < len(p); i++ {
+		if p[i] == '\\' {
+			i++
+			if i == len(p) {
+				break
+			}
+		}
+		b.WriteByte(p[i])
+	}
+	return b.String()
+}
+
+// Cone reports whether s matches in cone mode: whether it was asked to
+// and all its patterns allow it.
+func (s *SparseCheckout) Cone() bool {
+	return s.cone
+}
+
+// Errors returns the patterns git would warn about: those that made s
+// give up cone mode, and, outside cone mode, invalid ones, which match
+// nothing.
+func (s *SparseCheckout) Errors() []PatternError {
+	return s.errors
+}
+
+// Contains reports whether relPath is in the sparse checkout: for a
+// file, whether git checks it out, and for a directory, given with a
+// trailing slash, whether the patterns select it. In cone mode, that is
+// whether the directory is one of those named, or inside one checked out
+// whole. relPath is as for Match on a Matcher; the root is always in the
+// checkout, and a path outside it or with a NUL byte never is.
+func (s *SparseCheckout) Contains(relPath string) bool {
+	if strings.IndexByte(relPath, 0) >= 0 {
+		return false
+	}
+	isDir := strings.HasSuffix(relPath, "/")
+	relPath, ok := resolvePath(relPath)
+	if !ok {
+		return false
+	}
+	if relPath == "" {
+		return true
+	}
+	segs := strings.Split(relPath, "/")
+	if !s.cone {
+		for ; len(segs) > 0; segs, isDir = segs[:len(segs)-1], true {
+			for i := len(s.patterns) - 1; i >= 0; i-- {
+				if p := &s.patterns[i]; matchDirect(p, segs, isDir) {
+					return !p.negate
+				}
+			}
+		}
+		return false
+	}
+
+	if s.full {
+		return true
+	}
+	for i := len(segs); i > 0; i-- {
+		if s.recursive[strings.Join(segs[:i], "/")] {
+			return true
+		}
+	}
+	if isDir {
+		return s.parents[relPath]
+	}
+	return len(segs) == 1 || s.parents[strings.Join(segs[:len(segs)-1], "/")]
+}
@@ -0,0 +1,134 @@
+package gitignore_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+// sparseFiles are the files of the tree the sparse-checkout tests check
+// out, with git read-tree -mu.
+var sparseFiles = []string{
+	"top.txt", "A/a.txt", "A/B/b.txt", "A/B/C/c.txt", "A/D/d.txt",
+	"E/e.txt", "F/f.txt", "F/G/g.txt",
+}
+
+func TestSparseCheckout(t *testing.T) {
+	for _, tt := range []struct {
+		patterns string
+		cone     bool
+		want     []string // the files git checks out
+	}{
+		// As git sparse-checkout set --cone A/B F/G writes it.
+		{
+			"/*\n!/*/\n/A/\n!/A/*/\n/F/\n!/F/*/\n/A/B/\n/F/G/\n", true,
+			[]string{"top.txt", "A/a.txt", "A/B/b.txt", "A/B/C/c.txt", "F/f.txt", "F/G/g.txt"},
+		},
+		{"/*\n", true, sparseFiles},
+		{"/*\n!/*/\n/A/\n", true, []string{"top.txt", "A/a.txt", "A/B/b.txt", "A/B/C/c.txt", "A/D/d.txt"}},
+		// Without cone mode, a path is decided by the last pattern that
+		// matches it, or else as its directory is.
+		{"*.txt\n!A/\nA/B/\n!b.txt\n", false, []string{
+			"top.txt", "A/a.txt", "A/B/C/c.txt", "A/D/d.txt", "E/e.txt", "F/f.txt", "F/G/g.txt",
+		}},
+		{"!c.txt\n/A/\n!/A/D/\n", false, []string{"A/a.txt", "A/B/b.txt"}},
+		{"/A/**\n/*.txt\n", false, []string{"top.txt", "A/a.txt", "A/B/b.txt", "A/B/C/c.txt", "A/D/d.txt"}},
+		// A pattern cone mode can't take makes git match them all without it.
+		{"/*\n!/*/\n/A/*.txt\n", true, []string{"top.txt", "A/a.txt"}},
+	} {
+		s := gitignore.ParseSparseCheckout([]byte(tt.patterns), tt.cone)
+		want := make(map[string]bool)
+		for _, f := range tt.want {
+			want[f] = true
+		}
+		for _, f := range sparseFiles {
+			if got := s.Contains(f); got != want[f] {
+				t.Errorf("%q: Contains(%q) = %v, want %v", tt.patterns, f, got, want[f])
+			}
+		}
+		for _, path := range []string{"", "."} {
+			if !s.Contains(path) {
+				t.Errorf("%q: Contains(%q) = false", tt.patterns, path)
+			}
+		}
+		for _, path := range []string{"../top.txt", "top\x00.txt"} {
+			if s.Contains(path) {
+				t.Errorf("%q: Contains(%q) = true", tt.patterns, path)
+			}
+		}
+	}
+}
+
+func TestSparseCheckoutCone(t *testing.T) {
+	s := gitignore.ParseSparseCheckout([]byte("/*\n!/*/\n/A/\n!/A/*/\n/A/B/\n/a\\*b/\n"), true)
+	if !s.Cone() || len(s.Errors()) != 0 {
+		t.Fatalf("Cone() = %v, Errors() = %v", s.Cone(), s.Errors())
+	}
+	for path, want := range map[string]bool{
+		"A/":     true, // a parent of A/B
+		"A/B/":   true,
+		"A/B/C/": true,
+		"A/D/":   false,
+		"E/":     false,
+		"a*b/x":  true,
+		"ab/x":   false,
+	} {
+		if got := s.Contains(path); got != want {
+			t.Errorf("Contains(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	for _, tt := range []struct {
+		patterns string
+		line     int
+	}{
+		{"/*\n!/*/\n*.txt\n", 3},
+		{"/A\n", 1},
+		{"/A/**/\n", 1},
+		{"/A/*.go/\n", 1},
+		{"!/A/*/\n", 1},
+		{"!/A/\n", 1},
+		{"/A/*\n", 1},
+		{"/A/\n!/A/*/\n/A/\n", 3},
+	} {
+		s := gitignore.ParseSparseCheckout([]byte(tt.patterns), true)
+		errs := s.Errors()
+		if s.Cone() || len(errs) != 1 || errs[0].Line != tt.line {
+			t.Errorf("%q: Cone() = %v, Errors() = %v, want an error on line %d", tt.patterns, s.Cone(), errs, tt.line)
+		}
+	}
+}
+
+func TestLoadSparseCheckout(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".git/info/sparse-checkout": "/*\n!/*/\n/A/\n",
+		".git/config":               "[core]\n\tsparseCheckoutCone = false\n",
+		".git/config.worktree":      "[core]\n\tsparseCheckout = true\n\tsparseCheckoutCone = true\n",
+	})
+	s, err := gitignore.LoadSparseCheckout(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Cone() || !s.Contains("A/B/b.txt") || s.Contains("E/e.txt") {
+		t.Errorf("Cone() = %v, want cone mode with A checked out", s.Cone())
+	}
+
+	if err := os.Remove(filepath.Join(root, ".git", "config.worktree")); err != nil {
+		t.Fatal(err)
+	}
+	if s, err = gitignore.LoadSparseCheckout(root); err != nil || s.Cone() {
+		t.Errorf("without config.worktree: Cone() = %v, %v", s.Cone(), err)
+	}
+
+	if err := os.Remove(filepath.Join(root, ".git", "info", "sparse-checkout")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gitignore.LoadSparseCheckout(root); !os.IsNotExist(err) {
+		t.Errorf("missing file: got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package gitignore
+
+import "sync/atomic"
+
+// WithStats makes the Matcher count how each pattern fares during
+// matching, for profiling a .gitignore file: a pattern that is tried
+// often but rarely matches is a candidate for tightening or moving. The
+// counters cost a few atomic adds per lookup. Read them with Stats.
+//
+// Tried and FastRejects are kept only by the default engine, which tries
+// patterns one at a time. With WithDFA, a lookup the automaton answers
+// counts towards Lookups and Matches alone, since it runs every pattern
+// of a scope at once.
+func WithStats() Option {
+	return func(c *config) {
+		c.stats = true
+	}
+}
+
+// Stats is a snapshot of the counters kept by a Matcher created with
+// WithStats.
+type Stats struct {
+	Lookups  uint64         // paths looked up, including by Walk
+	Patterns []PatternStats // in priority order, global excludes first
+}
+
+// PatternStats holds the counters for one pattern.
+type PatternStats struct {
+	Pattern string // original pattern text
+	Source  string // file the pattern came from (empty for programmatic patterns)
+	Line    int    // 1-based line number in Source
+
+	Matches     uint64 // lookups this pattern decided, as the last match
+	Tried       uint64 // times the default engine matched the pattern against a path
+	FastRejects uint64 // tries ruled out by the literal suffix check alone
+}
+
+type matchStats struct {
+	lookups  atomic.Uint64
+	patterns []patternCounters
+}
+
+type patternCounters struct {
+	matches, tried, fastRejects atomic.Uint64
+}
+
+// Stats returns the counters collected so far. Without WithStats it
+// returns the zero Stats.
+func (m *Matcher) Stats() Stats {
+	if m.stats == nil {
+		return Stats{}
+	}
+	s := Stats{
+		Lookups:  m.stats.lookups.Load(),
+		Patterns: make([]PatternStats, len(m.patterns)),
+	}
+	for i := range m.patterns {
+		p, c := &m.patterns[i], &m.stats.patterns[i]
+		s.Patterns[i] = PatternStats{
+			Pattern:     m.patternText(p),
+			Source:      m.sources[p.src].path,
+			Line:        int(p.line),
+			Matches:     c.matches.Load(),
+			Tried:       c.tried.Load(),
+			FastRejects: c.fastRejects.Load(),
+		}
+	}
+	if g := m.globalMatcher(); g != nil {
+		s.Patterns = append(g.Stats().Patterns, s.Patterns...)
+	}
+	return s
+}
+
+// grow makes room for counters for n patterns.
+func (s *matchStats) grow(n int) {
+	for len(s.patterns) < n {
+		s.patterns = append(s.patterns, patternCounters{})
+	}
+}
+
+// copyFrom sets c to the counts of o.
+func (c *patternCounters) copyFrom(o *patternCounters) {
+	c.matches.Store(o.matches.Load())
+	c.tried.Store(o.tried.Load())
+	c.fastRejects.Store(o.fastRejects.Load())
+}
+
+// record counts a lookup decided by pattern i, or by none if i is -1.
+func (s *matchStats) record(i int) {
+	s.lookups.Add(1)
+	if i >= 0 {
+		s.patterns[i].matches.Add(1)
+	}
+}
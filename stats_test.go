@@ -0,0 +1,111 @@
+package gitignore_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestStats(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	m := gitignore.New(t.TempDir(), gitignore.WithStats())
+	m.AddPatterns([]byte("*.log\n!keep.log\nbuild/\n*_gen.*\n"), "")
+
+	for _, p := range []string{"a.log", "b.log", "keep.log", "build/", "main.go", "x.go"} {
+		m.Match(p)
+	}
+
+	s := m.Stats()
+	if s.Lookups != 6 {
+		t.Errorf("Lookups = %d, want 6", s.Lookups)
+	}
+	if len(s.Patterns) != 4 {
+		t.Fatalf("got %d pattern stats, want 4", len(s.Patterns))
+	}
+	want := []struct {
+		pattern string
+		line    int
+		matches uint64
+	}{
+		{"*.log", 1, 2},
+		{"!keep.log", 2, 1},
+		{"build/", 3, 1},
+		{"*_gen.*", 4, 0},
+	}
+	for i, w := range want {
+		got := s.Patterns[i]
+		if got.Pattern != w.pattern || got.Line != w.line || got.Matches != w.matches {
+			t.Errorf("Patterns[%d] = {%q, line %d, %d matches}, want {%q, line %d, %d matches}",
+				i, got.Pattern, got.Line, got.Matches, w.pattern, w.line, w.matches)
+		}
+		if got.Tried < got.Matches {
+			t.Errorf("Patterns[%d]: Tried = %d, less than Matches = %d", i, got.Tried, got.Matches)
+		}
+	}
+	// "*_gen.*" has no usable suffix, so it is tried against every path
+	// that the later patterns don't decide.
+	if s.Patterns[3].Tried == 0 {
+		t.Error("expected *_gen.* to be tried")
+	}
+}
+
+func TestStatsFastRejects(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	// The index only offers "*.tar.gz" to paths with a segment ending in
+	// ".tar.gz", so the suffix check rules it out only in the full scan
+	// done for a path that gathers too many candidate lists.
+	var others, path strings.Builder
+	for i := range 100 {
+		fmt.Fprintf(&others, "z*.a%d\n", i)
+		fmt.Fprintf(&path, "x.a%d/", i)
+	}
+	m := gitignore.New(t.TempDir(), gitignore.WithStats())
+	m.AddPatterns([]byte("*.tar.gz\n"+others.String()), "")
+	m.Match(path.String() + "a.gz")
+	m.Match("a.tar.gz")
+	m.Match("a.gz")
+
+	p := m.Stats().Patterns[0]
+	if p.Tried != 2 || p.FastRejects != 1 || p.Matches != 1 {
+		t.Errorf("got Tried=%d FastRejects=%d Matches=%d, want 2, 1, 1", p.Tried, p.FastRejects, p.Matches)
+	}
+}
+
+func TestStatsDFA(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	// The automaton answers the ASCII paths without trying any pattern;
+	// the non-ASCII one goes through the default engine.
+	m := gitignore.New(t.TempDir(), gitignore.WithStats(), gitignore.WithDFA())
+	m.AddPatterns([]byte("*.log\n!keep.log\n"), "")
+	for _, p := range []string{"a.log", "keep.log", "café.log"} {
+		m.Match(p)
+	}
+
+	s := m.Stats()
+	if s.Lookups != 3 {
+		t.Errorf("Lookups = %d, want 3", s.Lookups)
+	}
+	log, keep := s.Patterns[0], s.Patterns[1]
+	if log.Matches != 2 || keep.Matches != 1 {
+		t.Errorf("Matches = %d, %d, want 2, 1", log.Matches, keep.Matches)
+	}
+	if log.Tried != 1 || keep.Tried != 0 {
+		t.Errorf("Tried = %d, %d, want 1, 0", log.Tried, keep.Tried)
+	}
+}
+
+func TestStatsDisabled(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	m := gitignore.New(t.TempDir())
+	m.AddPatterns([]byte("*.log\n"), "")
+	m.Match("a.log")
+	if s := m.Stats(); s.Lookups != 0 || s.Patterns != nil {
+		t.Errorf("Stats() = %+v without WithStats, want zero", s)
+	}
+}
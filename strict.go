@@ -0,0 +1,34 @@
+package gitignore
+
+// WithStrictEscapes makes patterns that this package reads more leniently
+// than git fail to compile, so Errors reports them and, as in git, they
+// match nothing. These are the patterns with a [ that starts no bracket
+// expression, because nothing closes it: "a[", "[ab" or "[]". Git's
+// wildmatch never matches such a pattern, while by default the [ is read
+// as a literal, as if it were written "\[", since that is almost always
+// what was meant. Every other escape and bracket is read as git reads it
+// either way.
+func WithStrictEscapes() Option {
+	return func(c *config) {
+		c.strict = true
+	}
+}
+
+// unclosedBracket reports whether glob has a [ outside any escape or
+// bracket expression with no ] closing it, reading glob as matchSegment
+// does.
+func unclosedBracket(glob string) bool {
+	for i := 0; i < len(glob); i++ {
+		switch glob[i] {
+		case '\\':
+			i++
+		case '[':
+			_, next, ok := matchBracket(glob, i, 0)
+			if !ok {
+				return true
+			}
+			i = next - 1
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package gitignore_test
+
+import (
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestStrictEscapes(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	patterns := "a[\n[ab\n*[\n[]\n[!]\n\\[x\n[[]\n[]]\n[a\\]\n"
+	// Git matches nothing with the first five patterns or the last; the
+	// rest have no unclosed [.
+	unclosed := []string{"a[", "[ab", "*[", "[]", "[!]", "[a\\]"}
+	tests := []struct {
+		path         string
+		strict, want bool
+	}{
+		{"a[", false, true},
+		{"[ab", false, true},
+		{"x[", false, true},
+		{"[]", false, true},
+		{"[!]", false, true},
+		{"[a]", false, true},
+		{"[x", true, true},
+		{"[", true, true},
+		{"]", true, true},
+		{"a", false, false},
+	}
+	for _, opts := range [][]gitignore.Option{nil, {gitignore.WithDFA()}, {gitignore.WithIgnoreCase(true)}} {
+		lenient := gitignore.New(t.TempDir(), opts...)
+		lenient.AddPatterns([]byte(patterns), "")
+		strict := gitignore.New(t.TempDir(), append(opts, gitignore.WithStrictEscapes())...)
+		strict.AddPatterns([]byte(patterns), "")
+		for _, tt := range tests {
+			if got := lenient.Match(tt.path); got != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+			if got := strict.Match(tt.path); got != (tt.want && tt.strict) {
+				t.Errorf("strict Match(%q) = %v, want %v", tt.path, got, tt.want && tt.strict)
+			}
+		}
+		if errs := lenient.Errors(); len(errs) != 0 {
+			t.Errorf("Errors() = %v, want none", errs)
+		}
+		errs := strict.Errors()
+		if len(errs) != len(unclosed) {
+			t.Fatalf("strict Errors() = %v, want %d", errs, len(unclosed))
+		}
+		for i, e := range errs {
+			if e.Pattern != unclosed[i] || e.Message != "unclosed bracket" {
+				t.Errorf("error %d = %+v, want an unclosed bracket in %q", i, e, unclosed[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package gitignore
+
+// suffixTrie files patterns by the literal suffix of their last concrete
+// segment ("*.log", "*_test.go", "*~"), spelled backwards, so a single
+// walk from the end of a path segment finds every suffix the segment
+// ends with. Patterns whose suffix no segment has are never gathered, at
+// a cost that doesn't grow with the number of suffixes.
+type suffixTrie struct {
+	root suffixNode
+}
+
+type suffixNode struct {
+	edges []suffixEdge // usually few, so searched linearly
+	list  []int32      // patterns whose suffix ends here, ascending
+}
+
+type suffixEdge struct {
+	b    byte
+	next *suffixNode
+}
+
+func (n *suffixNode) child(b byte) *suffixNode {
+	for i := range n.edges {
+		if n.edges[i].b == b {
+			return n.edges[i].next
+		}
+	}
+	return nil
+}
+
+// add files pattern i under suffix.
+func (t *suffixTrie) add(suffix string, i int) {
+	n := &t.root
+	for j := len(suffix) - 1; j >= 0; j-- {
+		next := n.child(suffix[j])
+		if next == nil {
+			next = &suffixNode{}
+			n.edges = append(n.edges, suffixEdge{b: suffix[j], next: next})
+		}
+		n = next
+	}
+	n.list = append(n.list, int32(i))
+}
+
+// remove takes pattern i out of the list for suffix.
+func (t *suffixTrie) remove(suffix string, i int) {
+	n := &t.root
+	for j := len(suffix) - 1; j >= 0 && n != nil; j-- {
+		n = n.child(suffix[j])
+	}
+	if n != nil {
+		n.list = deleteIndex(n.list, i)
+	}
+}
+
+// appendLists appends the lists of every suffix that seg ends with.
+func (t *suffixTrie) appendLists(lists [][]int32, seg string) [][]int32 {
+	n := &t.root
+	for j := len(seg) - 1; j >= 0; j-- {
+		if n = n.child(seg[j]); n == nil {
+			break
+		}
+		if len(n.list) > 0 {
+			lists = append(lists, n.list)
+		}
+	}
+	return lists
+}
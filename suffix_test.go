@@ -0,0 +1,42 @@
+package gitignore_test
+
+import (
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestSuffixIndex(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	// Nested suffixes share trie nodes; each must still be found, and
+	// last-match-wins must hold across them.
+	m := gitignore.New(t.TempDir(), gitignore.WithVerify())
+	m.AddPatterns([]byte("*z\n!*.gz\n*.tar.gz\n*~\nbuild/*.o\n*_test.go\n!keep_test.go\nout*.txt/\n"), "")
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"a.z", true},
+		{"a.gz", false},
+		{"a.tar.gz", true},
+		{"a.tgz", true},
+		{"notes~", true},
+		{"src/notes~/x", true},
+		{"build/x.o", true},
+		{"x.o", false},
+		{"pkg/a_test.go", true},
+		{"pkg/keep_test.go", false},
+		{"pkg/test.go", false},
+		{"out1.txt/", true},
+		{"out1.txt/a", true},
+		{"out1.txt", false},
+		{"readme", false},
+	}
+	for _, tt := range tests {
+		if got := m.Match(tt.path); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package gitignore
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Syncthing decides which files Syncthing leaves out of a folder, given
+// the folder's .stignore, for sync tools that need to agree with it.
+// Syncthing's rules aren't git's:
+//
+//   - Only the .stignore at the root of the folder is read, along with
+//     the files it includes with "#include FILE", relative to the file
+//     doing the including. Lines starting with "//" are comments, and
+//     leading and trailing blanks are trimmed.
+//   - The first pattern matching a path decides it. "!" makes a pattern
+//     include what it matches, "(?i)" makes it ignore case, and "(?d)"
+//     marks what it matches as deletable; the prefixes can come in any
+//     order.
+//   - A pattern starting with a slash matches from the root of the
+//     folder; any other, at any depth. A pattern also matches everything
+//     inside a directory it matches, and one ending in a slash only
+//     what is inside the directory.
+//   - "*" matches anything but a slash, "**" anything including slashes,
+//     wherever it is, "?" one character other than a slash, brackets one
+//     character of a set, and "{a,b}" either of its alternatives.
+//   - A path is decided by itself: Syncthing still looks inside an
+//     ignored directory when a "!" pattern could match something there.
+//   - Syncthing's own files, .stfolder, .stignore and .stversions, and
+//     its temporary files are always ignored.
+type Syncthing struct {
+	rules []syncthingRule
+}
+
+// syncthingRule is a compiled .stignore pattern.
+type syncthingRule struct {
+	re        *regexp.Regexp
+	include   bool
+	foldCase  bool
+	deletable bool
+}
+
+// syncthingInternal lists the files Syncthing keeps its state in.
+var syncthingInternal = []string{".stfolder", ".stignore", ".stversions"}
+
+// LoadSyncthing reads the .stignore in root, if there is one. It returns
+// a PatternError for a pattern Syncthing rejects, and an error if an
+// included file is missing or included twice, as Syncthing then fails to
+// load the patterns. Of the options, only WithIgnoreCase applies: with
+// it every pattern ignores case, as on macOS and Windows, where
+// Syncthing does so whatever the pattern says.
+func LoadSyncthing(root string, opts ...Option) (*Syncthing, error) {
+	c := newConfig(opts)
+	s := &Syncthing{}
+	source := filepath.Join(root, ".stignore")
+	data, err := os.ReadFile(source)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.parse(data, source, c.ignoreCase, map[string]bool{source: true}); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// parse adds the patterns in data, read from source, to s. seen holds
+// the files already read, which can't be included again.
+func (s *Syncthing) parse(data []byte, source string, foldCase bool, seen map[string]bool) error {
+	data = bytes.TrimPrefix(data, []byte(utf8BOM))
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "//") {
+			continue
+		}
+		if strings.HasPrefix(text, "#include") {
+			if err := s.include(text, source, foldCase, seen); err != nil {
+				return PatternError{Pattern: text, Source: source, Line: line, Message: err.Error()}
+			}
+			continue
+		}
+		var err error
+		switch {
+		case strings.HasSuffix(text, "/**"):
+			err = s.addPattern(text, foldCase)
+		case strings.HasSuffix(text, "/"):
+			err = s.addPattern(text+"**", foldCase)
+		default:
+			if err = s.addPattern(text, foldCase); err == nil {
+				err = s.addPattern(text+"/**", foldCase)
+			}
+		}
+		if err != nil {
+			return PatternError{Pattern: text, Source: source, Line: line, Message: err.Error()}
+		}
+	}
+	return sc.Err()
+}
+
+// include reads the file the "#include FILE" line names.
+func (s *Syncthing) include(line, source string, foldCase bool, seen map[string]bool) error {
+	_, name, _ := strings.Cut(line, " ")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("no file to include")
+	}
+	file := filepath.Join(filepath.Dir(source), filepath.FromSlash(name))
+	if seen[file] {
+		return errors.New("file is included more than once")
+	}
+	seen[file] = true
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	return s.parse(data, file, foldCase, seen)
+}
+
+// addPattern adds the rules for the pattern p, prefixes and all, as
+// Syncthing's parseLine makes them.
+func (s *Syncthing) addPattern(p string, foldCase bool) error {
+	r := syncthingRule{foldCase: foldCase}
+	// Each prefix can be given once.
+	var seen [3]bool
+	for more := true; more; {
+		switch {
+		case strings.HasPrefix(p, "!") && !seen[0]:
+			seen[0], r.include, p = true, true, p[1:]
+		case strings.HasPrefix(p, "(?i)") && !seen[1]:
+			seen[1], r.foldCase, p = true, true, p[4:]
+		case strings.HasPrefix(p, "(?d)") && !seen[2]:
+			seen[2], r.deletable, p = true, true, p[4:]
+		default:
+			more = false
+		}
+	}
+	if p == "" {
+		return errors.New("missing pattern after prefixes")
+	}
+	if r.foldCase {
+		p = strings.ToLower(p)
+	}
+	var globs []string
+	switch {
+	case strings.HasPrefix(p, "/"):
+		globs = []string{p[1:]}
+	case strings.HasPrefix(p, "**/"):
+		globs = []string{p, p[3:]}
+	default:
+		globs = []string{p, "**/" + p}
+	}
+	for _, g := range globs {
+		expr, err := syncthingRegexp(g)
+		if err != nil {
+			return err
+		}
+		if r.re, err = regexp.Compile("(?s)^" + expr + "$"); err != nil {
+			return err
+		}
+		s.rules = append(s.rules, r)
+	}
+	return nil
+}
+
+// syncthingRegexp returns the regular expression matching what the
+// Syncthing glob does, or an error for an unclosed bracket or brace.
+func syncthingRegexp(glob string) (string, error) {
+	var b strings.Builder
+	depth := 0 // of the braces b is in
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case c == '\\':
+			if i+1 < len(glob) {
+				i++
+			}
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		case c == '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				for i+1 < len(glob) && glob[i+1] == '*' {
+					i++
+				}
+				b.WriteString(".*")
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			class, end, ok := rsyncClass(glob, i)
+			if !ok {
+				return "", errors.New("unclosed bracket")
+			}
+			b.WriteString(class)
+			i = end
+		case c == '{':
+			depth++
+			b.WriteString("(?:")
+		case c == ',' && depth > 0:
+			b.WriteByte('|')
+		case c == '}' && depth > 0:
+			depth--
+			b.WriteByte(')')
+		default:
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	if depth > 0 {
+		return "", errors.New("unclosed brace")
+	}
+	return b.String(), nil
+}
+
+// Match reports whether Syncthing ignores relPath. The path is
+// slash-separated and relative to the folder root; a trailing slash,
+// for a directory, makes no difference. The root itself, and a path
+// outside it or with a NUL byte, are never ignored.
+func (s *Syncthing) Match(relPath string) bool {
+	r, internal := s.decide(relPath)
+	return internal || r != nil && !r.include
+}
+
+// Deletable reports whether relPath is ignored by a "(?d)" pattern,
+// which lets Syncthing delete it when it is in the way of deleting its
+// directory.
+func (s *Syncthing) Deletable(relPath string) bool {
+	r, _ := s.decide(relPath)
+	return r != nil && !r.include && r.deletable
+}
+
+// decide returns the first rule matching relPath, unless it is one of
+// Syncthing's own files, for which it reports true.
+func (s *Syncthing) decide(relPath string) (*syncthingRule, bool) {
+	if strings.IndexByte(relPath, 0) >= 0 {
+		return nil, false
+	}
+	relPath, ok := resolvePath(relPath)
+	if !ok || relPath == "" {
+		return nil, false
+	}
+	for _, name := range syncthingInternal {
+		if relPath == name || strings.HasPrefix(relPath, name+"/") {
+			return nil, true
+		}
+	}
+	if base := path.Base(relPath); strings.HasSuffix(base, ".tmp") &&
+		(strings.HasPrefix(base, ".syncthing.") || strings.HasPrefix(base, "~syncthing~")) {
+		return nil, true
+	}
+	lower := strings.ToLower(relPath)
+	for i := range s.rules {
+		r := &s.rules[i]
+		target := relPath
+		if r.foldCase {
+			target = lower
+		}
+		if r.re.MatchString(target) {
+			return r, false
+		}
+	}
+	return nil, false
+}
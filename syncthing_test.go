@@ -0,0 +1,139 @@
+package gitignore_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestSyncthing(t *testing.T) {
+	for _, tt := range []struct {
+		stignore string
+		want     map[string]bool
+	}{
+		// The examples of Syncthing's documentation.
+		{"foo\n", map[string]bool{"foo": true, "subdir/foo": true, "foo/x": true, "afoo": false}},
+		{"te*ne\n", map[string]bool{"telephone": true, "subdir/telephone": true, "tele/phone": false}},
+		{"te**ne\n", map[string]bool{"telephone": true, "subdir/telephone": true, "tele/sub/dir/phone": true}},
+		{"te??st\n", map[string]bool{"tebest": true, "teb/st": false}},
+		{"[a-z]x\n", map[string]bool{"ax": true, "Ax": false}},
+		{"{banana,pineapple}\n", map[string]bool{"banana": true, "pineapple": true, "apple": false}},
+		{"{a,b{c,d}}.x\n", map[string]bool{"a.x": true, "bd.x": true, "b.x": false}},
+		{"\\*x\n", map[string]bool{"*x": true, "ax": false}},
+		{"/foo\n", map[string]bool{"foo": true, "subdir/foo": false, "foo/x": true}},
+		{"(?i)test\n", map[string]bool{"test": true, "TEST": true, "tEsT": true}},
+		{"(?i)!picture*.png\n*.png\n", map[string]bool{"Picture1.PNG": false, "x.png": true}},
+		{"!(?i)picture*.png\n*.png\n", map[string]bool{"Picture1.PNG": false}},
+		{"file // comment\n", map[string]bool{"file": false}}, // not a comment
+		// The first match wins, and a path is decided by itself.
+		{"!foo/keep\nfoo\n", map[string]bool{"foo": true, "foo/keep": false, "foo/other": true}},
+		{"foo\n!foo/keep\n", map[string]bool{"foo/keep": true}},
+		{"!*.txt\n*\n", map[string]bool{"a.txt": false, "d": true, "d/a.txt": false, "d/a.md": true}},
+		// A trailing slash matches only what is inside the directory.
+		{"build/\n", map[string]bool{"build": false, "build/x": true, "a/build/x/y": true}},
+		{"build/**\n", map[string]bool{"build": false, "build/x": true}},
+		{"**/build\n", map[string]bool{"build": true, "a/build": true, "a/build/x": true}},
+		// Comments, blanks and the internal files.
+		{"// *.txt\n\n  a.txt  \n", map[string]bool{"a.txt": true, "// *.txt": false}},
+		{"", map[string]bool{
+			".stfolder": true, ".stignore": true, ".stversions/a": true, "a/.stignore": false,
+			".syncthing.a.txt.tmp": true, "d/~syncthing~a.tmp": true, "a.tmp": false, "a": false,
+		}},
+	} {
+		root := t.TempDir()
+		writeFiles(t, root, map[string]string{".stignore": tt.stignore})
+		s, err := gitignore.LoadSyncthing(root)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.stignore, err)
+		}
+		for path, want := range tt.want {
+			if got := s.Match(path); got != want {
+				t.Errorf("%q: Match(%q) = %v, want %v", tt.stignore, path, got, want)
+			}
+		}
+		for _, path := range []string{"", ".", "../foo", "foo\x00"} {
+			if s.Match(path) {
+				t.Errorf("%q: Match(%q) = true", tt.stignore, path)
+			}
+		}
+	}
+}
+
+func TestSyncthingOptions(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".stignore":       "#include rules/more\n(?d).DS_Store\n(?d)(?i)thumbs.db\n",
+		"rules/more":      "#include ../other\n!keep.log\n",
+		"other":           "*.log\n",
+		"rules/.keep":     "",
+		"sub/.stignore":   "*.md\n", // nested .stignore files aren't read
+		"sub/readme.md":   "",
+		"twice/.stignore": "#include a\n#include a\n",
+		"twice/a":         "x\n",
+	})
+	s, err := gitignore.LoadSyncthing(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]bool{
+		"a.log":     true, // from the nested include
+		"keep.log":  true, // *.log comes first
+		"sub/x.md":  false,
+		".DS_Store": true,
+		"Thumbs.DB": true,
+	} {
+		if got := s.Match(path); got != want {
+			t.Errorf("Match(%q) = %v, want %v", path, got, want)
+		}
+	}
+	for path, want := range map[string]bool{"a/.DS_Store": true, "Thumbs.db": true, "a.log": false} {
+		if got := s.Deletable(path); got != want {
+			t.Errorf("Deletable(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	s, err = gitignore.LoadSyncthing(root, gitignore.WithIgnoreCase(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Match("A.LOG") || !s.Match("x/.ds_store") {
+		t.Error("WithIgnoreCase: want patterns that ignore case")
+	}
+
+	if _, err := gitignore.LoadSyncthing(filepath.Join(root, "twice")); err == nil {
+		t.Error("want an error for a file included twice")
+	}
+	if s, err := gitignore.LoadSyncthing(filepath.Join(root, "sub", "readme.md")); err == nil || s != nil {
+		t.Errorf("root is a file: got %v", err)
+	}
+	if s, err := gitignore.LoadSyncthing(t.TempDir()); err != nil || s.Match("a") {
+		t.Errorf("no .stignore: got %v", err)
+	}
+}
+
+func TestSyncthingErrors(t *testing.T) {
+	for _, tt := range []struct {
+		stignore string
+		line     int
+	}{
+		{"a\n!\n", 2},
+		{"(?i)(?d)\n", 1},
+		{"[ab\n", 1},
+		{"{a,b\n", 1},
+		{"a\n#include missing\n", 2},
+		{"#include\n", 1},
+	} {
+		root := t.TempDir()
+		if err := os.WriteFile(filepath.Join(root, ".stignore"), []byte(tt.stignore), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := gitignore.LoadSyncthing(root)
+		var perr gitignore.PatternError
+		if !errors.As(err, &perr) || perr.Line != tt.line {
+			t.Errorf("%q: got %v, want a PatternError on line %d", tt.stignore, err, tt.line)
+		}
+	}
+}
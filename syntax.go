@@ -0,0 +1,166 @@
+package gitignore
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// A Compiler reads a pattern syntax other than gitignore's, registered
+// with RegisterSyntax for WithSyntax. Compile translates a line of an
+// ignore file, as written and without its line ending, into the
+// gitignore pattern lines that ignore the same paths relative to the
+// directory of the file: a syntax whose patterns are all anchored might
+// compile "*.log" to "/*.log". The Matcher compiles and matches those
+// lines as it does the lines of a .gitignore, with its options, so
+// walking, Equivalent, SaveCache and the rest work on them too, and
+// MatchDetail and Errors report them, not the line they came from.
+//
+// A line may compile to several rules, which take its line number, or
+// none, as a comment or a blank line does; the rules of a later line
+// take precedence. An error leaves the line out and is reported by
+// Errors, whatever the InvalidPatternPolicy. Matchers may be built on
+// several goroutines at once, so Compile must be safe for concurrent
+// use.
+type Compiler interface {
+	Compile(line string) ([]string, error)
+}
+
+// CompilerFunc adapts a function to a Compiler.
+type CompilerFunc func(line string) ([]string, error)
+
+// Compile returns f(line).
+func (f CompilerFunc) Compile(line string) ([]string, error) {
+	return f(line)
+}
+
+// gitignoreSyntax is the name of the default syntax, which is read
+// without a Compiler.
+const gitignoreSyntax = "gitignore"
+
+// syntaxes holds the Compilers registered by RegisterSyntax, by name.
+var syntaxes struct {
+	sync.RWMutex
+	m map[string]Compiler
+}
+
+// RegisterSyntax makes the pattern syntax c compiles available to
+// WithSyntax as name, so a package can plug a dialect into the matching
+// and walking of this one without forking it. It is meant to be called
+// from an init function:
+//
+//	func init() {
+//		gitignore.RegisterSyntax("anchored", gitignore.CompilerFunc(compileAnchored))
+//	}
+//
+// "gitignore", the default, is always registered. RegisterSyntax panics
+// if name is empty or already registered, or if c is nil.
+func RegisterSyntax(name string, c Compiler) {
+	if name == "" {
+		panic("gitignore: RegisterSyntax with an empty name")
+	}
+	if c == nil {
+		panic("gitignore: RegisterSyntax of " + name + " with a nil Compiler")
+	}
+	syntaxes.Lock()
+	defer syntaxes.Unlock()
+	if _, ok := syntaxes.m[name]; ok || name == gitignoreSyntax {
+		panic("gitignore: RegisterSyntax called twice for " + name)
+	}
+	if syntaxes.m == nil {
+		syntaxes.m = make(map[string]Compiler)
+	}
+	syntaxes.m[name] = c
+}
+
+// Syntaxes returns the names of the registered pattern syntaxes, sorted.
+func Syntaxes() []string {
+	syntaxes.RLock()
+	defer syntaxes.RUnlock()
+	names := []string{gitignoreSyntax}
+	for name := range syntaxes.m {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// WithSyntax makes the Matcher read its per-directory ignore files, the
+// file LoadIgnoreFile loads and the patterns given to AddPatterns and
+// AddFromFile in the syntax registered as name, instead of as gitignore
+// lines. The files git reads for itself, .git/info/exclude and the
+// global excludes, are still read as gitignore files, as are those of
+// WithIgnoreFile. WithSyntax("gitignore") is the default. It panics if
+// no syntax of that name is registered.
+func WithSyntax(name string) Option {
+	if name != gitignoreSyntax && lookupSyntax(name) == nil {
+		panic("gitignore: unknown pattern syntax " + name)
+	}
+	return func(c *config) {
+		c.syntax = name
+		if name == gitignoreSyntax {
+			c.syntax = ""
+		}
+	}
+}
+
+// lookupSyntax returns the Compiler registered as name, or nil if there
+// is none.
+func lookupSyntax(name string) Compiler {
+	syntaxes.RLock()
+	defer syntaxes.RUnlock()
+	return syntaxes.m[name]
+}
+
+// addCompiled adds the rules c compiles line, at lineNum in source, into.
+// It reports false once m holds as many patterns as its limits allow.
+func (m *Matcher) addCompiled(c Compiler, line, dir string, dirSegs []string, source string, lineNum int) bool {
+	rules, err := c.Compile(line)
+	if err == nil && slices.ContainsFunc(rules, func(r string) bool { return strings.ContainsAny(r, "\r\n") }) {
+		err = errCompiledLines
+	}
+	if err != nil {
+		m.errors = append(m.errors, PatternError{
+			Pattern: line,
+			Source:  source,
+			Line:    lineNum,
+			Message: err.Error(),
+		})
+		return true
+	}
+	// Each rule's text is what it was compiled from, as for a brace
+	// expansion, so it is saved, replayed and compared like any other.
+	from := len(m.patterns)
+	src := m.addSource(source, dir, strings.Join(rules, "\n"))
+	off := 0
+	more := true
+	for _, r := range rules {
+		rule := trimTrailingSpaces(r)
+		start := off
+		off += len(r) + 1
+		if rule == "" || rule[0] == '#' {
+			continue
+		}
+		if m.braces && !m.regexLine(rule) {
+			expanded, ok := m.addBraces(rule, dir, dirSegs, source, lineNum)
+			if more = ok; !more {
+				break
+			}
+			if expanded {
+				continue
+			}
+		}
+		if more = m.addLine(rule, src, start, dir, dirSegs, source, lineNum); !more {
+			break
+		}
+	}
+	if len(m.patterns) == from {
+		m.sources = m.sources[:src]
+	}
+	return more
+}
+
+// errCompiledLines is the error for a Compiler returning a rule that
+// isn't a single line.
+var errCompiledLines = errors.New("compiled rule spans lines")
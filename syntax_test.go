@@ -0,0 +1,169 @@
+package gitignore_test
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func init() {
+	gitignore.RegisterSyntax("anchored", gitignore.CompilerFunc(compileAnchored))
+	gitignore.RegisterSyntax("multiline", gitignore.CompilerFunc(func(line string) ([]string, error) {
+		return []string{line + "\n" + line}, nil
+	}))
+}
+
+// compileAnchored compiles a test syntax whose patterns are all anchored
+// to the directory of their file, "|" separates alternatives and "//"
+// starts a comment.
+func compileAnchored(line string) ([]string, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "//") {
+		return nil, nil
+	}
+	if strings.Contains(line, "[") {
+		return nil, errors.New("brackets aren't supported")
+	}
+	neg := ""
+	if rest, ok := strings.CutPrefix(line, "!"); ok {
+		neg, line = "!", rest
+	}
+	var rules []string
+	for alt := range strings.SplitSeq(line, "|") {
+		rules = append(rules, neg+"/"+strings.TrimPrefix(alt, "/"))
+	}
+	return rules, nil
+}
+
+func TestSyntax(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".git/info/exclude": "*.tmp\n",
+		".gitignore":        "// comment\n*.log|build/\n!keep.log\n# kept\n",
+		"sub/.gitignore":    "  gen  \n",
+	})
+	m := gitignore.NewFromDirectory(root, gitignore.WithSyntax("anchored"))
+	for path, want := range map[string]bool{
+		"a.log":       true,
+		"sub/a.log":   false,
+		"keep.log":    false,
+		"build/x":     true,
+		"sub/build/x": false,
+		"sub/gen":     true,
+		"sub/x/gen":   false,
+		"# kept":      true,
+		"sub/a.tmp":   true, // .git/info/exclude is a gitignore file
+	} {
+		if got := m.Match(path); got != want {
+			t.Errorf("Match(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	// Each rule reports the line it was compiled from.
+	r := m.MatchDetail("build/x")
+	if r.Pattern != "/build/" || r.Line != 2 || r.Source != filepath.Join(root, ".gitignore") {
+		t.Errorf("MatchDetail = %+v", r)
+	}
+
+	m.AddPatterns([]byte("a.txt\n"), "sub")
+	if !m.Match("sub/a.txt") || m.Match("sub/x/a.txt") {
+		t.Error("AddPatterns: want the WithSyntax syntax")
+	}
+
+	def := gitignore.NewFromDirectory(root, gitignore.WithSyntax("gitignore"))
+	def.AddPatterns([]byte("a.txt\n"), "sub")
+	if !def.Match("sub/x/a.txt") {
+		t.Error(`WithSyntax("gitignore"): want gitignore lines`)
+	}
+}
+
+func TestSyntaxWalk(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":     "a.log\n",
+		"a.log":          "",
+		"sub/a.log":      "",
+		"sub/.gitignore": "b.log\n",
+		"sub/b.log":      "",
+		"sub/c/b.log":    "",
+	})
+	var got []string
+	err := gitignore.Walk(root, func(path string, d fs.DirEntry) error {
+		if !d.IsDir() {
+			got = append(got, path)
+		}
+		return nil
+	}, gitignore.WithSyntax("anchored"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{".gitignore", "sub/.gitignore", "sub/a.log", "sub/c/b.log"}
+	slices.Sort(got)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Walk = %q, want %q", got, want)
+	}
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	m := gitignore.New(t.TempDir(), gitignore.WithSyntax("anchored"), gitignore.WithInvalidPatterns(gitignore.LiteralInvalid))
+	m.AddPatterns([]byte("a\n[bc]\nd\n"), "")
+	errs := m.Errors()
+	if len(errs) != 1 || errs[0].Pattern != "[bc]" || errs[0].Line != 2 || errs[0].Message != "brackets aren't supported" {
+		t.Errorf("Errors = %v", errs)
+	}
+	if m.Match("[bc]") || !m.Match("a") || !m.Match("d") {
+		t.Error("want the line that didn't compile left out")
+	}
+
+	m = gitignore.New(t.TempDir(), gitignore.WithSyntax("multiline"))
+	m.AddPatterns([]byte("x\n"), "")
+	if errs := m.Errors(); len(errs) != 1 || m.Match("x") {
+		t.Errorf("Errors = %v, want a rule spanning lines reported", errs)
+	}
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{".gitignore": "[x]\n"})
+	if _, err := gitignore.LoadDirectory(root, gitignore.WithSyntax("anchored"), gitignore.WithInvalidPatterns(gitignore.FailOnInvalid)); err == nil {
+		t.Error("FailOnInvalid: want the compile error")
+	}
+}
+
+func TestRegisterSyntax(t *testing.T) {
+	names := gitignore.Syntaxes()
+	if !slices.Contains(names, "gitignore") || !slices.Contains(names, "anchored") || !slices.IsSorted(names) {
+		t.Errorf("Syntaxes = %q", names)
+	}
+
+	c := gitignore.CompilerFunc(compileAnchored)
+	for name, f := range map[string]func(){
+		"duplicate":      func() { gitignore.RegisterSyntax("anchored", c) },
+		"gitignore":      func() { gitignore.RegisterSyntax("gitignore", c) },
+		"empty name":     func() { gitignore.RegisterSyntax("", c) },
+		"nil compiler":   func() { gitignore.RegisterSyntax("nil", nil) },
+		"unknown syntax": func() { gitignore.WithSyntax("unknown") },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: want a panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+}
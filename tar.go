@@ -0,0 +1,333 @@
+package gitignore
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// tarIgnoreFiles are the files GNU tar reads for --exclude-vcs-ignores,
+// in the order it reads them.
+var tarIgnoreFiles = []string{".cvsignore", ".gitignore", ".bzrignore", ".hgignore"}
+
+// TarVCSIgnores decides which files GNU tar leaves out of an archive
+// when given --exclude-vcs-ignores, for archive tools that have to
+// exclude exactly what tar does. Tar reads the .cvsignore, .gitignore,
+// .bzrignore and .hgignore of each directory it archives, but its rules
+// are its own rather than those of the version control systems:
+//
+//   - A pattern is matched, with fnmatch and FNM_PATHNAME, against the
+//     whole member name, the name without a leading "./" and its base
+//     name, so "*.o" matches at any depth, but "src/*.o" only if the
+//     member names start there. Anchoring and "**" mean nothing, and a
+//     pattern with a trailing slash matches nothing.
+//   - The files of a directory and of the directories above it within
+//     the archived tree apply, .cvsignore included, and a path is left
+//     out if any file excludes it; nothing re-includes it. Tar doesn't
+//     descend into a directory it leaves out.
+//   - In a .gitignore, as in the other files, leading and trailing
+//     whitespace is trimmed; "#" starts a comment and "\#" escapes it,
+//     but a "!" is part of the name. A .cvsignore holds names separated
+//     by whitespace, without comments.
+//   - In a .bzrignore, "!" makes a pattern re-include what matches it,
+//     "!!" is dropped, and "RE:" makes it a regular expression. Within
+//     each file the last pattern matching a name decides; if none does
+//     and the first pattern re-includes, the name is left out.
+//   - A .hgignore's patterns are regular expressions until a
+//     "syntax: glob" line, and "syntax: regexp" switches back. A
+//     trailing slash is dropped.
+//   - A regular expression is a POSIX extended one that may match any
+//     part of the name, but one without any of ".{}()?*[]" must equal
+//     the whole name instead, as with a glob without wildcards.
+type TarVCSIgnores struct {
+	root string
+	name string
+	dirs map[string][]tarIgnoreFile // by directory, relative to root; "" for root
+}
+
+// tarIgnoreFile is the patterns of an ignore file, in order.
+type tarIgnoreFile []tarPattern
+
+// tarPattern is a pattern of a tarIgnoreFile: a literal name, a glob or
+// a regular expression. A regular expression that didn't compile leaves
+// all three empty and matches nothing.
+type tarPattern struct {
+	include bool
+	literal string
+	glob    []string // split at slashes; nil if not a glob
+	re      *regexp.Regexp
+}
+
+// LoadTarVCSIgnores reads the ignore files below root as tar does when
+// archiving root under the given name, which the member names start
+// with: "." for "tar -C root -c .", or "proj" for "tar -c proj" run in
+// root's parent. It returns an error if an ignore file can't be read.
+func LoadTarVCSIgnores(root, name string) (*TarVCSIgnores, error) {
+	if name == "" {
+		return nil, errors.New("gitignore: tar member name of the root is empty")
+	}
+	t := &TarVCSIgnores{root: root, name: strings.TrimSuffix(name, "/"), dirs: map[string][]tarIgnoreFile{}}
+	if t.name == "" {
+		t.name = "/"
+	}
+	err := t.walk(func(rel string, d fs.DirEntry) error {
+		if !d.IsDir() {
+			return nil
+		}
+		for _, file := range tarIgnoreFiles {
+			data, err := os.ReadFile(filepath.Join(t.root, filepath.FromSlash(rel), file))
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			t.dirs[rel] = append(t.dirs[rel], parseTarIgnore(file, data))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Match reports whether tar leaves relPath out, because an ignore file
+// excludes it or a directory above it. relPath is slash-separated and
+// relative to the root; a trailing slash is allowed and ignored.
+func (t *TarVCSIgnores) Match(relPath string) bool {
+	relPath = strings.Trim(relPath, "/")
+	if relPath == "" {
+		return false
+	}
+	for i := 0; i <= len(relPath); i++ {
+		if (i == len(relPath) || relPath[i] == '/') && t.excluded(relPath[:i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Members returns the member names of the archive tar makes, in the
+// order tar --sort=name adds them: depth first, each directory's entries
+// sorted by name. Directories, root first, end in a slash. Symlinks
+// aren't followed.
+func (t *TarVCSIgnores) Members() ([]string, error) {
+	var members []string
+	err := t.walk(func(rel string, d fs.DirEntry) error {
+		m := t.member(rel)
+		if d.IsDir() && !strings.HasSuffix(m, "/") {
+			m += "/"
+		}
+		members = append(members, m)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// walk calls fn for root and each path below it that tar archives, with
+// the path relative to root, directories before their entries.
+func (t *TarVCSIgnores) walk(fn func(rel string, d fs.DirEntry) error) error {
+	return fs.WalkDir(os.DirFS(t.root), ".", func(rel string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			rel = ""
+		} else if t.excluded(rel) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		return fn(rel, d)
+	})
+}
+
+// member returns the member name of rel.
+func (t *TarVCSIgnores) member(rel string) string {
+	switch {
+	case rel == "":
+		return t.name
+	case strings.HasSuffix(t.name, "/"):
+		return t.name + rel
+	}
+	return t.name + "/" + rel
+}
+
+// excluded reports whether the ignore files of the directories holding
+// rel exclude it, its directories being archived.
+func (t *TarVCSIgnores) excluded(rel string) bool {
+	name := t.member(rel)
+	stripped := name
+	for strings.HasPrefix(stripped, "./") {
+		stripped = stripped[2:]
+	}
+	base := path.Base(name)
+	dir := rel
+	for dir != "" {
+		dir = parentDir(dir)
+		for _, f := range t.dirs[dir] {
+			if f.excludes(name) || f.excludes(stripped) || f.excludes(base) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parentDir returns the directory holding the slash-separated rel, or ""
+// for the root.
+func parentDir(rel string) string {
+	if i := strings.LastIndexByte(rel, '/'); i >= 0 {
+		return rel[:i]
+	}
+	return ""
+}
+
+// excludes reports whether f excludes name: whether the last pattern
+// matching it doesn't re-include it, or none does and the first
+// pattern re-includes, as gnulib's excluded_file_name decides.
+func (f tarIgnoreFile) excludes(name string) bool {
+	for i := len(f) - 1; i >= 0; i-- {
+		if f[i].matches(name) {
+			return !f[i].include
+		}
+	}
+	return len(f) > 0 && f[0].include
+}
+
+// matches reports whether p matches name.
+func (p *tarPattern) matches(name string) bool {
+	switch {
+	case p.re != nil:
+		return p.re.MatchString(name)
+	case p.glob != nil:
+		segs := strings.Split(name, "/")
+		if len(segs) != len(p.glob) {
+			return false
+		}
+		for i, g := range p.glob {
+			if !matchSegment(g, segs[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return p.literal != "" && p.literal == name
+}
+
+// parseTarIgnore returns the patterns tar reads from the ignore file
+// named file.
+func parseTarIgnore(file string, data []byte) tarIgnoreFile {
+	var f tarIgnoreFile
+	regex := true // for a .hgignore
+	for line := range strings.SplitSeq(string(data), "\n") {
+		line = strings.TrimFunc(line, isCSpace)
+		if line == "" {
+			continue
+		}
+		switch file {
+		case ".cvsignore":
+			for _, word := range strings.FieldsFunc(line, isCSpace) {
+				f = append(f, newTarPattern(word, false, false))
+			}
+		case ".gitignore":
+			if line[0] == '#' {
+				continue
+			}
+			if strings.HasPrefix(line, `\#`) {
+				line = line[1:]
+			}
+			f = append(f, newTarPattern(line, false, false))
+		case ".bzrignore":
+			if line[0] == '#' {
+				continue
+			}
+			include := false
+			if line[0] == '!' {
+				line = line[1:]
+				if strings.HasPrefix(line, "!") {
+					line = line[1:]
+				} else {
+					include = true
+				}
+			}
+			re := strings.HasPrefix(line, "RE:")
+			f = append(f, newTarPattern(strings.TrimPrefix(line, "RE:"), re, include))
+		case ".hgignore":
+			if line[0] == '#' {
+				continue
+			}
+			if syntax, ok := strings.CutPrefix(line, "syntax:"); ok {
+				switch strings.TrimLeftFunc(syntax, isCSpace) {
+				case "regexp":
+					regex = true
+				case "glob":
+					regex = false
+				}
+				continue
+			}
+			f = append(f, newTarPattern(strings.TrimSuffix(line, "/"), regex, false))
+		}
+	}
+	return f
+}
+
+// newTarPattern returns the pattern s, a regular expression if re is
+// set and a glob otherwise, read as gnulib's add_exclude reads it.
+func newTarPattern(s string, re, include bool) tarPattern {
+	p := tarPattern{include: include}
+	switch {
+	case re && strings.ContainsAny(s, ".{}()?*[]"):
+		p.re, _ = regexp.CompilePOSIX(s)
+	case re:
+		p.literal = s
+	case tarHasWildcards(s):
+		p.glob = strings.Split(s, "/")
+	default:
+		p.literal = unescapeGlob(s)
+	}
+	return p
+}
+
+// tarHasWildcards reports whether the glob s has an unescaped wildcard.
+func tarHasWildcards(s string) bool {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '?', '*', '[', ']':
+			return true
+		}
+	}
+	return false
+}
+
+// unescapeGlob removes the backslashes escaping the characters of s.
+func unescapeGlob(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// isCSpace reports whether r is whitespace to C's isspace.
+func isCSpace(r rune) bool {
+	switch r {
+	case ' ', '\t', '\n', '\v', '\f', '\r':
+		return true
+	}
+	return false
+}
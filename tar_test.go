@@ -0,0 +1,98 @@
+package gitignore_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestTarVCSIgnores(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":        "*.o\n# comment\n\\#x\n!keep.o\nbuild/\nsrc/*.tmp\n/abs\n  lead  \nvendor\n",
+		"sub/.cvsignore":    "*.log core\n",
+		"bzr/.bzrignore":    "*.tmp\n!keep.tmp\nRE:^.*\\.bak$\n!!x\n",
+		"inc/.bzrignore":    "!*.c\n",
+		"hg/.hgignore":      "\\.pyc$\nsyntax: glob\n*.swp\nsyntax: regexp\nbuild\nout/\n",
+		"vendor/.gitignore": "!*\n",
+	})
+	for name, want := range map[string]map[string]bool{
+		".": {
+			"a.o": true, "sub/b.o": true, "#x": true, "# comment": false, "keep.o": true, "!keep.o": true,
+			"build": false, "build/x": false, "src/a.tmp": true, "sub/src/a.tmp": false, "abs": false,
+			"lead": true, "vendor": true, "vendor/a": true, "vendor/": true,
+			"sub/x.log": true, "sub/deep/y.log": true, "sub/core": true, "x.log": false,
+			"bzr/a.tmp": true, "bzr/keep.tmp": false, "bzr/f.bak": true, "bzr/x": true, "bzr/y": false,
+			// The first pattern re-includes, so what no pattern matches is
+			// left out, and a member name with a slash matches no pattern.
+			"inc/a.c": true, "inc/a.h": true,
+			"hg/a.pyc": true, "hg/x.swp": true, "hg/build": true, "hg/mybuild": false, "hg/out": true,
+			"": false,
+		},
+		"proj": {"src/a.tmp": false, "a.o": true, "hg/build": true},
+	} {
+		tv, err := gitignore.LoadTarVCSIgnores(root, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for path, want := range want {
+			if got := tv.Match(path); got != want {
+				t.Errorf("%s: Match(%q) = %v, want %v", name, path, got, want)
+			}
+		}
+	}
+}
+
+func TestTarVCSIgnoresMembers(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":      "*.o\nskip\n",
+		"a":               "",
+		"b/x":             "",
+		"b/y.o":           "",
+		"b.txt":           "",
+		"skip/.gitignore": "",
+		"skip/z":          "",
+	})
+	if err := os.RemoveAll(filepath.Join(root, ".git")); err != nil {
+		t.Fatal(err)
+	}
+	tv, err := gitignore.LoadTarVCSIgnores(root, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := tv.Members()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"./", "./.gitignore", "./a", "./b/", "./b/x", "./b.txt"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Members = %q, want %q", got, want)
+	}
+
+	if tv, err = gitignore.LoadTarVCSIgnores(root, "proj/"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := tv.Members(); len(got) != len(want) || got[0] != "proj/" || got[3] != "proj/b/" {
+		t.Errorf("Members = %q", got)
+	}
+}
+
+func TestTarVCSIgnoresErrors(t *testing.T) {
+	root := t.TempDir()
+	if _, err := gitignore.LoadTarVCSIgnores(root, ""); err == nil {
+		t.Error("want an error for an empty name")
+	}
+	if err := os.MkdirAll(filepath.Join(root, "d", ".hgignore"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gitignore.LoadTarVCSIgnores(root, "."); err == nil {
+		t.Error("want an error for an unreadable .hgignore")
+	}
+	if _, err := gitignore.LoadTarVCSIgnores(filepath.Join(root, "missing"), "."); err == nil {
+		t.Error("want an error for a missing root")
+	}
+}
@@ -0,0 +1,83 @@
+package gitignore
+
+import (
+	"embed"
+	"errors"
+	"path"
+	"slices"
+	"strings"
+)
+
+// templateFS holds templates from the github/gitignore collection, which
+// is in the public domain (CC0), for Generate. It is a selection of the
+// widely used ones, not the whole collection; those in its Global folder,
+// like macOS, are kept alongside the rest.
+//
+//go:embed templates/*.gitignore
+var templateFS embed.FS
+
+// Templates returns the names of the templates Generate knows, sorted.
+func Templates() []string {
+	entries, _ := templateFS.ReadDir("templates")
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = strings.TrimSuffix(e.Name(), ".gitignore")
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	})
+	return names
+}
+
+// Generate returns a .gitignore holding the named templates, such as
+// "Go", "Node" and "macOS", in order, for scaffolding tools that write
+// one without going online. Names are as Templates lists them, in any
+// case. Each template starts with a "### Name ###" comment, and a pattern
+// another template already has is left out, unless a negation comes
+// between them, which it might then undo. It is an error for a name to
+// have no template.
+func Generate(names ...string) ([]byte, error) {
+	var b strings.Builder
+	last := map[string]int{} // of a pattern, the index of its line
+	negated := -1            // index of the last negation
+	n := 0
+	for _, name := range names {
+		name, data, err := template(name)
+		if err != nil {
+			return nil, err
+		}
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString("### " + name + " ###\n")
+		text := strings.TrimSuffix(string(data), "\n")
+		for line := range strings.SplitSeq(text, "\n") {
+			p := trimTrailingSpaces(strings.TrimSuffix(line, "\r"))
+			if p != "" && p[0] != '#' {
+				if i, ok := last[p]; ok && i > negated {
+					continue
+				}
+				last[p] = n
+				if p[0] == '!' {
+					negated = n
+				}
+			}
+			b.WriteString(line)
+			b.WriteByte('\n')
+			n++
+		}
+	}
+	return []byte(b.String()), nil
+}
+
+// template returns the name, as Templates has it, and the text of the
+// template named name in any case.
+func template(name string) (string, []byte, error) {
+	for _, t := range Templates() {
+		if strings.EqualFold(t, name) {
+			data, err := templateFS.ReadFile(path.Join("templates", t+".gitignore"))
+			return t, data, err
+		}
+	}
+	return "", nil, errors.New("gitignore: no template named " + name)
+}
@@ -0,0 +1,78 @@
+package gitignore_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	names := gitignore.Templates()
+	for _, want := range []string{"Go", "Node", "Python", "macOS", "Windows"} {
+		found := false
+		for _, n := range names {
+			found = found || n == want
+		}
+		if !found {
+			t.Errorf("Templates() = %v, missing %q", names, want)
+		}
+	}
+
+	data, err := gitignore.Generate("go", "Python", "Node", "macOS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+	for _, header := range []string{"### Go ###\n", "\n### Python ###\n", "\n### Node ###\n", "\n### macOS ###\n"} {
+		if !strings.Contains(out, header) {
+			t.Errorf("Generate: missing %q", header)
+		}
+	}
+	// .env is in Go, Node and Python; dist/ differs from Node's dist.
+	if n := strings.Count(out, "\n.env\n"); n != 1 {
+		t.Errorf("Generate: .env appears %d times, want once", n)
+	}
+	if !strings.Contains(out, "\ndist/\n") || !strings.Contains(out, "\ndist\n") {
+		t.Error("Generate: want patterns differing in text kept")
+	}
+
+	m := gitignore.New(t.TempDir())
+	m.AddPatterns(data, "")
+	if errs := m.Errors(); len(errs) != 0 {
+		t.Errorf("Errors() = %v", errs)
+	}
+	for path, want := range map[string]bool{
+		"main.exe":                  true,
+		"node_modules/x/index.js":   true,
+		"a/__pycache__/b.pyc":       true,
+		".DS_Store":                 true,
+		"Icon\r":                    true,
+		".yarn/cache/x.zip":         true,
+		".yarn/releases/yarn-4.cjs": false,
+		"main.go":                   false,
+	} {
+		if got := m.Match(path); got != want {
+			t.Errorf("Match(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	if _, err := gitignore.Generate("Go", "NoSuchLanguage"); err == nil || !strings.Contains(err.Error(), "NoSuchLanguage") {
+		t.Errorf("Generate(NoSuchLanguage) error = %v", err)
+	}
+}
+
+func TestGenerateNegations(t *testing.T) {
+	data, err := gitignore.Generate("Linux", "Vim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// *~ is in Linux and Vim, with Vim's !*.svg between them, so Vim's
+	// is kept to exclude *.svg~ again.
+	if n := strings.Count(string(data), "\n*~\n"); n != 2 {
+		t.Errorf("Generate: *~ appears %d times, want twice", n)
+	}
+}
@@ -0,0 +1,130 @@
+package gitignore
+
+// TraceStep is one pattern evaluated for a path by Trace.
+type TraceStep struct {
+	Pattern string // original pattern text
+	Source  string // file the pattern came from (empty for programmatic patterns)
+	Line    int    // 1-based line number in Source
+	Negate  bool   // the pattern re-includes rather than excludes
+	Global  bool   // the pattern is from the global excludes
+
+	Matched bool        // the pattern matches the path
+	Decided bool        // the pattern is the one deciding the path, as the last match
+	Reason  TraceReason // why the pattern matches or doesn't
+}
+
+// TraceReason says why a pattern in a TraceStep matches a path or
+// doesn't.
+type TraceReason int
+
+const (
+	// TraceMatched is the reason of every matching pattern.
+	TraceMatched TraceReason = iota
+
+	// TraceScope means the path isn't inside the directory of the
+	// .gitignore the pattern is from.
+	TraceScope
+
+	// TraceSuffix means no segment of the path ends with the literal
+	// text the pattern ends with, as ".log" for "*.log", so the pattern
+	// was ruled out without matching it.
+	TraceSuffix
+
+	// TraceDirOnly means the pattern, written with a trailing slash,
+	// would match the path if it were a directory.
+	TraceDirOnly
+
+	// TraceShadowed means a later copy of the pattern, with the same
+	// text and scope, takes its place.
+	TraceShadowed
+
+	// TraceNoMatch means the pattern was matched against the path and
+	// doesn't match it.
+	TraceNoMatch
+)
+
+func (r TraceReason) String() string {
+	switch r {
+	case TraceMatched:
+		return "matched"
+	case TraceScope:
+		return "outside the pattern's directory"
+	case TraceSuffix:
+		return "no segment ends with the pattern's suffix"
+	case TraceDirOnly:
+		return "pattern only matches directories"
+	case TraceShadowed:
+		return "shadowed by a later copy of the pattern"
+	case TraceNoMatch:
+		return "no match"
+	}
+	return "unknown reason " + itoa(int(r))
+}
+
+// Trace explains how Match decides relPath: it evaluates every pattern
+// against the path, in the order Match gives them precedence, the
+// repository's patterns from last to first and then the global excludes,
+// and records whether each one matches and, if not, why. The first step
+// that matches is marked Decided; the path is ignored if that pattern
+// isn't a negation. Trace returns nil for a path Match never ignores,
+// such as the root or one outside it.
+//
+// Trace follows Match, which decides each path on its own. For why
+// MatchFull ignores a path whose step list says otherwise, trace the
+// parent directories, with a trailing slash: one of them is decided by
+// a pattern that isn't a negation.
+//
+// Unlike Match, Trace tries every pattern and allocates its result, so
+// it is meant for debugging rather than for every path of a walk. It
+// doesn't count towards Stats.
+func (m *Matcher) Trace(relPath string) []TraceStep {
+	relPath, isDir, ok := m.path(relPath, true)
+	if !ok {
+		return nil
+	}
+	pathSegs := m.normalize(splitPath(relPath, nil))
+	if len(pathSegs) == 0 {
+		return nil
+	}
+	var steps []TraceStep
+	decided := false
+	for o := m; o != nil; o = o.globalMatcher() {
+		for i := len(o.patterns) - 1; i >= 0; i-- {
+			p := &o.patterns[i]
+			reason := o.traceReason(i, pathSegs, isDir)
+			matched := reason == TraceMatched
+			steps = append(steps, TraceStep{
+				Pattern: o.patternText(p),
+				Source:  o.sources[p.src].path,
+				Line:    int(p.line),
+				Negate:  p.negate,
+				Global:  o != m,
+				Matched: matched,
+				Decided: matched && !decided,
+				Reason:  reason,
+			})
+			decided = decided || matched
+		}
+	}
+	return steps
+}
+
+// traceReason returns why pattern i of m matches pathSegs or doesn't,
+// checking in the order lookups rule patterns out.
+func (m *Matcher) traceReason(i int, pathSegs []string, isDir bool) TraceReason {
+	p, h := &m.patterns[i], &m.hot[i]
+	rel, ok := underPrefix(p, pathSegs)
+	switch {
+	case h.shadowed:
+		return TraceShadowed
+	case !ok:
+		return TraceScope
+	case !h.exact && h.literalSuffix != "" && !anyHasSuffix(rel, h.literalSuffix):
+		return TraceSuffix
+	case tryPattern(p, rel, isDir):
+		return TraceMatched
+	case p.dirOnly && !isDir && tryPattern(p, rel, true):
+		return TraceDirOnly
+	}
+	return TraceNoMatch
+}
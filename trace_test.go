@@ -0,0 +1,96 @@
+package gitignore_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestTrace(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	m := gitignore.New(t.TempDir())
+	m.AddPatterns([]byte("*.log\nbuild/\n*.log\n!keep.log\n"), "")
+	m.AddPatterns([]byte("*.tmp\n"), "src")
+
+	steps := m.Trace("build")
+	want := []struct {
+		pattern string
+		line    int
+		reason  gitignore.TraceReason
+	}{
+		{"*.tmp", 1, gitignore.TraceScope},
+		{"!keep.log", 4, gitignore.TraceNoMatch},
+		{"*.log", 3, gitignore.TraceSuffix},
+		{"build/", 2, gitignore.TraceDirOnly},
+		{"*.log", 1, gitignore.TraceShadowed},
+	}
+	if len(steps) != len(want) {
+		t.Fatalf("Trace(build) = %+v, want %d steps", steps, len(want))
+	}
+	for i, w := range want {
+		if s := steps[i]; s.Pattern != w.pattern || s.Line != w.line || s.Reason != w.reason || s.Matched || s.Decided {
+			t.Errorf("step %d = %+v, want %s on line %d: %v", i, s, w.pattern, w.line, w.reason)
+		}
+	}
+
+	steps = m.Trace("build/keep.log")
+	if !steps[1].Decided || !steps[1].Negate || !steps[2].Matched || steps[2].Decided || !steps[3].Matched {
+		t.Errorf("Trace(build/keep.log) = %+v, want the negation deciding over two matches", steps)
+	}
+	if got := gitignore.TraceDirOnly.String(); got != "pattern only matches directories" {
+		t.Errorf("TraceDirOnly.String() = %q", got)
+	}
+	for _, p := range []string{"", ".", "../a.log", "a\x00.log"} {
+		if steps := m.Trace(p); steps != nil {
+			t.Errorf("Trace(%q) = %+v, want nil", p, steps)
+		}
+	}
+}
+
+func TestTraceAgreesWithMatchDetail(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":         "*.log\n!keep.log\nbuild/\n/docs/*.md\n",
+		"src/.gitignore":     "!*.log\n*.tmp\ngen/\n",
+		"src/app/.gitignore": "*.log\n!gen/\n",
+	})
+	xdg := t.TempDir()
+	writeFiles(t, xdg, map[string]string{"git/ignore": "*.global\n*.tmp\n"})
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	paths := []string{
+		"a.log", "keep.log", "src/a.log", "src/app/a.log", "src/app/keep.log", "build/", "build",
+		"src/build/x.go", "docs/a.md", "src/docs/a.md", "a.tmp", "src/a.tmp", "a.global",
+		"src/gen/", "src/app/gen/", "src/app/gen/x.go", "src/gen/x.go", "x.go", "./src/a.tmp",
+	}
+	for _, opts := range [][]gitignore.Option{nil, {gitignore.WithDFA()}, {gitignore.WithIgnoreCase(true)}} {
+		m := gitignore.NewFromDirectory(root, opts...)
+		for _, path := range paths {
+			want := m.MatchDetail(path)
+			var got gitignore.MatchResult
+			decided := 0
+			for _, s := range m.Trace(path) {
+				if s.Matched != (s.Reason == gitignore.TraceMatched) {
+					t.Errorf("Trace(%q): step %+v has Matched and Reason disagreeing", path, s)
+				}
+				if s.Decided {
+					decided++
+					got = gitignore.MatchResult{
+						Ignored: !s.Negate, Matched: true, Pattern: s.Pattern,
+						Source: s.Source, Line: s.Line, Negate: s.Negate,
+					}
+					if s.Global != (s.Source == filepath.Join(xdg, "git", "ignore")) {
+						t.Errorf("Trace(%q): step %+v has Global wrong", path, s)
+					}
+				}
+			}
+			if got != want || decided > 1 {
+				t.Errorf("Trace(%q) decided by %+v (%d steps), want %+v", path, got, decided, want)
+			}
+		}
+	}
+}
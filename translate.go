@@ -0,0 +1,390 @@
+package gitignore
+
+import (
+	"path"
+	"slices"
+	"strings"
+)
+
+// IgnoreFormat is an ignore file syntax Translate reads and writes.
+type IgnoreFormat int
+
+const (
+	// FormatGitignore is the syntax of .gitignore.
+	FormatGitignore IgnoreFormat = iota
+
+	// FormatDockerignore is the syntax of the .dockerignore of a Docker
+	// build context. Every pattern is relative to the context root,
+	// whether or not it holds a slash, patterns are trimmed of spaces at
+	// both ends, a trailing slash is dropped, and a negation can
+	// re-include a path inside an excluded directory.
+	FormatDockerignore
+
+	// FormatNPMIgnore is the syntax of .npmignore: that of .gitignore, as
+	// read by minimatch, which also expands {a,b} and reads extglobs like
+	// +(a|b).
+	FormatNPMIgnore
+)
+
+func (f IgnoreFormat) String() string {
+	switch f {
+	case FormatGitignore:
+		return "gitignore"
+	case FormatDockerignore:
+		return "dockerignore"
+	case FormatNPMIgnore:
+		return "npmignore"
+	}
+	return "IgnoreFormat(" + itoa(int(f)) + ")"
+}
+
+// TranslateIssue is a rule Translate couldn't carry over as it was, as
+// it reports them.
+type TranslateIssue struct {
+	Pattern string // the rule as written in the input
+	Line    int    // 1-based line number in the input
+	Message string // what differs
+	Dropped bool   // the rule was left out of the output
+}
+
+func (t TranslateIssue) String() string {
+	return itoa(t.Line) + ": " + t.Pattern + ": " + t.Message
+}
+
+// Translate rewrites an ignore file from one format into another, so a
+// project keeping a .gitignore, a .dockerignore and a .npmignore can
+// generate them from one of them:
+//
+//	out, issues := gitignore.Translate(data, gitignore.FormatGitignore, gitignore.FormatDockerignore)
+//
+// Rules are rewritten to ignore the same paths in the output format:
+// "*.log" in a .gitignore is "**/*.log" in a .dockerignore, and "{a,b}"
+// in a .npmignore is two lines of a .gitignore. Comments and blank lines
+// are kept, so the lines of the output follow those of the input, bar
+// the rules that become several lines or none.
+//
+// Where the formats differ in what they can say, the rule is reported:
+// left out if it has no form in the output, like an extglob or a
+// Docker pattern read as a regular expression, and kept if it has one
+// that differs, like a directory-only pattern, which a .dockerignore
+// can't write, or a negation that git never applies because it is
+// inside an excluded directory, which would apply in a .dockerignore.
+// Issues are in line order.
+func Translate(data []byte, from, to IgnoreFormat) ([]byte, []TranslateIssue) {
+	var issues []TranslateIssue
+	report := func(line int, pattern, msg string, dropped bool) {
+		issues = append(issues, TranslateIssue{Pattern: pattern, Line: line, Message: msg, Dropped: dropped})
+	}
+
+	// Rules go through gitignore syntax on their way to the output.
+	type rule struct {
+		text string // in gitignore syntax
+		line int
+		orig string
+	}
+	var rules []rule
+	text := strings.TrimPrefix(string(data), utf8BOM)
+	text = strings.TrimSuffix(text, "\n")
+	for i, orig := range strings.Split(text, "\n") {
+		orig = strings.TrimSuffix(orig, "\r")
+		lines, msg := toGitignore(orig, from)
+		if msg != "" {
+			report(i+1, orig, msg, true)
+		}
+		for _, l := range lines {
+			rules = append(rules, rule{l, i + 1, orig})
+		}
+	}
+
+	// Negations that git never applies are applied by Docker.
+	never := map[int]string{}
+	if (from == FormatDockerignore) != (to == FormatDockerignore) {
+		texts := make([]string, len(rules))
+		for i, r := range rules {
+			texts[i] = r.text
+		}
+		m := newMatcher(&config{})
+		m.addPatterns([]byte(strings.Join(texts, "\n")), "", "")
+		for _, issue := range m.Lint() {
+			if strings.HasPrefix(issue.Message, "never applies") {
+				never[issue.Line-1] = issue.Message
+			}
+		}
+	}
+
+	var b strings.Builder
+	for i, r := range rules {
+		if msg, ok := never[i]; ok {
+			if from == FormatDockerignore {
+				report(r.line, r.orig, "re-includes inside an excluded directory, which "+to.String()+" doesn't: "+msg, false)
+			} else {
+				report(r.line, r.orig, msg+", but would apply in "+to.String(), true)
+				continue
+			}
+		}
+		lines, msg, dropped := fromGitignore(r.text, to)
+		if msg != "" {
+			report(r.line, r.orig, msg, dropped)
+		}
+		if to == FormatNPMIgnore && r.text != "" && r.text[0] != '#' {
+			if msg := npmForced(r.text); msg != "" {
+				report(r.line, r.orig, msg, false)
+			}
+		}
+		for _, l := range lines {
+			b.WriteString(l)
+			b.WriteByte('\n')
+		}
+	}
+	slices.SortStableFunc(issues, func(a, b TranslateIssue) int { return a.Line - b.Line })
+	return []byte(b.String()), issues
+}
+
+// toGitignore rewrites line, in format f, as gitignore lines, or returns
+// why it has no such form.
+func toGitignore(line string, f IgnoreFormat) ([]string, string) {
+	switch f {
+	case FormatDockerignore:
+		return dockerToGitignore(line)
+	case FormatNPMIgnore:
+		if line == "" || line[0] == '#' {
+			return []string{line}, ""
+		}
+		if extglob(line) {
+			return nil, "extglobs have no gitignore form"
+		}
+		alts, ok := expandBraces(trimTrailingSpaces(line), 1)
+		if !ok {
+			return nil, "braces expand to more than " + itoa(maxBraceExpansions) + " patterns"
+		}
+		if alts == nil {
+			return []string{line}, ""
+		}
+		return alts, ""
+	}
+	return []string{line}, ""
+}
+
+// extglob reports whether line, a .npmignore pattern, holds an extglob
+// such as +(a|b) or !(a).
+func extglob(line string) bool {
+	for i := 0; i+1 < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\\':
+			i++
+		case c == '[':
+			if _, next, ok := matchBracket(line, i, 0); ok {
+				i = next - 1
+			}
+		case strings.IndexByte("+@!?*", c) >= 0 && line[i+1] == '(' && (c != '!' || i > 0):
+			return true
+		}
+	}
+	return false
+}
+
+// dockerRegexpChars are the characters Docker leaves to the regular
+// expression it compiles a pattern into, outside brackets, beyond those
+// that mean the same in a glob.
+const dockerRegexpChars = "+(){}|^"
+
+// dockerToGitignore rewrites a .dockerignore line as in Docker's
+// ReadAll, and the pattern as a gitignore line.
+func dockerToGitignore(line string) ([]string, string) {
+	if line != "" && line[0] == '#' {
+		return []string{line}, ""
+	}
+	p := strings.TrimSpace(line)
+	if p == "" {
+		return []string{""}, ""
+	}
+	neg := p[0] == '!'
+	if neg {
+		p = strings.TrimSpace(p[1:])
+	}
+	if p != "" {
+		p = strings.TrimPrefix(path.Clean(p), "/")
+	}
+	if p == "" || p == "." || p == ".." || strings.HasPrefix(p, "../") {
+		return nil, "matches the context root or outside it"
+	}
+	var b strings.Builder
+	if neg {
+		b.WriteByte('!')
+	}
+	b.WriteByte('/')
+	for i := 0; i < len(p); i++ {
+		switch c := p[i]; {
+		case c == '\\' && i+1 < len(p):
+			b.WriteString(p[i : i+2])
+			i++
+		case c == '[':
+			_, next, ok := matchBracket(p, i, 0)
+			if !ok {
+				return nil, "invalid pattern: unclosed bracket"
+			}
+			class := p[i:next]
+			if class[1] == '!' {
+				// A regular expression class, so "!" is a member.
+				class = `[\!` + class[2:]
+			}
+			b.WriteString(class)
+			i = next - 1
+		case c == '*' && i+1 < len(p) && p[i+1] == '*':
+			if (i > 0 && p[i-1] != '/') || (i+2 < len(p) && p[i+2] != '/') {
+				return nil, "** inside a name has no gitignore form"
+			}
+			b.WriteString("**")
+			i++
+		case strings.IndexByte(dockerRegexpChars, c) >= 0:
+			return nil, "reads as a regular expression in dockerignore"
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return []string{b.String()}, ""
+}
+
+// fromGitignore rewrites a gitignore line in format f, returning the
+// lines and what differs, if anything, and whether that leaves it out.
+func fromGitignore(line string, f IgnoreFormat) ([]string, string, bool) {
+	if line == "" || line[0] == '#' {
+		return []string{line}, "", false
+	}
+	switch f {
+	case FormatDockerignore:
+		return gitignoreToDocker(line)
+	case FormatNPMIgnore:
+		return []string{escapeMinimatch(line)}, "", false
+	}
+	return []string{line}, "", false
+}
+
+// escapeMinimatch escapes the braces and parentheses in the gitignore
+// line, which minimatch would read as alternatives or extglobs.
+func escapeMinimatch(line string) string {
+	var b strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; c {
+		case '\\':
+			end := min(i+2, len(line))
+			b.WriteString(line[i:end])
+			i = end - 1
+		case '[':
+			next := i + 1
+			if _, end, ok := matchBracket(line, i, 0); ok {
+				next = end
+			}
+			b.WriteString(line[i:next])
+			i = next - 1
+		case '{', '}', '(', ')':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// gitignoreToDocker is fromGitignore for FormatDockerignore.
+func gitignoreToDocker(line string) ([]string, string, bool) {
+	p, msg := compilePattern(line, nil, nil)
+	if msg != "" {
+		return nil, "invalid pattern: " + msg, true
+	}
+	for _, s := range p.segments {
+		if s.glue {
+			// Git reads "x**/y" two ways; see matchGlued.
+			return nil, "** after other characters in a name has no dockerignore form", true
+		}
+	}
+	body := trimTrailingSpaces(strings.TrimPrefix(line, "!"))
+	body = strings.TrimSuffix(body, "/")
+	body = strings.TrimPrefix(body, "/")
+	if body[0] == ' ' || strings.HasSuffix(body, `\ `) {
+		return nil, "dockerignore trims spaces at both ends", true
+	}
+	var b strings.Builder
+	if p.negate {
+		b.WriteByte('!')
+	}
+	if !p.anchored {
+		b.WriteString("**/")
+	}
+	segs := strings.Split(body, "/")
+	for i, seg := range segs {
+		if i > 0 {
+			b.WriteByte('/')
+		}
+		if len(seg) >= 2 && strings.TrimLeft(seg, "*") == "" {
+			b.WriteString("**")
+			continue
+		}
+		dockerSegment(&b, simplifyWildcards(seg))
+	}
+	if p.dirOnly {
+		return []string{b.String()}, "directory-only; dockerignore ignores a file of the name too", false
+	}
+	return []string{b.String()}, "", false
+}
+
+// dockerSegment appends the gitignore segment seg as Docker reads it:
+// escapes Docker's regular expression would read differently are
+// dropped, and its special characters escaped.
+func dockerSegment(b *strings.Builder, seg string) {
+	for i := 0; i < len(seg); i++ {
+		switch c := seg[i]; {
+		case c == '\\' && i+1 < len(seg):
+			i++
+			if e := seg[i]; isAlnum(e) || e == ' ' || e >= 0x80 {
+				b.WriteByte(e)
+			} else {
+				b.WriteString(seg[i-1 : i+1])
+			}
+		case c == '[':
+			_, next, ok := matchBracket(seg, i, 0)
+			if !ok {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := seg[i:next]
+			if class[1] == '!' {
+				class = "[^" + class[2:]
+			}
+			b.WriteString(class)
+			i = next - 1
+		case strings.IndexByte(dockerRegexpChars, c) >= 0:
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
+
+// isAlnum reports whether c is an ASCII letter or digit.
+func isAlnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// npmForcedNames are files npm always leaves out or, for package.json,
+// always includes, whatever a .npmignore says.
+var npmForcedNames = []string{"package.json", "node_modules/", ".git/", ".npmrc", "package-lock.json"}
+
+// npmForced returns what npm overrides of the gitignore line, or "".
+func npmForced(line string) string {
+	m := newMatcher(&config{})
+	m.addPatterns([]byte(line), "", "")
+	for _, name := range npmForcedNames {
+		r := m.MatchDetail(name)
+		switch {
+		case !r.Matched:
+		case name == "package.json" && !r.Negate:
+			return "npm always includes package.json"
+		case name != "package.json" && r.Negate:
+			return "npm always leaves out " + strings.TrimSuffix(name, "/")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,119 @@
+package gitignore_test
+
+import (
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to gitignore.IgnoreFormat
+		in, want string
+		issues   []gitignore.TranslateIssue
+	}{
+		{
+			name: "gitignore to dockerignore",
+			from: gitignore.FormatGitignore, to: gitignore.FormatDockerignore,
+			in:   "# build output\n*.log\n/dist\nsrc/*.tmp\n\nnode_modules/\n!keep.log\n[!a]+.c\n\\#x\n",
+			want: "# build output\n**/*.log\ndist\nsrc/*.tmp\n\n**/node_modules\n!**/keep.log\n**/[^a]\\+.c\n**/\\#x\n",
+			issues: []gitignore.TranslateIssue{
+				{Pattern: "node_modules/", Line: 6, Message: "directory-only; dockerignore ignores a file of the name too"},
+			},
+		},
+		{
+			name: "gitignore negations git never applies",
+			from: gitignore.FormatGitignore, to: gitignore.FormatDockerignore,
+			in:   "build/\n!build/keep\n x\nx**/y\n",
+			want: "**/build\n",
+			issues: []gitignore.TranslateIssue{
+				{Pattern: "build/", Line: 1, Message: "directory-only; dockerignore ignores a file of the name too"},
+				{Pattern: "!build/keep", Line: 2, Message: "never applies: directory build is excluded, but would apply in dockerignore", Dropped: true},
+				{Pattern: " x", Line: 3, Message: "dockerignore trims spaces at both ends", Dropped: true},
+				{Pattern: "x**/y", Line: 4, Message: "** after other characters in a name has no dockerignore form", Dropped: true},
+			},
+		},
+		{
+			name: "dockerignore to gitignore",
+			from: gitignore.FormatDockerignore, to: gitignore.FormatGitignore,
+			in:   "# context\n  *.md \n/docs/\n**/temp*\nbuild\n!build/keep\n[!a]x\n[^b]y\na+b\nx**y\n./../up\n",
+			want: "# context\n/*.md\n/docs\n/**/temp*\n/build\n!/build/keep\n/[\\!a]x\n/[^b]y\n",
+			issues: []gitignore.TranslateIssue{
+				{Pattern: "!build/keep", Line: 6, Message: "re-includes inside an excluded directory, which gitignore doesn't: never applies: directory build is excluded"},
+				{Pattern: "a+b", Line: 9, Message: "reads as a regular expression in dockerignore", Dropped: true},
+				{Pattern: "x**y", Line: 10, Message: "** inside a name has no gitignore form", Dropped: true},
+				{Pattern: "./../up", Line: 11, Message: "matches the context root or outside it", Dropped: true},
+			},
+		},
+		{
+			name: "npmignore to gitignore",
+			from: gitignore.FormatNPMIgnore, to: gitignore.FormatGitignore,
+			in:   "*.{md,txt}\ntest/\n!+(a|b).js\n\\{x}\n",
+			want: "*.md\n*.txt\ntest/\n\\{x}\n",
+			issues: []gitignore.TranslateIssue{
+				{Pattern: "!+(a|b).js", Line: 3, Message: "extglobs have no gitignore form", Dropped: true},
+			},
+		},
+		{
+			name: "gitignore to npmignore",
+			from: gitignore.FormatGitignore, to: gitignore.FormatNPMIgnore,
+			in:   "{a,b}\n*(x).c\n*.json\n!node_modules/\n",
+			want: "\\{a,b\\}\n*\\(x\\).c\n*.json\n!node_modules/\n",
+			issues: []gitignore.TranslateIssue{
+				{Pattern: "*.json", Line: 3, Message: "npm always includes package.json"},
+				{Pattern: "!node_modules/", Line: 4, Message: "npm always leaves out node_modules"},
+			},
+		},
+		{
+			name: "dockerignore to npmignore",
+			from: gitignore.FormatDockerignore, to: gitignore.FormatNPMIgnore,
+			in:   "*.o\r\n(x)\n",
+			want: "/*.o\n",
+			issues: []gitignore.TranslateIssue{
+				{Pattern: "(x)", Line: 2, Message: "reads as a regular expression in dockerignore", Dropped: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		out, issues := gitignore.Translate([]byte(tt.in), tt.from, tt.to)
+		if string(out) != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.name, out, tt.want)
+		}
+		if len(issues) != len(tt.issues) {
+			t.Errorf("%s: issues = %v, want %v", tt.name, issues, tt.issues)
+			continue
+		}
+		for i := range issues {
+			if issues[i] != tt.issues[i] {
+				t.Errorf("%s: issue %d = %+v, want %+v", tt.name, i, issues[i], tt.issues[i])
+			}
+		}
+	}
+}
+
+func TestTranslateMatches(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	// Going to dockerignore and back ignores the same paths.
+	in := "*.log\n/dist\nsrc/**/gen\n!keep.log\n[[:digit:]]*.tmp\n"
+	docker, issues := gitignore.Translate([]byte(in), gitignore.FormatGitignore, gitignore.FormatDockerignore)
+	back, more := gitignore.Translate(docker, gitignore.FormatDockerignore, gitignore.FormatGitignore)
+	if len(issues) != 0 || len(more) != 0 {
+		t.Fatalf("issues = %v, %v", issues, more)
+	}
+	a := gitignore.New(t.TempDir())
+	a.AddPatterns([]byte(in), "")
+	b := gitignore.New(t.TempDir())
+	b.AddPatterns(back, "")
+	for _, path := range []string{"a.log", "d/a.log", "keep.log", "dist/x", "x/dist", "src/gen", "src/a/gen/x", "1.tmp", "d/2a.tmp", "a.tmp"} {
+		if a.Match(path) != b.Match(path) {
+			t.Errorf("Match(%q): %v before, %v after going through\n%s", path, a.Match(path), b.Match(path), docker)
+		}
+	}
+
+	if got := gitignore.FormatNPMIgnore.String(); got != "npmignore" {
+		t.Errorf("String() = %q", got)
+	}
+}
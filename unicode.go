@@ -0,0 +1,216 @@
+package gitignore
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// WithPrecomposeUnicode makes matching treat canonically equivalent
+// Unicode names as the same, as git does on macOS when
+// core.precomposeUnicode is set. Patterns, scope directories and queried
+// paths are normalized to NFC before they are compared, so a file name
+// the filesystem reports decomposed (NFD), as macOS does, still matches a
+// pattern typed precomposed, and the other way round. Pass the value of
+// core.precomposeUnicode read from the repository's config.
+//
+// Normalizing a path that is already NFC, which includes every ASCII
+// path, costs a scan and no allocation.
+func WithPrecomposeUnicode(precompose bool) Option {
+	return func(c *config) {
+		c.precompose = precompose
+	}
+}
+
+// WithUnicodeClasses makes wildcards and bracket expressions read paths
+// by Unicode code point rather than by byte, for patterns written against
+// non-ASCII file names. A ? or a bracket expression then matches one
+// code point, so "?.txt" matches "é.txt", and the POSIX classes classify
+// it with the unicode package: [[:alpha:]] matches any letter, [[:digit:]]
+// any decimal digit, [[:space:]] any white space, and [[:punct:]] any
+// punctuation or symbol. A non-ASCII character in a bracket, or at either
+// end of a range, stands for its code point, so "[à-ÿ]" matches "é".
+//
+// On ASCII the classes are as without the option, but git compares
+// bytes, so with it a pattern can match non-ASCII paths that git leaves
+// alone, and the other way round.
+func WithUnicodeClasses() Option {
+	return func(c *config) {
+		c.unicode = true
+	}
+}
+
+// matchSegmentRunes is matchSegment reading text by code point, for the
+// segments of a Matcher made WithUnicodeClasses. Literal bytes are still
+// compared one at a time, and a backslash still escapes a single byte. A
+// byte that isn't valid UTF-8 is read as a code point of its own; see
+// decodeRune.
+func matchSegmentRunes(glob, text string) bool {
+	gx, tx := 0, 0
+	starGx, starTx := -1, -1
+
+	for tx < len(text) {
+		if gx < len(glob) {
+			ch := glob[gx]
+			switch {
+			case ch == '\\' && gx+1 < len(glob):
+				gx++
+				if text[tx] == glob[gx] {
+					gx++
+					tx++
+					continue
+				}
+			case ch == '?':
+				_, size := decodeRune(text[tx:])
+				gx++
+				tx += size
+				continue
+			case ch == '*':
+				starGx = gx
+				starTx = tx
+				gx++
+				continue
+			case ch == '[':
+				r, size := decodeRune(text[tx:])
+				matched, newGx, ok := matchBracketRune(glob, gx, r)
+				if ok && matched {
+					gx = newGx
+					tx += size
+					continue
+				}
+				if !ok && text[tx] == '[' {
+					gx++
+					tx++
+					continue
+				}
+			default:
+				if text[tx] == ch {
+					gx++
+					tx++
+					continue
+				}
+			}
+		}
+
+		// A * takes whole code points, so what follows it starts on one.
+		if starGx >= 0 {
+			_, size := decodeRune(text[starTx:])
+			starTx += size
+			tx = starTx
+			gx = starGx + 1
+			continue
+		}
+		return false
+	}
+
+	for gx < len(glob) && glob[gx] == '*' {
+		gx++
+	}
+	return gx == len(glob)
+}
+
+// matchBracketRune is matchBracket for the code point r. An ASCII r takes
+// the byte path, so ASCII paths match as they do without
+// WithUnicodeClasses.
+func matchBracketRune(glob string, pos int, r rune) (bool, int, bool) {
+	if r < utf8.RuneSelf {
+		return matchBracket(glob, pos, byte(r))
+	}
+	i := pos + 1
+	if i >= len(glob) {
+		return false, 0, false
+	}
+
+	negate := false
+	if glob[i] == '!' || glob[i] == '^' {
+		negate = true
+		i++
+	}
+
+	matched := false
+	first := true
+	for i < len(glob) {
+		if glob[i] == ']' && !first {
+			return matched != negate, i + 1, true
+		}
+		first = false
+
+		if glob[i] == '[' && i+1 < len(glob) && glob[i+1] == ':' {
+			if end := findPosixClassEnd(glob, i+2); end >= 0 {
+				if matchUnicodeClass(glob[i+2:end], r) {
+					matched = true
+				}
+				i = end + 2
+				continue
+			}
+		}
+
+		lo, n := bracketRune(glob, i)
+		i += n
+		if i+1 < len(glob) && glob[i] == '-' && glob[i+1] != ']' {
+			hi, n := bracketRune(glob, i+1)
+			i += 1 + n
+			if r == lo || r >= lo && r <= hi {
+				matched = true
+			}
+		} else if r == lo {
+			matched = true
+		}
+	}
+	return false, 0, false
+}
+
+// bracketRune decodes the character, possibly escaped, at glob[i] in a
+// bracket expression, returning it and the bytes it takes.
+func bracketRune(glob string, i int) (rune, int) {
+	if glob[i] == '\\' && i+1 < len(glob) {
+		r, n := decodeRune(glob[i+1:])
+		return r, n + 1
+	}
+	return decodeRune(glob[i:])
+}
+
+// decodeRune is utf8.DecodeRuneInString, except that a byte that isn't
+// part of valid UTF-8 decodes to a value of its own past unicode.MaxRune
+// rather than to utf8.RuneError, so two such bytes are only equal if
+// they are the same byte, as git compares them, and neither is equal to
+// an encoded U+FFFD. No POSIX class holds one.
+func decodeRune(s string) (rune, int) {
+	r, n := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError && n == 1 {
+		return invalidRune + rune(s[0]), 1
+	}
+	return r, n
+}
+
+// invalidRune is where decodeRune puts the bytes that aren't valid UTF-8.
+const invalidRune = unicode.MaxRune + 1
+
+// matchUnicodeClass is matchPosixClass for a non-ASCII code point.
+func matchUnicodeClass(name string, r rune) bool {
+	switch name {
+	case "alnum":
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	case "alpha":
+		return unicode.IsLetter(r)
+	case "blank":
+		return unicode.Is(unicode.Zs, r)
+	case "cntrl":
+		return unicode.IsControl(r)
+	case "digit":
+		return unicode.IsDigit(r)
+	case "graph":
+		return unicode.IsGraphic(r) && !unicode.IsSpace(r)
+	case "lower":
+		return unicode.IsLower(r)
+	case "print":
+		return unicode.IsGraphic(r)
+	case "punct":
+		return unicode.IsPunct(r) || unicode.IsSymbol(r)
+	case "space":
+		return unicode.IsSpace(r)
+	case "upper":
+		return unicode.IsUpper(r)
+	}
+	// xdigit is ASCII only.
+	return false
+}
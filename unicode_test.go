@@ -0,0 +1,140 @@
+package gitignore_test
+
+import (
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+func TestPrecomposeUnicode(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	const (
+		nfc = "caf\u00e9"  // é as one code point
+		nfd = "cafe\u0301" // e followed by a combining acute accent
+	)
+	tests := []struct {
+		pattern, dir, path string
+	}{
+		{nfc + ".txt", "", nfd + ".txt"},
+		{nfd + ".txt", "", nfc + ".txt"},
+		{nfc + "/", "", "x/" + nfd + "/"},
+		{"*.log", nfc, nfd + "/a.log"},
+		{"*.log", nfd, nfc + "/a.log"},
+		{"/" + nfc + "*", "", nfd + "-menu"},
+	}
+	for _, tt := range tests {
+		for _, opts := range [][]gitignore.Option{nil, {gitignore.WithDFA()}, {gitignore.WithIgnoreCase(true)}} {
+			m := gitignore.New(t.TempDir(), append(opts, gitignore.WithPrecomposeUnicode(true))...)
+			m.AddPatterns([]byte(tt.pattern+"\n"), tt.dir)
+			if !m.Match(tt.path) {
+				t.Errorf("%q in %q: Match(%q) = false, want true", tt.pattern, tt.dir, tt.path)
+			}
+		}
+
+		m := gitignore.New(t.TempDir())
+		m.AddPatterns([]byte(tt.pattern+"\n"), tt.dir)
+		if m.Match(tt.path) {
+			t.Errorf("%q in %q: Match(%q) = true without WithPrecomposeUnicode", tt.pattern, tt.dir, tt.path)
+		}
+	}
+}
+
+func TestPrecomposeUnicodeASCIIDoesNotAllocate(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	m := gitignore.New(t.TempDir(), gitignore.WithPrecomposeUnicode(true))
+	m.AddPatterns([]byte("*.log\nbuild/\n"), "")
+	for _, p := range []string{"src/app.log", "src/main.go", "build/"} {
+		allocs := testing.AllocsPerRun(100, func() {
+			m.Match(p)
+		})
+		if allocs != 0 {
+			t.Errorf("Match(%q) allocated %v times, want 0", p, allocs)
+		}
+	}
+}
+
+func TestUnicodeClasses(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tests := []struct {
+		pattern, path  string
+		unicode, bytes bool // with and without WithUnicodeClasses
+	}{
+		{"[[:alpha:]]", "é", true, false},
+		{"[[:alpha:]].txt", "ж.txt", true, false},
+		{"[[:upper:]]x", "Éx", true, false},
+		{"[[:lower:]]x", "Éx", false, false},
+		{"[[:digit:]]", "٣", true, false},
+		{"[[:space:]]", "a b", false, false},
+		{"a[[:space:]]b", "a b", true, false},
+		{"[[:punct:]]", "«", true, false},
+		{"[[:punct:]]", "€", true, false},
+		{"[[:xdigit:]]", "٣", false, false},
+		{"[![:alpha:]]", "é", false, false},
+		{"[![:alpha:]]", "•", true, false},
+		{"*[[:alpha:]]", "aé", true, false},
+		{"*[![:alpha:]]", "aé", false, true}, // a byte of é is no letter
+		{"[à-ÿ]", "é", true, false},
+		{"[é]", "é", true, false},
+		{"[\\é]", "é", true, false},
+		{"?.txt", "é.txt", true, false},
+		{"??.txt", "é.txt", false, true},
+		{"caf?", "café", true, false},
+		{"[[:alpha:]]*", "abc", true, true},
+		{"[[:alpha:]]", "1", false, false},
+		{"[!a-z]", "-", true, true},
+	}
+	for _, opts := range [][]gitignore.Option{nil, {gitignore.WithDFA()}, {gitignore.WithIgnoreCase(true)}} {
+		for _, tt := range tests {
+			m := gitignore.New(t.TempDir(), append(opts, gitignore.WithUnicodeClasses())...)
+			m.AddPatterns([]byte(tt.pattern+"\n"), "")
+			if got := m.Match(tt.path); got != tt.unicode {
+				t.Errorf("%q: Match(%q) = %v, want %v", tt.pattern, tt.path, got, tt.unicode)
+			}
+			m = gitignore.New(t.TempDir(), opts...)
+			m.AddPatterns([]byte(tt.pattern+"\n"), "")
+			if got := m.Match(tt.path); got != tt.bytes {
+				t.Errorf("%q: Match(%q) = %v without WithUnicodeClasses, want %v", tt.pattern, tt.path, got, tt.bytes)
+			}
+		}
+	}
+
+	a := gitignore.New(t.TempDir())
+	b := gitignore.New(t.TempDir(), gitignore.WithUnicodeClasses())
+	if a.Fingerprint() == b.Fingerprint() {
+		t.Error("expected WithUnicodeClasses to change the fingerprint")
+	}
+}
+
+func TestUnicodeClassesInvalidUTF8(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"[\xff]z", "\xffz", true},
+		{"[\xff]z", "\xfez", false},
+		{"[\xff]z", "�z", false},
+		{"[�]z", "\xffz", false},
+		{"[\x80-\xff]z", "\xc0z", true},
+		{"?z", "\xffz", true},
+		{"??z", "\xff\xfez", true},
+		{"*z", "\xff\xfez", true},
+		{"[[:alpha:]]z", "\xffz", false},
+		{"[![:alpha:]]z", "\xffz", true},
+	}
+	for _, tt := range tests {
+		m := gitignore.New(t.TempDir(), gitignore.WithUnicodeClasses())
+		m.AddPatterns([]byte(tt.pattern+"\n"), "")
+		if got := m.Match(tt.path); got != tt.want {
+			t.Errorf("%q: Match(%q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
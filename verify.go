@@ -0,0 +1,46 @@
+package gitignore
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WithVerify makes every lookup check its answer against a plain scan of
+// all patterns from last to first, the way the rules are written down,
+// and panic if they disagree. The index, the directory cache and the
+// automata of WithDFA all try patterns out of order to skip the ones
+// that can't match; this mode exists to test that they never change
+// which pattern wins. It makes each lookup cost a full scan, so use it
+// in tests and fuzzing only.
+func WithVerify() Option {
+	return func(c *config) {
+		c.verify = true
+	}
+}
+
+// naiveLookup is lookup by trying every pattern, last first.
+func (m *Matcher) naiveLookup(pathSegs []string, isDir bool) int {
+	for i := len(m.patterns) - 1; i >= 0; i-- {
+		if matchPattern(&m.patterns[i], pathSegs, isDir) {
+			return i
+		}
+	}
+	return -1
+}
+
+// verifyLookup panics unless i is what naiveLookup finds for pathSegs.
+func (m *Matcher) verifyLookup(pathSegs []string, isDir bool, i int) {
+	want := m.naiveLookup(pathSegs, isDir)
+	if i == want {
+		return
+	}
+	describe := func(i int) string {
+		if i < 0 {
+			return "no pattern"
+		}
+		p := &m.patterns[i]
+		return fmt.Sprintf("pattern %d %q (%s:%d)", i, m.patternText(p), m.sources[p.src].path, p.line)
+	}
+	panic(fmt.Sprintf("gitignore: lookup of %q (dir %v) found %s, but a full scan finds %s",
+		strings.Join(pathSegs, "/"), isDir, describe(i), describe(want)))
+}
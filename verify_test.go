@@ -0,0 +1,71 @@
+package gitignore_test
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+// TestVerifyRandomPatterns runs random pattern sets through every lookup
+// strategy with WithVerify, which panics if one disagrees with a plain
+// scan.
+func TestVerifyRandomPatterns(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	globs := []string{
+		"a", "b", "ab", "vendor", "*", "?", "a*", "*b", "[ab]", "**", "*.log", "x.log", `\*`, "[",
+	}
+	names := []string{"a", "b", "ab", "ba", "vendor", "x.log", "y.log", "*"}
+	configs := [][]gitignore.Option{
+		{gitignore.WithVerify()},
+		{gitignore.WithVerify(), gitignore.WithDirCache(8)},
+		{gitignore.WithVerify(), gitignore.WithDFA()},
+	}
+
+	r := rand.New(rand.NewSource(1))
+	line := func() string {
+		var segs []string
+		for range 1 + r.Intn(3) {
+			segs = append(segs, globs[r.Intn(len(globs))])
+		}
+		s := strings.Join(segs, "/")
+		if r.Intn(4) == 0 {
+			s = "/" + s
+		}
+		if r.Intn(3) == 0 {
+			s += "/"
+		}
+		if r.Intn(3) == 0 {
+			s = "!" + s
+		}
+		return s
+	}
+	for range 200 {
+		ms := make([]*gitignore.Matcher, len(configs))
+		for i, opts := range configs {
+			ms[i] = gitignore.New(t.TempDir(), opts...)
+		}
+		for _, scope := range []string{"", "a", "a/b", ""} {
+			var lines []string
+			for range 1 + r.Intn(5) {
+				lines = append(lines, line())
+			}
+			for _, m := range ms {
+				m.AddPatterns([]byte(strings.Join(lines, "\n")), scope)
+			}
+		}
+		for range 50 {
+			var segs []string
+			for range 1 + r.Intn(4) {
+				segs = append(segs, names[r.Intn(len(names))])
+			}
+			path := strings.Join(segs, "/")
+			isDir := r.Intn(2) == 0
+			for _, m := range ms {
+				m.MatchPath(path, isDir)
+			}
+		}
+	}
+}
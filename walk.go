@@ -0,0 +1,451 @@
+package gitignore
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// Walk walks the directory tree rooted at root, calling fn for each file
+// and directory that is not ignored by gitignore rules. It loads .gitignore
+// files as it descends, so patterns from deeper directories take effect for
+// their subtrees. The .git directory is always skipped.
+//
+// Paths passed to fn are relative to root and use the OS path separator.
+// The root directory itself is not passed to fn. Options such as
+// WithFilesOnly narrow which entries reach fn without affecting which
+// directories are traversed.
+func Walk(root string, fn func(path string, d fs.DirEntry) error, opts ...Option) error {
+	return WalkEntries(root, pathFunc(fn), opts...)
+}
+
+// WalkFS is like Walk but reads the tree from fsys, treating the root of
+// fsys as the repository root. Patterns are loaded from .git/info/exclude
+// and .gitignore files inside fsys, plus the user's global excludes file.
+//
+// Symlinks are never followed. If fsys implements fs.ReadLinkFS, entries
+// that ReadDir reports as directories are checked with fs.Lstat, so a
+// filesystem that resolves link targets in ReadDir still has its symlinks
+// reported as non-directory entries, the same as Walk does on disk.
+//
+// Paths passed to fn are slash-separated and relative to the root of fsys.
+func WalkFS(fsys fs.FS, fn func(path string, d fs.DirEntry) error, opts ...Option) error {
+	return WalkFSEntries(fsys, pathFunc(fn), opts...)
+}
+
+// Entry describes a path visited by WalkEntries or WalkFSEntries.
+type Entry struct {
+	Path     string      // relative to the walk root, in the same form Walk or WalkFS would pass
+	DirEntry fs.DirEntry // the directory entry for Path
+
+	// Depth is the number of directories between the walk root and the
+	// entry: 0 for entries directly under the root, 1 for their children,
+	// and so on.
+	Depth int
+
+	// Submodule is true for a directory that contains its own .git and
+	// was reported without being descended into. It is only set when
+	// the walk uses WithSubmodules.
+	Submodule bool
+}
+
+// WalkEntries is like Walk but passes each visited path to fn as an Entry.
+func WalkEntries(root string, fn func(Entry) error, opts ...Option) error {
+	m := New(root, opts...)
+	w := newOSWalker(root, m, fn)
+	if err := w.configure(opts); err != nil {
+		return err
+	}
+	if err := w.checkLimits(0); err != nil {
+		return err
+	}
+	if err := w.checkInvalid(); err != nil {
+		return err
+	}
+	return w.walk("", 0)
+}
+
+// WalkFSEntries is like WalkFS but passes each visited path to fn as an
+// Entry.
+func WalkFSEntries(fsys fs.FS, fn func(Entry) error, opts ...Option) error {
+	m := newFromFS(fsys, opts)
+	w := newFSWalker(fsys, m, fn)
+	if err := w.configure(opts); err != nil {
+		return err
+	}
+	if err := w.checkLimits(0); err != nil {
+		return err
+	}
+	if err := w.checkInvalid(); err != nil {
+		return err
+	}
+	return w.walk("", 0)
+}
+
+// WalkChan walks root like WalkEntries from a background goroutine,
+// sending each entry on the returned entry channel. The channel is
+// unbuffered, so traversal only advances as fast as the caller receives.
+//
+// When the walk finishes, the entry channel is closed and the error
+// channel receives the walk's error, or nil, before being closed too.
+// Cancelling ctx stops the walk and reports ctx.Err(). Callers that stop
+// receiving entries early must cancel ctx to release the goroutine.
+func WalkChan(ctx context.Context, root string, opts ...Option) (<-chan Entry, <-chan error) {
+	entries := make(chan Entry)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		defer close(entries)
+		errc <- WalkEntries(root, func(e Entry) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			select {
+			case entries <- e:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}, opts...)
+	}()
+	return entries, errc
+}
+
+func pathFunc(fn func(string, fs.DirEntry) error) func(Entry) error {
+	return func(e Entry) error {
+		return fn(e.Path, e.DirEntry)
+	}
+}
+
+// newFromFS is the fs.FS counterpart of New.
+func newFromFS(fsys fs.FS, opts []Option) *Matcher {
+	c := newConfig(opts)
+	m := newMatcher(c)
+	m.global = newGlobalExcludes(c)
+
+	if !c.plain {
+		excludePath := path.Join(".git", "info", "exclude")
+		if data, err := fs.ReadFile(fsys, excludePath); err == nil {
+			m.addPatterns(data, "", excludePath)
+		}
+	}
+
+	for _, name := range m.ignoreFileNames() {
+		if data, err := fs.ReadFile(fsys, name); err == nil && (c.noLstat || !m.skipsLinkFS(fsys, name)) {
+			m.addIgnoreFile(data, "", name)
+			break
+		}
+	}
+
+	return m
+}
+
+// walker holds the state shared across one traversal. Directory reads go
+// through fsys; rel paths are always slash-separated.
+type walker struct {
+	fsys fs.FS
+	m    *Matcher
+	fn   func(Entry) error
+	cfg  *config
+
+	// root is the OS directory being walked, empty when walking a
+	// caller-supplied fs.FS. It controls the form of the paths passed to
+	// fn and recorded as pattern sources.
+	root string
+
+	// extra holds patterns from WithIgnoreFile. It is consulted before m
+	// and kept separate so loading nested .gitignore files into m can't
+	// override it.
+	extra *Matcher
+
+	// layers are consulted after extra and before m, each loading its
+	// own per-directory ignore files, for modes that rank ignore files
+	// by kind rather than by depth. The first with a matching pattern
+	// decides.
+	layers []*Matcher
+
+	// files counts the nested .gitignore files loaded, for Limits.
+	files int
+
+	// segs is the stack of path segments leading to the entry being
+	// examined, reused across the whole walk.
+	segs []string
+
+	// only holds the WithOnly globs, nil if every path is wanted.
+	only *Matcher
+
+	// lstatDirs re-checks directory entries with fs.Lstat so symlinks
+	// reported with their target's type are not descended into.
+	lstatDirs bool
+}
+
+func newOSWalker(root string, m *Matcher, fn func(Entry) error) *walker {
+	// os.ReadDir already reports symlinks as symlinks, so there is no
+	// need to lstat directory entries.
+	return &walker{fsys: os.DirFS(root), m: m, fn: fn, cfg: &config{}, root: root}
+}
+
+func newFSWalker(fsys fs.FS, m *Matcher, fn func(Entry) error) *walker {
+	_, canLstat := fsys.(fs.ReadLinkFS)
+	return &walker{fsys: fsys, m: m, fn: fn, cfg: &config{}, lstatDirs: canLstat}
+}
+
+// configure applies opts to the walker and loads any extra ignore files.
+func (w *walker) configure(opts []Option) error {
+	w.cfg = newConfig(opts)
+	if len(w.cfg.extraFiles) > 0 {
+		w.extra = newMatcher(w.cfg)
+		for _, f := range w.cfg.extraFiles {
+			data, err := os.ReadFile(f)
+			if err != nil {
+				return err
+			}
+			w.extra.addPatterns(data, "", f)
+		}
+	}
+	if len(w.cfg.only) > 0 {
+		w.only = newMatcher(w.cfg)
+		for _, g := range w.cfg.only {
+			w.only.addPatterns([]byte(g), "", "")
+		}
+		if errs := w.only.Errors(); len(errs) > 0 {
+			return errs[0]
+		}
+	}
+	return nil
+}
+
+// wants reports whether a non-ignored entry should be passed to fn.
+func (w *walker) wants(segs []string, d fs.DirEntry) bool {
+	if !w.cfg.wants(d) {
+		return false
+	}
+	return w.only == nil || w.only.matchSegs(segs, d.IsDir())
+}
+
+// ignored reports whether the entry with path segments segs is excluded
+// from the walk.
+func (w *walker) ignored(segs []string, isDir bool) bool {
+	if w.extra != nil {
+		if r := w.extra.matchDetailSegs(segs, isDir); r.Matched {
+			return r.Ignored
+		}
+	}
+	for _, l := range w.layers {
+		if r := l.matchDetailSegs(segs, isDir); r.Matched {
+			return r.Ignored
+		}
+	}
+	return w.m.matchSegs(segs, isDir)
+}
+
+// fsPath converts a slash-separated relative path into a name for fsys.
+func fsPath(rel string) string {
+	if rel == "" {
+		return "."
+	}
+	return rel
+}
+
+// callerPath converts a slash-separated relative path into the form
+// passed to fn.
+func (w *walker) callerPath(rel string) string {
+	if w.root != "" {
+		return filepath.FromSlash(rel)
+	}
+	return rel
+}
+
+// readGitignore reads the ignore file called file in directory rel, for
+// m.
+func (w *walker) readGitignore(m *Matcher, rel, file string) ([]byte, error) {
+	name := rel + "/" + file
+	if m.deps != nil && w.root != "" {
+		return m.deps.readFS(w.fsys, name, w.sourcePath(rel, file))
+	}
+	return fs.ReadFile(w.fsys, name)
+}
+
+// sourcePath returns the path recorded as the source of patterns loaded
+// from the ignore file called file in directory rel.
+func (w *walker) sourcePath(rel, file string) string {
+	if w.root != "" {
+		return filepath.Join(w.root, filepath.FromSlash(rel), file)
+	}
+	return path.Join(rel, file)
+}
+
+// classify returns the entry to use for the directory entry d, replacing
+// it with its lstat information if it is really a symlink.
+func (w *walker) classify(rel string, d fs.DirEntry) fs.DirEntry {
+	info, err := fs.Lstat(w.fsys, rel)
+	if err != nil || info.Mode()&fs.ModeSymlink == 0 {
+		return d
+	}
+	return fs.FileInfoToDirEntry(info)
+}
+
+func (w *walker) walk(rel string, depth int) error {
+	entries, err := w.readDir(rel)
+	if err != nil {
+		return err
+	}
+	return w.walkEntries(rel, depth, entries)
+}
+
+// readDir lists directory rel, recording it if the Matcher tracks its
+// sources.
+func (w *walker) readDir(rel string) ([]fs.DirEntry, error) {
+	if w.m.deps != nil && w.root != "" {
+		// Stat before reading, so an entry created during the read
+		// still changes the recorded time.
+		info, err := fs.Stat(w.fsys, fsPath(rel))
+		if err != nil {
+			return nil, err
+		}
+		w.m.deps.addDir(filepath.Join(w.root, filepath.FromSlash(rel)), info)
+	}
+	return fs.ReadDir(w.fsys, fsPath(rel))
+}
+
+// walkEntries processes the already-read entries of directory rel. Entry
+// types come from the directory listing; nothing is stat'ed unless
+// lstatDirs is set.
+func (w *walker) walkEntries(rel string, depth int, entries []fs.DirEntry) error {
+	// Load .gitignore for this directory before processing entries. The
+	// listing tells us whether there is one, saving a failed open in
+	// directories without it.
+	if rel != "" {
+		for _, l := range w.layers {
+			if file := w.ignoreFile(l, entries); file != "" {
+				if err := w.loadGitignore(l, rel, file); err != nil {
+					return err
+				}
+			}
+		}
+		if file := w.ignoreFile(w.m, entries); file != "" {
+			if err := w.loadGitignore(w.m, rel, file); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+
+		// Always skip .git directories.
+		if name == ".git" && entry.IsDir() {
+			continue
+		}
+
+		// Match against the segment stack so ignored entries never pay
+		// for building their path string.
+		w.segs = append(w.segs[:depth], name)
+		segs := w.segs[:depth+1]
+
+		entryRel := ""
+		if w.lstatDirs && !w.cfg.noLstat && entry.IsDir() {
+			entryRel = joinRel(rel, name)
+			entry = w.classify(entryRel, entry)
+		}
+
+		if w.ignored(segs, entry.IsDir()) {
+			continue
+		}
+
+		if entryRel == "" {
+			entryRel = joinRel(rel, name)
+		}
+
+		// With WithSubmodules, read the child listing up front; it both
+		// reveals a nested .git and is reused if we descend.
+		var children []fs.DirEntry
+		read := false
+		submodule := false
+		if entry.IsDir() && w.cfg.submodules {
+			var err error
+			if children, err = w.readDir(entryRel); err != nil {
+				return err
+			}
+			read = true
+			submodule = hasEntry(children, ".git")
+		}
+
+		if w.fn != nil && w.wants(segs, entry) {
+			e := Entry{Path: w.callerPath(entryRel), DirEntry: entry, Depth: depth, Submodule: submodule}
+			if err := w.fn(e); err != nil {
+				return err
+			}
+		}
+
+		if !entry.IsDir() || submodule {
+			continue
+		}
+		if err := w.checkDepth(entryRel, depth+1); err != nil {
+			return err
+		}
+		if !read {
+			var err error
+			if children, err = w.readDir(entryRel); err != nil {
+				return err
+			}
+		}
+		if err := w.walkEntries(entryRel, depth+1, children); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// hasEntry reports whether entries contains one called name.
+func hasEntry(entries []fs.DirEntry, name string) bool {
+	for _, e := range entries {
+		if e.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoreFile returns the name of the ignore file m reads from a
+// directory with the given entries, or "" if it has none.
+func (w *walker) ignoreFile(m *Matcher, entries []fs.DirEntry) string {
+	for _, name := range m.ignoreFileNames() {
+		if e := findFile(entries, name); e != nil && !m.skipsLink(e.Type()) {
+			return name
+		}
+	}
+	return ""
+}
+
+// ignoreFileNames returns the names of the per-directory ignore files m
+// reads, in order of preference.
+func (m *Matcher) ignoreFileNames() []string {
+	if m.names == nil {
+		return gitignoreNames
+	}
+	return m.names
+}
+
+var gitignoreNames = []string{".gitignore"}
+
+// findFile returns the non-directory called name in entries, or nil.
+func findFile(entries []fs.DirEntry, name string) fs.DirEntry {
+	for _, e := range entries {
+		if e.Name() == name && !e.IsDir() {
+			return e
+		}
+	}
+	return nil
+}
+
+// joinRel appends name to the slash-separated directory rel.
+func joinRel(rel, name string) string {
+	if rel == "" {
+		return name
+	}
+	return rel + "/" + name
+}
@@ -0,0 +1,564 @@
+package gitignore_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/git-pkgs/gitignore"
+)
+
+// followFS wraps an fs.FS whose ReadDir reports symlinks with the type of
+// their target, the way some virtual filesystems do.
+type followFS struct {
+	fstest.MapFS
+}
+
+func (f followFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := f.MapFS.ReadDir(name)
+	if err != nil {
+		return nil, err
+	}
+	for i, e := range entries {
+		if e.Type()&fs.ModeSymlink == 0 {
+			continue
+		}
+		p := e.Name()
+		if name != "." {
+			p = name + "/" + p
+		}
+		if info, err := fs.Stat(f.MapFS, p); err == nil {
+			entries[i] = fs.FileInfoToDirEntry(renamed{info, e.Name()})
+		}
+	}
+	return entries, nil
+}
+
+// noLinkFS hides the ReadLinkFS methods of the wrapped filesystem.
+type noLinkFS struct {
+	fsys fs.FS
+}
+
+func (f noLinkFS) Open(name string) (fs.File, error) { return f.fsys.Open(name) }
+
+func (f noLinkFS) ReadDir(name string) ([]fs.DirEntry, error) { return fs.ReadDir(f.fsys, name) }
+
+type renamed struct {
+	fs.FileInfo
+	name string
+}
+
+func (r renamed) Name() string { return r.name }
+
+func walkFSPaths(t *testing.T, fsys fs.FS) map[string]fs.DirEntry {
+	t.Helper()
+	got := make(map[string]fs.DirEntry)
+	err := gitignore.WalkFS(fsys, func(path string, d fs.DirEntry) error {
+		got[path] = d
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestWalkFS(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	fsys := fstest.MapFS{
+		".gitignore":         {Data: []byte("*.log\nbuild/\n")},
+		".git/info/exclude":  {Data: []byte("*.local\n")},
+		".git/HEAD":          {Data: []byte("ref: refs/heads/main\n")},
+		"README.md":          {Data: []byte("x")},
+		"app.local":          {Data: []byte("x")},
+		"build/out.js":       {Data: []byte("x")},
+		"src/.gitignore":     {Data: []byte("*.tmp\n")},
+		"src/main.go":        {Data: []byte("x")},
+		"src/cache.tmp":      {Data: []byte("x")},
+		"src/debug.log":      {Data: []byte("x")},
+		"src/nested/util.go": {Data: []byte("x")},
+		"cache.tmp":          {Data: []byte("x")},
+	}
+
+	got := walkFSPaths(t, fsys)
+
+	for _, p := range []string{".gitignore", "README.md", "cache.tmp", "src", "src/main.go", "src/nested", "src/nested/util.go"} {
+		if _, ok := got[p]; !ok {
+			t.Errorf("WalkFS missing expected path %q", p)
+		}
+	}
+	for _, p := range []string{".git", ".git/HEAD", "app.local", "build", "build/out.js", "src/cache.tmp", "src/debug.log"} {
+		if _, ok := got[p]; ok {
+			t.Errorf("WalkFS should not have yielded %q", p)
+		}
+	}
+}
+
+func TestWalkFSSymlinks(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	base := fstest.MapFS{
+		".gitignore": {Data: []byte("link-file/\n")},
+		"real/a.txt": {Data: []byte("x")},
+		"link":       {Data: []byte("real"), Mode: fs.ModeSymlink},
+		"link-file":  {Data: []byte("real"), Mode: fs.ModeSymlink},
+	}
+
+	t.Run("ReadLinkFS", func(t *testing.T) {
+		got := walkFSPaths(t, followFS{base})
+		d, ok := got["link"]
+		if !ok {
+			t.Fatal("WalkFS missing symlink entry")
+		}
+		if d.IsDir() || d.Type()&fs.ModeSymlink == 0 {
+			t.Errorf("link: type = %v, want symlink", d.Type())
+		}
+		if _, ok := got["link/a.txt"]; ok {
+			t.Error("WalkFS should not descend into a symlinked directory")
+		}
+		// A symlink is not a directory, so a dir-only pattern does not match it.
+		if _, ok := got["link-file"]; !ok {
+			t.Error("WalkFS should yield link-file; dir-only patterns don't match symlinks")
+		}
+	})
+
+	t.Run("without ReadLinkFS", func(t *testing.T) {
+		got := walkFSPaths(t, noLinkFS{followFS{base}})
+		if _, ok := got["link/a.txt"]; !ok {
+			t.Error("without ReadLinkFS the reported directory type should be trusted")
+		}
+	})
+}
+
+func TestWalkFilters(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	fsys := fstest.MapFS{
+		".gitignore":        {Data: []byte("gen/\n")},
+		"main.go":           {Data: []byte("x")},
+		"README.md":         {Data: []byte("x")},
+		"pkg/util.go":       {Data: []byte("x")},
+		"pkg/util_test.go":  {Data: []byte("x")},
+		"pkg/doc.txt":       {Data: []byte("x")},
+		"pkg/link.go":       {Data: []byte("util.go"), Mode: fs.ModeSymlink},
+		"gen/generated.go":  {Data: []byte("x")},
+		"docs/guide/a.md":   {Data: []byte("x")},
+		"docs/guide/b.html": {Data: []byte("x")},
+	}
+
+	tests := []struct {
+		name string
+		opts []gitignore.Option
+		want []string
+	}{
+		{
+			name: "files only",
+			opts: []gitignore.Option{gitignore.WithFilesOnly()},
+			want: []string{".gitignore", "README.md", "docs/guide/a.md", "docs/guide/b.html", "main.go", "pkg/doc.txt", "pkg/util.go", "pkg/util_test.go"},
+		},
+		{
+			name: "dirs only",
+			opts: []gitignore.Option{gitignore.WithDirsOnly()},
+			want: []string{"docs", "docs/guide", "pkg"},
+		},
+		{
+			name: "extensions",
+			opts: []gitignore.Option{gitignore.WithExtensions(".go", "md")},
+			want: []string{"README.md", "docs/guide/a.md", "main.go", "pkg/link.go", "pkg/util.go", "pkg/util_test.go"},
+		},
+		{
+			name: "files only with extension",
+			opts: []gitignore.Option{gitignore.WithFilesOnly(), gitignore.WithExtensions("go")},
+			want: []string{"main.go", "pkg/util.go", "pkg/util_test.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := gitignore.WalkFS(fsys, func(path string, d fs.DirEntry) error {
+				got = append(got, path)
+				return nil
+			}, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWalkSubmodules(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	fsys := fstest.MapFS{
+		".gitignore":       {Data: []byte("")},
+		"main.go":          {Data: []byte("x")},
+		"libs/sub/.git":    {Data: []byte("gitdir: ../../.git/modules/sub\n")},
+		"libs/sub/lib.go":  {Data: []byte("x")},
+		"nested/.git/HEAD": {Data: []byte("ref: refs/heads/main\n")},
+		"nested/file.txt":  {Data: []byte("x")},
+		"plain/file.txt":   {Data: []byte("x")},
+	}
+
+	collect := func(opts ...gitignore.Option) map[string]gitignore.Entry {
+		got := make(map[string]gitignore.Entry)
+		err := gitignore.WalkFSEntries(fsys, func(e gitignore.Entry) error {
+			got[e.Path] = e
+			return nil
+		}, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	got := collect(gitignore.WithSubmodules())
+	for _, p := range []string{"libs/sub", "nested"} {
+		e, ok := got[p]
+		if !ok {
+			t.Errorf("missing submodule entry %q", p)
+			continue
+		}
+		if !e.Submodule || !e.DirEntry.IsDir() {
+			t.Errorf("%q: Submodule=%v IsDir=%v, want true/true", p, e.Submodule, e.DirEntry.IsDir())
+		}
+	}
+	for _, p := range []string{"libs/sub/lib.go", "nested/file.txt", "libs/sub/.git"} {
+		if _, ok := got[p]; ok {
+			t.Errorf("should not descend into submodule, got %q", p)
+		}
+	}
+	if e := got["plain"]; e.Submodule {
+		t.Error("plain directory should not be marked as a submodule")
+	}
+	if _, ok := got["plain/file.txt"]; !ok {
+		t.Error("missing plain/file.txt")
+	}
+
+	// Without the option, submodule contents are walked like any directory.
+	got = collect()
+	if _, ok := got["libs/sub/lib.go"]; !ok {
+		t.Error("without WithSubmodules, expected libs/sub/lib.go")
+	}
+	if got["libs/sub"].Submodule {
+		t.Error("without WithSubmodules, Submodule should be false")
+	}
+}
+
+func TestWalkEntriesDepth(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	fsys := fstest.MapFS{
+		"top.txt":       {Data: []byte("x")},
+		"a/mid.txt":     {Data: []byte("x")},
+		"a/b/c/deep.go": {Data: []byte("x")},
+	}
+
+	want := map[string]int{
+		"top.txt":       0,
+		"a":             0,
+		"a/mid.txt":     1,
+		"a/b":           1,
+		"a/b/c":         2,
+		"a/b/c/deep.go": 3,
+	}
+
+	got := make(map[string]int)
+	err := gitignore.WalkFSEntries(fsys, func(e gitignore.Entry) error {
+		got[e.Path] = e.Depth
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for p, d := range want {
+		if gd, ok := got[p]; !ok || gd != d {
+			t.Errorf("%q: depth = %d (present=%v), want %d", p, gd, ok, d)
+		}
+	}
+}
+
+func TestWalkWithIgnoreFile(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":     "*.log\n",
+		"src/.gitignore": "!keep.tmp\n",
+		"src/main.go":    "x",
+		"src/keep.tmp":   "x",
+		"src/other.tmp":  "x",
+		"debug.log":      "x",
+		"trace.log":      "x",
+		"docs/guide.md":  "x",
+	})
+	extra := filepath.Join(t.TempDir(), "extra-ignore")
+	if err := os.WriteFile(extra, []byte("*.tmp\ndocs/\n!trace.log\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]bool)
+	err := gitignore.Walk(root, func(path string, d fs.DirEntry) error {
+		got[filepath.ToSlash(path)] = true
+		return nil
+	}, gitignore.WithIgnoreFile(extra))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{"src/main.go", "trace.log"} {
+		if !got[p] {
+			t.Errorf("missing %q", p)
+		}
+	}
+	// src/.gitignore's negation is loaded later but can't override the extra file.
+	for _, p := range []string{"src/keep.tmp", "src/other.tmp", "debug.log", "docs"} {
+		if got[p] {
+			t.Errorf("should not yield %q", p)
+		}
+	}
+
+	// The extra file only applies to the walk it was given to.
+	m := gitignore.NewFromDirectory(root)
+	if m.Match("src/other.tmp") {
+		t.Error("extra ignore file leaked into NewFromDirectory")
+	}
+
+	err = gitignore.Walk(root, func(string, fs.DirEntry) error { return nil },
+		gitignore.WithIgnoreFile(filepath.Join(root, "missing")))
+	if err == nil {
+		t.Error("expected error for unreadable ignore file")
+	}
+}
+
+// writeFiles creates each slash-separated path under root with the given
+// content, along with a .git/info directory so root looks like a repository.
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(root, ".git", "info"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		full := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWalkWithOnly(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	fsys := fstest.MapFS{
+		".gitignore":          {Data: []byte("drafts/\n")},
+		"README.md":           {Data: []byte("x")},
+		"main.go":             {Data: []byte("x")},
+		"docs/index.html":     {Data: []byte("x")},
+		"docs/api/ref.txt":    {Data: []byte("x")},
+		"pkg/notes.md":        {Data: []byte("x")},
+		"pkg/CHANGELOG.md":    {Data: []byte("x")},
+		"drafts/wip.md":       {Data: []byte("x")},
+		"drafts/docs/more.md": {Data: []byte("x")},
+	}
+
+	var got []string
+	err := gitignore.WalkFS(fsys, func(path string, d fs.DirEntry) error {
+		got = append(got, path)
+		return nil
+	}, gitignore.WithOnly("**/*.md", "docs/**", "!CHANGELOG.md"), gitignore.WithFilesOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(got)
+	want := []string{"README.md", "docs/api/ref.txt", "docs/index.html", "pkg/notes.md"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	err = gitignore.WalkFS(fsys, func(string, fs.DirEntry) error { return nil },
+		gitignore.WithOnly("[[:bogus:]]"))
+	var perr gitignore.PatternError
+	if !errors.As(err, &perr) {
+		t.Errorf("expected PatternError for invalid glob, got %v", err)
+	}
+}
+
+func TestWalkChan(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":  "*.log\n",
+		"a.txt":       "x",
+		"b.log":       "x",
+		"src/main.go": "x",
+	})
+
+	entries, errc := gitignore.WalkChan(context.Background(), root)
+	var got []string
+	for e := range entries {
+		got = append(got, filepath.ToSlash(e.Path))
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(got)
+	want := []string{".gitignore", "a.txt", "src", "src/main.go"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWalkChanCancel(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	root := t.TempDir()
+	files := make(map[string]string)
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		files[name+".txt"] = "x"
+	}
+	writeFiles(t, root, files)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	entries, errc := gitignore.WalkChan(ctx, root)
+	<-entries
+	cancel()
+	for range entries {
+	}
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
+// countFS counts the filesystem operations a walk performs.
+type countFS struct {
+	followFS
+	reads, lstats int
+}
+
+func (c *countFS) ReadFile(name string) ([]byte, error) {
+	c.reads++
+	return c.followFS.ReadFile(name)
+}
+
+func (c *countFS) Lstat(name string) (fs.FileInfo, error) {
+	c.lstats++
+	return c.followFS.Lstat(name)
+}
+
+func TestWalkFSAvoidsStat(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	base := fstest.MapFS{
+		"a/b/c/file.txt": {Data: []byte("x")},
+		"d/e/file.txt":   {Data: []byte("x")},
+		"d/.gitignore":   {Data: []byte("*.tmp\n")},
+		"real/x.txt":     {Data: []byte("x")},
+		"link":           {Data: []byte("real"), Mode: fs.ModeSymlink},
+	}
+
+	// Every directory entry is lstat'ed once, and only directories with a
+	// .gitignore in their listing get it read: the two root files plus
+	// d/.gitignore.
+	c := &countFS{followFS: followFS{base}}
+	got := walkFSPaths(t, c)
+	if _, ok := got["link/x.txt"]; ok {
+		t.Error("descended into symlink")
+	}
+	if c.lstats != 7 {
+		t.Errorf("lstats = %d, want 7 (one per directory entry)", c.lstats)
+	}
+	if c.reads != 3 {
+		t.Errorf("reads = %d, want 3", c.reads)
+	}
+
+	c = &countFS{followFS: followFS{base}}
+	got = make(map[string]fs.DirEntry)
+	err := gitignore.WalkFS(c, func(path string, d fs.DirEntry) error {
+		got[path] = d
+		return nil
+	}, gitignore.WithoutLstat())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.lstats != 0 {
+		t.Errorf("WithoutLstat: lstats = %d, want 0", c.lstats)
+	}
+	if _, ok := got["link/x.txt"]; !ok {
+		t.Error("WithoutLstat: expected the reported directory type to be trusted")
+	}
+}
+
+func TestWalkIgnoreFileNames(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":       "*.log\n",
+		".npmignore":       "*.tmp\n",
+		"a.log":            "",
+		"a.tmp":            "",
+		"sub/.gitignore":   "*.md\n",
+		"sub/b.md":         "",
+		"sub/b.tmp":        "",
+		"other/.npmignore": "*.txt\n",
+		"other/.gitignore": "*.go\n",
+		"other/c.txt":      "",
+		"other/c.go":       "",
+	})
+	opt := gitignore.WithIgnoreFileNames(".npmignore", ".gitignore")
+
+	want := map[string]bool{
+		"a.log":       false, // the root's .gitignore isn't read
+		"a.tmp":       true,
+		"sub/b.md":    true, // sub has no .npmignore
+		"sub/b.tmp":   true,
+		"other/c.txt": true,
+		"other/c.go":  false,
+	}
+	got := make(map[string]bool)
+	err := gitignore.Walk(root, func(path string, d fs.DirEntry) error {
+		got[filepath.ToSlash(path)] = true
+		return nil
+	}, gitignore.WithFilesOnly(), opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsGot := make(map[string]bool)
+	err = gitignore.WalkFS(os.DirFS(root), func(path string, d fs.DirEntry) error {
+		fsGot[path] = true
+		return nil
+	}, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := gitignore.NewFromDirectory(root, opt)
+	for path, ignored := range want {
+		if got[path] == ignored {
+			t.Errorf("Walk visited %s = %v, want %v", path, got[path], !ignored)
+		}
+		if fsGot[path] == ignored {
+			t.Errorf("WalkFS visited %s = %v, want %v", path, fsGot[path], !ignored)
+		}
+		if m.Match(path) != ignored {
+			t.Errorf("Match(%q) = %v, want %v", path, !ignored, ignored)
+		}
+	}
+	if !gitignore.New(root).Match("a.log") {
+		t.Error("without WithIgnoreFileNames, .gitignore should be read")
+	}
+}
@@ -1,13 +1,25 @@
 package gitignore
 
+import "strings"
+
 // matchSegments matches path segments against pattern segments using two-pointer
 // backtracking. A doubleStar segment matches zero or more path segments.
+//
+// Only the most recent ** is kept as a backtrack point. That is enough:
+// once a later ** has matched, any extra segments an earlier ** might
+// absorb can be absorbed by the later one instead. Each path position is
+// therefore retried at most once per pattern segment, so matching costs
+// at most len(patSegs)*len(pathSegs) segment comparisons, with no
+// recursion, however many ** an untrusted pattern stacks up.
 func matchSegments(patSegs []segment, pathSegs []string) bool {
 	px, tx := 0, 0
 	// Backtrack point for the most recent ** we passed.
 	starPx, starTx := -1, -1
 
 	for tx < len(pathSegs) {
+		if px < len(patSegs) && patSegs[px].glue && matchGlued(patSegs[px:], pathSegs[tx:], false) {
+			return true
+		}
 		if px < len(patSegs) && patSegs[px].doubleStar {
 			// Save backtrack point: try matching zero path segments first.
 			starPx = px
@@ -15,7 +27,7 @@ func matchSegments(patSegs []segment, pathSegs []string) bool {
 			px++
 			continue
 		}
-		if px < len(patSegs) && !patSegs[px].doubleStar && matchSegment(patSegs[px].raw, pathSegs[tx]) {
+		if px < len(patSegs) && !patSegs[px].doubleStar && patSegs[px].match(pathSegs[tx]) {
 			px++
 			tx++
 			continue
@@ -40,8 +52,68 @@ func matchSegments(patSegs []segment, pathSegs []string) bool {
 	return true
 }
 
+// matchSegmentsBelow reports whether patSegs matches some path that
+// extends pathSegs by at least one segment. It walks pathSegs as
+// matchSegments does; if pattern segments are left when the path runs
+// out, or a ** was passed that can take more, the extra segments can be
+// chosen to match them.
+func matchSegmentsBelow(patSegs []segment, pathSegs []string) bool {
+	px, tx := 0, 0
+	starPx, starTx := -1, -1
+
+	for tx < len(pathSegs) {
+		if px < len(patSegs) && patSegs[px].glue && matchGlued(patSegs[px:], pathSegs[tx:], true) {
+			return true
+		}
+		if px < len(patSegs) && patSegs[px].doubleStar {
+			starPx = px
+			starTx = tx
+			px++
+			continue
+		}
+		if px < len(patSegs) && !patSegs[px].doubleStar && patSegs[px].match(pathSegs[tx]) {
+			px++
+			tx++
+			continue
+		}
+		if starPx >= 0 {
+			starTx++
+			tx = starTx
+			px = starPx + 1
+			continue
+		}
+		return false
+	}
+	return px < len(patSegs) || starPx >= 0
+}
+
+// matchGlued tries the other reading git gives a pattern whose literal
+// text runs into a **, which patSegs starts with: "x*", "**", "y" from
+// "x**/y". Git compares the "x" on its own, and wildmatch, finding "**/"
+// at the start of what is left, lets it match nothing, slash included,
+// so "xy" matches too. The glue segment only follows literal ones, with
+// no ** to backtrack to, so each match tries this once. With below set,
+// it is matchSegmentsBelow that the rest of the path is given to.
+func matchGlued(patSegs []segment, pathSegs []string, below bool) bool {
+	if len(patSegs) < 3 || patSegs[2].doubleStar || len(pathSegs) == 0 {
+		// With nothing but ** after it, the ** reading covers this one.
+		return false
+	}
+	stem := patSegs[0].raw[:len(patSegs[0].raw)-1]
+	rest, ok := strings.CutPrefix(pathSegs[0], stem)
+	if !ok || !patSegs[2].match(rest) {
+		return false
+	}
+	if below {
+		return matchSegmentsBelow(patSegs[3:], pathSegs[1:])
+	}
+	return matchSegments(patSegs[3:], pathSegs[1:])
+}
+
 // matchSegment matches a single path component against a glob pattern segment.
 // Handles *, ?, [...], and \-escapes. Uses two-pointer backtracking for *.
+// As in matchSegments, only the last * is a backtrack point, bounding the
+// work at len(glob)*len(text) steps however many stars the glob has.
 func matchSegment(glob, text string) bool {
 	gx, tx := 0, 0
 	starGx, starTx := -1, -1
@@ -170,7 +242,9 @@ func matchBracket(glob string, pos int, ch byte) (bool, int, bool) {
 				hi = glob[i]
 			}
 			i++
-			if ch >= lo && ch <= hi {
+			// Like git, read lo as a character of its own before the
+			// range, so even a reversed range like "c-a" matches c.
+			if ch == lo || ch >= lo && ch <= hi {
 				matched = true
 			}
 		} else {
@@ -0,0 +1,239 @@
+package gitignore
+
+// CouldIgnoreWithin reports whether some path below the directory dir
+// could be ignored by the patterns loaded so far: whether any pattern
+// that ignores can match a path inside it. dir is relative to the
+// repository root, with or without a trailing slash; "" is the root.
+//
+// A false result is certain, so a walker that finds dir itself not
+// ignored can take everything below it without asking Match, as long as
+// no .gitignore inside dir adds patterns. A true result only means that
+// one pattern might match: "*.log" could ignore something in any
+// directory. Each call tries every pattern, so call it once per
+// directory rather than once per file.
+func (m *Matcher) CouldIgnoreWithin(dir string) bool {
+	return m.couldMatchAnyWithin(dir, false)
+}
+
+// CouldReincludeWithin reports whether some path below the directory dir
+// could be re-included by a negated pattern loaded so far. It answers as
+// CouldIgnoreWithin does, for negations: a walker that finds dir
+// ignored, and will only descend into it to look for re-included paths,
+// can skip it when this returns false.
+func (m *Matcher) CouldReincludeWithin(dir string) bool {
+	return m.couldMatchAnyWithin(dir, true)
+}
+
+// IsCompletelyIgnored reports whether the directory dir is ignored along
+// with everything below it: Match reports dir ignored, and no negated
+// pattern loaded so far could re-include a path inside it. A clean or
+// archive tool can then delete or skip the whole subtree without looking
+// inside. dir is given as for CouldIgnoreWithin.
+//
+// A negation only counts if it could win over the pattern that ignores
+// dir, which also ignores everything below it, so "!build/keep" before
+// "build/" doesn't stop build from being completely ignored. A pattern
+// that matches directories only, like "**/", ignores nothing below them,
+// so the answer is false for the directories it decides. Git itself
+// never re-includes a path under an ignored directory; see MatchFull.
+func (m *Matcher) IsCompletelyIgnored(dir string) bool {
+	var buf [stackSegs]string
+	dirSegs, ok := m.dirSegs(dir, buf[:0], true)
+	if !ok {
+		return false
+	}
+	owner, i := m.find(dirSegs, true)
+	if !ignoredBy(owner, i) {
+		return false
+	}
+	if p := &owner.patterns[i]; p.dirOnly && !p.hasConcrete {
+		// Like "**/", pattern i matches directories themselves only,
+		// so a file below may match nothing at all.
+		return false
+	}
+	// The repository's patterns take precedence over the global
+	// excludes, and pattern i over those before it.
+	if owner != m && m.couldMatchWithin(dirSegs, true, 0) {
+		return false
+	}
+	return !owner.couldMatchWithin(dirSegs, true, i+1)
+}
+
+// MatchDirContents reports whether every path below the directory dir is
+// ignored, as MatchFull would report each one, without probing any. dir
+// is given as for CouldIgnoreWithin. dir itself need not be ignored:
+//
+//   - "build/" ignores build, so git never looks inside it, and
+//     everything below it is ignored too.
+//   - "build/**" and "build/*" leave build itself alone but ignore each
+//     entry directly inside it, and so everything further down.
+//
+// Either way a negation that the ignoring pattern doesn't win over, and
+// that could match an entry directly inside dir, like "!build/keep"
+// after "build/*", makes the answer false; "!build/sub/keep" doesn't,
+// since git never looks inside build/sub. A true result is certain. A
+// false one may come from patterns that only cover dir between them,
+// like "build/a*" and "build/[!a]*".
+func (m *Matcher) MatchDirContents(dir string) (allIgnored bool) {
+	var buf [stackSegs]string
+	dirSegs, ok := m.dirSegs(dir, buf[:0], false)
+	if !ok {
+		return false
+	}
+	if len(dirSegs) > 0 {
+		if owner, i, _ := m.findFull(dirSegs, true); ignoredBy(owner, i) {
+			return true
+		}
+	}
+	// The repository's patterns take precedence over the global
+	// excludes, and later patterns over earlier ones.
+	for o := m; o != nil; o = o.globalMatcher() {
+		for i := len(o.patterns) - 1; i >= 0; i-- {
+			p := &o.patterns[i]
+			switch {
+			case o.hot[i].shadowed:
+			case p.negate && couldMatchChild(p, dirSegs):
+				return false
+			case !p.negate && o.coversChildren(p, dirSegs):
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// coversChildren reports whether p, a pattern of m, matches every path
+// directly below dirSegs, as a file or a directory: whether the part of
+// p past dirSegs is "*" or "**".
+func (m *Matcher) coversChildren(p *pattern, dirSegs []string) bool {
+	rel, ok := underPrefix(p, dirSegs)
+	if !ok || p.dirOnly || p.re != nil {
+		return false
+	}
+	if segs := p.segments; m.endsInDoubleStar(p) {
+		// A ** written at the end matches something below the path it
+		// ends, as in "build/**", so it has to take the whole child.
+		return matchSegments(segs[:len(segs)-1], rel)
+	}
+	return splitsAtChild(p.segments, rel, true)
+}
+
+// couldMatchChild reports whether p could match a path directly below
+// dirSegs.
+func couldMatchChild(p *pattern, dirSegs []string) bool {
+	rel, ok := underPrefix(p, dirSegs)
+	if !ok {
+		// Unless its scope is elsewhere, p is scoped to a directory
+		// below dirSegs, and matches nothing directly inside it.
+		return false
+	}
+	if p.re != nil {
+		return true
+	}
+	for _, s := range p.segments {
+		if s.glue {
+			// The reading without a directory can't be cut in two;
+			// see matchGlued.
+			return couldMatchBelow(p, dirSegs)
+		}
+	}
+	if p.dirOnly && p.hasConcrete && splitsAtChild(descendants(p), rel, false) {
+		return true
+	}
+	return splitsAtChild(p.segments, rel, false)
+}
+
+// splitsAtChild reports whether segs can be cut into a part matching rel
+// and a part matching one more segment, as spansOne says. A ** where
+// they are cut can be in both, matching the end of rel and the segment.
+func splitsAtChild(segs []segment, rel []string, every bool) bool {
+	for k := range segs {
+		if !spansOne(segs[k:], every) {
+			continue
+		}
+		if matchSegments(segs[:k], rel) || segs[k].doubleStar && matchSegments(segs[:k+1], rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// spansOne reports whether segs could match a single path segment: all
+// but at most one of them is a **. If every is set, that one must be
+// "*", so that segs match any single segment.
+func spansOne(segs []segment, every bool) bool {
+	concrete := 0
+	for _, s := range segs {
+		if s.doubleStar {
+			continue
+		}
+		if concrete++; concrete > 1 || every && s.raw != "*" {
+			return false
+		}
+	}
+	return len(segs) > 0
+}
+
+// couldMatchAnyWithin is couldMatchWithin for dir, a path as given to
+// CouldIgnoreWithin, over m and the global excludes.
+func (m *Matcher) couldMatchAnyWithin(dir string, negate bool) bool {
+	var buf [stackSegs]string
+	dirSegs, ok := m.dirSegs(dir, buf[:0], true)
+	if !ok {
+		return false
+	}
+	for o := m; o != nil; o = o.globalMatcher() {
+		if o.couldMatchWithin(dirSegs, negate, 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// dirSegs splits dir, which may end in a slash, into segs, cleaned as
+// path cleans it and normalized as find would normalize it. legacy is as
+// for path.
+func (m *Matcher) dirSegs(dir string, segs []string, legacy bool) ([]string, bool) {
+	dir, _, ok := m.path(dir, legacy)
+	if dir == "" {
+		return nil, ok
+	}
+	return m.normalize(splitPath(dir, segs)), true
+}
+
+// couldMatchWithin reports whether a pattern of m from index from on,
+// with the given polarity, could match a path below dirSegs.
+func (m *Matcher) couldMatchWithin(dirSegs []string, negate bool, from int) bool {
+	for i := from; i < len(m.patterns); i++ {
+		p := &m.patterns[i]
+		if p.negate == negate && !m.hot[i].shadowed && couldMatchBelow(p, dirSegs) {
+			return true
+		}
+	}
+	return false
+}
+
+// couldMatchBelow reports whether p could match a path below dirSegs.
+func couldMatchBelow(p *pattern, dirSegs []string) bool {
+	n := min(len(p.prefixSegs), len(dirSegs))
+	for i := range n {
+		if p.prefixSegs[i] != dirSegs[i] {
+			return false
+		}
+	}
+	if len(p.prefixSegs) > len(dirSegs) {
+		// The pattern's .gitignore is itself below dirSegs.
+		return true
+	}
+	if p.re != nil {
+		// A regular expression could match anything.
+		return true
+	}
+	rel := dirSegs[len(p.prefixSegs):]
+	if p.dirOnly && p.hasConcrete {
+		// Everything below a matching directory matches too, whether
+		// that directory is above dirSegs or below it.
+		return matchSegmentsBelow(descendants(p), rel)
+	}
+	return matchSegmentsBelow(p.segments, rel)
+}
@@ -0,0 +1,243 @@
+package gitignore_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/git-pkgs/gitignore"
+	"github.com/git-pkgs/gitignore/gitignorediff"
+)
+
+func TestCouldIgnoreWithin(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":           "/build/\ndocs/**/*.tmp\nsrc/gen/\n!src/gen/keep.go\n",
+		"web/app/.gitignore":   "node_modules\n",
+		"tools/lint/main.go":   "",
+		"web/app/package.json": "",
+	})
+	m, err := gitignore.LoadDirectory(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		dir               string
+		ignore, reinclude bool
+	}{
+		{"", true, true},
+		{"build", true, false},
+		{"build/out/", true, false},
+		{"docs", true, false},
+		{"docs/api/v1", true, false},
+		{"src", true, true},
+		{"src/gen", true, true},
+		{"src/gen/sub", true, false},
+		{"src/lib", false, false},
+		{"tools", false, false},
+		{"tools/lint/", false, false},
+		{"web", true, false},
+		{"web/app", true, false},
+		{"web/api", false, false},
+		{"sub/build", false, false},
+	}
+	for _, tt := range tests {
+		if got := m.CouldIgnoreWithin(tt.dir); got != tt.ignore {
+			t.Errorf("CouldIgnoreWithin(%q) = %v, want %v", tt.dir, got, tt.ignore)
+		}
+		if got := m.CouldReincludeWithin(tt.dir); got != tt.reinclude {
+			t.Errorf("CouldReincludeWithin(%q) = %v, want %v", tt.dir, got, tt.reinclude)
+		}
+	}
+}
+
+func TestCouldIgnoreWithinUnanchored(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	m := gitignore.New(t.TempDir())
+	m.AddPatterns([]byte("*.log\n"), "")
+	for _, dir := range []string{"", "a", "a/b/c"} {
+		if !m.CouldIgnoreWithin(dir) {
+			t.Errorf("CouldIgnoreWithin(%q) = false, want *.log to apply anywhere", dir)
+		}
+		if m.CouldReincludeWithin(dir) {
+			t.Errorf("CouldReincludeWithin(%q) = true, want false", dir)
+		}
+	}
+
+	// Whatever CouldIgnoreWithin rules out, Match agrees with.
+	m = gitignore.New(t.TempDir())
+	m.AddPatterns([]byte("a/*/c\n!a/**/d/\nx*/y/\n"), "")
+	for _, tt := range []struct {
+		dir  string
+		path string
+	}{
+		{"a/b/c", "a/b/c/z"},
+		{"a", "a/b/c"},
+		{"xa", "xa/y/z"},
+	} {
+		if m.Match(tt.path) && !m.CouldIgnoreWithin(tt.dir) {
+			t.Errorf("CouldIgnoreWithin(%q) = false, but %q is ignored", tt.dir, tt.path)
+		}
+	}
+	if m.CouldIgnoreWithin("b") || m.CouldIgnoreWithin("a/b/d") || !m.CouldReincludeWithin("a/b") {
+		t.Error("unexpected result for a directory no pattern reaches")
+	}
+}
+
+func TestWithinGenerated(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	// A false answer from CouldIgnoreWithin or CouldReincludeWithin, and
+	// a true one from IsCompletelyIgnored, must hold for every path below
+	// the directory.
+	g := gitignorediff.NewGenerator(1)
+	for range 500 {
+		c := g.Case()
+		m := gitignore.New(t.TempDir())
+		m.AddPatterns([]byte(c.Patterns), "")
+		for _, dir := range c.Paths {
+			if !strings.HasSuffix(dir, "/") {
+				continue
+			}
+			ignore, reinclude := m.CouldIgnoreWithin(dir), m.CouldReincludeWithin(dir)
+			complete := m.IsCompletelyIgnored(dir)
+			for _, p := range c.Paths {
+				if p == dir || !strings.HasPrefix(p, dir) {
+					continue
+				}
+				r := m.MatchDetail(p)
+				if r.Ignored && !ignore || r.Negate && !reinclude || complete && !r.Ignored {
+					t.Errorf("%q below %q is decided by %q, patterns:\n%s", p, dir, r.Pattern, c.Patterns)
+				}
+			}
+		}
+	}
+}
+
+func TestIsCompletelyIgnored(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tests := []struct {
+		patterns string
+		dir      string
+		want     bool
+	}{
+		{"build/\n", "", false},
+		{"!build/keep\nbuild/\n", "build", true},
+		{"!build/keep\nbuild/\n", "build/", true},
+		{"!build/keep\nbuild/\n", "./build", true},
+		{"build/\n!build/keep\n", "build", false},
+		{"dist/\n!dist/*.txt\n", "dist", false},
+		{"dist/\n!dist/*.txt\n", "dist/sub", true},
+		{"*.cache\n!a.cache/b\n", "x.cache/", true},
+		{"*.cache\n!a.cache/b\n", "a.cache", false},
+		{"tmp/\n!tmp/**/logs/\n", "tmp", false},
+		{"tmp/\n!**/logs/\n", "tmp", false},
+		{"tmp/\n!/logs/\n", "tmp", true},
+		{"**/\n", "src", false}, // the files inside may match nothing
+		{"**/\n!/gen/\n", "gen", false},
+		{"src/\n", "src/gen", true},
+		{"src/\n", "lib", false},
+	}
+	for _, tt := range tests {
+		m := gitignore.New(t.TempDir())
+		m.AddPatterns([]byte(tt.patterns), "")
+		if got := m.IsCompletelyIgnored(tt.dir); got != tt.want {
+			t.Errorf("%q: IsCompletelyIgnored(%q) = %v, want %v", tt.patterns, tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestIsCompletelyIgnoredGlobal(t *testing.T) {
+	xdgDir := t.TempDir()
+	writeFiles(t, xdgDir, map[string]string{"git/ignore": "node_modules/\n!node_modules/.keep\n"})
+	t.Setenv("XDG_CONFIG_HOME", xdgDir)
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+
+	m := gitignore.New(t.TempDir())
+	if m.IsCompletelyIgnored("node_modules") {
+		t.Error("expected the global negation to count")
+	}
+	m.AddPatterns([]byte("!web/node_modules/keep.js\n"), "")
+	if m.IsCompletelyIgnored("web/node_modules") || !m.IsCompletelyIgnored("api/node_modules/x") {
+		t.Error("expected repository negations to count only where they could match")
+	}
+}
+
+func TestMatchDirContents(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	tests := []struct {
+		patterns string
+		dir      string
+		want     bool
+	}{
+		{"build/\n", "build", true},
+		{"build/\n", "build/sub/", true},
+		{"build/**\n", "build", true},
+		{"build/*\n", "build/", true},
+		{"build/*\n", "", false},
+		{"build/*/\n", "build", false}, // files directly inside are kept
+		{"build/**/\n", "build", false},
+		{"build/*\n!build/keep\n", "build", false},
+		{"build/*\n!build/sub/keep\n", "build", true},
+		{"build/*\n!build/sub/\n", "build", false},
+		{"!build/keep\nbuild/*\n", "build", true},
+		{"build/*\n!*.go\n", "build", false},
+		{"build/*\n!**/\n", "build", false},
+		{"*\n", "", true},
+		{"*\n!.gitignore\n", "", false},
+		{"*\n!.gitignore\n", "src", true},
+		{"*.log\n", "logs", false},
+		{"/a*/**\n", "abc", true},
+		{"a/**/*\n", "a/b/c", true},
+		{"build/a*\nbuild/[!a]*\n", "build", false},
+		{"x**/**\n!xa\n", "xb", true},
+		{"x/*\n!x**/y\n", "x", false},
+	}
+	for _, tt := range tests {
+		m := gitignore.New(t.TempDir())
+		m.AddPatterns([]byte(tt.patterns), "")
+		if got := m.MatchDirContents(tt.dir); got != tt.want {
+			t.Errorf("%q: MatchDirContents(%q) = %v, want %v", tt.patterns, tt.dir, got, tt.want)
+		}
+	}
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"vendor/.gitignore": "*\n!.gitignore\n"})
+	m := gitignore.NewFromDirectory(root)
+	if m.MatchDirContents("vendor") || !m.MatchDirContents("vendor/lib") || m.MatchDirContents("src") {
+		t.Error("expected the nested patterns to apply inside vendor only")
+	}
+}
+
+func TestMatchDirContentsGenerated(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	// A true answer must hold for every path below the directory.
+	g := gitignorediff.NewGenerator(2)
+	for range 500 {
+		c := g.Case()
+		m := gitignore.New(t.TempDir())
+		m.AddPatterns([]byte(c.Patterns), "")
+		for _, dir := range c.Paths {
+			if !strings.HasSuffix(dir, "/") || !m.MatchDirContents(dir) {
+				continue
+			}
+			for _, p := range c.Paths {
+				if p != dir && strings.HasPrefix(p, dir) && !m.MatchFull(p) {
+					t.Errorf("%q below %q is not ignored, patterns:\n%s", p, dir, c.Patterns)
+				}
+			}
+		}
+	}
+}